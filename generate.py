@@ -16,6 +16,25 @@ import numpy as np
 STATIONS_FILE = "stations.json"
 RESULTS_DIR = "results"
 DATA_DIR = "data"
+UNICODE_FLAG = "--unicode"
+
+# Extra multi-byte station names for --unicode datasets, from two-byte Latin
+# to four-byte Gothic, plus a decomposed "Zürich" that must stay distinct
+# from the precomposed one in stations.json.
+UNICODE_STATIONS = [
+    {"name": "São Paulo", "mean_temp": 19.2},
+    {"name": "İzmir", "mean_temp": 17.9},
+    {"name": "Kraków", "mean_temp": 8.7},
+    {"name": "Ürümqi", "mean_temp": 7.4},
+    {"name": "Zu\u0308rich", "mean_temp": 9.3},
+    {"name": "Москва", "mean_temp": 5.8},
+    {"name": "Αθήνα", "mean_temp": 19.2},
+    {"name": "東京", "mean_temp": 15.4},
+    {"name": "서울", "mean_temp": 12.5},
+    {"name": "मुंबई", "mean_temp": 27.1},
+    {"name": "القاهرة", "mean_temp": 22.3},
+    {"name": "𐌰𐌸𐌹𐌽𐌰", "mean_temp": 15.0},
+]
 
 
 @dataclass
@@ -46,15 +65,21 @@ class Colors:
     RESET = "\033[0m"
 
 
-def load_and_prepare_stations() -> tuple[np.ndarray, np.ndarray, np.ndarray, int]:
+def load_and_prepare_stations(
+    unicode_only: bool = False,
+) -> tuple[np.ndarray, np.ndarray, np.ndarray, int]:
     """
     Load station data from JSON file and prepare np arrays for fast access.
+    :param unicode_only: keep only non-ASCII names and add UNICODE_STATIONS
     :return:
     """
     file = Path(__file__).parent / STATIONS_FILE
     with open(file, "r", encoding="utf-8") as f:
         data = json.load(f)
 
+    if unicode_only:
+        data = [s for s in data if not s["name"].isascii()] + UNICODE_STATIONS
+
     names = [s["name"].encode("utf-8") + b";" for s in data]
     st_arr = np.array(names, dtype=object)
 
@@ -72,7 +97,12 @@ def load_and_prepare_stations() -> tuple[np.ndarray, np.ndarray, np.ndarray, int
     return st_arr, mean_temps, temp_lookup, -min_temp
 
 
-STATION_NAMES, STATION_TEMPS, TEMP_LOOKUP, TEMP_OFFSET = load_and_prepare_stations()
+# Read at import so spawned workers build the same station table.
+UNICODE_ONLY = UNICODE_FLAG in sys.argv[1:]
+
+STATION_NAMES, STATION_TEMPS, TEMP_LOOKUP, TEMP_OFFSET = load_and_prepare_stations(
+    UNICODE_ONLY
+)
 POOL_SIZE = 5_000_000
 
 GLOBAL_STATION_INDICES = np.random.randint(0, len(STATION_NAMES), size=POOL_SIZE)
@@ -410,8 +440,9 @@ class FileGenerator:
 def get_num_rows() -> int:
     """Get number of rows to generate from command-line argument or default."""
     num_rows = 100_000_000
-    if len(sys.argv) > 1:
-        arg = sys.argv[1].lower()
+    args = [a for a in sys.argv[1:] if a != UNICODE_FLAG]
+    if args:
+        arg = args[0].lower()
         if arg == "b":
             num_rows = 1_000_000_000
         else:
@@ -433,6 +464,8 @@ def main():
 
     num_rows = get_num_rows()
     row_suffix = format_row_count(num_rows)
+    if UNICODE_ONLY:
+        row_suffix += "-unicode"
     filename = str(data_folder / f"measurements-{row_suffix}.txt")
     results_filename = str(results_folder / f"results-{row_suffix}.csv")
 
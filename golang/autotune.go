@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"os"
+	"runtime"
+	"time"
+)
+
+var (
+	autoTune       = flag.Bool("autotune", false, "pick the fastest buffer/worker configuration per strategy on a sample before the full run")
+	autotuneSample = byteSize(32 << 20)
+)
+
+func init() {
+	flag.Var(&autotuneSample, "autotune-sample", "bytes read from the head of the file for -autotune")
+}
+
+var (
+	autotuneBuffers = []int{64 << 10, 256 << 10, 1 << 20, 4 << 20}
+	autotuneWorkers = []int{runtime.NumCPU() / 2, runtime.NumCPU(), runtime.NumCPU() * 2}
+)
+
+// autotuneStrategies benchmarks every buffer/worker combination on a sample
+// taken from the head of dataFile and builds each strategy with the fastest
+// configuration found. Options already set explicitly on the command line
+// are kept fixed rather than searched.
+func autotuneStrategies(keys []string, base strategies.StrategyOptions, dataFile string, sampleSize int64) []namedStrategy {
+	out.Headerf("=== Auto-tuning on a %s sample ===", formatByteSize(int(sampleSize)))
+
+	samplePath, _, err := writeHead(dataFile, "autotune-sample-*.txt", sampleSize, 0)
+	if err != nil {
+		out.Errorf("Error creating auto-tune sample: %v (using default options)", err)
+		out.Println()
+		return buildStrategies(keys, base)
+	}
+	defer os.Remove(samplePath)
+
+	candidates := autotuneCandidates(base)
+	tuned := make([]namedStrategy, 0, len(keys))
+
+	for _, key := range keys {
+		entry, ok := lookupStrategy(key)
+		if !ok {
+			continue
+		}
+
+		best := base
+		bestTime := time.Duration(-1)
+		for _, opts := range candidates {
+			result := benchmarkStrategy(entry.name(), entry.New(opts), samplePath)
+			if result.Success && (bestTime < 0 || result.ExecutionTime < bestTime) {
+				best, bestTime = opts, result.ExecutionTime
+			}
+		}
+
+		out.Printf("  %-24s buffer=%-7s workers=%d\n", entry.name(), describeBuffer(best.BufferSize), best.Workers)
+		tuned = append(tuned, namedStrategy{name: entry.name(), strategy: entry.New(best), key: key, opts: best})
+	}
+	out.Println()
+	return tuned
+}
+
+// autotuneCandidates expands the search grid, leaving fields the user has
+// already set untouched.
+func autotuneCandidates(base strategies.StrategyOptions) []strategies.StrategyOptions {
+	buffers := autotuneBuffers
+	if base.BufferSize > 0 {
+		buffers = []int{base.BufferSize}
+	}
+	workers := autotuneWorkers
+	if base.Workers > 0 {
+		workers = []int{base.Workers}
+	}
+
+	candidates := make([]strategies.StrategyOptions, 0, len(buffers)*len(workers))
+	seen := make(map[[2]int]bool) // buffer size and workers
+	for _, b := range buffers {
+		for _, w := range workers {
+			opts := base
+			opts.BufferSize = b
+			opts.Workers = max(w, 1)
+			if key := [2]int{opts.BufferSize, opts.Workers}; !seen[key] {
+				seen[key] = true
+				candidates = append(candidates, opts)
+			}
+		}
+	}
+	return candidates
+}
+
+func describeBuffer(size int) string {
+	if size == 0 {
+		return "default"
+	}
+	return formatByteSize(size)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// batchSizeFlag is the flag.Value of -batch-size: a number of lines, or
+// "auto" to let the batch strategy grow its batches from its default.
+type batchSizeFlag struct {
+	lines int
+	auto  bool
+}
+
+var batchSize batchSizeFlag
+
+func (b *batchSizeFlag) String() string {
+	switch {
+	case b.auto:
+		return "auto"
+	case b.lines == 0:
+		return ""
+	default:
+		return strconv.Itoa(b.lines)
+	}
+}
+
+func (b *batchSizeFlag) Set(s string) error {
+	if strings.EqualFold(s, "auto") {
+		*b = batchSizeFlag{auto: true}
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return fmt.Errorf("invalid batch size %q: want a positive number of lines or auto", s)
+	}
+	*b = batchSizeFlag{lines: n}
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+)
+
+var (
+	checkpoint = flag.String("checkpoint", "", "save the progress of a single -strategies table strategy to this file every -checkpoint-every and, if it holds an interrupted run's, resume from it; removed once the run completes")
+	ckptEvery  = flag.Duration("checkpoint-every", time.Minute, "how often each worker adds its progress to the -checkpoint file")
+)
+
+// checkpointKeys returns the keys among keys whose strategies run on this
+// system and honor -checkpoint.
+func checkpointKeys(keys []string) []string {
+	var checkpointers []string
+	for _, key := range keys {
+		if entry, ok := lookupStrategy(key); ok && entry.supported() && entry.Checkpoints {
+			checkpointers = append(checkpointers, key)
+		}
+	}
+	return checkpointers
+}
+
+// printCheckpoint says whether the run resumes from the checkpoint at path
+// or starts one there.
+func printCheckpoint(path string) {
+	if _, err := os.Stat(path); err == nil {
+		out.Printf("%s resuming from %s\n\n", out.Paint("Checkpoint:", ColorBlue), path)
+		return
+	}
+	out.Printf("%s saving progress to %s every %v\n\n", out.Paint("Checkpoint:", ColorBlue), path, *ckptEvery)
+}
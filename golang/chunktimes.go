@@ -0,0 +1,173 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+var chunkTimes = flag.Bool("chunk-times", false, "after each strategy, print every worker's chunks and busy time and the slowest chunks, against the median, to spot stragglers")
+
+// chunkTimesShown is how many of a run's slowest chunks -chunk-times lists.
+const chunkTimesShown = 5
+
+// chunkTracer records the chunks of every run for -chunk-times; nil
+// without it.
+var chunkTracer *chunkTimeTracer
+
+// chunkTiming is how long a worker took to aggregate one chunk.
+type chunkTiming struct {
+	worker   int
+	offset   int64
+	bytes    int64
+	duration time.Duration
+}
+
+// chunkTimeTracer is a strategies.Tracer timing the SpanChunk spans of a
+// run, and passing every span on to next, the -otel tracer, if set.
+type chunkTimeTracer struct {
+	next strategies.Tracer
+
+	mu     sync.Mutex
+	chunks []chunkTiming
+}
+
+func (t *chunkTimeTracer) StartSpan(ctx context.Context, name string, attrs ...strategies.SpanAttr) (context.Context, func()) {
+	end := func() {}
+	if t.next != nil {
+		ctx, end = t.next.StartSpan(ctx, name, attrs...)
+	}
+	if name != strategies.SpanChunk {
+		return ctx, end
+	}
+
+	var c chunkTiming
+	for _, a := range attrs {
+		v, _ := a.Value.(int64)
+		switch a.Key {
+		case "worker":
+			c.worker = int(v)
+		case "offset":
+			c.offset = v
+		case "bytes":
+			c.bytes = v
+		}
+	}
+	start := time.Now()
+	return ctx, func() {
+		c.duration = time.Since(start)
+		end()
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.chunks = append(t.chunks, c)
+	}
+}
+
+// take returns the chunks timed since the last call, and forgets them.
+func (t *chunkTimeTracer) take() []chunkTiming {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	chunks := t.chunks
+	t.chunks = nil
+	return chunks
+}
+
+// workerTimes is what one worker spent on its chunks.
+type workerTimes struct {
+	worker  int
+	chunks  int
+	bytes   int64
+	busy    time.Duration
+	slowest time.Duration
+}
+
+// printChunkTimes shows where a run's time went across its workers: each
+// worker's chunks and time busy with them, against the median worker, and
+// the slowest chunks against the median chunk. A worker or chunk far above
+// the median is a straggler the others waited on.
+func printChunkTimes(result BenchmarkResult) {
+	if len(result.Chunks) == 0 {
+		out.Println("   No chunk times: only MCMP and the strategies reading through the shared chunk driver report them")
+		return
+	}
+
+	var workers []workerTimes
+	for _, c := range result.Chunks {
+		for len(workers) <= c.worker {
+			workers = append(workers, workerTimes{worker: len(workers)})
+		}
+		w := &workers[c.worker]
+		w.chunks++
+		w.bytes += c.bytes
+		w.busy += c.duration
+		w.slowest = max(w.slowest, c.duration)
+	}
+	busy := make([]time.Duration, 0, len(workers))
+	for _, w := range workers {
+		if w.chunks > 0 {
+			busy = append(busy, w.busy)
+		}
+	}
+	medianBusy := medianDuration(busy)
+
+	chunks := slices.Clone(result.Chunks)
+	slices.SortFunc(chunks, func(a, b chunkTiming) int { return cmp.Compare(b.duration, a.duration) })
+	durations := make([]time.Duration, len(chunks))
+	for i, c := range chunks {
+		durations[i] = c.duration
+	}
+	medianChunk := medianDuration(durations)
+
+	out.Printf("   Chunk times: %d chunks over %d workers, median chunk %s, median worker busy %s\n",
+		len(chunks), len(busy), formatDuration(medianChunk), formatDuration(medianBusy))
+	w := tabwriter.NewWriter(out.Writer(), 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, out.Paint("   WORKER\tCHUNKS\tMB\tBUSY\tSLOWEST CHUNK\tVS MEDIAN", ColorBold, ColorCyan))
+	for _, wt := range workers {
+		if wt.chunks == 0 {
+			continue
+		}
+		ratio := float64(wt.busy) / float64(medianBusy)
+		row := fmt.Sprintf("   %d\t%d\t%.1f\t%s\t%s\t%.2fx", wt.worker, wt.chunks, float64(wt.bytes)/1024/1024,
+			formatDuration(wt.busy), formatDuration(wt.slowest), ratio)
+		fmt.Fprintln(w, out.Paint(row, stragglerColor(ratio)))
+	}
+	w.Flush()
+
+	out.Printf("   Slowest chunks:\n")
+	w = tabwriter.NewWriter(out.Writer(), 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, out.Paint("   OFFSET (MB)\tMB\tWORKER\tTIME\tVS MEDIAN", ColorBold, ColorCyan))
+	for _, c := range chunks[:min(len(chunks), chunkTimesShown)] {
+		ratio := float64(c.duration) / float64(medianChunk)
+		row := fmt.Sprintf("   %.1f\t%.1f\t%d\t%s\t%.2fx", float64(c.offset)/1024/1024, float64(c.bytes)/1024/1024,
+			c.worker, formatDuration(c.duration), ratio)
+		fmt.Fprintln(w, out.Paint(row, stragglerColor(ratio)))
+	}
+	w.Flush()
+}
+
+// stragglerColor marks a worker or chunk taking half as long again as the
+// median.
+func stragglerColor(ratio float64) string {
+	if ratio >= 1.5 {
+		return ColorYellow
+	}
+	return ""
+}
+
+// medianDuration returns the median of d, or 0 if it is empty.
+func medianDuration(d []time.Duration) time.Duration {
+	if len(d) == 0 {
+		return 0
+	}
+	sorted := slices.Sorted(slices.Values(d))
+	return sorted[len(sorted)/2]
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"os"
+	"strings"
+	"time"
+)
+
+// runConvertCommand implements "convert [flags] [file]": it writes the
+// measurements of a text file in the binary format the binary strategy
+// reads, and returns the exit status.
+func runConvertCommand(args []string) int {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	output := fs.String("o", "", "binary file to write (default: the input with its extension replaced by .bin)")
+	noColor := fs.Bool("no-color", false, "disable ANSI colors (also honors NO_COLOR and non-TTY output)")
+	recordFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s convert [flags] [file]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Write a measurements file as pre-parsed binary records, which the binary strategy\n")
+		fmt.Fprintf(fs.Output(), "aggregates without parsing text. Values must fit 16 bits in units of the last decimal.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	out = newOutput(*noColor)
+
+	out.Headerf("=== One Billion Row Challenge - Convert to Binary ===")
+	out.Println()
+
+	if err := setRecordFormat(); err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	dataFile := getDataFile(fs.Args())
+	if *output == "" {
+		*output = binaryPath(dataFile)
+	}
+	if *output == dataFile {
+		out.Errorf("Error: %s would overwrite its own input; name another with -o", *output)
+		return 1
+	}
+	in, err := openInput(dataFile)
+	if err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	defer in.Close()
+
+	f, err := os.Create(*output)
+	if err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	start := time.Now()
+	stats, err := strategies.ConvertToBinary(context.Background(), f, in, parseModeOption())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(*output)
+		out.Errorf("Error converting %s: %v", dataFile, err)
+		return 1
+	}
+
+	out.Printf("%s %s %s\n", out.Paint("Wrote:", ColorBlue), *output,
+		out.Paint(fmt.Sprintf("(%.2f MB)", float64(stats.Bytes)/1024/1024), ColorYellow))
+	out.Printf("%s %d\n", out.Paint("Rows:", ColorBlue), stats.Rows)
+	out.Printf("%s %d\n", out.Paint("Distinct stations:", ColorBlue), stats.Stations)
+	if stats.Malformed > 0 {
+		out.Warnf("Skipped %d malformed lines", stats.Malformed)
+	}
+	out.Printf("%s %s\n\n", out.Paint("Converted in:", ColorBlue), formatDuration(time.Since(start)))
+	out.Successf("✓ Benchmark it with: %s %s", os.Args[0], *output)
+	return 0
+}
+
+// binaryPath is the default output of convert for dataFile.
+func binaryPath(dataFile string) string {
+	base := strings.TrimSuffix(dataFile, ".zst")
+	if i := strings.LastIndexByte(base, '.'); i > strings.LastIndexByte(base, os.PathSeparator) {
+		base = base[:i]
+	}
+	return base + ".bin"
+}
+
+// isBinary reports whether path names a file written by convert, which
+// only the binary strategy reads as records rather than text.
+func isBinary(path string) bool {
+	return strings.HasSuffix(path, ".bin")
+}
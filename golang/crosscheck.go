@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"unicode/utf8"
+)
+
+var crosscheck = flag.Bool("crosscheck", false, "fail any strategy whose stations differ from the first successful strategy's, byte for byte in names, or whose names are not valid UTF-8")
+
+// crosscheckResults compares the stations of every successful strategy
+// with those of the first one, names byte for byte and aggregates exactly,
+// and fails any strategy that differs. A name that is not valid UTF-8 also
+// fails its strategy: a multi-byte name cut at a chunk or buffer boundary
+// shows up as one.
+func crosscheckResults(results []BenchmarkResult) {
+	out.Headerf("=== Crosscheck ===")
+	out.Println()
+
+	var ref *BenchmarkResult
+	for i := range results {
+		if results[i].Success {
+			ref = &results[i]
+			break
+		}
+	}
+	if ref == nil {
+		out.Println("No successful strategy to compare against")
+		out.Println()
+		return
+	}
+	out.Printf("Reference: %s (%d stations)\n", ref.StrategyName, len(ref.Stations))
+
+	want := make(map[string]strategies.StationResult, len(ref.Stations))
+	for _, st := range ref.Stations {
+		want[st.StationID] = st
+	}
+
+	for i := range results {
+		r := &results[i]
+		if !r.Success {
+			continue
+		}
+		err := checkStationNames(r.Stations)
+		if err == nil && r != ref {
+			err = diffStations(want, r.Stations)
+		}
+		if err != nil {
+			r.Success = false
+			r.Error = fmt.Errorf("crosscheck: %v", err)
+			out.Errorf("✗ %s: %v", r.StrategyName, err)
+		} else {
+			out.Successf("✓ %s", r.StrategyName)
+		}
+	}
+	out.Println()
+}
+
+// checkStationNames reports the first station name that is not valid UTF-8.
+func checkStationNames(stations []strategies.StationResult) error {
+	for _, st := range stations {
+		if !utf8.ValidString(st.StationID) {
+			return fmt.Errorf("station %q is not valid UTF-8", st.StationID)
+		}
+	}
+	return nil
+}
+
+// diffStations reports the first station of got that is missing from want
+// or has different aggregates, or else a station of want missing from got.
+func diffStations(want map[string]strategies.StationResult, got []strategies.StationResult) error {
+	for _, st := range got {
+		exp, ok := want[st.StationID]
+		if !ok {
+			return fmt.Errorf("unexpected station %q", st.StationID)
+		}
+		if st.Count != exp.Count || st.Sum != exp.Sum || st.Minimum != exp.Minimum || st.Maximum != exp.Maximum {
+			return fmt.Errorf("station %q: count=%d sum=%d min=%d max=%d, want count=%d sum=%d min=%d max=%d",
+				st.StationID, st.Count, st.Sum, st.Minimum, st.Maximum, exp.Count, exp.Sum, exp.Minimum, exp.Maximum)
+		}
+	}
+	if len(got) != len(want) {
+		return fmt.Errorf("%d stations, want %d", len(got), len(want))
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// resultEntry is one station of a printed result, its values as written.
+type resultEntry struct {
+	name           string
+	min, mean, max string
+	count          int64 // -1 when the format has no counts
+}
+
+// parseResultEntries reads a result printed in the 1BRC's format,
+// {a=1.0/2.0/3.0, b=...}, or one a=1.0/2.0/3.0 per line.
+func parseResultEntries(output string) ([]resultEntry, error) {
+	body := strings.TrimSpace(output)
+	var lines []string
+	if strings.HasPrefix(body, "{") && strings.HasSuffix(body, "}") {
+		lines = strings.Split(body[1:len(body)-1], ", ")
+	} else {
+		lines = strings.Split(body, "\n")
+	}
+
+	var entries []resultEntry
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		i := strings.LastIndexByte(line, '=')
+		values := strings.Split(line[i+1:], "/")
+		if i <= 0 || len(values) != 3 {
+			return nil, fmt.Errorf("%q is not name=min/mean/max", line)
+		}
+		entries = append(entries, resultEntry{line[:i], values[0], values[1], values[2], -1})
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no stations")
+	}
+	return entries, nil
+}
+
+// readResultFile reads the stations of a result file: the 1BRC's format,
+// as merge and most implementations print it, or the JSON array that
+// serve's /stations returns.
+func readResultFile(path string) ([]resultEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		return parseResultEntries(string(data))
+	}
+
+	var stations []stationJSON
+	if err := json.Unmarshal(data, &stations); err != nil {
+		return nil, err
+	}
+	entries := make([]resultEntry, len(stations))
+	for i, st := range stations {
+		entries[i] = resultEntry{st.Name, st.Min.String(), st.Mean.String(), st.Max.String(), st.Count}
+	}
+	return entries, nil
+}
+
+// runDiffCommand implements "diff [flags] a b": it compares the stations
+// of two result files and returns 0 if they agree, 1 if they do not and 2
+// on a usage error.
+func runDiffCommand(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	tolerance := fs.Float64("tolerance", 0, "largest difference in min, mean or max still counted as equal, e.g. 0.1")
+	noColor := fs.Bool("no-color", false, "disable ANSI colors (also honors NO_COLOR and non-TTY output)")
+	fs.StringVar(filter, "filter", "", "regular expression a station name must match as a whole, e.g. 'Ber.*': compare only the matching stations")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s diff [flags] a b\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Compare two results, each in the 1BRC output format or serve's JSON, and list the\n")
+		fmt.Fprintf(fs.Output(), "stations missing from either and every value that differs. Exits 1 on a mismatch.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	out = newOutput(*noColor)
+
+	if fs.NArg() != 2 || *tolerance < 0 {
+		fs.Usage()
+		return 2
+	}
+	if err := setStationFilter(); err != nil {
+		out.Errorf("Error: %v", err)
+		return 2
+	}
+	pathA, pathB := fs.Arg(0), fs.Arg(1)
+	a, err := readResultFile(pathA)
+	if err != nil {
+		out.Errorf("Error reading %s: %v", pathA, err)
+		return 1
+	}
+	b, err := readResultFile(pathB)
+	if err != nil {
+		out.Errorf("Error reading %s: %v", pathB, err)
+		return 1
+	}
+	unselected := func(e resultEntry) bool { return !selected(e.name) }
+	a, b = slices.DeleteFunc(a, unselected), slices.DeleteFunc(b, unselected)
+	out.Printf("%s %s (%d stations)\n", out.Paint("A:", ColorBlue), pathA, len(a))
+	out.Printf("%s %s (%d stations)\n\n", out.Paint("B:", ColorBlue), pathB, len(b))
+
+	differences := diffResults(a, b, *tolerance)
+	if differences == 0 {
+		out.Successf("✓ Results agree")
+		return 0
+	}
+	out.Println()
+	out.Errorf("✗ %d differences", differences)
+	return 1
+}
+
+// diffResults prints every station of a or b missing from the other and
+// every field that differs by more than tolerance, in name order, and
+// returns how many it printed.
+func diffResults(a, b []resultEntry, tolerance float64) int {
+	byName := make(map[string]resultEntry, len(b))
+	for _, e := range b {
+		byName[e.name] = e
+	}
+	names := make([]string, 0, len(a)+len(b))
+	inA := make(map[string]resultEntry, len(a))
+	for _, e := range a {
+		inA[e.name] = e
+		names = append(names, e.name)
+	}
+	for _, e := range b {
+		if _, ok := inA[e.name]; !ok {
+			names = append(names, e.name)
+		}
+	}
+	slices.Sort(names)
+
+	differences := 0
+	for _, name := range names {
+		ea, okA := inA[name]
+		eb, okB := byName[name]
+		switch {
+		case !okB:
+			out.Errorf("  %q only in A", name)
+			differences++
+			continue
+		case !okA:
+			out.Errorf("  %q only in B", name)
+			differences++
+			continue
+		}
+		for _, f := range []struct{ field, a, b string }{
+			{"min", ea.min, eb.min}, {"mean", ea.mean, eb.mean}, {"max", ea.max, eb.max},
+		} {
+			if delta, ok := valueDelta(f.a, f.b); !ok || math.Abs(delta) > tolerance+1e-9 {
+				out.Errorf("  %q %s: %s vs %s", name, f.field, f.a, f.b)
+				differences++
+			}
+		}
+		if ea.count >= 0 && eb.count >= 0 && ea.count != eb.count {
+			out.Errorf("  %q count: %d vs %d", name, ea.count, eb.count)
+			differences++
+		}
+	}
+	return differences
+}
+
+// valueDelta returns b - a, and false if either is not a number.
+func valueDelta(a, b string) (float64, bool) {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	return y - x, errA == nil && errB == nil
+}
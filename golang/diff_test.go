@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseResultEntries(t *testing.T) {
+	want := []resultEntry{{"Abha", "-2.3", "18.6", "40.2", -1}, {"São Paulo", "21.4", "23.5", "25.6", -1}, {"a=b", "1.0", "1.0", "1.0", -1}}
+	for _, output := range []string{
+		"{Abha=-2.3/18.6/40.2, São Paulo=21.4/23.5/25.6, a=b=1.0/1.0/1.0}\n",
+		"Abha=-2.3/18.6/40.2\nSão Paulo=21.4/23.5/25.6\n\na=b=1.0/1.0/1.0",
+	} {
+		entries, err := parseResultEntries(output)
+		if err != nil {
+			t.Errorf("%q: %v", output, err)
+			continue
+		}
+		if fmt.Sprint(entries) != fmt.Sprint(want) {
+			t.Errorf("%q parsed as %v, want %v", output, entries, want)
+		}
+	}
+
+	for _, output := range []string{"", "{}", "Abha=1.0/2.0", "=1.0/2.0/3.0", "Abha"} {
+		if _, err := parseResultEntries(output); err == nil {
+			t.Errorf("%q: no error", output)
+		}
+	}
+}
+
+func TestDiffResults(t *testing.T) {
+	a := []resultEntry{
+		{"Abha", "-2.3", "18.6", "40.2", 3},
+		{"Berlin", "-10.3", "2.1", "9.9", 4},
+		{"Oslo", "1.0", "1.0", "1.0", 1},
+	}
+	b := []resultEntry{
+		{"Abha", "-2.3", "18.7", "40.2", 3},
+		{"Berlin", "-10.3", "2.1", "9.9", 5},
+		{"Rome", "1.0", "1.0", "1.0", -1},
+	}
+	buf := captureOutput(t)
+	if n := diffResults(a, b, 0); n != 4 {
+		t.Errorf("found %d differences, want 4:\n%s", n, buf)
+	}
+	want := `  "Abha" mean: 18.6 vs 18.7
+  "Berlin" count: 4 vs 5
+  "Oslo" only in A
+  "Rome" only in B
+`
+	if buf.String() != want {
+		t.Errorf("printed:\n%s\nwant:\n%s", buf, want)
+	}
+
+	buf.Reset()
+	if n := diffResults(a[:1], b[:1], 0.1); n != 0 {
+		t.Errorf("found %d differences within the tolerance:\n%s", n, buf)
+	}
+	if n := diffResults([]resultEntry{{"Abha", "x", "1.0", "1.0", -1}}, a[:1], 100); n != 1 {
+		t.Errorf("found %d differences against a value that is not a number, want 1", n)
+	}
+}
+
+func TestRunDiffCommand(t *testing.T) {
+	savedOut, savedFilter := out, *filter
+	t.Cleanup(func() {
+		out, *filter = savedOut, savedFilter
+		setStationFilter()
+	})
+
+	text := writeTestFile(t, "a.txt", "{Abha=-2.3/18.6/40.2, Berlin=-10.3/2.1/9.9}\n")
+	json := writeTestFile(t, "b.json", `[{"name":"Abha","min":-2.3,"mean":18.6,"max":40.2,"count":3},`+
+		`{"name":"Berlin","min":-10.3,"mean":2.2,"max":9.9,"count":4}]`)
+	for _, tc := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{text, text}, 0},
+		{[]string{text, json}, 1},
+		{[]string{"-tolerance", "0.1", text, json}, 0},
+		{[]string{"-filter", "Abha", text, json}, 0},
+		{[]string{text, json + ".missing"}, 1},
+		{[]string{text}, 2},
+		{[]string{"-tolerance", "-1", text, json}, 2},
+		{[]string{"-filter", "(", text, json}, 2},
+	} {
+		if got := runDiffCommand(tc.args); got != tc.want {
+			t.Errorf("diff %s exited %d, want %d", strings.Join(tc.args, " "), got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"os"
+	"time"
+)
+
+var estimate = flag.Bool("estimate-stations", false, "first estimate the distinct stations with a HyperLogLog pass, size every strategy's tables and maps for them and suggest a dense-array or map strategy")
+
+// stationEstimate is the number of distinct stations -estimate-stations
+// found, which strategyOptions sizes the tables and maps for; 0 without it.
+var stationEstimate int
+
+// estimateStations runs the HyperLogLog pre-pass over dataFile for
+// -estimate-stations, prints the estimate with the table and map sizes it
+// leads to and the strategy it suggests, and returns it.
+func estimateStations(dataFile string, opts strategies.StrategyOptions) int {
+	start := time.Now()
+	n, err := strategies.EstimateStations(context.Background(), dataFile, opts)
+	if err != nil {
+		out.Errorf("Error estimating the stations of %s: %v", dataFile, err)
+		os.Exit(1)
+	}
+	sized := strategies.SizedOptions(opts, n)
+	layout := "Go maps"
+	if n <= strategies.MaxDenseStations {
+		layout = "a dense array"
+	}
+	out.Printf("%s ~%d distinct (HyperLogLog, %s); tables of %d slots, maps for %d\n", out.Paint("Stations:", ColorBlue),
+		n, formatDuration(time.Since(start)), sized.TableSize, sized.MapCapacity)
+	out.Printf("%s %s, which aggregates into %s\n\n", out.Paint("Suggested strategy:", ColorBlue), strategies.SuggestStrategy(n), layout)
+	return n
+}
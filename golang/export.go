@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+var export = flag.String("export", "", "write the first successful strategy's stations to files, a comma-separated list of format:file with a format of csv, json, parquet (built with -tags parquet) or sqlite, which adds every strategy's outcome and the run's settings, e.g. csv:stations.csv,sqlite:results.db")
+
+// The tables -export sqlite writes, as its schema declares them.
+const (
+	exportRunSQL = `CREATE TABLE run (finished_at TEXT, data_file TEXT, file_bytes INTEGER, stations_from TEXT, ` +
+		`rows INTEGER, stations INTEGER, delimiter TEXT, decimals INTEGER, unit TEXT, hash TEXT, workers INTEGER, ` +
+		`go_version TEXT, os TEXT, arch TEXT, cpus INTEGER)`
+	exportStrategiesSQL = `CREATE TABLE strategies (name TEXT, ok INTEGER, error TEXT, seconds REAL, ` +
+		`memory_mb REAL, rows INTEGER, stations INTEGER, malformed INTEGER)`
+	exportStationsSQL = `CREATE TABLE stations (name TEXT, min REAL, mean REAL, max REAL, count INTEGER, ` +
+		`stddev REAL, p50 REAL, p90 REAL, p99 REAL)`
+)
+
+// exportRun is what -export writes from: the stations of source, the
+// first successful strategy, and the run around them.
+type exportRun struct {
+	source   *BenchmarkResult
+	results  []BenchmarkResult
+	dataFile string
+	dataSize int64
+	opts     strategies.StrategyOptions
+}
+
+// exportWriters maps each -export format to the function writing it.
+var exportWriters = map[string]func(path string, run exportRun) error{
+	"csv":     exportCSV,
+	"json":    exportJSON,
+	"parquet": exportParquet,
+	"sqlite":  exportSQLite,
+}
+
+// exportTarget is one format:file of -export.
+type exportTarget struct {
+	format, path string
+}
+
+// exportTargets parses -export, a comma-separated list of format:file.
+func exportTargets() ([]exportTarget, error) {
+	var targets []exportTarget
+	for _, spec := range strings.Split(*export, ",") {
+		format, path, ok := strings.Cut(spec, ":")
+		if _, known := exportWriters[format]; !ok || !known || path == "" {
+			return nil, fmt.Errorf("-export takes format:file, with a format of %s, got %q", exportFormats(), spec)
+		}
+		if format == "parquet" && !parquetBuilt {
+			return nil, fmt.Errorf("-export parquet needs a benchmark built with -tags parquet")
+		}
+		targets = append(targets, exportTarget{format, path})
+	}
+	return targets, nil
+}
+
+// exportFormats lists the -export formats in order.
+func exportFormats() string {
+	formats := make([]string, 0, len(exportWriters))
+	for format := range exportWriters {
+		formats = append(formats, format)
+	}
+	slices.Sort(formats)
+	return strings.Join(formats, ", ")
+}
+
+// exportResults writes the stations of the first successful strategy to
+// every -export target, replacing any file there.
+func exportResults(results []BenchmarkResult, targets []exportTarget, dataFile string, dataSize int64, opts strategies.StrategyOptions) {
+	i := stationsFrom(results)
+	if i < 0 {
+		out.Errorf("No successful strategy to export results from")
+		out.Println()
+		return
+	}
+	run := exportRun{&results[i], results, dataFile, dataSize, opts}
+	for _, t := range targets {
+		if err := exportWriters[t.format](t.path, run); err != nil {
+			out.Errorf("Error exporting %s to %s: %v", t.format, t.path, err)
+			continue
+		}
+		out.Printf("%s %s %s\n", out.Paint("Exported:", ColorBlue), t.path,
+			out.Paint(fmt.Sprintf("(%s, %d stations from %s)", t.format, len(run.source.Stations), run.source.StrategyName), ColorYellow))
+	}
+	out.Println()
+}
+
+// writeFile creates path and writes it with write, reporting the first of
+// their errors and closing the file's.
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// exportCSV writes the stations as the REPL's export csv does.
+func exportCSV(path string, run exportRun) error {
+	return writeFile(path, func(w io.Writer) error {
+		return writeStationsCSV(w, run.source.Stations)
+	})
+}
+
+// exportJSON writes the stations as the array serve returns for
+// /stations, which diff also reads.
+func exportJSON(path string, run exportRun) error {
+	return writeFile(path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stationViews(run.source.Stations, decimals))
+	})
+}
+
+// exportSQLite writes the stations to a new SQLite database with the run's
+// settings and every strategy's outcome alongside. Temperatures are in
+// degrees of -unit; the spread is NULL without -distribution.
+func exportSQLite(path string, run exportRun) error {
+	scale := math.Pow10(decimals)
+	degrees := func(v float64) float64 { return inUnit(v, decimals) / scale }
+	stations := make([][]any, len(run.source.Stations))
+	for i, st := range run.source.Stations {
+		row := []any{st.StationID, degrees(float64(st.Minimum)), degrees(st.Average), degrees(float64(st.Maximum)), st.Count,
+			nil, nil, nil, nil}
+		if d := distributionOf(st); d != nil {
+			row[5] = d.StdDev() * outputUnit.scale / scale
+			row[6], row[7], row[8] = degrees(d.Median()), degrees(d.Quantile(0.9)), degrees(d.Quantile(0.99))
+		}
+		stations[i] = row
+	}
+
+	outcomes := make([][]any, len(run.results))
+	for i, r := range run.results {
+		row := []any{r.StrategyName, int64(0), nil, r.ExecutionTime.Seconds(), float64(r.MemoryUsed) / 1024 / 1024,
+			r.Rows, int64(r.ResultCount), nil}
+		if r.Success {
+			row[1] = int64(1)
+		}
+		if r.Error != nil {
+			row[2] = r.Error.Error()
+		}
+		if r.MalformedLines >= 0 {
+			row[7] = r.MalformedLines
+		}
+		outcomes[i] = row
+	}
+
+	settings := []any{time.Now().UTC().Format(time.RFC3339), run.dataFile, run.dataSize, run.source.StrategyName,
+		run.source.Rows, int64(len(run.source.Stations)), delimiter, int64(decimals), *unit, hashFunc, int64(run.opts.Workers),
+		runtime.Version(), runtime.GOOS, runtime.GOARCH, int64(runtime.NumCPU())}
+	if run.opts.Workers == 0 {
+		settings[10] = int64(runtime.NumCPU())
+	}
+
+	return writeSQLite(path, []sqliteTable{
+		{"run", exportRunSQL, [][]any{settings}},
+		{"strategies", exportStrategiesSQL, outcomes},
+		{"stations", exportStationsSQL, stations},
+	})
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestExportSQLiteRoundTrip(t *testing.T) {
+	many := make([]strategies.StationResult, 20000) // enough leaves for two interior levels
+	for i := range many {
+		many[i] = testStation(fmt.Sprintf("Station %05d", i), false, int64(i%999-499), int64(i%7))
+	}
+	many = append(many, testStation(strings.Repeat("Long", 3000), false, 1)) // spills to overflow pages
+
+	for _, tc := range []struct {
+		name     string
+		stations []strategies.StationResult
+	}{
+		{"distribution", testStations(true)},
+		{"many", many},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			captureOutput(t)
+			path := filepath.Join(t.TempDir(), "results.db")
+			results := testResults(tc.stations)
+			exportResults(results, []exportTarget{{"sqlite", path}}, "measurements.txt", 1234, strategies.StrategyOptions{Workers: 3})
+
+			db := readSQLite(t, path)
+			for name, sql := range map[string]string{"run": exportRunSQL, "strategies": exportStrategiesSQL, "stations": exportStationsSQL} {
+				if db[name].sql != sql {
+					t.Errorf("table %s is declared as %q, want %q", name, db[name].sql, sql)
+				}
+			}
+
+			run := db["run"].rows
+			if len(run) != 1 {
+				t.Fatalf("run has %d rows, want 1", len(run))
+			}
+			wantRun := map[int]any{1: "measurements.txt", 2: int64(1234), 3: "Swiss", 4: results[1].Rows,
+				5: int64(len(tc.stations)), 6: ";", 7: int64(1), 8: "celsius", 9: "fnv32", 10: int64(3), 12: runtime.GOOS}
+			for col, want := range wantRun {
+				if run[0][col] != want {
+					t.Errorf("run column %d = %v, want %v", col, run[0][col], want)
+				}
+			}
+
+			outcomes := db["strategies"].rows
+			if len(outcomes) != 2 {
+				t.Fatalf("strategies has %d rows, want 2", len(outcomes))
+			}
+			if outcomes[0][0] != "Broken" || outcomes[0][1] != int64(0) || outcomes[0][2] != os.ErrNotExist.Error() || outcomes[0][7] != nil {
+				t.Errorf("failed strategy exported as %v", outcomes[0])
+			}
+			if outcomes[1][0] != "Swiss" || outcomes[1][1] != int64(1) || outcomes[1][2] != nil || outcomes[1][7] != int64(3) {
+				t.Errorf("successful strategy exported as %v", outcomes[1])
+			}
+
+			rows := db["stations"].rows
+			if len(rows) != len(tc.stations) {
+				t.Fatalf("stations has %d rows, want %d", len(rows), len(tc.stations))
+			}
+			for i, st := range tc.stations {
+				want := []any{st.StationID, float64(st.Minimum) / 10, st.Average / 10, float64(st.Maximum) / 10, st.Count,
+					nil, nil, nil, nil}
+				if d := distributionOf(st); d != nil {
+					want[5], want[6], want[7], want[8] = d.StdDev()/10, d.Median()/10, d.Quantile(0.9)/10, d.Quantile(0.99)/10
+				}
+				if !sameRow(rows[i], want) {
+					t.Errorf("row %d = %v, want %v", i+1, rows[i], want)
+				}
+			}
+		})
+	}
+}
+
+// TestExportSQLiteOpensInSQLite checks the file against SQLite itself,
+// where the sqlite3 shell is installed.
+func TestExportSQLiteOpensInSQLite(t *testing.T) {
+	shell, err := exec.LookPath("sqlite3")
+	if err != nil {
+		t.Skip("no sqlite3 shell")
+	}
+	captureOutput(t)
+	path := filepath.Join(t.TempDir(), "results.db")
+	stations := testStations(true)
+	exportResults(testResults(stations), []exportTarget{{"sqlite", path}}, "measurements.txt", 1234, strategies.StrategyOptions{})
+
+	query := "PRAGMA integrity_check; SELECT name, max, count FROM stations WHERE min < 0 ORDER BY name; SELECT count(*) FROM strategies WHERE ok;"
+	got, err := exec.Command(shell, path, query).CombinedOutput()
+	if err != nil {
+		t.Fatalf("sqlite3: %v\n%s", err, got)
+	}
+	if want := "ok\nAbha|40.2|3\nBerlin|9.9|4\n1\n"; string(got) != want {
+		t.Errorf("sqlite3 printed %q, want %q", got, want)
+	}
+}
+
+func TestExportJSONReadsBackForDiff(t *testing.T) {
+	captureOutput(t)
+	path := filepath.Join(t.TempDir(), "results.json")
+	stations := testStations(true)
+	exportResults(testResults(stations), []exportTarget{{"json", path}}, "measurements.txt", 0, strategies.StrategyOptions{})
+
+	entries, err := readResultFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []resultEntry{
+		{"Abha", "-2.3", "18.6", "40.2", 3},
+		{"Berlin", "-10.3", "2.1", "9.9", 4},
+		{"Bern", "0.0", "0.0", "0.0", 1},
+		{"São Paulo", "21.4", "23.5", "25.6", 2},
+	}
+	if fmt.Sprint(entries) != fmt.Sprint(want) {
+		t.Errorf("read back %v, want %v", entries, want)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	captureOutput(t)
+	path := filepath.Join(t.TempDir(), "results.csv")
+	exportResults(testResults(testStations(false)), []exportTarget{{"csv", path}}, "measurements.txt", 0, strategies.StrategyOptions{})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"name", "min", "mean", "max", "count"},
+		{"Abha", "-2.3", "18.6", "40.2", "3"},
+		{"Berlin", "-10.3", "2.1", "9.9", "4"},
+		{"Bern", "0.0", "0.0", "0.0", "1"},
+		{"São Paulo", "21.4", "23.5", "25.6", "2"},
+	}
+	if fmt.Sprint(records) != fmt.Sprint(want) {
+		t.Errorf("wrote %v, want %v", records, want)
+	}
+}
+
+func TestExportTargets(t *testing.T) {
+	saved := *export
+	t.Cleanup(func() { *export = saved })
+
+	*export = "csv:a.csv,sqlite:b.db"
+	targets, err := exportTargets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []exportTarget{{"csv", "a.csv"}, {"sqlite", "b.db"}}; fmt.Sprint(targets) != fmt.Sprint(want) {
+		t.Errorf("parsed %v, want %v", targets, want)
+	}
+
+	bad := []string{"csv", "csv:", "xml:a.xml", "csv:a.csv,"}
+	if !parquetBuilt {
+		bad = append(bad, "parquet:a.parquet")
+	}
+	for _, spec := range bad {
+		*export = spec
+		if _, err := exportTargets(); err == nil {
+			t.Errorf("-export %q: no error", spec)
+		}
+	}
+}
+
+// sameRow reports whether a row read back holds want, its floats to
+// within rounding.
+func sameRow(row, want []any) bool {
+	if len(row) != len(want) {
+		return false
+	}
+	for i, v := range row {
+		if f, ok := v.(float64); ok {
+			if w, ok := want[i].(float64); !ok || math.Abs(f-w) > 1e-9 {
+				return false
+			}
+		} else if v != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sqliteTestTable is a table as readSQLite decodes it: its CREATE TABLE
+// statement and its rows in rowid order.
+type sqliteTestTable struct {
+	sql  string
+	rows [][]any
+}
+
+// readSQLite decodes the database at path independently of writeSQLite,
+// following the file format: the header, the schema on page 1 and every
+// table's b-tree with its overflow chains. It fails the test on anything
+// SQLite would reject as corrupt.
+func readSQLite(t *testing.T, path string) map[string]sqliteTestTable {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) < sqliteHeaderSize || string(data[:16]) != "SQLite format 3\x00" {
+		t.Fatal("no SQLite header")
+	}
+	r := &sqliteTestReader{t: t, data: data, pageSize: int(binary.BigEndian.Uint16(data[16:]))}
+	r.usable = r.pageSize - int(data[20])
+	if pages := int(binary.BigEndian.Uint32(data[28:])); pages*r.pageSize != len(data) {
+		t.Fatalf("header counts %d pages of %d bytes in a %d-byte file", pages, r.pageSize, len(data))
+	}
+	if binary.BigEndian.Uint32(data[56:]) != 1 {
+		t.Fatal("text encoding is not UTF-8")
+	}
+
+	tables := make(map[string]sqliteTestTable)
+	for _, row := range r.tableRows(1) {
+		if len(row) != 5 || row[0] != "table" {
+			t.Fatalf("schema row %v is not a table", row)
+		}
+		name, _ := row[1].(string)
+		root, _ := row[3].(int64)
+		sql, _ := row[4].(string)
+		tables[name] = sqliteTestTable{sql, r.tableRows(uint32(root))}
+	}
+	return tables
+}
+
+type sqliteTestReader struct {
+	t                *testing.T
+	data             []byte
+	pageSize, usable int
+}
+
+// page returns page n, which must exist.
+func (r *sqliteTestReader) page(n uint32) []byte {
+	if n < 1 || int(n)*r.pageSize > len(r.data) {
+		r.t.Fatalf("page %d out of range", n)
+	}
+	return r.data[int(n-1)*r.pageSize : int(n)*r.pageSize]
+}
+
+// tableRows returns the rows of the table b-tree rooted at page root,
+// checking that the rowids ascend and stay within their parents' keys.
+func (r *sqliteTestReader) tableRows(root uint32) [][]any {
+	var rows [][]any
+	last := int64(0)
+	var walk func(n uint32, maxKey int64)
+	walk = func(n uint32, maxKey int64) {
+		page := r.page(n)
+		header := page
+		if n == 1 {
+			header = page[sqliteHeaderSize:]
+		}
+		cells := int(binary.BigEndian.Uint16(header[3:]))
+		switch header[0] {
+		case 0x05:
+			if cells == 0 {
+				r.t.Fatalf("interior page %d has no cells", n)
+			}
+			for i := range cells {
+				cell := page[binary.BigEndian.Uint16(header[12+2*i:]):]
+				key, _ := readSQLiteVarint(cell[4:])
+				walk(binary.BigEndian.Uint32(cell), int64(key))
+			}
+			walk(binary.BigEndian.Uint32(header[8:]), maxKey)
+		case 0x0d:
+			for i := range cells {
+				cell := page[binary.BigEndian.Uint16(header[8+2*i:]):]
+				size, n1 := readSQLiteVarint(cell)
+				rowid, n2 := readSQLiteVarint(cell[n1:])
+				if int64(rowid) <= last || int64(rowid) > maxKey {
+					r.t.Fatalf("rowid %d out of order after %d, under a key of %d", rowid, last, maxKey)
+				}
+				last = int64(rowid)
+				rows = append(rows, r.record(r.payload(cell[n1+n2:], int(size))))
+			}
+		default:
+			r.t.Fatalf("page %d is of kind %#x, not a table b-tree page", n, header[0])
+		}
+	}
+	walk(root, math.MaxInt64)
+	return rows
+}
+
+// payload returns the size bytes of a leaf cell's record, which starts at
+// cell and continues on overflow pages if it is too big for the page.
+func (r *sqliteTestReader) payload(cell []byte, size int) []byte {
+	maxLocal := r.usable - 35
+	if size <= maxLocal {
+		return cell[:size]
+	}
+	minLocal := (r.usable-12)*32/255 - 23
+	local := minLocal + (size-minLocal)%(r.usable-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+	payload := append([]byte(nil), cell[:local]...)
+	for next := binary.BigEndian.Uint32(cell[local:]); len(payload) < size; {
+		if next == 0 {
+			r.t.Fatalf("overflow chain ends %d bytes short", size-len(payload))
+		}
+		page := r.page(next)
+		payload = append(payload, page[4:4+min(r.usable-4, size-len(payload))]...)
+		next = binary.BigEndian.Uint32(page)
+	}
+	return payload
+}
+
+// record decodes a record into nil, int64, float64 and string values.
+func (r *sqliteTestReader) record(rec []byte) []any {
+	headerSize, n := readSQLiteVarint(rec)
+	types, body := rec[n:headerSize], rec[headerSize:]
+	var values []any
+	for len(types) > 0 {
+		typ, n := readSQLiteVarint(types)
+		types = types[n:]
+		switch {
+		case typ == 0:
+			values = append(values, nil)
+		case typ >= 1 && typ <= 6:
+			width := []int{1, 2, 3, 4, 6, 8}[typ-1]
+			var v int64
+			for _, b := range body[:width] {
+				v = v<<8 | int64(b)
+			}
+			shift := 64 - 8*width
+			values = append(values, v<<shift>>shift)
+			body = body[width:]
+		case typ == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(body)))
+			body = body[8:]
+		case typ == 8 || typ == 9:
+			values = append(values, int64(typ-8))
+		case typ >= 13 && typ%2 == 1:
+			size := (typ - 13) / 2
+			values = append(values, string(body[:size]))
+			body = body[size:]
+		default:
+			r.t.Fatalf("unexpected serial type %d", typ)
+		}
+	}
+	if len(body) != 0 {
+		r.t.Fatalf("record has %d bytes past its values", len(body))
+	}
+	return values
+}
+
+// readSQLiteVarint decodes a varint at the start of b and returns it with
+// its length.
+func readSQLiteVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := range 8 {
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return v<<8 | uint64(b[8]), 9
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"regexp"
+	"slices"
+)
+
+var filter = flag.String("filter", "", "regular expression a station name must match as a whole, e.g. 'Ber.*': print the matching stations of every strategy after the summary and rank only them with -top")
+
+// stationFilter matches the station names -filter selects; nil selects
+// every station.
+var stationFilter *regexp.Regexp
+
+// setStationFilter compiles -filter. The expression must match the whole
+// name, so "Ber.*" selects the names starting with Ber and "Berlin" only
+// Berlin.
+func setStationFilter() error {
+	if *filter == "" {
+		stationFilter = nil
+		return nil
+	}
+	// Compiled bare first, so that errors quote the expression as given.
+	if _, err := regexp.Compile(*filter); err != nil {
+		return fmt.Errorf("-filter: %v", err)
+	}
+	stationFilter = regexp.MustCompile(`^(?:` + *filter + `)$`)
+	return nil
+}
+
+// selected reports whether -filter selects the station name.
+func selected(name string) bool {
+	return stationFilter == nil || stationFilter.MatchString(name)
+}
+
+// filterStations returns the stations -filter selects, leaving stations
+// as it is.
+func filterStations(stations []strategies.StationResult) []strategies.StationResult {
+	if stationFilter == nil {
+		return stations
+	}
+	return slices.DeleteFunc(slices.Clone(stations), func(st strategies.StationResult) bool {
+		return !selected(st.StationID)
+	})
+}
+
+// printFilteredStations prints the stations -filter selects from every
+// successful or -keep-partial strategy, so that strategies disagreeing on
+// one of them stand out without the rest of the output around them.
+func printFilteredStations(results []BenchmarkResult) {
+	out.Headerf("=== Stations matching %s ===", *filter)
+	out.Println()
+	for _, r := range results {
+		if !r.Success && !r.Partial || r.Stations == nil {
+			continue
+		}
+		stations := filterStations(r.Stations)
+		count := fmt.Sprintf("(%d)", len(stations))
+		if r.Partial {
+			count = fmt.Sprintf("(%d, partial)", len(stations))
+		}
+		out.Printf("%s %s\n", out.Paint(r.StrategyName+":", ColorBlue), out.Paint(count, ColorYellow))
+		out.Println(formatStations(stations, decimals))
+	}
+	out.Println()
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"slices"
+)
+
+// The flags that tune the strategies and describe the input's records,
+// shared by the benchmark and the subcommands that build strategies.
+var (
+	workers       int
+	bufferSize    byteSize
+	chunkSize     byteSize
+	tableSize     int
+	maxLoadFactor float64
+	mapShards     int
+	maxMemory     byteSize
+	hashFunc      string
+	hugePages     bool
+	pinWorkers    bool
+	zeroCopyKeys  bool
+	lineIndex     bool
+	stationHash   strategies.HashFunc // -hash, once checkStrategyFlags looked it up
+
+	delimiter    string
+	decimals     int
+	tolerantNums bool
+	parseMode    string
+)
+
+func init() {
+	strategyFlags(flag.CommandLine)
+	recordFlags(flag.CommandLine)
+}
+
+// strategyFlags registers the flags that tune the strategies on fs.
+func strategyFlags(fs *flag.FlagSet) {
+	fs.IntVar(&workers, "workers", 0, "goroutines used by parallel strategies (0 = runtime.NumCPU())")
+	fs.Var(&bufferSize, "buffer-size", "read buffer size per worker, e.g. 64KiB or 1MiB (0 = strategy default)")
+	fs.Var(&chunkSize, "chunk-size", "size of the work units parallel strategies pull from their queue (0 = derived from file size)")
+	fs.IntVar(&tableSize, "table-size", 0, "initial slots per hash table, rounded up to a power of two; tables double at -max-load-factor, cuckoo tables at no more than 0.5 (0 = 131072)")
+	fs.Float64Var(&maxLoadFactor, "max-load-factor", 0, "share of slots a hash table fills before doubling, in (0, 1] (0 = 0.75)")
+	fs.IntVar(&mapShards, "map-shards", 0, "locked shards the sharded-map strategy splits its shared map into, rounded up to a power of two, at most 65536 (0 = 64)")
+	fs.Var(&batchSize, "batch-size", "lines the batch strategy sends its workers at a time, or auto to double them from 100 while the workers wait on its splitter, up to 4096 (default 100)")
+	fs.Var(&maxMemory, "max-memory", "stay within this much memory, e.g. 6GiB, at the cost of speed: smaller tables and buffers, fewer workers if need be, a streaming merge and -gomemlimit of the same (0 = no limit)")
+	fs.StringVar(&hashFunc, "hash", "fnv32", "station name hash used by every strategy: fnv32, fnv64, xxhash or wyhash")
+	fs.BoolVar(&hugePages, "huge-pages", false, "back linear-probing tables and mmap regions with transparent huge pages (MADV_HUGEPAGE)")
+	fs.BoolVar(&pinWorkers, "pin-workers", false, "lock each worker goroutine to an OS thread pinned to one CPU core (Linux only)")
+	fs.BoolVar(&zeroCopyKeys, "zero-copy-keys", false, "key stations by views into the mmap'd file instead of copied strings (mmap strategy)")
+	fs.BoolVar(&lineIndex, "line-index", false, "cut chunks at line starts from a <file>.lineidx sidecar, built on the first run and reused until the file changes")
+}
+
+// recordFlags registers the flags describing the input's records on fs.
+func recordFlags(fs *flag.FlagSet) {
+	fs.StringVar(&delimiter, "delimiter", ";", "byte separating station name from value, e.g. , or tab")
+	fs.IntVar(&decimals, "decimals", 1, "digits after the decimal point in every value (0-6)")
+	fs.BoolVar(&tolerantNums, "tolerant-decimals", false, "also accept values with fewer than -decimals fraction digits or none, e.g. 12 and 12.5 with -decimals=2")
+	fs.StringVar(&parseMode, "parse-mode", "lenient", "malformed lines: lenient skips and counts them, strict aborts at the first one with its line number")
+}
+
+// checkStrategyFlags checks the flags strategyFlags registers and looks up
+// -hash.
+func checkStrategyFlags() error {
+	if workers < 0 {
+		return fmt.Errorf("-workers must be >= 0 (0 = runtime.NumCPU()), got %d", workers)
+	}
+	if maxLoadFactor < 0 || maxLoadFactor > 1 {
+		return fmt.Errorf("-max-load-factor must be in (0, 1], got %g", maxLoadFactor)
+	}
+	if mapShards < 0 {
+		return fmt.Errorf("-map-shards must not be negative, got %d", mapShards)
+	}
+	var err error
+	if stationHash, err = strategies.LookupHashFunction(hashFunc); err != nil {
+		return fmt.Errorf("-hash: %v", err)
+	}
+	return nil
+}
+
+// setRecordFormat applies -delimiter, -decimals and -tolerant-decimals,
+// and checks -parse-mode. The delimiter is a single byte, or "tab" since a
+// literal tab is awkward to pass.
+func setRecordFormat() error {
+	delim := delimiter
+	if delim == "tab" {
+		delim = "\t"
+	}
+	if len(delim) != 1 {
+		return fmt.Errorf("-delimiter must be a single byte or tab, got %q", delimiter)
+	}
+	format := strategies.RecordFormat{Delimiter: delim[0], FractionDigits: decimals, TolerantNumbers: tolerantNums}
+	if err := strategies.SetRecordFormat(format); err != nil {
+		return fmt.Errorf("-delimiter/-decimals: %v", err)
+	}
+	switch parseMode {
+	case "lenient", "strict":
+	default:
+		return fmt.Errorf("-parse-mode must be lenient or strict, got %q", parseMode)
+	}
+	return nil
+}
+
+// parseModeOption maps -parse-mode to its strategies.ParseMode.
+func parseModeOption() strategies.ParseMode {
+	if parseMode == "strict" {
+		return strategies.ParseStrict
+	}
+	return strategies.ParseLenient
+}
+
+// strategyOptions builds the strategy tunables from the command line flags.
+func strategyOptions() strategies.StrategyOptions {
+	opts := strategies.StrategyOptions{
+		Workers:       workers,
+		BufferSize:    int(bufferSize),
+		ChunkSize:     int(chunkSize),
+		TableSize:     tableSize,
+		MaxLoadFactor: maxLoadFactor,
+		MapShards:     mapShards,
+		Hash:          stationHash,
+
+		BatchSize:         batchSize.lines,
+		AdaptiveBatchSize: batchSize.auto,
+
+		DisableIOHints: *ioHints == "off",
+		HugePages:      hugePages,
+		PinWorkers:     pinWorkers,
+		ZeroCopyKeys:   zeroCopyKeys,
+		LineIndex:      lineIndex,
+		ParseMode:      parseModeOption(),
+
+		SampleFraction: *sample,
+		SampleSeed:     sampleSeed,
+
+		Checkpoint:         *checkpoint,
+		CheckpointInterval: *ckptEvery,
+	}
+	if *distribution {
+		opts.NewAggregator = strategies.NewDistribution
+	}
+	if tracer != nil {
+		opts.Tracer = tracer
+	}
+	if chunkTracer != nil {
+		opts.Tracer = chunkTracer
+	}
+	opts.PhaseTimer = phaseTimer
+	if quarantine != nil {
+		opts.Quarantine = quarantine
+	}
+	if stationEstimate > 0 {
+		opts = strategies.SizedOptions(opts, stationEstimate)
+	}
+	if maxMemory > 0 {
+		opts = strategies.LowMemoryOptions(opts, int64(maxMemory))
+	}
+	return opts
+}
+
+// checkFlags checks the benchmark's flags, on their own and against each
+// other, and applies those describing the input and its output.
+func checkFlags() error {
+	if err := checkStrategyFlags(); err != nil {
+		return err
+	}
+	if *maxRows < 0 {
+		return fmt.Errorf("-max-rows must be positive, got %d", *maxRows)
+	}
+	if *ckptEvery <= 0 {
+		return fmt.Errorf("-checkpoint-every must be positive, got %v", *ckptEvery)
+	}
+	if *iterations < 1 {
+		return fmt.Errorf("-iterations must be at least 1, got %d", *iterations)
+	}
+	switch *schedule {
+	case "interleaved", "grouped":
+	default:
+		return fmt.Errorf("-schedule must be interleaved or grouped, got %q", *schedule)
+	}
+	switch *runOrderFlag {
+	case "fixed", "rotate", "shuffle":
+	default:
+		return fmt.Errorf("-order must be fixed, rotate or shuffle, got %q", *runOrderFlag)
+	}
+	if *finalists < 0 || (*finalists > 0 && heatSize <= 0) {
+		return fmt.Errorf("-finalists must be positive and -heat-size more than 0")
+	}
+	if *sample < 0 || *sample >= 1 {
+		return fmt.Errorf("-sample must be in (0, 1), got %g", *sample)
+	}
+	if _, ok := topFields[*topBy]; *top < 0 || !ok {
+		return fmt.Errorf("-top must be positive and -by max, min, mean, stddev or median, got %d and %q", *top, *topBy)
+	}
+	if (*topBy == "stddev" || *topBy == "median") && !*distribution {
+		return fmt.Errorf("-by %s needs -distribution", *topBy)
+	}
+	switch *ioHints {
+	case "on", "off", "compare":
+	default:
+		return fmt.Errorf("-io-hints must be on, off or compare, got %q", *ioHints)
+	}
+	switch *gcMode {
+	case "on", "off", "compare":
+	default:
+		return fmt.Errorf("-gc must be on, off or compare, got %q", *gcMode)
+	}
+	if *gcMode == "compare" && *ioHints == "compare" {
+		return fmt.Errorf("-gc compare and -io-hints compare cannot be used together")
+	}
+	if *quarantineTo != "" && parseMode == "strict" {
+		return fmt.Errorf("-quarantine collects the lines lenient mode skips, so it cannot go with -parse-mode strict")
+	}
+	if *checkpoint != "" && (*iterations > 1 || *ioHints == "compare" || *gcMode == "compare" || *autoTune || *finalists > 0 || *sample > 0) {
+		return fmt.Errorf("-checkpoint runs its strategy once, so it cannot go with -iterations, -io-hints=compare, -gc=compare, -autotune, -finalists or -sample")
+	}
+	if *sample > 0 && (*autoTune || *validate || *partialOut != "" || *rangeCheck || *goldenDir != "") {
+		return fmt.Errorf("-sample reads part of the file, so it cannot go with -autotune, -validate, -partial-out, -check-range or -golden")
+	}
+	if _, ok := lookupStrategy(*goldenKey); *goldenDir != "" && !ok {
+		return fmt.Errorf("unknown -golden-strategy %q (available: %s)", *goldenKey, strategyKeys())
+	}
+	if err := setRecordFormat(); err != nil {
+		return err
+	}
+	if err := setStationFilter(); err != nil {
+		return err
+	}
+	return setOutputUnit()
+}
+
+// checkInput checks that dataFile suits the flags: several of them need a
+// single uncompressed text file, a local one or one they can read more
+// than once.
+func checkInput(dataFile string) error {
+	files := dataFiles(dataFile)
+	dataset, stream, remote := datasetFiles != nil, isStream(dataFile), isURL(dataFile)
+	compressed, binary := slices.ContainsFunc(files, isCompressed), slices.ContainsFunc(files, isBinary)
+	text := !dataset && !stream && !compressed && !binary // a single text file or URL
+	localText := text && !remote && *cluster == ""        // one this process reads itself
+
+	switch {
+	case (*autoTune || *diagnoseHash) && dataset:
+		return fmt.Errorf("-autotune and -diagnose-hash need a single data file")
+	case (*autoTune || *diagnoseHash) && compressed:
+		return fmt.Errorf("-autotune and -diagnose-hash need an uncompressed data file")
+	case (*autoTune || *diagnoseHash || *validate) && binary:
+		return fmt.Errorf("-autotune, -diagnose-hash and -validate need a text data file")
+	case (*autoTune || *diagnoseHash || *validate || *ioHints == "compare" || *iterations > 1) && stream:
+		return fmt.Errorf("-autotune, -diagnose-hash, -validate, -io-hints=compare and -iterations need a regular file")
+	case (*autoTune || *diagnoseHash || *validate) && remote:
+		return fmt.Errorf("-autotune, -diagnose-hash and -validate need a local file")
+	case *cluster != "" && (stream || compressed || binary):
+		return fmt.Errorf("-cluster needs a text file or URL the workers can open")
+	case *sample > 0 && !localText:
+		return fmt.Errorf("-sample needs a single local text file")
+	case *referenceCmd != "" && (dataset || stream || remote):
+		return fmt.Errorf("-reference-cmd needs a single local data file")
+	case *repl && slices.ContainsFunc(files, isStdin):
+		return fmt.Errorf("-repl reads its queries from stdin, so the data cannot come from it")
+	case *estimate && !text:
+		return fmt.Errorf("-estimate-stations needs a single text file or URL")
+	case *rangeCheck && !text:
+		return fmt.Errorf("-check-range needs a single text file or URL")
+	case *goldenDir != "" && (dataset || stream || remote):
+		return fmt.Errorf("-golden needs a single local data file")
+	case (*maxRows > 0 || maxBytes > 0) && !localText:
+		return fmt.Errorf("-max-rows and -max-bytes need a single local text file")
+	case *checkpoint != "" && (!text || *cluster != ""):
+		return fmt.Errorf("-checkpoint needs a single text file or URL")
+	case *finalists > 0 && !localText:
+		return fmt.Errorf("-finalists needs a single local text file")
+	}
+	return nil
+}
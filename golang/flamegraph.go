@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/pprof"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var flamegraph = flag.String("flamegraph", "", "write per-strategy CPU profiles and SVG flamegraphs to directory")
+
+// strategyProfile captures a CPU profile for a single strategy run so that a
+// flamegraph can be rendered for it once the run finishes.
+type strategyProfile struct {
+	file *os.File
+	path string
+}
+
+// startStrategyProfile begins CPU profiling into <dir>/<slug>.cpu.prof.
+// Only one CPU profile can be active per process, so this cannot be combined
+// with the global -cpuprofile flag.
+func startStrategyProfile(dir, strategyName string) (*strategyProfile, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, slugify(strategyName)+".cpu.prof")
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &strategyProfile{file: f, path: path}, nil
+}
+
+// stop ends profiling and closes the profile file.
+func (p *strategyProfile) stop() error {
+	pprof.StopCPUProfile()
+	return p.file.Close()
+}
+
+// foldedStacks reads the profile back as folded stacks ("root;child;leaf
+// <microseconds>"), the format flamegraph.pl, speedscope and inferno read.
+func (p *strategyProfile) foldedStacks() ([]string, error) {
+	traces, err := runPprof("-traces", p.path)
+	if err != nil {
+		return nil, err
+	}
+	return foldTraces(traces), nil
+}
+
+// writeFoldedStacks saves folded stacks next to the profile.
+func (p *strategyProfile) writeFoldedStacks(folded []string) (string, error) {
+	foldedPath := strings.TrimSuffix(p.path, ".prof") + ".folded"
+	f, err := os.Create(foldedPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, stack := range folded {
+		fmt.Fprintln(w, stack)
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return foldedPath, nil
+}
+
+// renderFlamegraph draws folded stacks as an SVG flamegraph next to the
+// profile: one box per frame, as wide as its share of the samples, on top
+// of its caller. Unlike pprof's call graph, it needs no graphviz.
+func (p *strategyProfile) renderFlamegraph(title string, folded []string) (string, error) {
+	svgPath := strings.TrimSuffix(p.path, ".prof") + ".svg"
+	f, err := os.Create(svgPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	writeFlamegraphSVG(w, title, buildFlameTree(folded))
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return svgPath, nil
+}
+
+func runPprof(args ...string) ([]byte, error) {
+	cmd := exec.Command("go", append([]string{"tool", "pprof"}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go tool pprof: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// foldTraces parses `go tool pprof -traces` output, where each sample is a
+// block of frames listed leaf-first with the sample value on the first line,
+// and returns one folded line per distinct stack.
+func foldTraces(traces []byte) []string {
+	totals := make(map[string]int64)
+
+	var frames []string
+	var value int64
+	flush := func() {
+		if len(frames) > 0 {
+			slices.Reverse(frames)
+			totals[strings.Join(frames, ";")] += value
+		}
+		frames, value = frames[:0], 0
+	}
+
+	inBlock := false
+	for line := range strings.Lines(string(traces)) {
+		line = strings.TrimRight(line, "\n")
+		if strings.HasPrefix(line, "-----------+") {
+			flush()
+			inBlock = true
+			continue
+		}
+		if !inBlock {
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimSuffix(line, " (inline)"))
+		switch {
+		case len(fields) == 0:
+			continue
+		case len(frames) == 0 && len(fields) == 2:
+			if d, err := time.ParseDuration(fields[0]); err == nil {
+				value = d.Microseconds()
+			}
+			frames = append(frames, fields[1])
+		default:
+			frames = append(frames, fields[0])
+		}
+	}
+	flush()
+
+	folded := make([]string, 0, len(totals))
+	for stack, v := range totals {
+		folded = append(folded, fmt.Sprintf("%s %d", stack, v))
+	}
+	slices.Sort(folded)
+	return folded
+}
+
+// slugify turns a display name like "MCMP Strategy" into "mcmp-strategy".
+func slugify(name string) string {
+	var b strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			lastDash = false
+		} else if !lastDash && b.Len() > 0 {
+			b.WriteByte('-')
+			lastDash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// writeFlamegraph stops the strategy's CPU profile and renders it as folded
+// stacks and an SVG flamegraph. Failures are reported but do not fail the
+// benchmark.
+func writeFlamegraph(profile *strategyProfile, strategyName string) {
+	if err := profile.stop(); err != nil {
+		out.Errorf("Error writing CPU profile: %v", err)
+		return
+	}
+
+	folded, err := profile.foldedStacks()
+	if err != nil {
+		out.Warnf("⚠ Folding stacks failed for %s: %v", profile.path, err)
+		return
+	}
+	if foldedPath, err := profile.writeFoldedStacks(folded); err != nil {
+		out.Warnf("⚠ Folded stacks failed for %s: %v", profile.path, err)
+	} else {
+		out.Successf("🔥 Folded stacks saved → %s", foldedPath)
+	}
+
+	if svgPath, err := profile.renderFlamegraph(strategyName, folded); err != nil {
+		out.Warnf("⚠ SVG rendering failed for %s: %v", profile.path, err)
+	} else {
+		out.Successf("🔥 Flamegraph saved → %s", svgPath)
+	}
+}
+
+// flameNode is a frame of a flamegraph: the samples spent in it and its
+// callees, which are its children.
+type flameNode struct {
+	name     string
+	value    int64
+	children []*flameNode
+}
+
+// child returns n's child named name, adding it if it has none.
+func (n *flameNode) child(name string) *flameNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &flameNode{name: name}
+	n.children = append(n.children, c)
+	return c
+}
+
+// buildFlameTree merges folded stacks into a tree under a root holding
+// every sample. Children are sorted by name, as flamegraph.pl does, so that
+// the same frames line up from one profile to the next.
+func buildFlameTree(folded []string) *flameNode {
+	root := &flameNode{name: "all"}
+	for _, line := range folded {
+		i := strings.LastIndexByte(line, ' ')
+		if i < 0 {
+			continue
+		}
+		value, err := strconv.ParseInt(line[i+1:], 10, 64)
+		if err != nil || value <= 0 {
+			continue
+		}
+		root.value += value
+		n := root
+		for _, frame := range strings.Split(line[:i], ";") {
+			n = n.child(frame)
+			n.value += value
+		}
+	}
+	var sortChildren func(n *flameNode)
+	sortChildren = func(n *flameNode) {
+		slices.SortFunc(n.children, func(a, b *flameNode) int { return strings.Compare(a.name, b.name) })
+		for _, c := range n.children {
+			sortChildren(c)
+		}
+	}
+	sortChildren(root)
+	return root
+}
+
+const (
+	flameWidth     = 1200 // SVG width in pixels
+	flameRowHeight = 16
+	flameCharWidth = 7 // approximate width of a label character
+)
+
+// writeFlamegraphSVG draws root's tree with the root at the bottom. Hovering
+// a box shows its frame, samples and share of the total.
+func writeFlamegraphSVG(w io.Writer, title string, root *flameNode) {
+	depth := flameDepth(root)
+	height := (depth+2)*flameRowHeight + 8
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace" font-size="11">`+"\n", flameWidth, height)
+	fmt.Fprintf(w, `<text x="%d" y="14" text-anchor="middle" font-size="14">%s</text>`+"\n", flameWidth/2, html.EscapeString(title))
+	if root.value == 0 {
+		fmt.Fprintln(w, "</svg>")
+		return
+	}
+
+	scale := float64(flameWidth) / float64(root.value)
+	var draw func(n *flameNode, x float64, level int)
+	draw = func(n *flameNode, x float64, level int) {
+		width := float64(n.value) * scale
+		if width < 0.5 {
+			return
+		}
+		y := height - (level+1)*flameRowHeight
+		share := 100 * float64(n.value) / float64(root.value)
+		fmt.Fprintf(w, `<g><title>%s (%s, %.2f%%)</title><rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s" rx="2"/>`,
+			html.EscapeString(n.name), time.Duration(n.value)*time.Microsecond, share, x, y, width, flameRowHeight-1, flameColor(n.name))
+		if chars := int(width/flameCharWidth) - 1; chars >= 3 {
+			label := n.name[strings.LastIndexByte(n.name, '/')+1:] // the full name is in the title
+			if len(label) > chars {
+				label = label[:chars-2] + ".."
+			}
+			fmt.Fprintf(w, `<text x="%.1f" y="%d">%s</text>`, x+3, y+flameRowHeight-4, html.EscapeString(label))
+		}
+		fmt.Fprintln(w, "</g>")
+		for _, c := range n.children {
+			draw(c, x, level+1)
+			x += float64(c.value) * scale
+		}
+	}
+	draw(root, 0, 0)
+	fmt.Fprintln(w, "</svg>")
+}
+
+// flameDepth returns the number of rows n's tree takes.
+func flameDepth(n *flameNode) int {
+	deepest := 0
+	for _, c := range n.children {
+		deepest = max(deepest, flameDepth(c))
+	}
+	return deepest + 1
+}
+
+// flameColor picks a warm color for a frame, stable across profiles.
+func flameColor(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	v := h.Sum32()
+	return fmt.Sprintf("rgb(%d,%d,%d)", 205+v%50, 80+(v>>8)%130, 40+(v>>16)%40)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBuildFlameTree(t *testing.T) {
+	root := buildFlameTree([]string{
+		"main;run;parse 30",
+		"main;run;add 50",
+		"main;gc 20",
+		"malformed line",
+	})
+	if root.value != 100 || len(root.children) != 1 {
+		t.Fatalf("root = %d samples in %d children, want 100 in 1", root.value, len(root.children))
+	}
+	run := root.children[0].child("run")
+	if run.value != 80 || len(run.children) != 2 || run.children[0].name != "add" || run.children[1].value != 30 {
+		t.Errorf("run = %+v, want add 50 then parse 30", run)
+	}
+
+	var svg bytes.Buffer
+	writeFlamegraphSVG(&svg, "A & B", root)
+	for _, want := range []string{"<svg ", "A &amp; B", "<title>add (50µs, 50.00%)</title>", "</svg>"} {
+		if !strings.Contains(svg.String(), want) {
+			t.Errorf("SVG lacks %q", want)
+		}
+	}
+}
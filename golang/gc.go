@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+var (
+	gogc       = flag.String("gogc", "", "garbage collector target percentage applied before running strategies, or off (default: GOGC env)")
+	gcMode     = flag.String("gc", "on", "collector during each strategy's run: on as -gogc sets it; off disables it for the run, collecting only near -gomemlimit or else 3/4 of physical memory; compare runs every strategy both ways and reports the effect")
+	gomemlimit byteSize
+	gcConfig   gcSettings
+	noGCConfig gcSettings // what -gc off and compare run strategies under
+)
+
+func init() {
+	flag.Var(&gomemlimit, "gomemlimit", "soft memory limit for the Go runtime, e.g. 4GiB (0 = GOMEMLIMIT env)")
+}
+
+// noGCSuffix marks the collector-free twin of each strategy under
+// -gc compare.
+const noGCSuffix = " (no GC)"
+
+// gcSettings is the garbage collector configuration a strategy ran under.
+type gcSettings struct {
+	Percent     int   // GOGC; negative means the collector is off
+	MemoryLimit int64 // GOMEMLIMIT in bytes; math.MaxInt64 means no limit
+}
+
+func (g gcSettings) String() string {
+	percent := strconv.Itoa(g.Percent)
+	if g.Percent < 0 {
+		percent = "off"
+	}
+	limit := "off"
+	if g.MemoryLimit != math.MaxInt64 {
+		limit = formatByteSize(int(g.MemoryLimit))
+	}
+	return fmt.Sprintf("GOGC=%s GOMEMLIMIT=%s", percent, limit)
+}
+
+// applyGCFlags applies -gogc and -gomemlimit, leaving whatever the
+// environment configured in place for flags that were not given, and
+// returns the resulting settings.
+func applyGCFlags(gogc string, memLimit byteSize) (gcSettings, error) {
+	if gogc != "" {
+		percent := -1
+		if !strings.EqualFold(gogc, "off") {
+			var err error
+			if percent, err = strconv.Atoi(gogc); err != nil || percent < 0 {
+				return gcSettings{}, fmt.Errorf("invalid -gogc %q: want a non-negative percentage or off", gogc)
+			}
+		}
+		debug.SetGCPercent(percent)
+	}
+	if memLimit > 0 {
+		debug.SetMemoryLimit(int64(memLimit))
+	}
+
+	// SetGCPercent has no getter: read the value by setting it back.
+	percent := debug.SetGCPercent(100)
+	debug.SetGCPercent(percent)
+	return gcSettings{Percent: percent, MemoryLimit: debug.SetMemoryLimit(-1)}, nil
+}
+
+// noGCSettings is what -gc off runs a strategy under: the collector off,
+// and a memory limit at which the runtime collects after all, so a run
+// that allocates more than the machine holds slows down rather than dies.
+// The limit is limit if set, else three quarters of physical memory, else
+// none where that is unknown.
+func noGCSettings(limit byteSize) gcSettings {
+	g := gcSettings{Percent: -1, MemoryLimit: math.MaxInt64}
+	if limit > 0 {
+		g.MemoryLimit = int64(limit)
+	} else if ram := physicalMemory(); ram > 0 {
+		g.MemoryLimit = int64(ram / 4 * 3)
+	}
+	return g
+}
+
+// apply puts g in effect and returns a function restoring the settings
+// it replaced.
+func (g gcSettings) apply() (restore func()) {
+	percent := debug.SetGCPercent(g.Percent)
+	limit := debug.SetMemoryLimit(g.MemoryLimit)
+	return func() {
+		debug.SetGCPercent(percent)
+		debug.SetMemoryLimit(limit)
+	}
+}
+
+// withNoGCVariants pairs every strategy in suite with a twin run with the
+// collector off, for -gc compare.
+func withNoGCVariants(suite []namedStrategy) []namedStrategy {
+	paired := make([]namedStrategy, 0, 2*len(suite))
+	for _, s := range suite {
+		paired = append(paired, s, namedStrategy{name: s.name + noGCSuffix, strategy: s.strategy, noGC: true, key: s.key, opts: s.opts})
+	}
+	return paired
+}
+
+// printGCEffect compares each strategy against its collector-free twin.
+func printGCEffect(results []BenchmarkResult) {
+	byName := make(map[string]BenchmarkResult, len(results))
+	for _, r := range results {
+		byName[r.StrategyName] = r
+	}
+
+	out.Println()
+	out.Headerf("GC effect (collector on vs off):")
+	for _, on := range results {
+		if strings.HasSuffix(on.StrategyName, noGCSuffix) {
+			continue
+		}
+		off, ok := byName[on.StrategyName+noGCSuffix]
+		if !ok || !on.Success || !off.Success {
+			continue
+		}
+		change := (float64(off.ExecutionTime)/float64(on.ExecutionTime) - 1) * 100
+		out.Printf("  %-24s %s with GC (%d cycles, %s paused) vs %s without (%d cycles) (%+.1f%%)\n",
+			on.StrategyName, formatDuration(on.ExecutionTime), on.GCCycles, formatDuration(on.GCPause),
+			formatDuration(off.ExecutionTime), off.GCCycles, change)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+var (
+	goldenDir = flag.String("golden", "", "directory of golden results keyed by the input's SHA-256; fail every strategy whose stations differ from the input's, computing it first with -golden-strategy if there is none")
+	goldenKey = flag.String("golden-strategy", "basic", "trusted strategy that computes a missing -golden result")
+)
+
+// fileChecksum is the hex SHA-256 of the file's bytes as stored.
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadGolden returns the golden stations of dataFile from dir, where they
+// are kept as a partial file named by the input's checksum, so a renamed
+// or copied file keeps its golden result and an edited one gets a new one.
+// When there is none yet, the trusted strategy computes it and it is
+// written for later runs. Any failure ends the run, as nothing could be
+// checked.
+func loadGolden(dir, dataFile, trusted string, opts strategies.StrategyOptions) []strategies.StationResult {
+	sum, err := fileChecksum(dataFile)
+	if err != nil {
+		out.Errorf("Error checksumming %s for -golden: %v", dataFile, err)
+		os.Exit(1)
+	}
+	path := filepath.Join(dir, sum+".partial")
+
+	stations, err := readPartial(path)
+	if err == nil {
+		out.Printf("%s %s (%d stations)\n\n", out.Paint("Golden result:", ColorBlue), path, len(stations))
+		return stations
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		out.Errorf("Error reading golden result %s: %v", path, err)
+		os.Exit(1)
+	}
+
+	entry, _ := lookupStrategy(trusted)
+	opts.Checkpoint = "" // the checkpoint is the run's to resume from
+	out.Printf("%s none for this input yet, computing it with %s\n", out.Paint("Golden result:", ColorBlue), entry.name())
+	result := benchmarkStrategy(entry.name(), entry.strategy(opts), dataFile)
+	if !result.Success {
+		out.Errorf("Error: %s failed computing the golden result: %v", entry.name(), result.Error)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		out.Errorf("Error: -golden: %v", err)
+		os.Exit(1)
+	}
+	if err := writePartial(path, result.Stations); err != nil {
+		out.Errorf("Error writing golden result: %v", err)
+		os.Exit(1)
+	}
+	out.Printf("%s %s (%d stations, %s)\n\n", out.Paint("Golden result:", ColorBlue), path,
+		len(result.Stations), formatDuration(result.ExecutionTime))
+	return result.Stations
+}
+
+// checkGolden fails every successful strategy whose stations differ from
+// the golden ones in name or aggregates.
+func checkGolden(results []BenchmarkResult, golden []strategies.StationResult) {
+	out.Headerf("=== Golden check ===")
+	out.Println()
+
+	want := make(map[string]strategies.StationResult, len(golden))
+	for _, st := range golden {
+		want[st.StationID] = st
+	}
+	for i := range results {
+		r := &results[i]
+		if !r.Success {
+			continue
+		}
+		if err := diffStations(want, r.Stations); err != nil {
+			r.Success = false
+			r.Error = fmt.Errorf("golden: %v", err)
+			out.Errorf("✗ %s: %v", r.StrategyName, err)
+		} else {
+			out.Successf("✓ %s", r.StrategyName)
+		}
+	}
+	out.Println()
+}
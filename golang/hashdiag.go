@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+var diagnoseHash = flag.Bool("diagnose-hash", false, "report hash collisions among station names, then table load factors and probe-length histograms after the run")
+
+// printHashReport scans the data file for station names and reports how the
+// selected hash distributes them, flagging names that would be merged.
+func printHashReport(dataFile string, opts strategies.StrategyOptions) {
+	out.Headerf("=== Hash Diagnostics: %s ===", hashFunc)
+	out.Println()
+
+	report, err := strategies.DiagnoseHash(context.Background(), dataFile, opts)
+	if err != nil {
+		out.Errorf("Error diagnosing hash: %v", err)
+		out.Println()
+		return
+	}
+
+	out.Printf("Distinct names:  %d\n", report.Names)
+	out.Printf("Distinct keys:   %d\n", report.Keys)
+	for i, keys := range report.NamesPerKey {
+		if keys > 0 {
+			out.Printf("  %d name(s) per key: %d\n", i+1, keys)
+		}
+	}
+
+	merged := report.Names - report.Keys
+	if merged == 0 {
+		out.Successf("✓ No collisions: every name has its own key")
+	} else {
+		out.Errorf("✗ %d name(s) share a key with another and are silently merged", merged)
+		for _, group := range report.Collisions {
+			out.Printf("    %s\n", strings.Join(group, ", "))
+		}
+	}
+	out.Println()
+}
+
+// printTableDiagnostics lists load factor and the probe-length histogram of
+// every strategy that reports its hash tables.
+func printTableDiagnostics(results []BenchmarkResult) {
+	out.Println()
+	out.Headerf("=== Table Diagnostics ===")
+	out.Println()
+
+	w := tabwriter.NewWriter(out.Writer(), 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, out.Paint("STRATEGY\tKEYS\tSLOTS\tLOAD\tPROBE HISTOGRAM (length:keys)", ColorBold, ColorCyan))
+	fmt.Fprintf(w, "───────────────────────\t────────\t─────────\t──────\t─────────────────────────────\n")
+
+	for _, result := range results {
+		if result.Probes == nil {
+			continue
+		}
+		p := result.Probes
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.3f\t%s\n", result.StrategyName, p.Keys, p.Slots, p.LoadFactor(), formatProbeHistogram(p.Histogram[:]))
+	}
+	w.Flush()
+}
+
+// formatProbeHistogram renders the non-empty buckets as "1:950 2:40 16+:2".
+func formatProbeHistogram(histogram []int) string {
+	var parts []string
+	for i, keys := range histogram {
+		if keys == 0 {
+			continue
+		}
+		length := strconv.Itoa(i + 1)
+		if i == len(histogram)-1 {
+			length += "+"
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d", length, keys))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
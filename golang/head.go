@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+)
+
+var (
+	maxRows  = flag.Int64("max-rows", 0, "benchmark only the first N rows of the data file, copied once to a temporary file (0 = all)")
+	maxBytes byteSize
+)
+
+func init() {
+	flag.Var(&maxBytes, "max-bytes", "benchmark only the first N bytes of the data file, e.g. 1GiB, cut back to a whole line and copied once to a temporary file (0 = all)")
+}
+
+// writeHead copies the start of dataFile into a temporary file named after
+// pattern, stopping after maxBytes bytes or maxRows lines, whichever comes
+// first (0 = no limit). A line cut short by maxBytes is left out. It
+// returns the file's path and the rows in it.
+func writeHead(dataFile, pattern string, maxBytes, maxRows int64) (string, int64, error) {
+	src, err := os.Open(dataFile)
+	if err != nil {
+		return "", 0, err
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", 0, err
+	}
+	rows, err := copyHead(dst, src, maxBytes, maxRows)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", 0, err
+	}
+	return dst.Name(), rows, nil
+}
+
+// copyHead does the copying for writeHead, one block at a time.
+func copyHead(dst io.Writer, src io.Reader, maxBytes, maxRows int64) (int64, error) {
+	buf := make([]byte, 1<<20)
+	var copied, rows int64
+	var partial []byte // the bytes since the last newline
+	for {
+		n, err := io.ReadFull(src, buf)
+		block := buf[:n]
+		full := false
+		if maxBytes > 0 && copied+int64(len(partial)+len(block)) > maxBytes {
+			block = block[:maxBytes-copied-int64(len(partial))]
+			full = true
+		}
+
+		// Write up to the last newline in block, or the maxRows-th one.
+		end := bytes.LastIndexByte(block, '\n') + 1
+		lines := int64(bytes.Count(block[:end], []byte{'\n'}))
+		if maxRows > 0 && rows+lines >= maxRows {
+			lines, end = maxRows-rows, 0
+			for range lines {
+				end += bytes.IndexByte(block[end:], '\n') + 1
+			}
+			full = true
+		}
+		if end > 0 {
+			if _, err := dst.Write(partial); err != nil {
+				return rows, err
+			}
+			if _, err := dst.Write(block[:end]); err != nil {
+				return rows, err
+			}
+			copied += int64(len(partial) + end)
+			rows += lines
+			partial = partial[:0]
+		}
+		if full {
+			return rows, nil
+		}
+		partial = append(partial, block[end:]...)
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			// The file's last line has no newline.
+			if len(partial) > 0 {
+				if _, err := dst.Write(partial); err != nil {
+					return rows, err
+				}
+				rows++
+			}
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+	}
+}
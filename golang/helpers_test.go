@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// captureOutput sends what the runner prints to the returned buffer, free
+// of colors, until the test ends.
+func captureOutput(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	saved := out
+	out = &Output{w: buf}
+	t.Cleanup(func() { out = saved })
+	return buf
+}
+
+// testStation aggregates values, in tenths of a degree, into a station as
+// the strategies return it; with spread it also carries a Distribution.
+func testStation(name string, spread bool, values ...int64) strategies.StationResult {
+	st := strategies.StationResult{StationID: name, Minimum: values[0], Maximum: values[0]}
+	if spread {
+		st.Extra = strategies.NewDistribution()
+	}
+	for _, v := range values {
+		st.Add(v)
+		if st.Extra != nil {
+			st.Extra.Add(v)
+		}
+	}
+	st.Average = float64(st.Sum) / float64(st.Count)
+	return st
+}
+
+// testStations returns a few stations sorted by name, with the values
+// used throughout the runner's tests.
+func testStations(spread bool) []strategies.StationResult {
+	return []strategies.StationResult{
+		testStation("Abha", spread, -23, 180, 402),
+		testStation("Berlin", spread, -103, 31, 55, 99),
+		testStation("Bern", spread, 0),
+		testStation("São Paulo", spread, 214, 256),
+	}
+}
+
+// testResults wraps stations as the results of a run in which a failed
+// strategy came before the one that returned them.
+func testResults(stations []strategies.StationResult) []BenchmarkResult {
+	var rows int64
+	for _, st := range stations {
+		rows += st.Count
+	}
+	return []BenchmarkResult{
+		{StrategyName: "Broken", Error: os.ErrNotExist, ReadSyscalls: -1, MalformedLines: -1},
+		{StrategyName: "Swiss", Success: true, ResultCount: len(stations), Rows: rows, Stations: stations,
+			ReadSyscalls: 12, MalformedLines: 3},
+	}
+}
+
+// writeTestFile writes content to a file named name in a fresh directory
+// and returns its path.
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// datasetFiles holds the data files when the run reads several as one
+// dataset, and is nil for a single file.
+var datasetFiles []string
+
+// getDataset is getDataFile for the benchmark run, which also takes several
+// files or a glob pattern. Those are read as one dataset: datasetFiles lists
+// them and the returned path list is what strategies.MultiFileStrategy
+// reads.
+func getDataset(args []string) string {
+	if len(args) < 2 && (len(args) == 0 || isURL(args[0]) || !strings.ContainsAny(args[0], "*?[")) {
+		return getDataFile(args)
+	}
+
+	list := strings.Join(args, string(os.PathListSeparator))
+	files, err := strategies.ExpandFiles(list)
+	if err != nil {
+		out.Errorf("Error: %v", err)
+		os.Exit(1)
+	}
+	if len(files) == 1 {
+		return getDataFile(files)
+	}
+
+	var size int64
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			out.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+		size += info.Size()
+	}
+	datasetFiles = files
+	out.Printf("%s %d files %s\n\n", out.Paint("Using data files:", ColorBlue), len(files),
+		out.Paint(fmt.Sprintf("(%.2f MB)", float64(size)/1024/1024), ColorYellow))
+	return list
+}
+
+// dataFiles returns the files behind dataFile.
+func dataFiles(dataFile string) []string {
+	if datasetFiles != nil {
+		return datasetFiles
+	}
+	return []string{dataFile}
+}
+
+// isCompressed reports whether path names a zstd-compressed data file,
+// which only the zstd strategy can read.
+func isCompressed(path string) bool {
+	return strings.HasSuffix(path, ".zst")
+}
+
+// isStream reports whether path is a FIFO or other input that can only be
+// read once, front to back.
+func isStream(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.Mode().IsRegular() && !info.IsDir()
+}
+
+// sequentialKeys lists the strategies that can read a stream.
+func sequentialKeys() []string {
+	var keys []string
+	for _, key := range strategies.Registered() {
+		if entry, _ := lookupStrategy(key); entry.ReadsSequentially {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// isURL reports whether path is an http(s) URL or an s3:// or gs:// object,
+// which the strategies registered with ReadsURLs download in ranges.
+func isURL(path string) bool {
+	return strategies.IsRemote(path)
+}
+
+// urlKeys lists the strategies that can read a URL.
+func urlKeys() []string {
+	var keys []string
+	for _, key := range strategies.Registered() {
+		if entry, _ := lookupStrategy(key); entry.ReadsURLs {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// remoteSize is the size of the object at url. The run cannot go on
+// without it: the object is missing, private or on a server that ignores
+// ranges.
+func remoteSize(url string) int64 {
+	size, err := strategies.RemoteSize(context.Background(), url)
+	if err != nil {
+		out.Errorf("Error: %v", err)
+		os.Exit(1)
+	}
+	return size
+}
+
+// openInput opens path for a sequential read of its measurements,
+// decompressing .zst files on the fly.
+func openInput(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil || !isCompressed(path) {
+		return f, err
+	}
+	dec, err := zstd.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return zstdFile{dec.IOReadCloser(), f}, nil
+}
+
+// zstdFile closes both the decoder and the file underneath it.
+type zstdFile struct {
+	io.ReadCloser
+	f *os.File
+}
+
+func (z zstdFile) Close() error {
+	z.ReadCloser.Close()
+	return z.f.Close()
+}
+
+// defaultDataDir is the repository's data directory: ../data from the
+// working directory, as when running from golang/, or else from the
+// directory holding the binary, as when it is started from elsewhere or
+// from Explorer on Windows.
+func defaultDataDir() string {
+	dir := filepath.Join("..", "data")
+	if _, err := os.Stat(dir); err == nil {
+		return dir
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return dir
+	}
+	besideBinary := filepath.Join(filepath.Dir(exe), "..", "data")
+	if _, err := os.Stat(besideBinary); err != nil {
+		return dir
+	}
+	return besideBinary
+}
+
+// getDataFile determines which data file to use
+// Priority: 1) Command line argument, 2) Most recent measurements-*.txt, 3) Default measurements.txt
+func getDataFile(args []string) string {
+	if len(args) > 0 {
+		dataFile := args[0]
+		if isURL(dataFile) {
+			out.Printf("%s %s\n\n", out.Paint("Using data URL:", ColorBlue), dataFile)
+			return dataFile
+		}
+		if _, err := os.Stat(dataFile); err == nil {
+			out.Printf("%s %s\n\n", out.Paint("Using data file:", ColorBlue), dataFile)
+			return dataFile
+		}
+		out.Warnf("Warning: File '%s' not found, searching for alternatives...", dataFile)
+	}
+
+	dataDir := defaultDataDir()
+	pattern := filepath.Join(dataDir, "measurements-*.txt")
+	matches, err := filepath.Glob(pattern)
+
+	if err == nil && len(matches) > 0 {
+		// Sort by modification time (most recent first)
+		sort.Slice(matches, func(i, j int) bool {
+			infoI, errI := os.Stat(matches[i])
+			infoJ, errJ := os.Stat(matches[j])
+			if errI != nil || errJ != nil {
+				return false
+			}
+			return infoI.ModTime().After(infoJ.ModTime())
+		})
+
+		dataFile := matches[0]
+		fileInfo, _ := os.Stat(dataFile)
+		sizeMB := float64(fileInfo.Size()) / 1024 / 1024
+		out.Printf("%s %s %s\n\n", out.Paint("Auto-detected data file:", ColorBlue), dataFile,
+			out.Paint(fmt.Sprintf("(%.2f MB)", sizeMB), ColorYellow))
+		return dataFile
+	}
+
+	defaultFile := filepath.Join(dataDir, "measurements.txt")
+	out.Printf("%s %s\n\n", out.Paint("Using default data file:", ColorBlue), defaultFile)
+	return defaultFile
+}
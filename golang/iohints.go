@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"strings"
+)
+
+var ioHints = flag.String("io-hints", "on", "kernel readahead hints (fadvise/madvise, fcntl on macOS): on, off, or compare to run every strategy both ways")
+
+// unhintedSuffix marks the hint-free twin of a strategy in -io-hints=compare
+// mode.
+const unhintedSuffix = " (no hints)"
+
+// withUnhintedVariants follows every strategy in suite with a copy built
+// from the same options, -autotune's included, but with readahead hints
+// disabled, so both land side by side in the summary.
+func withUnhintedVariants(suite []namedStrategy) []namedStrategy {
+	paired := make([]namedStrategy, 0, 2*len(suite))
+	for _, s := range suite {
+		paired = append(paired, s)
+		entry, ok := lookupStrategy(s.key)
+		if !ok {
+			continue
+		}
+		opts := s.opts
+		opts.DisableIOHints = true
+		paired = append(paired, namedStrategy{name: s.name + unhintedSuffix, strategy: entry.strategy(opts), key: s.key, opts: opts})
+	}
+	return paired
+}
+
+// printHintEffect compares each strategy against its hint-free twin.
+func printHintEffect(results []BenchmarkResult) {
+	byName := make(map[string]BenchmarkResult, len(results))
+	for _, r := range results {
+		byName[r.StrategyName] = r
+	}
+
+	out.Println()
+	out.Headerf("I/O hint effect (fadvise/madvise on vs off):")
+	for _, hinted := range results {
+		if strings.HasSuffix(hinted.StrategyName, unhintedSuffix) {
+			continue
+		}
+		plain, ok := byName[hinted.StrategyName+unhintedSuffix]
+		if !ok || !hinted.Success || !plain.Success {
+			continue
+		}
+		change := (float64(hinted.ExecutionTime)/float64(plain.ExecutionTime) - 1) * 100
+		out.Printf("  %-24s %s with hints vs %s without (%+.1f%%)\n",
+			hinted.StrategyName, formatDuration(hinted.ExecutionTime), formatDuration(plain.ExecutionTime), change)
+	}
+}
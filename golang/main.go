@@ -2,287 +2,371 @@ package main
 
 import (
 	"flag"
-	"fmt"
-	"onebillion/strategies"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
 	"os"
-	"path/filepath"
 	"runtime"
 	"runtime/pprof"
-	"sort"
-	"text/tabwriter"
-	"time"
-)
-
-// BenchmarkResult holds the performance metrics for a strategy
-type BenchmarkResult struct {
-	StrategyName  string
-	ExecutionTime time.Duration
-	MemoryUsed    uint64
-	ResultCount   int
-	Success       bool
-	Error         error
-}
-
-// ANSI color codes for terminal output
-const (
-	ColorReset  = "\033[0m"
-	ColorRed    = "\033[31m"
-	ColorGreen  = "\033[32m"
-	ColorYellow = "\033[33m"
-	ColorBlue   = "\033[34m"
-	ColorPurple = "\033[35m"
-	ColorCyan   = "\033[36m"
-	ColorWhite  = "\033[37m"
-	ColorBold   = "\033[1m"
+	"strings"
 )
 
 var (
-	cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
-	memprofile = flag.String("memprofile", "", "write memory profile to file")
+	cpuprofile   = flag.String("cpuprofile", "", "write cpu profile to file")
+	memprofile   = flag.String("memprofile", "", "write memory profile to file")
+	timeout      = flag.Duration("timeout", 0, "abort a strategy and mark it FAILED after this long, e.g. 2m (0 = no limit)")
+	strategyList = flag.String("strategies", "", "comma-separated strategy keys to run instead of the default suite, e.g. lp-table,swiss")
+	noColor      = flag.Bool("no-color", false, "disable ANSI colors (also honors NO_COLOR and non-TTY output)")
+	verbose      = flag.Bool("verbose", false, "print a detailed I/O report (read syscall counts) after the summary")
 )
 
+var out *Output
+
+// commands maps each subcommand to the function running it on the
+// arguments after its name, which returns the exit status. Without a
+// subcommand, the benchmark runs.
+var commands = map[string]func(args []string) int{
+	"convert":  runConvertCommand,
+	"diff":     runDiffCommand,
+	"merge":    runMergeCommand,
+	"plan":     runPlanCommand,
+	"scaling":  runScalingCommand,
+	"serve":    runServeCommand,
+	"sweep":    runSweepCommand,
+	"split":    runSplitCommand,
+	"validate": runValidateCommand,
+	"worker":   runWorkerCommand,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := commands[os.Args[1]]; ok {
+			os.Exit(run(os.Args[2:]))
+		}
+	}
+
 	flag.Parse()
+	out = newOutput(*noColor)
+
+	// Flags that became subcommands still run them, with a warning.
+	switch {
+	case *plan:
+		out.Warnf("⚠ -plan is deprecated; use the plan subcommand")
+		os.Exit(planFile(getDataset(flag.Args())))
+	case *cpuSweep != "":
+		out.Warnf("⚠ -cpus is deprecated; use the scaling subcommand")
+		os.Exit(scaleFile(*cpuSweep, getDataset(flag.Args())))
+	case *sweepBuffers != "":
+		out.Warnf("⚠ -sweep-buffers is deprecated; use the sweep subcommand")
+		os.Exit(sweepFile(*sweepBuffers, getDataset(flag.Args())))
+	}
+
+	if *cpuprofile != "" && *flamegraph != "" {
+		out.Errorf("Error: -cpuprofile and -flamegraph cannot be used together")
+		os.Exit(1)
+	}
 
 	if *cpuprofile != "" {
 		f, err := os.Create(*cpuprofile)
 		if err != nil {
-			fmt.Printf("%sError creating CPU profile: %v%s\n", ColorRed, err, ColorReset)
+			out.Errorf("Error creating CPU profile: %v", err)
 			os.Exit(1)
 		}
 		defer f.Close()
 
 		if err := pprof.StartCPUProfile(f); err != nil {
-			fmt.Printf("%sError starting CPU profile: %v%s\n", ColorRed, err, ColorReset)
+			out.Errorf("Error starting CPU profile: %v", err)
 			os.Exit(1)
 		}
 		defer pprof.StopCPUProfile()
-		fmt.Printf("%s📊 CPU profiling enabled → %s%s\n", ColorGreen, *cpuprofile, ColorReset)
+		out.Successf("📊 CPU profiling enabled → %s", *cpuprofile)
 	}
 
 	if *memprofile != "" {
 		defer func() {
 			f, err := os.Create(*memprofile)
 			if err != nil {
-				fmt.Printf("%sError creating memory profile: %v%s\n", ColorRed, err, ColorReset)
+				out.Errorf("Error creating memory profile: %v", err)
 				return
 			}
 			defer f.Close()
 
 			runtime.GC() // get up-to-date statistics
 			if err := pprof.WriteHeapProfile(f); err != nil {
-				fmt.Printf("%sError writing memory profile: %v%s\n", ColorRed, err, ColorReset)
+				out.Errorf("Error writing memory profile: %v", err)
 			} else {
-				fmt.Printf("%s📊 Memory profile saved → %s%s\n", ColorGreen, *memprofile, ColorReset)
+				out.Successf("📊 Memory profile saved → %s", *memprofile)
 			}
 		}()
 	}
 
-	fmt.Printf("%s%s=== One Billion Row Challenge - Benchmark ===%s\n\n", ColorBold, ColorCyan, ColorReset)
-
-	dataFile := getDataFile()
-
-	strategies := []struct {
-		name     string
-		strategy strategies.Strategy
-	}{
-		{"MCMP Strategy", &strategies.MCMPStrategy{}},
-		{"Batch Strategy", &strategies.BatchStrategy{}},
-		{"Basic Strategy", &strategies.BasicStrategy{}},
-		{"Byte Strategy", &strategies.ByteReadingStrategy{}},
+	var err error
+	memLimit := gomemlimit
+	if memLimit == 0 {
+		memLimit = maxMemory
+	}
+	if gcConfig, err = applyGCFlags(*gogc, memLimit); err != nil {
+		out.Errorf("Error: %v", err)
+		os.Exit(1)
 	}
+	noGCConfig = noGCSettings(memLimit)
 
-	results := make([]BenchmarkResult, 0, len(strategies))
+	out.Headerf("=== One Billion Row Challenge - Benchmark ===")
+	out.Println()
 
-	for _, s := range strategies {
-		fmt.Printf("%s⏱️  Running: %s%s\n", ColorYellow, s.name, ColorReset)
-		result := benchmarkStrategy(s.name, s.strategy, dataFile)
-		results = append(results, result)
+	dataFile := getDataset(flag.Args())
+	if *otel {
+		tracer = newOTLPTracer()
+	}
+	var endRun func()
+	traceCtx, endRun = startSpan(traceCtx, "benchmark", strategies.SpanAttr{Key: "path", Value: dataFile})
 
-		if result.Success {
-			fmt.Printf("%s✓ Completed in: %v%s\n\n", ColorGreen, result.ExecutionTime, ColorReset)
-		} else {
-			fmt.Printf("%s✗ Failed: %v%s\n\n", ColorRed, result.Error, ColorReset)
+	var dataSize int64
+	for _, path := range dataFiles(dataFile) {
+		if info, err := os.Stat(path); err == nil {
+			dataSize += info.Size()
 		}
 	}
-
-	// Print summary
-	printSummary(results)
-}
-
-func benchmarkStrategy(name string, strategy strategies.Strategy, filePath string) BenchmarkResult {
-	result := BenchmarkResult{
-		StrategyName: name,
-		Success:      false,
+	remote := isURL(dataFile)
+	if remote {
+		dataSize = remoteSize(dataFile)
 	}
 
-	runtime.GC()
-
-	var memStatsBefore runtime.MemStats
-	runtime.ReadMemStats(&memStatsBefore)
-
-	// Start timing
-	startTime := time.Now()
-
-	// Execute strategy
-	stationResults, err := strategy.Calculate(filePath)
-
-	// End timing
-	executionTime := time.Since(startTime)
-
-	// Get memory stats after
-	var memStatsAfter runtime.MemStats
-	runtime.ReadMemStats(&memStatsAfter)
-
-	// Calculate memory used (in MB)
-	memoryUsed := memStatsAfter.Alloc - memStatsBefore.Alloc
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "rounds" {
+			out.Warnf("⚠ -rounds is deprecated; use -iterations")
+		}
+	})
+	if err := checkFlags(); err != nil {
+		out.Errorf("Error: %v", err)
+		os.Exit(1)
+	}
+	if err := checkInput(dataFile); err != nil {
+		out.Errorf("Error: %v", err)
+		os.Exit(1)
+	}
+	if *distribution && *top == 0 {
+		*top = 10
+	}
+	if *quarantineTo != "" {
+		quarantine = newQuarantineLog()
+	}
+	if *phases {
+		phaseTimer = &strategies.PhaseTimer{}
+	}
+	if *chunkTimes {
+		chunkTracer = &chunkTimeTracer{}
+		if tracer != nil {
+			chunkTracer.next = tracer
+		}
+	}
+	opts := strategyOptions()
 
-	result.ExecutionTime = executionTime
-	result.MemoryUsed = memoryUsed
-	result.ResultCount = len(stationResults)
+	inputSuite(dataFile)
+	if *pluginDir != "" {
+		keys, err := loadPlugins(*pluginDir)
+		if err != nil {
+			out.Errorf("Error: -plugins: %v", err)
+			os.Exit(1)
+		}
+		out.Printf("%s %s\n\n", out.Paint("Plugin strategies:", ColorBlue), strings.Join(keys, ", "))
+		if !isStream(dataFile) && !remote { // read only by their own strategies
+			defaultSuite = append(defaultSuite, keys...)
+		}
+	}
+	if *cluster != "" {
+		addrs, err := registerCluster()
+		if err != nil {
+			out.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+		out.Printf("%s %s\n\n", out.Paint("Cluster workers:", ColorBlue), strings.Join(addrs, ", "))
+		// Local strategies can still run alongside with -strategies.
+		defaultSuite = []string{"cluster"}
+	}
+	if *sample > 0 {
+		defaultSuite = samplerKeys(defaultSuite)
+	}
+	if *distribution {
+		if defaultSuite = distributionKeys(defaultSuite); len(defaultSuite) == 0 {
+			out.Errorf("Error: no strategy for this input computes distributions")
+			os.Exit(1)
+		}
+	}
 
+	var exports []exportTarget // files for -export
+	if *export != "" {
+		if exports, err = exportTargets(); err != nil {
+			out.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+	}
+	suiteKeys, err := selectedStrategies()
+	if err == nil {
+		err = checkSuite(suiteKeys, dataFile)
+	}
 	if err != nil {
-		result.Error = err
-		result.Success = false
-	} else {
-		result.Success = true
+		out.Errorf("Error: %v", err)
+		os.Exit(1)
 	}
 
-	return result
-}
-
-func printSummary(results []BenchmarkResult) {
-	fmt.Printf("%s%s=== Performance Summary ===%s\n\n", ColorBold, ColorCyan, ColorReset)
+	var fileRows int64                    // lines in the input, for -validate
+	var golden []strategies.StationResult // the input's stations, for -golden
+	capped := *maxRows > 0 || maxBytes > 0
+	if capped {
+		head, rows, err := writeHead(dataFile, "onebillion-head-*.txt", int64(maxBytes), *maxRows)
+		if err != nil {
+			out.Errorf("Error copying the head of %s: %v", dataFile, err)
+			os.Exit(1)
+		}
+		defer os.Remove(head)
+		info, err := os.Stat(head)
+		if err != nil {
+			out.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+		dataFile, dataSize, fileRows = head, info.Size(), rows
+		out.Printf("%s the first %d rows (%.2f MB), copied to %s\n\n", out.Paint("Capped input:", ColorBlue),
+			rows, float64(dataSize)/1024/1024, head)
+	}
 
-	if len(results) == 0 {
-		fmt.Println("No results to display")
-		return
+	if *estimate {
+		stationEstimate = estimateStations(dataFile, opts)
+		opts = strategyOptions()
 	}
 
-	// Find the fastest strategy
-	var fastest *BenchmarkResult
-	for i := range results {
-		if results[i].Success && (fastest == nil || results[i].ExecutionTime < fastest.ExecutionTime) {
-			fastest = &results[i]
+	sampled := 1.0 // share of the file the strategies aggregate
+	if *sample > 0 {
+		sampled, err = strategies.SampledFraction(dataFile, opts)
+		if err != nil {
+			out.Errorf("Error sampling %s: %v", dataFile, err)
+			os.Exit(1)
 		}
+		out.Printf("%s %.2f%% of the file in random chunks (seed %d)\n\n", out.Paint("Sampling:", ColorBlue),
+			sampled*100, opts.SampleSeed)
 	}
 
-	// Create a tabwriter for nicely formatted table output
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-
-	// Print header
-	fmt.Fprintf(w, "%s%sSTRATEGY\tTIME\tMEMORY (MB)\tRESULTS\tSTATUS%s\n",
-		ColorBold, ColorCyan, ColorReset)
-	fmt.Fprintf(w, "───────────────────────\t────────────\t───────────\t────────\t──────────────\n")
-
-	// Add rows to the table
-	for _, result := range results {
-		memoryMB := float64(result.MemoryUsed) / 1024 / 1024
-		timeStr := formatDuration(result.ExecutionTime)
-		statusStr := ""
-		rowColor := ""
+	if *checkpoint != "" {
+		printCheckpoint(*checkpoint)
+	}
 
-		if result.Success {
-			if fastest != nil && result.StrategyName == fastest.StrategyName {
-				statusStr = "✓ FASTEST"
-				rowColor = ColorGreen
-			} else {
-				statusStr = "✓"
-				rowColor = ""
-			}
-		} else {
-			statusStr = "✗ FAILED"
-			rowColor = ColorRed
+	strategies := buildStrategies(suiteKeys, opts)
+	if *goldenDir != "" {
+		golden = loadGolden(*goldenDir, dataFile, *goldenKey, opts)
+	}
+	if *finalists > 0 {
+		suiteKeys = runTournament(suiteKeys, opts, dataFile, dataSize, int64(heatSize), *finalists)
+		strategies = buildStrategies(suiteKeys, opts)
+	}
+	if *autoTune {
+		strategies = autotuneStrategies(suiteKeys, opts, dataFile, int64(autotuneSample))
+	}
+	if *ioHints == "compare" {
+		strategies = withUnhintedVariants(strategies)
+	}
+	switch *gcMode {
+	case "off":
+		for i := range strategies {
+			strategies[i].noGC = true
 		}
+	case "compare":
+		strategies = withNoGCVariants(strategies)
+	}
+	if *gcMode != "on" {
+		out.Printf("%s off while each strategy runs, %s\n\n", out.Paint("GC:", ColorBlue), noGCConfig)
+	}
 
-		fmt.Fprintf(w, "%s%s\t%s\t%.2f\t%d\t%s%s\n",
-			rowColor,
-			result.StrategyName,
-			timeStr,
-			memoryMB,
-			result.ResultCount,
-			statusStr,
-			ColorReset)
+	if *diagnoseHash {
+		printHashReport(dataFile, opts)
+	}
 
-		// Add error row if needed
-		if result.Error != nil {
-			fmt.Fprintf(w, "%s  Error: %v%s\t\t\t\t\n", ColorRed, result.Error, ColorReset)
+	if *validate {
+		if !capped { // a capped input's rows were counted as it was copied
+			for _, path := range dataFiles(dataFile) {
+				rows, err := countFileRows(path)
+				if err != nil {
+					out.Errorf("Error counting rows for -validate: %v", err)
+					os.Exit(1)
+				}
+				fileRows += rows
+			}
 		}
+		out.Printf("%s %d rows\n\n", out.Paint("Validating against:", ColorBlue), fileRows)
 	}
 
-	w.Flush()
+	if *keepPartial {
+		catchInterrupt()
+	}
+	results := runIterations(strategies, dataFile, dataSize, fileRows)
 
-	// Print comparison if multiple successful results
-	successfulResults := 0
-	for _, r := range results {
-		if r.Success {
-			successfulResults++
-		}
+	var reference BenchmarkResult
+	var referenceStations map[string]referenceStation
+	if *referenceCmd != "" {
+		reference, referenceStations = runReference(*referenceCmd, dataFile)
 	}
 
-	if successfulResults > 1 && fastest != nil {
-		fmt.Println()
-		fmt.Printf("%s%sSpeed Comparison (relative to fastest):%s\n", ColorBold, ColorCyan, ColorReset)
-		for _, result := range results {
-			if result.Success && result.StrategyName != fastest.StrategyName {
-				ratio := float64(result.ExecutionTime) / float64(fastest.ExecutionTime)
-				fmt.Printf("  %s is %.2fx slower than %s\n",
-					result.StrategyName, ratio, fastest.StrategyName)
-			}
-		}
+	_, endReport := startSpan(traceCtx, "report")
+	if *referenceCmd != "" {
+		compareWithReference(results, reference, referenceStations)
+	}
+	if *goldenDir != "" {
+		checkGolden(results, golden)
+	}
+	if *crosscheck {
+		crosscheckResults(results)
+	}
+	if *rangeCheck {
+		checkRange(results, dataFile, opts)
+	}
+	if *partialOut != "" {
+		writePartialResult(results, *partialOut)
+	}
+	if quarantine != nil {
+		writeQuarantine(quarantine, *quarantineTo)
+	}
+	if exports != nil {
+		exportResults(results, exports, dataFile, dataSize, opts)
+	}
+	if *referenceCmd != "" {
+		results = append(results, reference)
 	}
-}
 
-func formatDuration(d time.Duration) string {
-	if d < time.Millisecond {
-		return fmt.Sprintf("%.2f μs", float64(d.Microseconds()))
+	// Print summary
+	printSummary(results)
+	if *ioHints == "compare" {
+		printHintEffect(results)
 	}
-	if d < time.Second {
-		return fmt.Sprintf("%.2f ms", float64(d.Milliseconds()))
+	if *gcMode == "compare" {
+		printGCEffect(results)
 	}
-	if d < time.Minute {
-		return fmt.Sprintf("%.2f s", d.Seconds())
+	if *sample > 0 {
+		printSampleProjection(results, sampled)
 	}
-	return fmt.Sprintf("%.2f min", d.Minutes())
-}
+	if *filter != "" {
+		printFilteredStations(results)
+	}
+	if *top > 0 {
+		printTopStations(results, *top, *topBy)
+	}
+	if *phases {
+		printPhaseReport(results)
+	}
+	if *verbose {
+		printVerboseReport(results, dataSize)
+	}
+	if *diagnoseHash {
+		printTableDiagnostics(results)
+	}
+	endReport()
 
-// getDataFile determines which data file to use
-// Priority: 1) Command line argument, 2) Most recent measurements-*.txt, 3) Default measurements.txt
-func getDataFile() string {
-	args := flag.Args()
-	if len(args) > 0 {
-		dataFile := args[0]
-		if _, err := os.Stat(dataFile); err == nil {
-			fmt.Printf("%sUsing data file:%s %s\n\n", ColorBlue, ColorReset, dataFile)
-			return dataFile
+	endRun()
+	if tracer != nil {
+		if err := tracer.export(); err != nil {
+			out.Errorf("Error exporting spans: %v", err)
+		} else {
+			out.Successf("📈 Spans exported → %s", tracer.endpoint)
 		}
-		fmt.Printf("%sWarning: File '%s' not found, searching for alternatives...%s\n", ColorYellow, dataFile, ColorReset)
 	}
-
-	dataDir := "../data"
-	pattern := filepath.Join(dataDir, "measurements-*.txt")
-	matches, err := filepath.Glob(pattern)
-
-	if err == nil && len(matches) > 0 {
-		// Sort by modification time (most recent first)
-		sort.Slice(matches, func(i, j int) bool {
-			infoI, errI := os.Stat(matches[i])
-			infoJ, errJ := os.Stat(matches[j])
-			if errI != nil || errJ != nil {
-				return false
-			}
-			return infoI.ModTime().After(infoJ.ModTime())
-		})
-
-		dataFile := matches[0]
-		fileInfo, _ := os.Stat(dataFile)
-		sizeMB := float64(fileInfo.Size()) / 1024 / 1024
-		fmt.Printf("%sAuto-detected data file:%s %s %s(%.2f MB)%s\n\n",
-			ColorBlue, ColorReset, dataFile, ColorYellow, sizeMB, ColorReset)
-		return dataFile
+	if *repl {
+		out.Println()
+		runQueries(os.Stdin, results)
 	}
-
-	defaultFile := filepath.Join(dataDir, "measurements.txt")
-	fmt.Printf("%sUsing default data file:%s %s\n\n", ColorBlue, ColorReset, defaultFile)
-	return defaultFile
 }
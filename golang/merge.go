@@ -0,0 +1,170 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// runMergeCommand implements "merge [flags] partial...": it combines
+// partial-result files written with -partial-out into the final result,
+// printed in the 1BRC output format or written as one more partial, and
+// returns the exit status.
+func runMergeCommand(args []string) int {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	output := fs.String("o", "", "write the merged stations to this partial-result file instead of printing them")
+	noColor := fs.Bool("no-color", false, "disable ANSI colors (also honors NO_COLOR and non-TTY output)")
+	fs.IntVar(&decimals, "decimals", 1, "digits after the decimal point the partials were written with (0-6)")
+	fs.StringVar(filter, "filter", "", "regular expression a station name must match as a whole, e.g. 'Ber.*': print or write only the matching stations")
+	fs.StringVar(unit, "unit", "celsius", "scale the printed temperatures are in: celsius, fahrenheit or kelvin; -o partials stay in Celsius")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s merge [flags] partial...\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Combine partial results written with -partial-out, e.g. by runs on the shards of a\n")
+		fmt.Fprintf(fs.Output(), "dataset, into one result. Partials may be listed or given as glob patterns.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	out = newOutput(*noColor)
+
+	out.Headerf("=== One Billion Row Challenge - Merge Partial Results ===")
+	out.Println()
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	if err := setRecordFormat(); err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	if err := setStationFilter(); err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	if err := setOutputUnit(); err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	files, err := strategies.ExpandFiles(strings.Join(fs.Args(), string(os.PathListSeparator)))
+	if err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+
+	start := time.Now()
+	sets := make([][]strategies.StationResult, len(files))
+	for i, path := range files {
+		if sets[i], err = readPartial(path); err != nil {
+			out.Errorf("Error reading %s: %v", path, err)
+			return 1
+		}
+	}
+	merged := strategies.MergeResults(sets...)
+	var rows int64
+	for _, st := range merged {
+		rows += st.Count
+	}
+
+	out.Printf("%s %d\n", out.Paint("Partials:", ColorBlue), len(files))
+	out.Printf("%s %d\n", out.Paint("Rows:", ColorBlue), rows)
+	out.Printf("%s %d\n", out.Paint("Distinct stations:", ColorBlue), len(merged))
+	out.Printf("%s %s\n\n", out.Paint("Merged in:", ColorBlue), formatDuration(time.Since(start)))
+	merged = filterStations(merged)
+
+	if *output == "" {
+		out.Println(formatStations(merged, decimals))
+		return 0
+	}
+	if err := writePartial(*output, merged); err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	out.Successf("✓ Wrote %s", *output)
+	return 0
+}
+
+// writePartialResult writes the stations of the first successful strategy
+// to path for -partial-out.
+func writePartialResult(results []BenchmarkResult, path string) {
+	for _, r := range results {
+		if !r.Success {
+			continue
+		}
+		if err := writePartial(path, r.Stations); err != nil {
+			out.Errorf("Error writing partial result: %v", err)
+		} else {
+			out.Printf("%s %s %s\n\n", out.Paint("Partial result:", ColorBlue), path,
+				out.Paint(fmt.Sprintf("(from %s)", r.StrategyName), ColorYellow))
+		}
+		return
+	}
+	out.Errorf("No successful strategy to write a partial result from")
+	out.Println()
+}
+
+func readPartial(path string) ([]strategies.StationResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return strategies.ReadPartial(f)
+}
+
+func writePartial(path string, stations []strategies.StationResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = strategies.WritePartial(f, stations)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// formatStations renders stations as the 1BRC reference prints them:
+// {name=min/mean/max, ...} sorted by name, in -unit, the mean rounded half
+// up to the input's precision.
+func formatStations(stations []strategies.StationResult, digits int) string {
+	stations = slices.Clone(stations)
+	slices.SortFunc(stations, func(a, b strategies.StationResult) int {
+		return strings.Compare(a.StationID, b.StationID)
+	})
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, st := range stations {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s=%s/%s/%s", st.StationID,
+			formatTemperature(float64(st.Minimum), digits), formatTemperature(st.Average, digits), formatTemperature(float64(st.Maximum), digits))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// roundedMean is the station's mean rounded half up to the units of its
+// aggregates.
+func roundedMean(st strategies.StationResult) int64 {
+	return int64(math.Floor(st.Average + 0.5))
+}
+
+// formatFixed renders v, in units of the last of digits fraction digits, as
+// a decimal.
+func formatFixed(v int64, digits int) string {
+	if digits == 0 {
+		return fmt.Sprint(v)
+	}
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	unit := int64(math.Pow10(digits))
+	return fmt.Sprintf("%s%d.%0*d", sign, v/unit, digits, v%unit)
+}
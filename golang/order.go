@@ -0,0 +1,101 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+var (
+	iterations   = flag.Int("iterations", 1, "run every strategy this many times and report its median run")
+	schedule     = flag.String("schedule", "interleaved", "with -iterations, interleaved runs the whole suite once per iteration (ABAB), grouped runs each strategy's iterations back to back (AABB)")
+	runOrderFlag = flag.String("order", "fixed", "order strategies run in each iteration: fixed, rotate (start one further along each iteration) or shuffle")
+)
+
+func init() {
+	// -rounds was the first name of -iterations; it is kept so that
+	// scripts written for it still run.
+	flag.IntVar(iterations, "rounds", 1, "deprecated name of -iterations")
+}
+
+// runOrder returns the indexes of n strategies in the order they run in
+// the given iteration, as -order asks: as listed, rotated one further each
+// iteration, or shuffled afresh every iteration. Moving strategies around
+// keeps cache warmth or a CPU's thermal ramp from always favoring the same
+// position.
+func runOrder(n, iter int) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	switch *runOrderFlag {
+	case "rotate":
+		if n > 0 {
+			k := iter % n
+			order = append(order[k:], order[:k]...)
+		}
+	case "shuffle":
+		rand.Shuffle(n, func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+	return order
+}
+
+// orderNames lists the strategies of suite in the given order.
+func orderNames(suite []namedStrategy, order []int) string {
+	names := make([]string, len(order))
+	for i, j := range order {
+		names[i] = suite[j].name
+	}
+	return strings.Join(names, ", ")
+}
+
+// medianRun is the result that stands for a strategy's runs over all
+// iterations: its median run by time, or its first failure if any
+// iteration failed.
+func medianRun(runs []BenchmarkResult) BenchmarkResult {
+	for _, r := range runs {
+		if !r.Success {
+			return r
+		}
+	}
+	sorted := slices.Clone(runs)
+	slices.SortStableFunc(sorted, func(a, b BenchmarkResult) int {
+		return cmp.Compare(a.ExecutionTime, b.ExecutionTime)
+	})
+	return sorted[len(sorted)/2]
+}
+
+// runIterations runs every strategy -iterations times in the -schedule and
+// -order asked for, and returns each one's median run.
+func runIterations(suite []namedStrategy, dataFile string, dataSize, fileRows int64) []BenchmarkResult {
+	runs := make([][]BenchmarkResult, len(suite))
+	progress := newSuiteProgress(dataSize, len(suite)**iterations)
+
+	if *schedule == "grouped" {
+		// Back to back, so drift over the session falls between strategies.
+		for _, i := range runOrder(len(suite), 0) {
+			for iter := range *iterations {
+				runs[i] = append(runs[i], runStrategy(suite[i], dataFile, fileRows, progress, iter))
+			}
+		}
+	} else {
+		// Interleaved, so drift spreads evenly over every strategy.
+		for iter := range *iterations {
+			order := runOrder(len(suite), iter)
+			if *iterations > 1 || *runOrderFlag != "fixed" {
+				out.Headerf("Iteration %d of %d: %s", iter+1, *iterations, orderNames(suite, order))
+				out.Println()
+			}
+			for _, i := range order {
+				runs[i] = append(runs[i], runStrategy(suite[i], dataFile, fileRows, progress, iter))
+			}
+		}
+	}
+	results := make([]BenchmarkResult, len(suite))
+	for i := range runs {
+		results[i] = medianRun(runs[i])
+	}
+	return results
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var otel = flag.Bool("otel", false, "export spans of the run's phases (strategies, file opens, chunks, merges) over OTLP/HTTP to OTEL_EXPORTER_OTLP_ENDPOINT (default http://localhost:4318)")
+
+// otlpBatchSpans is the most spans sent in one export request.
+const otlpBatchSpans = 5000
+
+// tracer collects spans for -otel, and is nil otherwise.
+var tracer *otlpTracer
+
+// traceCtx carries the span of the whole run, so every strategy's span is
+// its child.
+var traceCtx = context.Background()
+
+// otlpTracer is a strategies.Tracer that keeps spans in memory and sends
+// them to an OpenTelemetry collector over OTLP/HTTP, JSON encoded, when
+// the run ends. That needs no OTel SDK, and costs the run one append per
+// span. The endpoint and service name come from the standard
+// OTEL_EXPORTER_OTLP_ENDPOINT and OTEL_SERVICE_NAME variables.
+type otlpTracer struct {
+	endpoint string
+	service  string
+	traceID  string
+
+	mu    sync.Mutex
+	spans []otlpSpan
+}
+
+// otlpSpan is a span in the OTLP JSON encoding.
+type otlpSpan struct {
+	TraceID      string          `json:"traceId"`
+	SpanID       string          `json:"spanId"`
+	ParentSpanID string          `json:"parentSpanId,omitempty"`
+	Name         string          `json:"name"`
+	Kind         int             `json:"kind"`
+	Start        string          `json:"startTimeUnixNano"`
+	End          string          `json:"endTimeUnixNano"`
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string            `json:"key"`
+	Value map[string]string `json:"value"`
+}
+
+// spanIDKey is the context key of the current span's id.
+type spanIDKey struct{}
+
+func newOTLPTracer() *otlpTracer {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "http://localhost:4318"
+	}
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "onebillion"
+	}
+	var id [16]byte
+	crand.Read(id[:])
+	return &otlpTracer{
+		endpoint: strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		service:  service,
+		traceID:  hex.EncodeToString(id[:]),
+	}
+}
+
+func (t *otlpTracer) StartSpan(ctx context.Context, name string, attrs ...strategies.SpanAttr) (context.Context, func()) {
+	span := otlpSpan{
+		TraceID: t.traceID,
+		SpanID:  fmt.Sprintf("%016x", rand.Uint64()),
+		Name:    name,
+		Kind:    1, // SPAN_KIND_INTERNAL
+		Start:   strconv.FormatInt(time.Now().UnixNano(), 10),
+	}
+	span.ParentSpanID, _ = ctx.Value(spanIDKey{}).(string)
+	for _, a := range attrs {
+		value := map[string]string{"stringValue": fmt.Sprint(a.Value)}
+		if v, ok := a.Value.(int64); ok {
+			value = map[string]string{"intValue": strconv.FormatInt(v, 10)}
+		}
+		span.Attributes = append(span.Attributes, otlpAttribute{a.Key, value})
+	}
+	return context.WithValue(ctx, spanIDKey{}, span.SpanID), func() {
+		span.End = strconv.FormatInt(time.Now().UnixNano(), 10)
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.spans = append(t.spans, span)
+	}
+}
+
+// export sends the spans ended so far to the collector.
+func (t *otlpTracer) export() error {
+	t.mu.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.mu.Unlock()
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	for len(spans) > 0 {
+		batch := spans[:min(len(spans), otlpBatchSpans)]
+		spans = spans[len(batch):]
+		body, err := json.Marshal(map[string]any{
+			"resourceSpans": []any{map[string]any{
+				"resource": map[string]any{"attributes": []otlpAttribute{
+					{"service.name", map[string]string{"stringValue": t.service}},
+				}},
+				"scopeSpans": []any{map[string]any{
+					"scope": map[string]string{"name": "github.com/utkarsh5026/onebillion"},
+					"spans": batch,
+				}},
+			}},
+		})
+		if err != nil {
+			return err
+		}
+		resp, err := client.Post(t.endpoint, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("%s: %s", t.endpoint, resp.Status)
+		}
+	}
+	return nil
+}
+
+// startSpan starts a span with the -otel tracer, or does nothing without
+// one.
+func startSpan(ctx context.Context, name string, attrs ...strategies.SpanAttr) (context.Context, func()) {
+	if tracer == nil {
+		return ctx, func() {}
+	}
+	return tracer.StartSpan(ctx, name, attrs...)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ANSI color codes for terminal output
+const (
+	ColorReset  = "\033[0m"
+	ColorRed    = "\033[31m"
+	ColorGreen  = "\033[32m"
+	ColorYellow = "\033[33m"
+	ColorBlue   = "\033[34m"
+	ColorPurple = "\033[35m"
+	ColorCyan   = "\033[36m"
+	ColorWhite  = "\033[37m"
+	ColorBold   = "\033[1m"
+)
+
+// Output is the single place the runner writes user-facing text through.
+// It decides once whether ANSI styling should be emitted, so that piping the
+// benchmark into a file or another program produces clean plain text.
+type Output struct {
+	w           io.Writer
+	color       bool
+	interactive bool
+}
+
+// newOutput writes to stdout. Colors are disabled when noColor is set, when
+// the NO_COLOR environment variable is present (https://no-color.org), when
+// stdout is not a terminal, or when it is a Windows console that cannot
+// interpret ANSI escapes.
+func newOutput(noColor bool) *Output {
+	interactive := isTerminal(os.Stdout)
+	_, noColorEnv := os.LookupEnv("NO_COLOR")
+
+	return &Output{
+		w:           os.Stdout,
+		color:       interactive && !noColor && !noColorEnv && enableANSI(os.Stdout),
+		interactive: interactive,
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Writer exposes the underlying writer, e.g. for a tabwriter.
+func (o *Output) Writer() io.Writer {
+	return o.w
+}
+
+// Interactive reports whether stdout is a terminal that can redraw lines.
+func (o *Output) Interactive() bool {
+	return o.interactive
+}
+
+// Paint wraps text in the given styles, or returns it unchanged when colors
+// are disabled.
+func (o *Output) Paint(text string, styles ...string) string {
+	if !o.color {
+		return text
+	}
+	prefix := strings.Join(styles, "")
+	if prefix == "" {
+		return text
+	}
+	return prefix + text + ColorReset
+}
+
+func (o *Output) Printf(format string, args ...any) {
+	fmt.Fprintf(o.w, format, args...)
+}
+
+func (o *Output) Println(args ...any) {
+	fmt.Fprintln(o.w, args...)
+}
+
+// Linef prints a single styled line terminated by a newline.
+func (o *Output) Linef(style, format string, args ...any) {
+	fmt.Fprintln(o.w, o.Paint(fmt.Sprintf(format, args...), style))
+}
+
+func (o *Output) Headerf(format string, args ...any) {
+	fmt.Fprintln(o.w, o.Paint(fmt.Sprintf(format, args...), ColorBold, ColorCyan))
+}
+
+func (o *Output) Successf(format string, args ...any) {
+	o.Linef(ColorGreen, format, args...)
+}
+
+func (o *Output) Warnf(format string, args ...any) {
+	o.Linef(ColorYellow, format, args...)
+}
+
+func (o *Output) Errorf(format string, args ...any) {
+	o.Linef(ColorRed, format, args...)
+}
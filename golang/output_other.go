@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// enableANSI reports whether the terminal f writes to interprets ANSI
+// escapes, which outside Windows they all do.
+func enableANSI(f *os.File) bool {
+	return true
+}
@@ -0,0 +1,237 @@
+//go:build parquet
+
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// parquetBuilt reports whether -export parquet is compiled in.
+const parquetBuilt = true
+
+// Parquet physical types, and the Thrift compact protocol's field types,
+// as far as the writer needs them.
+const (
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// parquetColumn is a required column: its name, physical type and values,
+// PLAIN encoded.
+type parquetColumn struct {
+	name     string
+	physical int32
+	utf8     bool
+	values   []byte
+}
+
+// exportParquet writes the stations as a Parquet file of one row group:
+// the name, min, mean, max and count, then the spread under -distribution.
+// Temperatures are doubles in degrees of -unit, as -export sqlite writes
+// them.
+func exportParquet(path string, run exportRun) error {
+	stations := run.source.Stations
+	scale := math.Pow10(decimals)
+	doubles := func(name string, value func(i int) float64) parquetColumn {
+		c := parquetColumn{name: name, physical: parquetDouble}
+		for i := range stations {
+			c.values = binary.LittleEndian.AppendUint64(c.values, math.Float64bits(value(i)/scale))
+		}
+		return c
+	}
+
+	names := parquetColumn{name: "name", physical: parquetByteArray, utf8: true}
+	counts := parquetColumn{name: "count", physical: parquetInt64}
+	for _, st := range stations {
+		names.values = binary.LittleEndian.AppendUint32(names.values, uint32(len(st.StationID)))
+		names.values = append(names.values, st.StationID...)
+		counts.values = binary.LittleEndian.AppendUint64(counts.values, uint64(st.Count))
+	}
+	columns := []parquetColumn{
+		names,
+		doubles("min", func(i int) float64 { return inUnit(float64(stations[i].Minimum), decimals) }),
+		doubles("mean", func(i int) float64 { return inUnit(stations[i].Average, decimals) }),
+		doubles("max", func(i int) float64 { return inUnit(float64(stations[i].Maximum), decimals) }),
+		counts,
+	}
+	if len(stations) > 0 && distributionOf(stations[0]) != nil {
+		columns = append(columns,
+			doubles("stddev", func(i int) float64 { return distributionOf(stations[i]).StdDev() * outputUnit.scale }),
+			doubles("p50", func(i int) float64 { return inUnit(distributionOf(stations[i]).Median(), decimals) }),
+			doubles("p90", func(i int) float64 { return inUnit(distributionOf(stations[i]).Quantile(0.9), decimals) }),
+			doubles("p99", func(i int) float64 { return inUnit(distributionOf(stations[i]).Quantile(0.99), decimals) }))
+	}
+	return writeFile(path, func(w io.Writer) error {
+		return writeParquet(w, columns, len(stations))
+	})
+}
+
+// writeParquet writes columns of rows values each as a Parquet file: the
+// magic, one uncompressed data page per column, all in one row group, and
+// the footer describing them. Required columns have no definition or
+// repetition levels, so a page is a header and the values.
+func writeParquet(w io.Writer, columns []parquetColumn, rows int) error {
+	file := []byte("PAR1")
+	offsets := make([]int64, len(columns))
+	sizes := make([]int64, len(columns))
+	for i, c := range columns {
+		page := newThriftWriter()
+		page.i32(1, 0) // data page
+		page.i32(2, int32(len(c.values)))
+		page.i32(3, int32(len(c.values)))
+		page.begin(5)
+		page.i32(1, int32(rows))
+		page.i32(2, 0) // PLAIN
+		page.i32(3, 3) // RLE levels, of which there are none
+		page.i32(4, 3)
+		page.end()
+		page.end()
+
+		offsets[i] = int64(len(file))
+		sizes[i] = int64(len(page.buf) + len(c.values))
+		file = append(append(file, page.buf...), c.values...)
+	}
+
+	meta := newThriftWriter()
+	meta.i32(1, 1) // format version
+	meta.list(2, thriftStruct, len(columns)+1)
+	meta.element()
+	meta.str(4, "schema")
+	meta.i32(5, int32(len(columns)))
+	meta.end()
+	for _, c := range columns {
+		meta.element()
+		meta.i32(1, c.physical)
+		meta.i32(3, 0) // required
+		meta.str(4, c.name)
+		if c.utf8 {
+			meta.i32(6, 0) // UTF8
+			meta.begin(10) // logical type STRING
+			meta.begin(1)
+			meta.end()
+			meta.end()
+		}
+		meta.end()
+	}
+	meta.i64(3, int64(rows))
+
+	var total int64
+	meta.list(4, thriftStruct, 1)
+	meta.element()
+	meta.list(1, thriftStruct, len(columns))
+	for i, c := range columns {
+		meta.element()
+		meta.i64(2, offsets[i])
+		meta.begin(3)
+		meta.i32(1, c.physical)
+		meta.list(2, thriftI32, 1)
+		meta.varint(0) // PLAIN
+		meta.list(3, thriftBinary, 1)
+		meta.bytes(c.name)
+		meta.i32(4, 0) // uncompressed
+		meta.i64(5, int64(rows))
+		meta.i64(6, sizes[i])
+		meta.i64(7, sizes[i])
+		meta.i64(9, offsets[i])
+		meta.end()
+		meta.end()
+		total += sizes[i]
+	}
+	meta.i64(2, total)
+	meta.i64(3, int64(rows))
+	meta.end()
+	meta.str(6, "onebillion benchmark")
+	meta.end()
+
+	file = append(file, meta.buf...)
+	file = binary.LittleEndian.AppendUint32(file, uint32(len(meta.buf)))
+	file = append(file, "PAR1"...)
+	_, err := w.Write(file)
+	return err
+}
+
+// thriftWriter encodes structs in the Thrift compact protocol, which the
+// Parquet footer and page headers use: each field is announced by its type
+// and the difference of its id from the previous field's, and integers are
+// zigzag varints.
+type thriftWriter struct {
+	buf  []byte
+	last []int16 // id of the last field written in each open struct
+}
+
+// newThriftWriter returns a writer inside the top-level struct, closed by
+// its final end.
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{last: []int16{0}}
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.buf = binary.AppendVarint(t.buf, int64(id))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) varint(v int64) {
+	t.buf = binary.AppendVarint(t.buf, v)
+}
+
+func (t *thriftWriter) bytes(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) str(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.bytes(s)
+}
+
+// list announces a list field of n elements of type elem, which follow.
+func (t *thriftWriter) list(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elem)
+		return
+	}
+	t.buf = append(t.buf, 0xf0|elem)
+	t.buf = binary.AppendUvarint(t.buf, uint64(n))
+}
+
+// begin opens a struct field, element a struct element of a list; end
+// closes either.
+func (t *thriftWriter) begin(id int16) {
+	t.field(id, thriftStruct)
+	t.element()
+}
+
+func (t *thriftWriter) element() {
+	t.last = append(t.last, 0)
+}
+
+func (t *thriftWriter) end() {
+	t.buf = append(t.buf, 0)
+	t.last = t.last[:len(t.last)-1]
+}
@@ -0,0 +1,13 @@
+//go:build !parquet
+
+package main
+
+import "errors"
+
+// parquetBuilt reports whether -export parquet is compiled in. It is left
+// out by default to keep the benchmark small; build with -tags parquet.
+const parquetBuilt = false
+
+func exportParquet(string, exportRun) error {
+	return errors.New("built without -tags parquet")
+}
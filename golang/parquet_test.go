@@ -0,0 +1,235 @@
+//go:build parquet
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportParquetRoundTrip(t *testing.T) {
+	many := make([]strategies.StationResult, 300) // without -distribution, so without the spread columns
+	for i := range many {
+		many[i] = testStation(fmt.Sprintf("Station %03d", i), false, int64(i-150), int64(i))
+	}
+	for _, tc := range []struct {
+		name     string
+		stations []strategies.StationResult
+	}{
+		{"distribution", testStations(true)},
+		{"many", many},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			captureOutput(t)
+			path := filepath.Join(t.TempDir(), "results.parquet")
+			exportResults(testResults(tc.stations), []exportTarget{{"parquet", path}}, "measurements.txt", 0, strategies.StrategyOptions{})
+
+			columns := readParquet(t, path, len(tc.stations))
+			want := map[string][]any{}
+			for _, st := range tc.stations {
+				want["name"] = append(want["name"], st.StationID)
+				want["min"] = append(want["min"], float64(st.Minimum)/10)
+				want["mean"] = append(want["mean"], st.Average/10)
+				want["max"] = append(want["max"], float64(st.Maximum)/10)
+				want["count"] = append(want["count"], st.Count)
+				if d := distributionOf(st); d != nil {
+					want["stddev"] = append(want["stddev"], d.StdDev()/10)
+					want["p50"] = append(want["p50"], d.Median()/10)
+					want["p90"] = append(want["p90"], d.Quantile(0.9)/10)
+					want["p99"] = append(want["p99"], d.Quantile(0.99)/10)
+				}
+			}
+			if len(columns) != len(want) {
+				t.Fatalf("read %d columns, want %d", len(columns), len(want))
+			}
+			for name, values := range want {
+				if !sameRow(columns[name], values) {
+					t.Errorf("column %s = %v, want %v", name, columns[name], values)
+				}
+			}
+		})
+	}
+}
+
+// readParquet decodes the Parquet file at path independently of
+// writeParquet: it checks the magic, decodes the footer, and reads every
+// column chunk's data page through the offsets the footer gives. It
+// returns each column's values by name.
+func readParquet(t *testing.T, path string, rows int) map[string][]any {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) < 12 || string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+		t.Fatal("no Parquet magic at both ends")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := &thriftReader{t: t, buf: data[len(data)-8-footerLen : len(data)-8]}
+	meta := footer.structure()
+	if len(footer.buf) != 0 {
+		t.Fatalf("footer has %d bytes past its end", len(footer.buf))
+	}
+	if meta[1] != int64(1) || meta[3] != int64(rows) {
+		t.Fatalf("footer has version %v and %v rows, want 1 and %d", meta[1], meta[3], rows)
+	}
+
+	// The schema is a root naming the number of columns, then the columns.
+	schema := meta[2].([]any)
+	root := schema[0].(map[int16]any)
+	if root[5] != int64(len(schema)-1) {
+		t.Fatalf("schema root has %v children, want %d", root[5], len(schema)-1)
+	}
+	types := make(map[string]int64)
+	for _, e := range schema[1:] {
+		e := e.(map[int16]any)
+		if e[3] != int64(0) {
+			t.Errorf("column %v is not required", e[4])
+		}
+		types[e[4].(string)] = e[1].(int64)
+	}
+
+	groups := meta[4].([]any)
+	if len(groups) != 1 {
+		t.Fatalf("%d row groups, want 1", len(groups))
+	}
+	group := groups[0].(map[int16]any)
+	if group[3] != int64(rows) {
+		t.Errorf("row group has %v rows, want %d", group[3], rows)
+	}
+	var total int64
+	columns := make(map[string][]any)
+	for _, chunk := range group[1].([]any) {
+		cm := chunk.(map[int16]any)[3].(map[int16]any)
+		name := cm[3].([]any)[0].(string)
+		if cm[1] != types[name] || cm[4] != int64(0) || cm[5] != int64(rows) {
+			t.Errorf("column %s chunk has type %v, codec %v and %v values", name, cm[1], cm[4], cm[5])
+		}
+		offset, size := cm[9].(int64), cm[7].(int64)
+		total += size
+
+		page := &thriftReader{t: t, buf: data[offset : offset+size]}
+		header := page.structure()
+		dataHeader := header[5].(map[int16]any)
+		if header[1] != int64(0) || dataHeader[1] != int64(rows) || dataHeader[2] != int64(0) {
+			t.Fatalf("column %s page header %v is not a PLAIN data page of %d values", name, header, rows)
+		}
+		if header[2] != int64(len(page.buf)) {
+			t.Fatalf("column %s page holds %d bytes, its header says %v", name, len(page.buf), header[2])
+		}
+		columns[name] = plainValues(t, types[name], page.buf, rows)
+	}
+	if group[2] != total {
+		t.Errorf("row group counts %v bytes, its chunks %d", group[2], total)
+	}
+	return columns
+}
+
+// plainValues decodes rows PLAIN-encoded values of a physical type.
+func plainValues(t *testing.T, physical int64, b []byte, rows int) []any {
+	var values []any
+	for range rows {
+		switch physical {
+		case parquetInt64:
+			values = append(values, int64(binary.LittleEndian.Uint64(b)))
+			b = b[8:]
+		case parquetDouble:
+			values = append(values, math.Float64frombits(binary.LittleEndian.Uint64(b)))
+			b = b[8:]
+		case parquetByteArray:
+			n := binary.LittleEndian.Uint32(b)
+			values = append(values, string(b[4:4+n]))
+			b = b[4+n:]
+		default:
+			t.Fatalf("unexpected physical type %d", physical)
+		}
+	}
+	if len(b) != 0 {
+		t.Fatalf("page has %d bytes past its values", len(b))
+	}
+	return values
+}
+
+// thriftReader decodes the Thrift compact protocol into maps of field id
+// to value: int64 for integers, string for binary, []any for lists and
+// map[int16]any for structs.
+type thriftReader struct {
+	t   *testing.T
+	buf []byte
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.t.Fatal("bad varint")
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *thriftReader) byte() byte {
+	if len(r.buf) == 0 {
+		r.t.Fatal("unexpected end of Thrift data")
+	}
+	b := r.buf[0]
+	r.buf = r.buf[1:]
+	return b
+}
+
+func (r *thriftReader) structure() map[int16]any {
+	fields := make(map[int16]any)
+	var id int16
+	for {
+		b := r.byte()
+		if b == 0 {
+			return fields
+		}
+		if delta := int16(b >> 4); delta != 0 {
+			id += delta
+		} else {
+			v := r.uvarint()
+			id = int16(v>>1) ^ -int16(v&1)
+		}
+		if _, dup := fields[id]; dup {
+			r.t.Fatalf("field %d appears twice", id)
+		}
+		fields[id] = r.value(b & 0x0f)
+	}
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		v := r.uvarint()
+		return int64(v>>1) ^ -int64(v&1)
+	case thriftBinary:
+		n := r.uvarint()
+		if n > uint64(len(r.buf)) {
+			r.t.Fatal("binary runs past the end")
+		}
+		s := string(r.buf[:n])
+		r.buf = r.buf[n:]
+		return s
+	case thriftList:
+		b := r.byte()
+		n := uint64(b >> 4)
+		if n == 15 {
+			n = r.uvarint()
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = r.value(b & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.structure()
+	}
+	r.t.Fatalf("unexpected Thrift type %d", typ)
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"slices"
+	"time"
+)
+
+var (
+	keepPartial = flag.Bool("keep-partial", false, "when -timeout or Ctrl-C stops a strategy that can, keep the stations it had aggregated, marked PARTIAL, for -top, -filter, -repl and -export if none completed; a second Ctrl-C quits")
+	partialOut  = flag.String("partial-out", "", "write the stations of the first strategy to succeed to this file, for the merge command to combine with other runs'")
+)
+
+// stopGrace is how long a strategy that -timeout or Ctrl-C stopped may
+// take to return before the runner abandons it and moves on.
+var stopGrace = 5 * time.Second
+
+// interrupt is cancelled by the first Ctrl-C once catchInterrupt has run.
+var interrupt = context.Background()
+
+// catchInterrupt makes the first Ctrl-C stop the running strategy, and
+// with it the rest of the suite, instead of the process, so that the
+// summary still follows. A second Ctrl-C quits as usual.
+func catchInterrupt() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	context.AfterFunc(ctx, stop)
+	interrupt = ctx
+}
+
+// interruptible returns a context that is also cancelled by the first
+// Ctrl-C, and a function releasing it.
+func interruptible(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if interrupt.Err() != nil {
+		cancel()
+		return ctx, cancel
+	}
+	stop := context.AfterFunc(interrupt, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// stationsFrom returns the index in results of the first successful
+// strategy with stations or, failing that, of the first -keep-partial one,
+// of which it warns; -1 if there is neither.
+func stationsFrom(results []BenchmarkResult) int {
+	if i := slices.IndexFunc(results, func(r BenchmarkResult) bool { return r.Success && r.Stations != nil }); i >= 0 {
+		return i
+	}
+	i := slices.IndexFunc(results, func(r BenchmarkResult) bool { return r.Partial && r.Stations != nil })
+	if i >= 0 {
+		out.Warnf("⚠ No strategy completed; using the partial stations of %s", results[i].StrategyName)
+		out.Println()
+	}
+	return i
+}
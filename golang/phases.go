@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var phases = flag.Bool("phases", false, "after the summary, break each strategy's time down into open, read, parse, aggregate and merge, with a stacked bar")
+
+// phaseBarWidth is the width of each strategy's bar in the -phases report.
+const phaseBarWidth = 40
+
+// phaseTimer times the phases of every run for -phases; nil without it.
+var phaseTimer *strategies.PhaseTimer
+
+// phaseShare is one phase of a run, scaled to the wall clock.
+type phaseShare struct {
+	name  string
+	glyph string
+	color string
+	time  time.Duration
+}
+
+// wallPhases scales a run's phase times to its wall-clock time. Open and
+// merge are wall-clock already. The workers' read, parse and aggregate
+// times are averaged over the workers, and what is left of the run, such
+// as workers idling while a straggler finishes its last chunk, is other.
+func wallPhases(result BenchmarkResult) []phaseShare {
+	p := result.Phases
+	total := result.ExecutionTime
+	shares := []phaseShare{
+		{"open", "·", ColorPurple, min(p.Open, total)},
+		{"read", "░", ColorBlue, 0},
+		{"parse", "▒", ColorYellow, 0},
+		{"aggregate", "▓", ColorGreen, 0},
+		{"merge", "█", ColorCyan, min(p.Merge, total-min(p.Open, total))},
+		{"other", " ", "", 0},
+	}
+	scanning := total - shares[0].time - shares[4].time
+	if p.Workers > 0 {
+		workers := time.Duration(p.Workers)
+		busy := (p.Read + p.Parse + p.Aggregate) / workers
+		scale := 1.0 // busy time over the wall clock of the scan comes from timer skew
+		if busy > scanning {
+			scale = float64(scanning) / float64(busy)
+		}
+		shares[1].time = time.Duration(float64(p.Read/workers) * scale)
+		shares[2].time = time.Duration(float64(p.Parse/workers) * scale)
+		shares[3].time = time.Duration(float64(p.Aggregate/workers) * scale)
+	}
+	shares[5].time = max(scanning-shares[1].time-shares[2].time-shares[3].time, 0)
+	return shares
+}
+
+// printPhaseReport breaks each strategy's time down into its phases, with
+// a stacked bar of them, so the phase worth optimizing next stands out.
+func printPhaseReport(results []BenchmarkResult) {
+	out.Println()
+	out.Headerf("=== Phase Breakdown ===")
+	out.Println()
+
+	w := tabwriter.NewWriter(out.Writer(), 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, out.Paint("STRATEGY\tTOTAL\tOPEN\tREAD\tPARSE\tAGGREGATE\tMERGE\tOTHER\t", ColorBold, ColorCyan))
+	fmt.Fprintf(w, "───────────────────────\t────────────\t──────\t──────\t──────\t─────────\t──────\t──────\t\n")
+	for _, result := range results {
+		if result.Phases == nil || result.ExecutionTime <= 0 {
+			continue
+		}
+		shares := wallPhases(result)
+		cells := []string{result.StrategyName, formatDuration(result.ExecutionTime)}
+		var bar strings.Builder
+		for i, s := range shares {
+			share := float64(s.time) / float64(result.ExecutionTime)
+			if result.Phases.Workers == 0 && s.name != "open" && s.name != "merge" {
+				// Only strategies on the shared chunk driver time their
+				// workers; for the rest the whole scan would show as other.
+				cells = append(cells, "-")
+				if i == len(shares)-1 {
+					bar.WriteString("not instrumented")
+				}
+				continue
+			}
+			cells = append(cells, fmt.Sprintf("%.0f%%", share*100))
+			bar.WriteString(out.Paint(strings.Repeat(s.glyph, int(share*phaseBarWidth+0.5)), s.color))
+		}
+		// The bar goes last, as its colors would throw tabwriter's widths.
+		fmt.Fprintf(w, "%s\t%s\n", strings.Join(cells, "\t"), bar.String())
+	}
+	w.Flush()
+
+	var legend []string
+	for _, s := range wallPhases(BenchmarkResult{Phases: &strategies.PhaseTimes{}}) {
+		if s.glyph != " " {
+			legend = append(legend, out.Paint(s.glyph, s.color)+" "+s.name)
+		}
+	}
+	out.Println()
+	out.Printf("%s\n", strings.Join(legend, "  "))
+	out.Println("Open and merge come from the MCMP family, mmap and the strategies on the shared chunk driver, and")
+	out.Println("read, parse and aggregate from the latter only, averaged over the workers; the rest is other. Parse")
+	out.Println("and aggregate are split by timing one line in 64. Other strategies show - where not instrumented.")
+}
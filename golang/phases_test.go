@@ -0,0 +1,38 @@
+package main
+
+import (
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPhaseReportMarksStrategiesWithoutWorkerTimes(t *testing.T) {
+	buf := captureOutput(t)
+	printPhaseReport([]BenchmarkResult{
+		{StrategyName: "Chunked", ExecutionTime: 100 * time.Millisecond, Phases: &strategies.PhaseTimes{
+			Read: 10 * time.Millisecond, Parse: 50 * time.Millisecond, Aggregate: 30 * time.Millisecond, Workers: 1,
+		}},
+		{StrategyName: "Unhooked", ExecutionTime: 100 * time.Millisecond, Phases: &strategies.PhaseTimes{Merge: 10 * time.Millisecond}},
+	})
+
+	rows := 0
+	for _, line := range strings.Split(buf.String(), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case strings.HasPrefix(line, "Chunked"):
+			rows++
+			if got := strings.Join(fields[3:9], " "); got != "0% 10% 50% 30% 0% 10%" {
+				t.Errorf("Chunked shares = %s", got)
+			}
+		case strings.HasPrefix(line, "Unhooked"):
+			rows++
+			if got := strings.Join(fields[3:9], " "); got != "0% - - - 10% -" || !strings.Contains(line, "not instrumented") {
+				t.Errorf("Unhooked row = %q", line)
+			}
+		}
+	}
+	if rows != 2 {
+		t.Errorf("report has %d of the 2 rows:\n%s", rows, buf)
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"encoding/binary"
+	"syscall"
+)
+
+// physicalMemory returns the machine's memory in bytes, or 0 if unknown.
+func physicalMemory() uint64 {
+	// hw.memsize is a uint64, which Sysctl returns as raw bytes with
+	// trailing zero bytes cut.
+	s, err := syscall.Sysctl("hw.memsize")
+	if err != nil || len(s) > 8 {
+		return 0
+	}
+	var buf [8]byte
+	copy(buf[:], s)
+	return binary.LittleEndian.Uint64(buf[:])
+}
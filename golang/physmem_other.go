@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package main
+
+// physicalMemory returns the machine's memory in bytes, or 0 if unknown,
+// as it is here.
+func physicalMemory() uint64 {
+	return 0
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"os"
+	"strings"
+)
+
+// plan is the old spelling of the plan subcommand, kept so that scripts
+// written for it still run.
+var plan = flag.Bool("plan", false, "deprecated: use the plan subcommand")
+
+// planChunksShown is how many chunks plan lists per strategy without
+// -verbose: the first ones and the last.
+const planChunksShown = 8
+
+// runPlanCommand implements "plan [flags] [file]": it prints how each
+// strategy would split the file, without running any, and returns the exit
+// status.
+func runPlanCommand(args []string) int {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	fs.StringVar(strategyList, "strategies", "", "comma-separated strategy keys to plan instead of the default suite, e.g. lp-table,swiss")
+	fs.Float64Var(sample, "sample", 0, "plan reading a random share of the file's chunks, e.g. 0.01, as the benchmark's -sample does (0 = all)")
+	fs.BoolVar(verbose, "verbose", false, "list every chunk, not just the first and last")
+	noColor := fs.Bool("no-color", false, "disable ANSI colors (also honors NO_COLOR and non-TTY output)")
+	strategyFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s plan [flags] [file]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Print each strategy's chunk boundaries, workers, buffer sizes and estimated memory for\n")
+		fmt.Fprintf(fs.Output(), "the file without running any, and exit 1 if a plan leaves bytes of it in no chunk.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	out = newOutput(*noColor)
+	return planFile(getDataFile(fs.Args()))
+}
+
+// planFile prints the plans for dataFile, for plan and -plan, and returns
+// the exit status: 1 if a plan is unsound.
+func planFile(dataFile string) int {
+	if datasetFiles != nil || isStream(dataFile) || isURL(dataFile) {
+		out.Errorf("Error: plan needs a single local data file")
+		return 1
+	}
+	if *sample < 0 || *sample >= 1 {
+		out.Errorf("Error: -sample must be in (0, 1), got %g", *sample)
+		return 1
+	}
+	if err := checkStrategyFlags(); err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	inputSuite(dataFile)
+	if *sample > 0 {
+		defaultSuite = samplerKeys(defaultSuite)
+	}
+	keys, err := selectedStrategies()
+	if err == nil {
+		err = checkSuite(keys, dataFile)
+	}
+	if err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+
+	if !printPlans(buildStrategies(keys, strategyOptions()), dataFile) {
+		return 1
+	}
+	return 0
+}
+
+// printPlans prints how each strategy would split dataFile,
+// without running any of them. It reports whether every plan assigns each
+// byte of the file to a chunk; a sampled plan is expected not to.
+func printPlans(suite []namedStrategy, dataFile string) bool {
+	out.Headerf("=== Plan for %s ===", dataFile)
+	out.Println()
+
+	sound := true
+	for _, s := range suite {
+		out.Linef(ColorBold, "%s", s.name)
+		entry, _ := lookupStrategy(s.key)
+		if entry.Plan == nil {
+			out.Println("  no chunk plan: this strategy does not read through the chunk queue")
+			out.Println()
+			continue
+		}
+		plan, err := entry.Plan(dataFile, s.opts)
+		if err != nil {
+			out.Errorf("  ✗ %v", err)
+			out.Println()
+			sound = false
+			continue
+		}
+
+		buffers := "no read buffers (the file is mapped)"
+		if plan.Buffers > 0 {
+			buffers = fmt.Sprintf("%d × %s read buffers", plan.Buffers, formatByteSize(int(plan.BufferSize)))
+		}
+		out.Printf("  %d workers, %s and a %d-slot table each, ~%.2f MB in all\n",
+			plan.Workers, buffers, plan.TableSlots, megabytes(plan.Memory()))
+		out.Printf("  %d chunks: %s\n", len(plan.Chunks), chunkList(plan.Chunks))
+
+		var assigned int64
+		for _, c := range plan.Chunks {
+			assigned += c.End - c.Start
+		}
+		gaps := plan.Gaps()
+		switch {
+		case *sample > 0:
+			out.Printf("  sampled: %.2f of %.2f MB assigned\n", megabytes(assigned), megabytes(plan.FileSize))
+		case len(gaps) > 0:
+			var unassigned int64
+			for _, g := range gaps {
+				unassigned += g.End - g.Start
+			}
+			out.Errorf("  ✗ %d bytes of the file are in no chunk: %s", unassigned, chunkList(gaps))
+			sound = false
+		default:
+			out.Successf("  ✓ every byte of the file is in a chunk")
+		}
+		out.Println()
+	}
+	return sound
+}
+
+// chunkList formats chunks as [start, end) ranges, eliding the middle of
+// long lists unless -verbose is set.
+func chunkList(chunks []strategies.Chunk) string {
+	shown := chunks
+	elided := 0
+	if !*verbose && len(chunks) > planChunksShown {
+		shown = append(chunks[:planChunksShown-1:planChunksShown-1], chunks[len(chunks)-1])
+		elided = len(chunks) - planChunksShown
+	}
+	parts := make([]string, 0, len(shown)+1)
+	for i, c := range shown {
+		if elided > 0 && i == len(shown)-1 {
+			parts = append(parts, fmt.Sprintf("… %d more …", elided))
+		}
+		parts = append(parts, fmt.Sprintf("[%d, %d)", c.Start, c.End))
+	}
+	return strings.Join(parts, " ")
+}
+
+func megabytes(n int64) float64 {
+	return float64(n) / 1024 / 1024
+}
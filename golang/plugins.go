@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"os"
+	"path/filepath"
+	"plugin"
+	"slices"
+)
+
+var pluginDir = flag.String("plugins", "", "directory of Go plugins (*.so) registering extra strategies; they join the default suite")
+
+// loadPlugins opens every Go plugin (*.so) in dir and returns the strategy
+// keys they registered, in sorted order.
+//
+// A plugin is a package main built with go build -buildmode=plugin against
+// the same strategies package and Go toolchain as the runner. Its init
+// functions run when it is opened and register its strategies:
+//
+//	func init() {
+//		strategies.Register("my-table", strategies.Entry{
+//			New: func(o strategies.StrategyOptions) strategies.Strategy {
+//				return NewMyTableStrategy(o)
+//			},
+//		})
+//	}
+//
+// Plugins are only supported where the standard plugin package is (Linux,
+// FreeBSD and macOS with cgo); elsewhere opening one fails.
+func loadPlugins(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no *.so plugins in %s", dir)
+	}
+
+	builtin := strategies.Registered()
+	for _, path := range paths {
+		if _, err := plugin.Open(path); err != nil {
+			return nil, fmt.Errorf("loading plugin %s: %w", filepath.Base(path), err)
+		}
+	}
+
+	var added []string
+	for _, key := range strategies.Registered() {
+		if !slices.Contains(builtin, key) {
+			added = append(added, key)
+		}
+	}
+	return added, nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"strings"
+	"sync"
+	"time"
+)
+
+var progress = flag.Bool("progress", true, "show a live progress bar while each strategy runs")
+
+const (
+	progressInterval = 200 * time.Millisecond
+	progressBarWidth = 30
+)
+
+// suiteProgress tracks how far the whole benchmark suite has come, so that
+// an ETA can be given for the remaining strategies as well as the current one.
+type suiteProgress struct {
+	startTime time.Time
+	dataSize  int64
+	total     int
+	completed int
+}
+
+func newSuiteProgress(dataSize int64, total int) *suiteProgress {
+	return &suiteProgress{
+		startTime: time.Now(),
+		dataSize:  dataSize,
+		total:     total,
+	}
+}
+
+// finishStrategy records that one more strategy has consumed the whole file.
+func (s *suiteProgress) finishStrategy() {
+	s.completed++
+}
+
+// eta extrapolates the remaining suite time from the average throughput
+// achieved so far, counting every strategy as one full pass over the file.
+func (s *suiteProgress) eta(currentBytes int64) time.Duration {
+	processed := int64(s.completed)*s.dataSize + min(currentBytes, s.dataSize)
+	remaining := int64(s.total)*s.dataSize - processed
+	return estimateRemaining(processed, remaining, time.Since(s.startTime))
+}
+
+// estimateRemaining returns how long the remaining bytes will take at the
+// average rate observed so far, or -1 while there is no rate to go on.
+func estimateRemaining(processed, remaining int64, elapsed time.Duration) time.Duration {
+	if processed <= 0 || elapsed <= 0 {
+		return -1
+	}
+	if remaining <= 0 {
+		return 0
+	}
+	perByte := float64(elapsed) / float64(processed)
+	return time.Duration(perByte * float64(remaining))
+}
+
+// progressBar polls a strategy's byte and row counters and redraws a single
+// status line with the completed percentage, the instantaneous throughput,
+// the rows parsed and the estimated time remaining.
+type progressBar struct {
+	tracker   strategies.ProgressTracker
+	suite     *suiteProgress
+	totalSize int64
+	startTime time.Time
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// startProgressBar begins rendering progress for strategy in the background.
+// It returns nil when the strategy does not report progress.
+func startProgressBar(strategy strategies.Strategy, suite *suiteProgress) *progressBar {
+	tracker, ok := strategy.(strategies.ProgressTracker)
+	if !ok || suite.dataSize <= 0 {
+		return nil
+	}
+
+	p := &progressBar{
+		tracker:   tracker,
+		suite:     suite,
+		totalSize: suite.dataSize,
+		startTime: time.Now(),
+		done:      make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go p.run()
+	return p
+}
+
+func (p *progressBar) run() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	lastBytes := int64(0)
+	lastTick := p.startTime
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			bytesRead := p.tracker.BytesRead()
+			elapsed := now.Sub(lastTick).Seconds()
+			mbPerSec := float64(bytesRead-lastBytes) / 1024 / 1024 / elapsed
+			p.render(bytesRead, p.tracker.RowsParsed(), mbPerSec, now)
+
+			lastBytes = bytesRead
+			lastTick = now
+		}
+	}
+}
+
+func (p *progressBar) render(bytesRead, rows int64, mbPerSec float64, now time.Time) {
+	fraction := min(float64(bytesRead)/float64(p.totalSize), 1)
+	filled := int(fraction * progressBarWidth)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+
+	eta := estimateRemaining(bytesRead, p.totalSize-bytesRead, now.Sub(p.startTime))
+	suiteETA := p.suite.eta(bytesRead)
+
+	out.Printf("\r  %s %5.1f%%  %8.1f MB/s  %7s rows  ETA %s  suite %s",
+		out.Paint(bar, ColorCyan), fraction*100, mbPerSec, formatCount(rows), formatETA(eta), formatETA(suiteETA))
+}
+
+// formatCount renders a count compactly, e.g. "950", "12.3K" or "1.00B".
+func formatCount(n int64) string {
+	switch {
+	case n >= 1_000_000_000:
+		return fmt.Sprintf("%.2fB", float64(n)/1e9)
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fK", float64(n)/1e3)
+	}
+	return fmt.Sprint(n)
+}
+
+// formatETA renders a remaining duration as a compact clock, e.g. "1:04:09"
+// or "03:27", and "--:--" when no estimate is available yet.
+func formatETA(d time.Duration) string {
+	if d < 0 {
+		return "--:--"
+	}
+	d = d.Round(time.Second)
+	h := int(d / time.Hour)
+	m := int(d % time.Hour / time.Minute)
+	s := int(d % time.Minute / time.Second)
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
+
+// stop halts rendering and clears the progress line so that the next
+// message starts on a clean line.
+func (p *progressBar) stop() {
+	if p == nil {
+		return
+	}
+	close(p.done)
+	p.wg.Wait()
+	out.Printf("\r%s\r", strings.Repeat(" ", progressBarWidth+75))
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var quarantineTo = flag.String("quarantine", "", "in lenient mode, also write every malformed line, after its byte offset and a tab, to this file, in file order")
+
+// quarantine collects the malformed lines for -quarantine; nil without it.
+var quarantine *quarantineLog
+
+// quarantineLog is the strategies.Quarantine behind -quarantine. Every
+// strategy skips the same malformed lines, so it keeps each once, by its
+// offset and text, and writes them all in file order when the run is over.
+// Some strategies pass a line with the '\r' of a CRLF ending and others
+// without; the one with it is kept, as the line was in the file.
+type quarantineLog struct {
+	mu    sync.Mutex
+	lines map[quarantinedLine]string
+}
+
+type quarantinedLine struct {
+	offset int64
+	text   string
+}
+
+func newQuarantineLog() *quarantineLog {
+	return &quarantineLog{lines: make(map[quarantinedLine]string)}
+}
+
+func (q *quarantineLog) QuarantineLine(offset int64, line []byte) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := quarantinedLine{offset, strings.TrimSuffix(string(line), "\r")}
+	if raw, ok := q.lines[key]; !ok || len(line) > len(raw) {
+		q.lines[key] = string(line)
+	}
+}
+
+// writeQuarantine writes the lines q collected to path, one per line as
+// the byte offset, a tab and the line as it was read, replacing any file
+// there.
+func writeQuarantine(q *quarantineLog, path string) {
+	lines := make([]quarantinedLine, 0, len(q.lines))
+	for l, raw := range q.lines {
+		lines = append(lines, quarantinedLine{l.offset, raw})
+	}
+	slices.SortFunc(lines, func(a, b quarantinedLine) int {
+		return cmp.Or(cmp.Compare(a.offset, b.offset), strings.Compare(a.text, b.text))
+	})
+
+	err := writeFile(path, func(w io.Writer) error {
+		bw := bufio.NewWriter(w)
+		for _, l := range lines {
+			fmt.Fprintf(bw, "%d\t%s\n", l.offset, l.text)
+		}
+		return bw.Flush()
+	})
+	if err != nil {
+		out.Errorf("Error writing the quarantine: %v", err)
+		out.Println()
+		return
+	}
+	out.Printf("%s %s %s\n\n", out.Paint("Quarantine:", ColorBlue), path,
+		out.Paint(fmt.Sprintf("(%d malformed lines)", len(lines)), ColorYellow))
+}
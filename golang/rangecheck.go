@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"math"
+	"text/tabwriter"
+	"time"
+)
+
+var rangeCheck = flag.Bool("check-range", false, "count the values outside -99.9..99.9 per station in a pass of their own, list the worst offenders and leave those values out of every strategy's stations; fail a strategy whose extremes are out of range where the input's are not")
+
+// rangeLimit is the magnitude, in tenths of a degree, past which
+// -check-range takes a temperature for a parse bug or corrupt input: the
+// 1BRC's -99.9..99.9.
+const rangeLimit = 999
+
+// maxOffenders bounds the stations -check-range lists.
+const maxOffenders = 10
+
+// checkRange runs the -check-range pass over dataFile: it lists the
+// stations with the most values out of range, then takes those values out
+// of every successful strategy's stations, and fails any strategy with an
+// extreme out of range that the input does not have, which only a parse
+// bug could have made.
+func checkRange(results []BenchmarkResult, dataFile string, opts strategies.StrategyOptions) {
+	out.Headerf("=== Range check ===")
+	out.Println()
+
+	limit := rangeLimit * int64(math.Pow10(decimals)) / 10
+	start := time.Now()
+	report, err := strategies.ScanRange(context.Background(), dataFile, opts, -limit, limit)
+	if err != nil {
+		out.Errorf("Error checking the values of %s: %v", dataFile, err)
+		out.Println()
+		return
+	}
+	out.Printf("%s %s..%s (%s)\n", out.Paint("Range:", ColorBlue), formatTemperature(float64(-limit), decimals),
+		formatTemperature(float64(limit), decimals), formatDuration(time.Since(start)))
+
+	inRange := make(map[string]strategies.StationResult, len(report))
+	if len(report) == 0 {
+		out.Successf("✓ Every value is in range")
+	} else {
+		var values int64
+		for _, r := range report {
+			values += r.Count
+			inRange[r.Station] = r.InRange
+		}
+		out.Printf("%s %d values at %d stations, left out of their min/mean/max\n", out.Paint("Out of range:", ColorBlue), values, len(report))
+		out.Println(out.Paint("Worst offenders:", ColorBlue))
+		w := tabwriter.NewWriter(out.Writer(), 0, 0, 2, ' ', 0)
+		for _, r := range report[:min(maxOffenders, len(report))] {
+			fmt.Fprintf(w, "  %s\t%d out of range\tworst %s\n", r.Station, r.Count, formatTemperature(float64(r.Worst), decimals))
+		}
+		w.Flush()
+	}
+
+	for i := range results {
+		r := &results[i]
+		if !r.Success || r.Stations == nil {
+			continue
+		}
+		stations, err := dropOutOfRange(r.Stations, inRange, limit)
+		if err != nil {
+			r.Success = false
+			r.Error = fmt.Errorf("range check: %v", err)
+			out.Errorf("✗ %s: %v", r.StrategyName, err)
+			continue
+		}
+		r.Stations, r.ResultCount = stations, len(stations)
+		out.Successf("✓ %s", r.StrategyName)
+	}
+	out.Println()
+}
+
+// dropOutOfRange returns stations with those in inRange replaced by their
+// in-range aggregates, or left out when they have none. It reports the
+// first other station with an extreme beyond limit.
+func dropOutOfRange(stations []strategies.StationResult, inRange map[string]strategies.StationResult, limit int64) ([]strategies.StationResult, error) {
+	kept := make([]strategies.StationResult, 0, len(stations))
+	for _, st := range stations {
+		clean, ok := inRange[st.StationID]
+		switch {
+		case !ok && (st.Minimum < -limit || st.Maximum > limit):
+			return nil, fmt.Errorf("station %q: min %s, max %s, though every value in the input is in range", st.StationID,
+				formatTemperature(float64(st.Minimum), decimals), formatTemperature(float64(st.Maximum), decimals))
+		case !ok:
+			kept = append(kept, st)
+		case clean.Count > 0:
+			if st.Extra == nil {
+				clean.Extra = nil
+			}
+			kept = append(kept, clean)
+		}
+	}
+	return kept, nil
+}
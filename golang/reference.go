@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var referenceCmd = flag.String("reference-cmd", "", "external implementation to time on the same file and check every strategy against, e.g. \"./calculate_average {}\" ({} is the data file, appended if absent)")
+
+// referenceStation is one station as an external implementation prints
+// it: min, mean and max, in units of the input's last fraction digit.
+type referenceStation struct {
+	min, mean, max int64
+}
+
+// runReference times the -reference-cmd on dataFile and parses what it
+// prints. The command is split at spaces, with no shell quoting; a {}
+// argument is replaced by the data file's path, which is appended when
+// there is none. Its output must be the 1BRC's {name=min/mean/max, ...},
+// or one name=min/mean/max per line.
+func runReference(command, dataFile string) (BenchmarkResult, map[string]referenceStation) {
+	args := strings.Fields(command)
+	if !strings.Contains(command, "{}") {
+		args = append(args, "{}")
+	}
+	for i, a := range args {
+		args[i] = strings.ReplaceAll(a, "{}", dataFile)
+	}
+	result := BenchmarkResult{
+		StrategyName:   "Reference: " + filepath.Base(args[0]),
+		ReadSyscalls:   -1,
+		MalformedLines: -1,
+		GC:             gcConfig,
+	}
+	out.Warnf("⏱️  Running: %s", result.StrategyName)
+	out.Println("   " + strings.Join(args, " "))
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	result.ExecutionTime = time.Since(start)
+
+	var stations map[string]referenceStation
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.Error = fmt.Errorf("timed out after %v", *timeout)
+	case err != nil && stderr.Len() > 0:
+		result.Error = fmt.Errorf("%v: %s", err, lastLine(stderr.String()))
+	case err != nil:
+		result.Error = err
+	default:
+		stations, result.Error = parseReferenceOutput(stdout.String(), decimals)
+		result.ResultCount = len(stations)
+		result.Success = result.Error == nil
+	}
+
+	if result.Success {
+		out.Successf("✓ Completed in: %v", result.ExecutionTime)
+	} else {
+		out.Errorf("✗ Failed: %v", result.Error)
+	}
+	out.Println()
+	return result, stations
+}
+
+// lastLine is the last non-empty line of s, the likeliest to say what
+// went wrong.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return lines[len(lines)-1]
+}
+
+// parseReferenceOutput reads stations printed as {a=1.0/2.0/3.0, b=...}
+// or one a=1.0/2.0/3.0 per line, with values of digits fraction digits.
+func parseReferenceOutput(output string, digits int) (map[string]referenceStation, error) {
+	entries, err := parseResultEntries(output)
+	if err != nil {
+		return nil, fmt.Errorf("output: %v", err)
+	}
+
+	stations := make(map[string]referenceStation, len(entries))
+	for _, e := range entries {
+		var st [3]int64
+		for j, v := range []string{e.min, e.mean, e.max} {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("output: %q: %v", e.name, err)
+			}
+			st[j] = int64(math.Round(f * math.Pow10(digits)))
+		}
+		stations[e.name] = referenceStation{st[0], st[1], st[2]}
+	}
+	return stations, nil
+}
+
+// compareWithReference fails every successful strategy whose stations
+// differ from the reference's in name, min or max. Means may differ by one
+// in the last digit, since implementations summing in floating point round
+// some halves the other way.
+func compareWithReference(results []BenchmarkResult, ref BenchmarkResult, want map[string]referenceStation) {
+	out.Headerf("=== Reference comparison ===")
+	out.Println()
+	if !ref.Success {
+		out.Println("The reference failed; nothing to compare against")
+		out.Println()
+		return
+	}
+	out.Printf("%s (%d stations)\n", ref.StrategyName, len(want))
+
+	for i := range results {
+		r := &results[i]
+		if !r.Success {
+			continue
+		}
+		if err := diffReference(want, r.Stations); err != nil {
+			r.Success = false
+			r.Error = fmt.Errorf("reference: %v", err)
+			out.Errorf("✗ %s: %v", r.StrategyName, err)
+		} else {
+			out.Successf("✓ %s", r.StrategyName)
+		}
+	}
+	out.Println()
+}
+
+// diffReference reports the first station of got that is missing from
+// want or prints differently, or else how many stations are missing.
+func diffReference(want map[string]referenceStation, got []strategies.StationResult) error {
+	for _, st := range got {
+		exp, ok := want[st.StationID]
+		if !ok {
+			return fmt.Errorf("station %q is not in the reference", st.StationID)
+		}
+		mean := roundedMean(st)
+		if st.Minimum != exp.min || st.Maximum != exp.max || mean < exp.mean-1 || mean > exp.mean+1 {
+			return fmt.Errorf("station %q: %s/%s/%s, reference %s/%s/%s", st.StationID,
+				formatFixed(st.Minimum, decimals), formatFixed(mean, decimals), formatFixed(st.Maximum, decimals),
+				formatFixed(exp.min, decimals), formatFixed(exp.mean, decimals), formatFixed(exp.max, decimals))
+		}
+	}
+	if len(got) != len(want) {
+		return fmt.Errorf("%d stations, reference %d", len(got), len(want))
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+var repl = flag.Bool("repl", false, "after the summary, answer queries such as \"top 5 by mean\", \"show Tokyo\", \"count\" or \"export csv\" about the first successful strategy's stations, read from stdin")
+
+// maxSuggestions bounds the names show offers for one it does not know.
+const maxSuggestions = 8
+
+// queryHelp lists the queries -repl answers.
+const queryHelp = `  top [n] [by max|min|mean|stddev|median]   the n (10) stations with the highest and lowest value
+  show <name>                                one station; names may contain spaces
+  count                                      stations and rows
+  export csv [file]                          every station as CSV, to the file or the terminal
+  help                                       this list
+  quit                                       leave (also exit or end of input)`
+
+// isStdin reports whether path is the file the process reads as stdin,
+// such as /dev/stdin or the pipe feeding it.
+func isStdin(path string) bool {
+	file, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	stdin, err := os.Stdin.Stat()
+	return err == nil && os.SameFile(file, stdin)
+}
+
+// runQueries answers queries read from in, one per line, about the
+// stations of the first successful strategy for -repl, among those
+// -filter selects, until in ends or a quit.
+func runQueries(in io.Reader, results []BenchmarkResult) {
+	i := stationsFrom(results)
+	if i < 0 {
+		out.Errorf("No successful strategy to query")
+		return
+	}
+	stations := filterStations(results[i].Stations)
+
+	out.Headerf("=== Query the results (from %s) ===", results[i].StrategyName)
+	out.Println(queryHelp)
+	out.Println()
+
+	lines := bufio.NewScanner(in)
+	for {
+		out.Printf("%s ", out.Paint(">", ColorBlue))
+		if !lines.Scan() {
+			out.Println()
+			return
+		}
+		fields := strings.Fields(lines.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch args := fields[1:]; fields[0] {
+		case "top":
+			queryTop(stations, args)
+		case "show":
+			queryShow(stations, strings.Join(args, " "))
+		case "count":
+			var rows int64
+			for _, st := range stations {
+				rows += st.Count
+			}
+			out.Printf("%d stations, %d rows\n", len(stations), rows)
+		case "export":
+			queryExport(stations, args)
+		case "help":
+			out.Println(queryHelp)
+		case "quit", "exit":
+			return
+		default:
+			out.Errorf("Unknown query %q; try help", fields[0])
+		}
+	}
+}
+
+// queryTop answers "top [n] [by field]".
+func queryTop(stations []strategies.StationResult, args []string) {
+	n, field := 10, "max"
+	if len(args) > 0 && args[0] != "by" {
+		v, err := strconv.Atoi(args[0])
+		if err != nil || v <= 0 {
+			out.Errorf("top: %q is not a positive count", args[0])
+			return
+		}
+		n, args = v, args[1:]
+	}
+	switch {
+	case len(args) == 2 && args[0] == "by":
+		field = args[1]
+	case len(args) != 0:
+		out.Errorf("usage: top [n] [by field]")
+		return
+	}
+	if _, ok := topFields[field]; !ok {
+		out.Errorf("top: unknown field %q (max, min, mean, stddev or median)", field)
+		return
+	}
+	if len(stations) == 0 {
+		out.Errorf("top: no stations")
+		return
+	}
+	if (field == "stddev" || field == "median") && distributionOf(stations[0]) == nil {
+		out.Errorf("top: ranking by %s needs -distribution", field)
+		return
+	}
+	printExtremes(rankStations(stations, field), min(n, len(stations)))
+}
+
+// queryShow answers "show <name>", suggesting names that share its first
+// three letters, ignoring case, when there is no such station.
+func queryShow(stations []strategies.StationResult, name string) {
+	if name == "" {
+		out.Errorf("usage: show <name>")
+		return
+	}
+	// Strategies return their stations sorted by name.
+	i, found := slices.BinarySearchFunc(stations, name, func(st strategies.StationResult, name string) int {
+		return strings.Compare(st.StationID, name)
+	})
+	if !found {
+		out.Errorf("No station named %q", name)
+		var similar []string
+		for _, st := range stations {
+			if len(similar) < maxSuggestions && strings.HasPrefix(strings.ToLower(st.StationID), strings.ToLower(name[:min(len(name), 3)])) {
+				similar = append(similar, st.StationID)
+			}
+		}
+		if similar != nil {
+			out.Printf("Did you mean: %s\n", strings.Join(similar, ", "))
+		}
+		return
+	}
+
+	st := stations[i]
+	w := tabwriter.NewWriter(out.Writer(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  %s\tmin %s\tmean %s\tmax %s\tcount %d", st.StationID, formatTemperature(float64(st.Minimum), decimals),
+		formatTemperature(st.Average, decimals), formatTemperature(float64(st.Maximum), decimals), st.Count)
+	if d := distributionOf(st); d != nil {
+		fmt.Fprintf(w, "\tσ %s\tp50 %s\tp90 %s\tp99 %s", formatDeviation(d.StdDev(), decimals),
+			formatEstimate(d.Median(), decimals), formatEstimate(d.Quantile(0.9), decimals), formatEstimate(d.Quantile(0.99), decimals))
+	}
+	fmt.Fprintln(w)
+	w.Flush()
+}
+
+// queryExport answers "export csv [file]".
+func queryExport(stations []strategies.StationResult, args []string) {
+	if len(args) == 0 || len(args) > 2 || args[0] != "csv" {
+		out.Errorf("usage: export csv [file]")
+		return
+	}
+	if len(args) == 1 {
+		if err := writeStationsCSV(out.Writer(), stations); err != nil {
+			out.Errorf("export: %v", err)
+		}
+		return
+	}
+
+	err := writeFile(args[1], func(w io.Writer) error {
+		return writeStationsCSV(w, stations)
+	})
+	if err != nil {
+		out.Errorf("export: %v", err)
+		return
+	}
+	out.Successf("✓ Wrote %d stations to %s", len(stations), args[1])
+}
+
+// writeStationsCSV writes stations as CSV with a header row: the name,
+// min, mean, max and count, then the spread under -distribution.
+func writeStationsCSV(w io.Writer, stations []strategies.StationResult) error {
+	cw := csv.NewWriter(w)
+	header := []string{"name", "min", "mean", "max", "count"}
+	spread := len(stations) > 0 && distributionOf(stations[0]) != nil
+	if spread {
+		header = append(header, "stddev", "p50", "p90", "p99")
+	}
+	cw.Write(header)
+	for _, st := range stations {
+		record := []string{st.StationID, formatTemperature(float64(st.Minimum), decimals), formatTemperature(st.Average, decimals),
+			formatTemperature(float64(st.Maximum), decimals), strconv.FormatInt(st.Count, 10)}
+		if d := distributionOf(st); spread && d != nil {
+			record = append(record, formatDeviation(d.StdDev(), decimals), formatEstimate(d.Median(), decimals),
+				formatEstimate(d.Quantile(0.9), decimals), formatEstimate(d.Quantile(0.99), decimals))
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunQueries(t *testing.T) {
+	buf := captureOutput(t)
+	csvPath := filepath.Join(t.TempDir(), "stations.csv")
+	queries := []string{
+		"count",
+		"show São Paulo",
+		"show Be",
+		"top 1 by min",
+		"top 0",
+		"top by stddev",
+		"export csv",
+		"export csv " + csvPath,
+		"nope",
+		"",
+		"quit",
+		"count", // not answered after quit
+	}
+	runQueries(strings.NewReader(strings.Join(queries, "\n")), testResults(testStations(false)))
+
+	_, transcript, ok := strings.Cut(buf.String(), queryHelp+"\n\n")
+	if !ok {
+		t.Fatalf("no help before the prompt:\n%s", buf)
+	}
+	want := `> 4 stations, 10 rows
+>   São Paulo  min 21.4  mean 23.5  max 25.6  count 2
+> No station named "Be"
+Did you mean: Berlin, Bern
+> Highest:
+   1.  São Paulo  21.4/23.5/25.6
+
+Lowest:
+   1.  Berlin  -10.3/2.1/9.9
+
+> top: "0" is not a positive count
+> top: ranking by stddev needs -distribution
+> name,min,mean,max,count
+Abha,-2.3,18.6,40.2,3
+Berlin,-10.3,2.1,9.9,4
+Bern,0.0,0.0,0.0,1
+São Paulo,21.4,23.5,25.6,2
+> ✓ Wrote 4 stations to ` + csvPath + `
+> Unknown query "nope"; try help
+> > `
+	if transcript != want {
+		t.Errorf("transcript:\n%s\nwant:\n%s", transcript, want)
+	}
+
+	data, err := os.ReadFile(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "name,min,mean,max,count\nAbha,-2.3,18.6,40.2,3\n") {
+		t.Errorf("export csv wrote %q", data)
+	}
+}
+
+func TestRunQueriesHonorsFilter(t *testing.T) {
+	saved := *filter
+	t.Cleanup(func() {
+		*filter = saved
+		setStationFilter()
+	})
+	*filter = "Ber.*"
+	if err := setStationFilter(); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := captureOutput(t)
+	runQueries(strings.NewReader("count\nshow Abha\n"), testResults(testStations(true)))
+	for _, want := range []string{"> 2 stations, 5 rows\n", `> No station named "Abha"` + "\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, buf)
+		}
+	}
+}
+
+func TestRunQueriesWithoutResults(t *testing.T) {
+	buf := captureOutput(t)
+	runQueries(strings.NewReader("count\n"), testResults(nil)[:1])
+	if got := buf.String(); got != "No successful strategy to query\n" {
+		t.Errorf("printed %q", got)
+	}
+}
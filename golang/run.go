@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"runtime"
+	"time"
+)
+
+// BenchmarkResult holds the performance metrics for a strategy
+type BenchmarkResult struct {
+	StrategyName  string
+	ExecutionTime time.Duration
+	MemoryUsed    uint64
+	ResultCount   int
+	Rows          int64 // measurements aggregated over all stations
+	Success       bool
+	Error         error
+
+	// Partial marks a run that -timeout or Ctrl-C stopped under
+	// -keep-partial, whose ResultCount, Rows and Stations cover only the
+	// part of the file it had read.
+	Partial bool
+
+	// ReadSyscalls is the number of read system calls issued, or -1 when
+	// the strategy does not count them.
+	ReadSyscalls int64
+
+	// MalformedLines is the number of lines skipped as malformed, or -1
+	// when the strategy does not count them.
+	MalformedLines int64
+
+	// GC is the collector configuration in effect for the run, and
+	// GCCycles and GCPause the collections it ran and the time they
+	// stopped the world.
+	GC       gcSettings
+	GCCycles uint32
+	GCPause  time.Duration
+
+	// Chunks holds how long each chunk took under -chunk-times.
+	Chunks []chunkTiming
+
+	// Phases holds where the run's time went under -phases.
+	Phases *strategies.PhaseTimes
+
+	// BatchSize is the number of lines per batch the run ended with, or 0
+	// when the strategy does not batch them.
+	BatchSize int
+
+	// Probes holds hash table probe lengths, or nil when the strategy does
+	// not report them.
+	Probes *strategies.ProbeStats
+
+	// Stations holds the strategy's results, kept only when keepStations
+	// says a later step needs them.
+	Stations []strategies.StationResult
+}
+
+// keepStations reports whether a step after the runs needs each
+// strategy's stations: -crosscheck, -partial-out, -reference-cmd, -golden,
+// -top, -filter, -repl, -export or -check-range.
+func keepStations() bool {
+	return *crosscheck || *partialOut != "" || *referenceCmd != "" || *goldenDir != "" || *top > 0 || *filter != "" || *repl || *export != "" ||
+		*rangeCheck
+}
+
+func benchmarkStrategy(name string, strategy strategies.Strategy, filePath string) BenchmarkResult {
+	result := BenchmarkResult{
+		StrategyName: name,
+		Success:      false,
+		ReadSyscalls: -1,
+		GC:           gcConfig,
+
+		MalformedLines: -1,
+	}
+
+	ctx, end := startSpan(traceCtx, "strategy", strategies.SpanAttr{Key: "strategy", Value: name})
+	defer end()
+	ctx, stopInterrupt := interruptible(ctx)
+	defer stopInterrupt()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	runtime.GC()
+	if phaseTimer != nil {
+		phaseTimer.Reset()
+	}
+
+	var memStatsBefore runtime.MemStats
+	runtime.ReadMemStats(&memStatsBefore)
+
+	// Start timing
+	startTime := time.Now()
+
+	// Execute strategy. Under -max-memory, results are counted as they are
+	// merged instead of being collected, unless a later step needs them.
+	var stationResults []strategies.StationResult
+	var err error
+	if maxMemory > 0 && !keepStations() {
+		var totals streamTotals
+		totals, err = withDeadline(ctx, func() (streamTotals, error) {
+			return streamStations(ctx, strategy, filePath)
+		})
+		result.ResultCount, result.Rows = totals.stations, totals.rows
+	} else {
+		stationResults, err = withDeadline(ctx, func() ([]strategies.StationResult, error) {
+			return strategy.Calculate(ctx, filePath)
+		})
+		result.ResultCount = len(stationResults)
+		for _, r := range stationResults {
+			result.Rows += r.Count
+		}
+	}
+	var partial *strategies.PartialError
+	if *keepPartial && errors.As(err, &partial) {
+		stationResults, result.Partial = partial.Results, true
+		result.ResultCount, result.Rows = len(stationResults), 0
+		for _, r := range stationResults {
+			result.Rows += r.Count
+		}
+	}
+
+	// End timing
+	executionTime := time.Since(startTime)
+
+	// Get memory stats after
+	var memStatsAfter runtime.MemStats
+	runtime.ReadMemStats(&memStatsAfter)
+
+	// Calculate memory used (in MB)
+	memoryUsed := memStatsAfter.Alloc - memStatsBefore.Alloc
+	result.GCCycles = memStatsAfter.NumGC - memStatsBefore.NumGC
+	result.GCPause = time.Duration(memStatsAfter.PauseTotalNs - memStatsBefore.PauseTotalNs)
+
+	result.ExecutionTime = executionTime
+	result.MemoryUsed = memoryUsed
+	if errors.Is(err, errAbandoned) {
+		// The strategy is still running, so its counters are not settled.
+		out.Warnf("⚠ %s did not return within %v of being stopped; leaving it running", name, stopGrace)
+		result.Error = fmt.Errorf("timed out after %v; still running", *timeout)
+		if interrupt.Err() != nil {
+			result.Error = errors.New("interrupted; still running")
+		}
+		return result
+	}
+	result.Chunks = chunkTracer.take()
+	if phaseTimer != nil {
+		times := phaseTimer.Times()
+		result.Phases = &times
+	}
+	if keepStations() {
+		result.Stations = stationResults
+	}
+	if counter, ok := strategy.(strategies.SyscallCounter); ok {
+		result.ReadSyscalls = counter.ReadSyscalls()
+	}
+	if counter, ok := strategy.(strategies.MalformedLineCounter); ok {
+		result.MalformedLines = counter.MalformedLines()
+	}
+	if reporter, ok := strategy.(strategies.BatchSizeReporter); ok {
+		result.BatchSize = reporter.BatchSize()
+	}
+	if reporter, ok := strategy.(strategies.ProbeStatsReporter); ok && err == nil {
+		probes := reporter.ProbeStats()
+		result.Probes = &probes
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		result.Error = fmt.Errorf("timed out after %v", *timeout)
+		result.Success = false
+	} else if errors.Is(err, context.Canceled) && interrupt.Err() != nil {
+		result.Error = errors.New("interrupted")
+		result.Success = false
+	} else if err != nil {
+		result.Error = err
+		result.Success = false
+	} else {
+		result.Success = true
+	}
+	if result.Partial {
+		result.Error = fmt.Errorf("%v; kept the stations of the first %.2f MB", result.Error, float64(partial.BytesRead)/1024/1024)
+	}
+
+	return result
+}
+
+// errAbandoned marks a run that withDeadline stopped waiting for.
+var errAbandoned = errors.New("abandoned")
+
+// withDeadline returns the outcome of run, or ctx.Err() if ctx expired
+// before run succeeded. Under -keep-partial the strategy's own error is
+// kept instead, as it carries the stations aggregated so far. Strategies
+// check ctx as they read, so a stopped run returns soon after; it is
+// waited for, so that it never competes for the CPU with the strategies
+// after it, but only for stopGrace. A strategy that ignores ctx, such as
+// a legacy one or a plugin, is then abandoned with an error wrapping
+// errAbandoned, and left to run out in the background.
+func withDeadline[T any](ctx context.Context, run func() (T, error)) (T, error) {
+	if ctx.Done() == nil {
+		return run()
+	}
+
+	type outcome struct {
+		value T
+		err   error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		value, err := run()
+		done <- outcome{value, err}
+	}()
+
+	var o outcome
+	select {
+	case o = <-done:
+	case <-ctx.Done():
+		select {
+		case o = <-done:
+		case <-time.After(stopGrace):
+			var zero T
+			return zero, fmt.Errorf("%w: %w", ctx.Err(), errAbandoned)
+		}
+	}
+	if o.err != nil && ctx.Err() != nil && !*keepPartial {
+		var zero T
+		return zero, ctx.Err()
+	}
+	return o.value, o.err
+}
+
+// streamTotals counts what a streamed run produced.
+type streamTotals struct {
+	stations int
+	rows     int64
+}
+
+// runStrategy benchmarks one strategy in the given iteration, with its
+// progress bar and, in the first iteration, its -flamegraph profile.
+func runStrategy(s namedStrategy, dataFile string, fileRows int64, suite *suiteProgress, iter int) BenchmarkResult {
+	out.Warnf("⏱️  Running: %s", s.name)
+	if desc := strategyDescription(s.strategy); desc != "" {
+		out.Println("   " + desc)
+	}
+
+	var profile *strategyProfile
+	if *flamegraph != "" && iter == 0 {
+		var err error
+		profile, err = startStrategyProfile(*flamegraph, s.name)
+		if err != nil {
+			out.Errorf("Error starting CPU profile for %s: %v", s.name, err)
+		}
+	}
+
+	var bar *progressBar
+	if *progress && out.Interactive() {
+		bar = startProgressBar(s.strategy, suite)
+	}
+
+	restoreGC := func() {}
+	if s.noGC {
+		restoreGC = noGCConfig.apply()
+	}
+	result := benchmarkStrategy(s.name, s.strategy, dataFile)
+	restoreGC()
+	if s.noGC {
+		result.GC = noGCConfig
+	}
+	if *validate {
+		validateRows(&result, fileRows)
+	}
+	bar.stop()
+	suite.finishStrategy()
+
+	if result.Success {
+		out.Successf("✓ Completed in: %v", result.ExecutionTime)
+	} else if result.Partial {
+		out.Warnf("◐ Stopped: %v", result.Error)
+	} else {
+		out.Errorf("✗ Failed: %v", result.Error)
+	}
+	if batchSize.auto && result.BatchSize > 0 {
+		out.Printf("   Batch size settled at %d lines\n", result.BatchSize)
+	}
+
+	if *chunkTimes {
+		printChunkTimes(result)
+	}
+	if profile != nil {
+		writeFlamegraph(profile, s.name)
+	}
+	out.Println()
+	return result
+}
+
+// streamStations runs strategy with strategies.Stream, which hands the
+// stations over as the per-worker tables are merged rather than building
+// one more table and a slice of them all.
+func streamStations(ctx context.Context, strategy strategies.Strategy, filePath string) (streamTotals, error) {
+	var totals streamTotals
+	err := strategies.Stream(ctx, strategy, filePath, func(r strategies.StationResult) bool {
+		totals.stations++
+		totals.rows += r.Count
+		return true
+	})
+	return totals, err
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stuckStrategy ignores its context and returns only once release is closed.
+type stuckStrategy struct{ release chan struct{} }
+
+func (s stuckStrategy) Calculate(context.Context, string) ([]strategies.StationResult, error) {
+	<-s.release
+	return nil, nil
+}
+
+func TestBenchmarkStrategyAbandonsAStrategyThatNeverReturns(t *testing.T) {
+	captureOutput(t)
+	savedTimeout, savedGrace := *timeout, stopGrace
+	*timeout, stopGrace = 20*time.Millisecond, 20*time.Millisecond
+	t.Cleanup(func() { *timeout, stopGrace = savedTimeout, savedGrace })
+
+	stuck := stuckStrategy{release: make(chan struct{})}
+	defer close(stuck.release)
+
+	done := make(chan BenchmarkResult, 1)
+	go func() { done <- benchmarkStrategy("stuck", stuck, "unused.txt") }()
+	select {
+	case result := <-done:
+		if result.Success {
+			t.Fatal("a strategy that never returned succeeded")
+		}
+		if result.Error == nil || !strings.Contains(result.Error.Error(), "timed out") {
+			t.Errorf("error = %v, want a timeout", result.Error)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("benchmarkStrategy waited for a strategy that never returns")
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"math/rand/v2"
+	"time"
+)
+
+var sample = flag.Float64("sample", 0, "aggregate a random share of the file's chunks, e.g. 0.01, and project each strategy's full-run time and rows from it (0 = all)")
+
+// sampleSeed picks the chunks -sample reads. It is drawn once per run, so
+// every strategy aggregates the same chunks and their times compare.
+var sampleSeed = rand.Uint64()
+
+// samplerKeys returns the keys whose strategies honor -sample, in order.
+func samplerKeys(keys []string) []string {
+	var samplers []string
+	for _, key := range keys {
+		if entry, ok := lookupStrategy(key); ok && entry.supported() && entry.SamplesChunks {
+			samplers = append(samplers, key)
+		}
+	}
+	return samplers
+}
+
+// printSampleProjection scales each strategy's sampled run up to the whole
+// file, of which the run read the share fraction. Times scale linearly,
+// which leaves out the fixed costs (opening, merging) a full run pays once,
+// so the projection errs slightly high.
+func printSampleProjection(results []BenchmarkResult, fraction float64) {
+	out.Println()
+	out.Headerf("Sample projection (%.2f%% of the file read):", fraction*100)
+	for _, r := range results {
+		if !r.Success {
+			continue
+		}
+		projected := time.Duration(float64(r.ExecutionTime) / fraction)
+		out.Printf("  %-24s %s sampled, ~%s for the full file, ~%d rows\n",
+			r.StrategyName, formatDuration(r.ExecutionTime), formatDuration(projected), int64(float64(r.Rows)/fraction))
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// cpuSweep is the old spelling of "scaling -cpus", kept so that scripts
+// written for it still run.
+var cpuSweep = flag.String("cpus", "", "deprecated: use the scaling subcommand")
+
+// runScalingCommand implements "scaling [flags] [file]": it benchmarks the
+// strategies at each -cpus count and returns the exit status.
+func runScalingCommand(args []string) int {
+	fs := flag.NewFlagSet("scaling", flag.ExitOnError)
+	fs.StringVar(strategyList, "strategies", "", "comma-separated strategy keys to run instead of the default suite, e.g. lp-table,swiss")
+	cpuList := fs.String("cpus", "1,2,4,8,16", "GOMAXPROCS values to run every strategy at; those above the machine's CPUs are skipped")
+	fs.DurationVar(timeout, "timeout", 0, "abort a run and mark it FAILED after this long, e.g. 2m (0 = no limit)")
+	noColor := fs.Bool("no-color", false, "disable ANSI colors (also honors NO_COLOR and non-TTY output)")
+	strategyFlags(fs)
+	recordFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s scaling [flags] [file]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Benchmark every strategy at each of -cpus, with GOMAXPROCS and, unless -workers is set,\n")
+		fmt.Fprintf(fs.Output(), "the worker count at that number, and report its speedup and parallel efficiency.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	out = newOutput(*noColor)
+	return scaleFile(*cpuList, getDataFile(fs.Args()))
+}
+
+// scaleFile runs the strategies on dataFile at each CPU count of list, for
+// scaling and -cpus, and returns the exit status.
+func scaleFile(list, dataFile string) int {
+	if datasetFiles != nil || isStream(dataFile) {
+		out.Errorf("Error: scaling reads the file once per CPU count, so it needs a single regular file")
+		return 1
+	}
+	cpus, err := parseCPUCounts(list)
+	if err == nil {
+		err = checkStrategyFlags()
+	}
+	if err == nil {
+		err = setRecordFormat()
+	}
+	if err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	inputSuite(dataFile)
+	keys, err := selectedStrategies()
+	if err == nil {
+		err = checkSuite(keys, dataFile)
+	}
+	if err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	if maxMemory > 0 {
+		debug.SetMemoryLimit(int64(maxMemory))
+	}
+
+	runCPUSweep(keys, strategyOptions(), dataFile, cpus)
+	return 0
+}
+
+// parseCPUCounts parses -cpus into ascending counts, dropping those above
+// the machine's CPUs, which would only time-slice the same cores.
+func parseCPUCounts(list string) ([]int, error) {
+	var counts []int
+	for _, field := range strings.Split(list, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("-cpus takes positive CPU counts, got %q", field)
+		}
+		if n > runtime.NumCPU() {
+			out.Warnf("Skipping -cpus %d: this machine has %d CPUs", n, runtime.NumCPU())
+			continue
+		}
+		counts = append(counts, n)
+	}
+	if len(counts) == 0 {
+		return nil, fmt.Errorf("-cpus leaves no count at or below this machine's %d CPUs", runtime.NumCPU())
+	}
+	slices.Sort(counts)
+	return slices.Compact(counts), nil
+}
+
+// runCPUSweep runs every strategy once per CPU count, with GOMAXPROCS and,
+// unless -workers is set, the worker count at that number, then prints how
+// each scaled. It is the runner's take on BenchmarkAllStrategiesWithCPUs.
+func runCPUSweep(keys []string, opts strategies.StrategyOptions, dataFile string, cpus []int) {
+	out.Headerf("=== CPU Scaling Sweep: %s CPUs ===", joinInts(cpus))
+	out.Println()
+
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+	sweeps := make([][]BenchmarkResult, 0, len(keys))
+	for _, key := range keys {
+		entry, ok := lookupStrategy(key)
+		if !ok {
+			continue
+		}
+		results := make([]BenchmarkResult, 0, len(cpus))
+		for _, n := range cpus {
+			runtime.GOMAXPROCS(n)
+			o := opts
+			if workers == 0 {
+				o.Workers = n
+				if opts.Workers > 0 { // as -max-memory capped them
+					o.Workers = min(n, opts.Workers)
+				}
+			}
+
+			out.Warnf("⏱️  Running: %s @ %d CPUs", entry.name(), n)
+			result := benchmarkStrategy(entry.name(), entry.strategy(o), dataFile)
+			if result.Success {
+				out.Successf("✓ Completed in: %v", result.ExecutionTime)
+			} else {
+				out.Errorf("✗ Failed: %v", result.Error)
+			}
+			results = append(results, result)
+		}
+		sweeps = append(sweeps, results)
+	}
+	out.Println()
+
+	for _, results := range sweeps {
+		printScaling(results, cpus)
+	}
+}
+
+// printScaling prints one strategy's times across the CPU counts, with its
+// speedup over the fewest CPUs it completed on and the parallel efficiency:
+// that speedup as a percentage of the increase in CPUs.
+func printScaling(results []BenchmarkResult, cpus []int) {
+	out.Headerf("%s:", results[0].StrategyName)
+
+	base := -1
+	for i := range results {
+		if results[i].Success {
+			base = i
+			break
+		}
+	}
+
+	w := tabwriter.NewWriter(out.Writer(), 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, out.Paint("CPUS\tTIME\tSPEEDUP\tEFFICIENCY\tSTATUS", ColorBold, ColorCyan))
+	fmt.Fprintf(w, "────\t────────────\t───────\t──────────\t────────\n")
+	for i, result := range results {
+		if !result.Success {
+			fmt.Fprintln(w, out.Paint(fmt.Sprintf("%d\t-\t-\t-\t✗ FAILED", cpus[i]), ColorRed))
+			continue
+		}
+		speedup := float64(results[base].ExecutionTime) / float64(result.ExecutionTime)
+		efficiency := speedup / (float64(cpus[i]) / float64(cpus[base])) * 100
+		rowColor := ""
+		if efficiency < 50 {
+			rowColor = ColorYellow
+		}
+		row := fmt.Sprintf("%d\t%s\t%.2fx\t%.0f%%\t✓",
+			cpus[i], formatDuration(result.ExecutionTime), speedup, efficiency)
+		fmt.Fprintln(w, out.Paint(row, rowColor))
+	}
+	w.Flush()
+	out.Println()
+}
+
+// joinInts formats counts as a comma-separated list.
+func joinInts(counts []int) string {
+	s := make([]string, len(counts))
+	for i, n := range counts {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
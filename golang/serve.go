@@ -0,0 +1,253 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxTop bounds the n of /top.
+const maxTop = 10_000
+
+// runServeCommand implements "serve [flags] [file...]": it aggregates the
+// file once and answers queries about the result over HTTP until it is
+// interrupted, and returns the exit status.
+func runServeCommand(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:8080", "address to serve the results API on; use :8080 to serve other machines")
+	key := fs.String("strategy", "", "strategy computing the results (default: swiss, or the one the input needs)")
+	noColor := fs.Bool("no-color", false, "disable ANSI colors (also honors NO_COLOR and non-TTY output)")
+	fs.IntVar(&workers, "workers", 0, "goroutines used by parallel strategies (0 = runtime.NumCPU())")
+	recordFlags(fs)
+	fs.BoolVar(distribution, "distribution", false, "also serve each station's standard deviation and median, p90 and p99")
+	fs.StringVar(unit, "unit", "celsius", "scale every served temperature is in: celsius, fahrenheit or kelvin")
+	fs.BoolVar(estimate, "estimate-stations", false, "first estimate the distinct stations with a HyperLogLog pass, size the tables and maps for them and, without -strategy, pick a dense-array or map strategy")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s serve [flags] [file...]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Aggregate the measurements once and serve the result as JSON:\n")
+		fmt.Fprintf(fs.Output(), "  GET /stations             every station, sorted by name\n")
+		fmt.Fprintf(fs.Output(), "  GET /stations/{name}      one station\n")
+		fmt.Fprintf(fs.Output(), "  GET /top?n=10&by=max      the n most extreme stations by max, min, mean or count\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	out = newOutput(*noColor)
+
+	out.Headerf("=== One Billion Row Challenge - Results API ===")
+	out.Println()
+
+	if err := setRecordFormat(); err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	if err := setOutputUnit(); err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	dataFile := getDataset(fs.Args())
+	if *estimate {
+		files := dataFiles(dataFile)
+		if len(files) > 1 || isStream(dataFile) || slices.ContainsFunc(files, isCompressed) || slices.ContainsFunc(files, isBinary) {
+			out.Errorf("Error: -estimate-stations needs a single text file or URL")
+			return 1
+		}
+		stationEstimate = estimateStations(dataFile, strategyOptions())
+	}
+	if *key == "" {
+		*key = serveStrategy(dataFile)
+	}
+	entry, ok := lookupStrategy(*key)
+	if !ok {
+		out.Errorf("Error: unknown strategy %q (available: %s)", *key, strategyKeys())
+		return 1
+	}
+	if *distribution && !entry.HostsAggregator {
+		out.Errorf("Error: %s cannot compute distributions; pick one of %s", *key, strings.Join(distributionKeys(strategies.Registered()), ", "))
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	start := time.Now()
+	results, err := entry.strategy(strategyOptions()).Calculate(ctx, dataFile)
+	if err != nil {
+		out.Errorf("Error aggregating with %s: %v", entry.name(), err)
+		return 1
+	}
+	api := newResultsAPI(results, decimals)
+	out.Printf("%s %d stations with %s in %s\n", out.Paint("Aggregated:", ColorBlue),
+		len(results), entry.name(), formatDuration(time.Since(start)))
+
+	lis, err := net.Listen("tcp", *listen)
+	if err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	out.Printf("%s http://%s/stations\n\n", out.Paint("Serving:", ColorBlue), lis.Addr())
+
+	server := &http.Server{Handler: api.handler(), ReadHeaderTimeout: 10 * time.Second}
+	context.AfterFunc(ctx, func() {
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	})
+	if err := server.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	out.Successf("✓ Stopped")
+	return 0
+}
+
+// serveStrategy picks the strategy for serve when -strategy is not given:
+// the one the input format or kind requires, or else swiss, or
+// double-buffer under -distribution.
+func serveStrategy(dataFile string) string {
+	files := dataFiles(dataFile)
+	switch {
+	case slices.ContainsFunc(files, isCompressed):
+		return "zstd"
+	case slices.ContainsFunc(files, isBinary):
+		return "binary"
+	case isStream(dataFile):
+		return "pipeline"
+	case *distribution:
+		return "double-buffer"
+	case stationEstimate > 0:
+		return strategies.SuggestStrategy(stationEstimate)
+	}
+	return "swiss"
+}
+
+// stationJSON is a station as the results API returns it. Temperatures
+// are JSON numbers with exactly the input's fraction digits; the spread,
+// only served under -distribution, is an estimate with one digit more.
+type stationJSON struct {
+	Name  string      `json:"name"`
+	Min   json.Number `json:"min"`
+	Mean  json.Number `json:"mean"`
+	Max   json.Number `json:"max"`
+	Count int64       `json:"count"`
+
+	StdDev json.Number `json:"stddev,omitempty"`
+	P50    json.Number `json:"p50,omitempty"`
+	P90    json.Number `json:"p90,omitempty"`
+	P99    json.Number `json:"p99,omitempty"`
+}
+
+// resultsAPI answers queries about one set of results, sorted by name.
+type resultsAPI struct {
+	stations []strategies.StationResult
+	digits   int
+}
+
+func newResultsAPI(results []strategies.StationResult, digits int) *resultsAPI {
+	stations := slices.Clone(results)
+	slices.SortFunc(stations, func(a, b strategies.StationResult) int {
+		return strings.Compare(a.StationID, b.StationID)
+	})
+	return &resultsAPI{stations, digits}
+}
+
+func (a *resultsAPI) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /stations", a.listStations)
+	mux.HandleFunc("GET /stations/{name}", a.getStation)
+	mux.HandleFunc("GET /top", a.top)
+	return mux
+}
+
+func (a *resultsAPI) listStations(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, stationViews(a.stations, a.digits))
+}
+
+func (a *resultsAPI) getStation(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	i, found := slices.BinarySearchFunc(a.stations, name, func(st strategies.StationResult, name string) int {
+		return strings.Compare(st.StationID, name)
+	})
+	if !found {
+		writeError(w, http.StatusNotFound, "no station %q", name)
+		return
+	}
+	writeJSON(w, http.StatusOK, stationViews(a.stations[i:i+1], a.digits)[0])
+}
+
+// top returns the n stations with the highest max, mean or count, or the
+// lowest min.
+func (a *resultsAPI) top(w http.ResponseWriter, r *http.Request) {
+	n := 10
+	if s := r.URL.Query().Get("n"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 1 || v > maxTop {
+			writeError(w, http.StatusBadRequest, "n must be a number from 1 to %d, got %q", maxTop, s)
+			return
+		}
+		n = v
+	}
+	var order func(a, b strategies.StationResult) int
+	switch by := cmp.Or(r.URL.Query().Get("by"), "max"); by {
+	case "max":
+		order = func(a, b strategies.StationResult) int { return cmp.Compare(b.Maximum, a.Maximum) }
+	case "min":
+		order = func(a, b strategies.StationResult) int { return cmp.Compare(a.Minimum, b.Minimum) }
+	case "mean":
+		order = func(a, b strategies.StationResult) int { return cmp.Compare(b.Average, a.Average) }
+	case "count":
+		order = func(a, b strategies.StationResult) int { return cmp.Compare(b.Count, a.Count) }
+	default:
+		writeError(w, http.StatusBadRequest, "by must be max, min, mean or count, got %q", by)
+		return
+	}
+
+	// Ties keep name order.
+	stations := slices.Clone(a.stations)
+	slices.SortStableFunc(stations, order)
+	writeJSON(w, http.StatusOK, stationViews(stations[:min(n, len(stations))], a.digits))
+}
+
+// stationViews renders stations as the results API and -export json
+// return them, with digits fraction digits.
+func stationViews(stations []strategies.StationResult, digits int) []stationJSON {
+	views := make([]stationJSON, len(stations))
+	for i, st := range stations {
+		views[i] = stationJSON{
+			Name:  st.StationID,
+			Min:   json.Number(formatTemperature(float64(st.Minimum), digits)),
+			Mean:  json.Number(formatTemperature(st.Average, digits)),
+			Max:   json.Number(formatTemperature(float64(st.Maximum), digits)),
+			Count: st.Count,
+		}
+		if d := distributionOf(st); d != nil {
+			views[i].StdDev = json.Number(formatDeviation(d.StdDev(), digits))
+			views[i].P50 = json.Number(formatEstimate(d.Median(), digits))
+			views[i].P90 = json.Number(formatEstimate(d.Quantile(0.9), digits))
+			views[i].P99 = json.Number(formatEstimate(d.Quantile(0.99), digits))
+		}
+	}
+	return views
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, map[string]string{"error": fmt.Sprintf(format, args...)})
+}
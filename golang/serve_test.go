@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestResultsAPI(t *testing.T) {
+	stations := testStations(false)
+	slices.Reverse(stations) // the API sorts them itself
+	api := newResultsAPI(stations, 1).handler()
+
+	for _, tc := range []struct {
+		path   string
+		status int
+		want   string
+	}{
+		{"/stations", http.StatusOK, `[{"name":"Abha","min":-2.3,"mean":18.6,"max":40.2,"count":3},` +
+			`{"name":"Berlin","min":-10.3,"mean":2.1,"max":9.9,"count":4},` +
+			`{"name":"Bern","min":0.0,"mean":0.0,"max":0.0,"count":1},` +
+			`{"name":"São Paulo","min":21.4,"mean":23.5,"max":25.6,"count":2}]`},
+		{"/stations/S%C3%A3o%20Paulo", http.StatusOK, `{"name":"São Paulo","min":21.4,"mean":23.5,"max":25.6,"count":2}`},
+		{"/stations/Paris", http.StatusNotFound, `{"error":"no station \"Paris\""}`},
+		{"/top?n=2", http.StatusOK, `[{"name":"Abha","min":-2.3,"mean":18.6,"max":40.2,"count":3},` +
+			`{"name":"São Paulo","min":21.4,"mean":23.5,"max":25.6,"count":2}]`},
+		{"/top?n=1&by=min", http.StatusOK, `[{"name":"Berlin","min":-10.3,"mean":2.1,"max":9.9,"count":4}]`},
+		{"/top?n=2&by=count", http.StatusOK, `[{"name":"Berlin","min":-10.3,"mean":2.1,"max":9.9,"count":4},` +
+			`{"name":"Abha","min":-2.3,"mean":18.6,"max":40.2,"count":3}]`},
+		{"/top?n=100&by=mean", http.StatusOK, `[{"name":"São Paulo","min":21.4,"mean":23.5,"max":25.6,"count":2},` +
+			`{"name":"Abha","min":-2.3,"mean":18.6,"max":40.2,"count":3},` +
+			`{"name":"Berlin","min":-10.3,"mean":2.1,"max":9.9,"count":4},` +
+			`{"name":"Bern","min":0.0,"mean":0.0,"max":0.0,"count":1}]`},
+		{"/top?n=0", http.StatusBadRequest, `{"error":"n must be a number from 1 to 10000, got \"0\""}`},
+		{"/top?by=stddev", http.StatusBadRequest, `{"error":"by must be max, min, mean or count, got \"stddev\""}`},
+	} {
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Code != tc.status || rec.Body.String() != tc.want+"\n" {
+			t.Errorf("GET %s: %d %s, want %d %s", tc.path, rec.Code, rec.Body, tc.status, tc.want)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("GET %s: Content-Type %q", tc.path, ct)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/stations", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /stations: %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestResultsAPIServesDistribution(t *testing.T) {
+	api := newResultsAPI(testStations(true), 1).handler()
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stations/Berlin", nil))
+
+	var got stationJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	d := distributionOf(testStations(true)[1])
+	want := stationViews(testStations(true)[1:2], 1)[0]
+	if got != want || got.StdDev == "" || got.P99 != json.Number(formatEstimate(d.Quantile(0.99), 1)) {
+		t.Errorf("served %+v, want %+v", got, want)
+	}
+}
+
+func TestServeStrategy(t *testing.T) {
+	saved := *distribution
+	t.Cleanup(func() { *distribution = saved })
+
+	text := writeTestFile(t, "measurements.txt", "Bern;1.0\n")
+	for _, tc := range []struct {
+		path         string
+		distribution bool
+		want         string
+	}{
+		{text, false, "swiss"},
+		{text, true, "double-buffer"},
+		{"measurements.txt.zst", true, "zstd"},
+		{"measurements.bin", false, "binary"},
+	} {
+		*distribution = tc.distribution
+		if got := serveStrategy(tc.path); got != tc.want {
+			t.Errorf("serveStrategy(%s) with -distribution=%v = %s, want %s", tc.path, tc.distribution, got, tc.want)
+		}
+	}
+}
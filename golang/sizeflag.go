@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// byteSize is a flag.Value that accepts plain byte counts ("65536") as well
+// as binary-suffixed sizes ("64KiB", "1MiB", "4k").
+type byteSize int
+
+var sizeUnits = []struct {
+	suffix string
+	factor int
+}{
+	{"gib", 1 << 30}, {"mib", 1 << 20}, {"kib", 1 << 10},
+	{"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10},
+	{"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10},
+	{"b", 1},
+}
+
+func parseByteSize(s string) (int, error) {
+	lower := strings.ToLower(strings.TrimSpace(s))
+	factor := 1
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(lower, u.suffix) {
+			lower = strings.TrimSpace(strings.TrimSuffix(lower, u.suffix))
+			factor = u.factor
+			break
+		}
+	}
+
+	n, err := strconv.Atoi(lower)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * factor, nil
+}
+
+func (b *byteSize) String() string {
+	return formatByteSize(int(*b))
+}
+
+func (b *byteSize) Set(s string) error {
+	n, err := parseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*b = byteSize(n)
+	return nil
+}
+
+// formatByteSize renders n using the largest binary unit that divides it
+// evenly, e.g. 65536 → "64KiB".
+func formatByteSize(n int) string {
+	switch {
+	case n == 0:
+		return "0"
+	case n%(1<<20) == 0:
+		return fmt.Sprintf("%dMiB", n>>20)
+	case n%(1<<10) == 0:
+		return fmt.Sprintf("%dKiB", n>>10)
+	default:
+		return strconv.Itoa(n)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits of the One Billion Row Challenge input format.
+const (
+	specMaxNameBytes = 100
+	specMaxStations  = 10_000
+)
+
+// specMaxLineBytes bounds the lines the checker buffers. Valid lines are at
+// most a few over specMaxNameBytes, so anything this long is reported and
+// skipped rather than read into memory.
+const specMaxLineBytes = 1 << 20
+
+// specViolation is one way a line breaks the spec.
+type specViolation struct {
+	line int64 // 1-based line number
+	msg  string
+}
+
+// specReport summarises a spec check of a whole file.
+type specReport struct {
+	lines      int64
+	stations   int // distinct station names seen, up to specMaxStations+1
+	violations int64
+	first      []specViolation // the first violations, in file order
+}
+
+// runValidateCommand implements "validate [flags] [file]": it checks a
+// measurements file against the 1BRC limits and returns the exit status,
+// 1 when any line breaks them.
+func runValidateCommand(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	maxReported := fs.Int("max-reports", 20, "violations listed with their line numbers; the rest are only counted")
+	noColor := fs.Bool("no-color", false, "disable ANSI colors (also honors NO_COLOR and non-TTY output)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s validate [flags] [file]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Check a measurements file against the 1BRC limits: station names of 1-%d bytes of UTF-8,\n", specMaxNameBytes)
+		fmt.Fprintf(fs.Output(), "temperatures in -99.9..99.9 with exactly one decimal, at most %d distinct stations.\n\n", specMaxStations)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	out = newOutput(*noColor)
+
+	out.Headerf("=== One Billion Row Challenge - Input Validation ===")
+	out.Println()
+
+	dataFile := getDataFile(fs.Args())
+	f, err := openInput(dataFile)
+	if err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	defer f.Close()
+
+	start := time.Now()
+	report, err := checkSpec(f, *maxReported)
+	if err != nil {
+		out.Errorf("Error reading %s: %v", dataFile, err)
+		return 1
+	}
+
+	for _, v := range report.first {
+		out.Errorf("line %d: %s", v.line, v.msg)
+	}
+	if unlisted := report.violations - int64(len(report.first)); unlisted > 0 {
+		out.Warnf("... and %d more", unlisted)
+	}
+	if len(report.first) > 0 {
+		out.Println()
+	}
+
+	stations := fmt.Sprint(report.stations)
+	if report.stations > specMaxStations {
+		stations = fmt.Sprintf("more than %d", specMaxStations)
+	}
+	out.Printf("%s %d\n", out.Paint("Lines:", ColorBlue), report.lines)
+	out.Printf("%s %s\n", out.Paint("Distinct stations:", ColorBlue), stations)
+	out.Printf("%s %s\n\n", out.Paint("Checked in:", ColorBlue), formatDuration(time.Since(start)))
+
+	if report.violations > 0 {
+		out.Errorf("✗ Violations: %d", report.violations)
+		return 1
+	}
+	out.Successf("✓ File is within the 1BRC limits")
+	return 0
+}
+
+// checkSpec reads measurements from r and reports every line that breaks
+// the 1BRC limits, listing at most maxReported of them. A trailing '\r' and
+// a leading UTF-8 BOM are ignored, as the strategies ignore them.
+func checkSpec(r io.Reader, maxReported int) (specReport, error) {
+	var report specReport
+	seen := make(map[string]struct{}, specMaxStations+1)
+	violate := func(format string, args ...any) {
+		report.violations++
+		if len(report.first) < maxReported {
+			report.first = append(report.first, specViolation{report.lines, fmt.Sprintf(format, args...)})
+		}
+	}
+
+	br := bufio.NewReaderSize(r, specMaxLineBytes)
+	for {
+		line, err := br.ReadSlice('\n')
+		if len(line) == 0 && err == io.EOF {
+			return report, nil
+		}
+		report.lines++
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			violate("line is longer than %d bytes", specMaxLineBytes)
+			for errors.Is(err, bufio.ErrBufferFull) {
+				_, err = br.ReadSlice('\n')
+			}
+			if err == io.EOF {
+				return report, nil
+			}
+			if err != nil {
+				return report, err
+			}
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return report, err
+		}
+
+		line = bytes.TrimSuffix(line, []byte{'\n'})
+		line = bytes.TrimSuffix(line, []byte{'\r'})
+		if report.lines == 1 {
+			line = bytes.TrimPrefix(line, []byte("\xef\xbb\xbf"))
+		}
+
+		name, temp, ok := bytes.Cut(line, []byte{';'})
+		switch {
+		case len(line) == 0:
+			violate("empty line")
+		case !ok:
+			violate("missing ';' between station name and temperature in %q", line)
+		default:
+			if msg := checkSpecName(name); msg != "" {
+				violate("%s", msg)
+			}
+			if msg := checkSpecTemp(temp); msg != "" {
+				violate("%s", msg)
+			}
+			if _, dup := seen[string(name)]; !dup && len(seen) <= specMaxStations {
+				seen[string(name)] = struct{}{}
+				if len(seen) == specMaxStations+1 {
+					violate("station %q is distinct station number %d, more than %d", name, len(seen), specMaxStations)
+				}
+			}
+		}
+		report.stations = len(seen)
+
+		if err == io.EOF {
+			return report, nil
+		}
+	}
+}
+
+// checkSpecName describes how a station name breaks the spec, or returns ""
+// for a valid one.
+func checkSpecName(name []byte) string {
+	switch {
+	case len(name) == 0:
+		return "empty station name"
+	case len(name) > specMaxNameBytes:
+		return fmt.Sprintf("station name %q is %d bytes, more than %d", name, len(name), specMaxNameBytes)
+	case !utf8.Valid(name):
+		return fmt.Sprintf("station name %q is not valid UTF-8", name)
+	}
+	return ""
+}
+
+// checkSpecTemp describes how a temperature breaks the spec, or returns ""
+// for one in -99.9..99.9 with exactly one decimal.
+func checkSpecTemp(temp []byte) string {
+	digits := bytes.TrimPrefix(temp, []byte{'-'})
+	whole, frac, ok := bytes.Cut(digits, []byte{'.'})
+	switch {
+	case !ok || len(whole) == 0 || len(frac) == 0 || !allDigits(whole) || !allDigits(frac):
+		return fmt.Sprintf("temperature %q is not a decimal number", temp)
+	case len(frac) != 1:
+		return fmt.Sprintf("temperature %q has %d decimals, want 1", temp, len(frac))
+	case len(whole) > 2:
+		return fmt.Sprintf("temperature %q is outside -99.9..99.9", temp)
+	}
+	return ""
+}
+
+func allDigits(b []byte) bool {
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// runSplitCommand implements "split [flags] [file]": it cuts a text file
+// into newline-aligned shards that the shards strategy reads one per
+// worker, and returns the exit status.
+func runSplitCommand(args []string) int {
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	n := fs.Int("n", runtime.NumCPU(), "number of shards to write")
+	dir := fs.String("o", "", "directory to write the shards to (default: the input's directory)")
+	noColor := fs.Bool("no-color", false, "disable ANSI colors (also honors NO_COLOR and non-TTY output)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s split [flags] [file]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Cut a measurements file into shards of about equal size, each ending at a newline,\n")
+		fmt.Fprintf(fs.Output(), "named <file>-part-NNN<ext>. The shards strategy reads them one per worker.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	out = newOutput(*noColor)
+
+	out.Headerf("=== One Billion Row Challenge - Split into Shards ===")
+	out.Println()
+
+	dataFile := getDataFile(fs.Args())
+	if isURL(dataFile) || isCompressed(dataFile) || isBinary(dataFile) {
+		out.Errorf("Error: split needs a local uncompressed text file")
+		return 1
+	}
+	if *dir == "" {
+		*dir = filepath.Dir(dataFile)
+	}
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+
+	start := time.Now()
+	shards, err := strategies.SplitFile(dataFile, *dir, *n)
+	if err != nil {
+		out.Errorf("Error splitting %s: %v", dataFile, err)
+		return 1
+	}
+
+	out.Printf("%s %d in %s\n", out.Paint("Wrote shards:", ColorBlue), len(shards), *dir)
+	out.Printf("%s %s\n\n", out.Paint("Split in:", ColorBlue), formatDuration(time.Since(start)))
+	ext := filepath.Ext(dataFile)
+	pattern := filepath.Join(*dir, strings.TrimSuffix(filepath.Base(dataFile), ext)+"-part-*"+ext)
+	out.Successf("✓ Benchmark them with: %s -strategies shards '%s'", os.Args[0], pattern)
+	return 0
+}
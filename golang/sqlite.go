@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+)
+
+const (
+	// sqlitePageSize is the page size of the databases writeSQLite writes.
+	sqlitePageSize = 4096
+
+	// sqliteHeaderSize is the database header at the start of page 1,
+	// ahead of that page's b-tree.
+	sqliteHeaderSize = 100
+
+	// sqliteMaxLocal and sqliteMinLocal bound the payload a table leaf
+	// cell keeps on its page; the rest goes to overflow pages.
+	sqliteMaxLocal = sqlitePageSize - 35
+	sqliteMinLocal = (sqlitePageSize-12)*32/255 - 23
+
+	// sqliteVersion is the SQLITE_VERSION_NUMBER the header names as the
+	// last writer, the release whose file format this follows.
+	sqliteVersion = 3_045_000
+)
+
+// sqliteTable is a table for writeSQLite: its CREATE TABLE statement and
+// its rows, whose values are nil, int64, float64 or string.
+type sqliteTable struct {
+	name string
+	sql  string
+	rows [][]any
+}
+
+// writeSQLite writes tables as a new SQLite database at path, replacing
+// any file there. It writes the file format directly, page by page, so it
+// needs neither cgo nor a driver: every table is a b-tree of rows keyed by
+// rowid, built bottom up, and page 1 holds the schema naming their roots.
+// Tables may not declare indexes or constraints that would need one.
+func writeSQLite(path string, tables []sqliteTable) error {
+	db := &sqliteBuilder{pages: [][]byte{nil}} // page 1 is written last
+	schema := make([][]any, len(tables))
+	for i, t := range tables {
+		root, err := db.table(t.rows)
+		if err != nil {
+			return fmt.Errorf("table %s: %w", t.name, err)
+		}
+		schema[i] = []any{"table", t.name, t.name, int64(root), t.sql}
+	}
+
+	// The schema has to fit on page 1 itself.
+	db.pages[0] = make([]byte, sqlitePageSize)
+	cells := make([][]byte, len(schema))
+	for i, row := range schema {
+		cell, err := db.leafCell(int64(i+1), sqliteRecord(row))
+		if err != nil {
+			return err
+		}
+		cells[i] = cell
+	}
+	if !fitsPage(sqliteHeaderSize+8, cells) {
+		return errors.New("schema does not fit on the first page")
+	}
+	writeBTreePage(db.pages[0], sqliteHeaderSize, 0x0d, cells, 0)
+	writeSQLiteHeader(db.pages[0], len(db.pages))
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	for _, page := range db.pages {
+		if _, err = f.Write(page); err != nil {
+			break
+		}
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// sqliteBuilder collects the pages of a database being written; page n is
+// pages[n-1].
+type sqliteBuilder struct {
+	pages [][]byte
+}
+
+// alloc appends an empty page and returns it with its number.
+func (db *sqliteBuilder) alloc() ([]byte, uint32) {
+	page := make([]byte, sqlitePageSize)
+	db.pages = append(db.pages, page)
+	return page, uint32(len(db.pages))
+}
+
+// table writes rows, with rowids from 1, as a table b-tree and returns its
+// root page: leaves packed in rowid order, then levels of interior pages
+// over them until one page remains.
+func (db *sqliteBuilder) table(rows [][]any) (uint32, error) {
+	type child struct {
+		page   uint32
+		maxKey int64
+	}
+	var level []child
+	var cells [][]byte
+	flushLeaf := func(maxKey int64) {
+		page, n := db.alloc()
+		writeBTreePage(page, 0, 0x0d, cells, 0)
+		level = append(level, child{n, maxKey})
+		cells = nil
+	}
+	for i, row := range rows {
+		cell, err := db.leafCell(int64(i+1), sqliteRecord(row))
+		if err != nil {
+			return 0, err
+		}
+		if !fitsPage(8, append(cells, cell)) {
+			flushLeaf(int64(i))
+		}
+		cells = append(cells, cell)
+	}
+	if cells != nil || level == nil {
+		flushLeaf(int64(len(rows)))
+	}
+
+	for len(level) > 1 {
+		// Each group of children becomes an interior page: a cell per
+		// child but the last, which is the page's right-most pointer. A
+		// page needs at least one cell, so a lone last child takes its
+		// neighbour.
+		var groups [][]child
+		var cells [][]byte
+		for _, c := range level {
+			cell := interiorCell(c.page, c.maxKey)
+			if groups == nil || !fitsPage(12, append(cells, cell)) {
+				groups = append(groups, nil)
+				cells = nil
+			}
+			groups[len(groups)-1] = append(groups[len(groups)-1], c)
+			cells = append(cells, cell)
+		}
+		if n := len(groups); n > 1 && len(groups[n-1]) == 1 {
+			prev := groups[n-2]
+			groups[n-2], groups[n-1] = prev[:len(prev)-1], append([]child{prev[len(prev)-1]}, groups[n-1]...)
+		}
+
+		var parents []child
+		for _, group := range groups {
+			last := group[len(group)-1]
+			cells := make([][]byte, len(group)-1)
+			for i, c := range group[:len(group)-1] {
+				cells[i] = interiorCell(c.page, c.maxKey)
+			}
+			page, n := db.alloc()
+			writeBTreePage(page, 0, 0x05, cells, last.page)
+			parents = append(parents, child{n, last.maxKey})
+		}
+		level = parents
+	}
+	return level[0].page, nil
+}
+
+// interiorCell returns the table interior cell pointing at the child page
+// whose largest rowid is maxKey.
+func interiorCell(page uint32, maxKey int64) []byte {
+	return appendSQLiteVarint(binary.BigEndian.AppendUint32(nil, page), uint64(maxKey))
+}
+
+// leafCell returns the table leaf cell holding record under rowid,
+// spilling what does not fit on the page to a chain of overflow pages.
+func (db *sqliteBuilder) leafCell(rowid int64, record []byte) ([]byte, error) {
+	if rowid <= 0 {
+		return nil, errors.New("rowid out of range")
+	}
+	cell := appendSQLiteVarint(nil, uint64(len(record)))
+	cell = appendSQLiteVarint(cell, uint64(rowid))
+	if len(record) <= sqliteMaxLocal {
+		return append(cell, record...), nil
+	}
+
+	local := sqliteMinLocal + (len(record)-sqliteMinLocal)%(sqlitePageSize-4)
+	if local > sqliteMaxLocal {
+		local = sqliteMinLocal
+	}
+	cell = append(cell, record[:local]...)
+	rest := record[local:]
+	var link []byte // where the next overflow page's number goes
+	for len(rest) > 0 {
+		page, n := db.alloc()
+		if link == nil {
+			cell = binary.BigEndian.AppendUint32(cell, n)
+		} else {
+			binary.BigEndian.PutUint32(link, n)
+		}
+		link = page[:4]
+		rest = rest[copy(page[4:], rest):]
+	}
+	return cell, nil
+}
+
+// fitsPage reports whether cells fit on one page after a b-tree header of
+// headerEnd bytes, counting a two-byte pointer per cell.
+func fitsPage(headerEnd int, cells [][]byte) bool {
+	size := headerEnd
+	for _, c := range cells {
+		size += 2 + len(c)
+	}
+	return size <= sqlitePageSize
+}
+
+// writeBTreePage lays out a b-tree page of the given kind, 0x0d for a
+// table leaf and 0x05 for a table interior page whose right-most child is
+// right, with its header at offset: the cell pointers follow the header
+// and the cells fill the page from its end.
+func writeBTreePage(page []byte, offset int, kind byte, cells [][]byte, right uint32) {
+	header := page[offset:]
+	header[0] = kind
+	binary.BigEndian.PutUint16(header[3:], uint16(len(cells)))
+	pointers := 8
+	if kind == 0x05 {
+		binary.BigEndian.PutUint32(header[8:], right)
+		pointers = 12
+	}
+	end := len(page)
+	for i, c := range cells {
+		end -= len(c)
+		copy(page[end:], c)
+		binary.BigEndian.PutUint16(header[pointers+2*i:], uint16(end))
+	}
+	binary.BigEndian.PutUint16(header[5:], uint16(end))
+}
+
+// writeSQLiteHeader fills in the database header of a database of pages
+// pages: UTF-8, schema format 4, no freelist.
+func writeSQLiteHeader(page []byte, pages int) {
+	copy(page, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(page[16:], sqlitePageSize)
+	page[18], page[19] = 1, 1                 // legacy rollback journal
+	page[21], page[22], page[23] = 64, 32, 32 // payload fractions, fixed by the format
+	binary.BigEndian.PutUint32(page[24:], 1)  // file change counter
+	binary.BigEndian.PutUint32(page[28:], uint32(pages))
+	binary.BigEndian.PutUint32(page[40:], 1) // schema cookie
+	binary.BigEndian.PutUint32(page[44:], 4) // schema format
+	binary.BigEndian.PutUint32(page[56:], 1) // UTF-8
+	binary.BigEndian.PutUint32(page[92:], 1) // the page count above is current
+	binary.BigEndian.PutUint32(page[96:], sqliteVersion)
+}
+
+// sqliteRecord encodes values in the record format: a header of serial
+// types, one per value, then the values themselves.
+func sqliteRecord(values []any) []byte {
+	var types, body []byte
+	for _, v := range values {
+		switch v := v.(type) {
+		case nil:
+			types = appendSQLiteVarint(types, 0)
+		case int64:
+			types = appendSQLiteVarint(types, 6)
+			body = binary.BigEndian.AppendUint64(body, uint64(v))
+		case float64:
+			types = appendSQLiteVarint(types, 7)
+			body = binary.BigEndian.AppendUint64(body, math.Float64bits(v))
+		case string:
+			types = appendSQLiteVarint(types, uint64(13+2*len(v)))
+			body = append(body, v...)
+		default:
+			panic(fmt.Sprintf("sqlite: unsupported value %T", v))
+		}
+	}
+	// The header's size counts its own varint, which may lengthen it.
+	n := 1
+	for len(appendSQLiteVarint(nil, uint64(len(types)+n))) > n {
+		n++
+	}
+	record := appendSQLiteVarint(nil, uint64(len(types)+n))
+	record = append(record, types...)
+	return append(record, body...)
+}
+
+// appendSQLiteVarint appends v as SQLite's big-endian varint: seven bits
+// per byte, the high bit set on all but the last, and all eight bits of a
+// ninth byte.
+func appendSQLiteVarint(b []byte, v uint64) []byte {
+	if v > 1<<56-1 {
+		b = append(b, byte(v>>57|0x80), byte(v>>50|0x80), byte(v>>43|0x80), byte(v>>36|0x80),
+			byte(v>>29|0x80), byte(v>>22|0x80), byte(v>>15|0x80), byte(v>>8|0x80))
+		return append(b, byte(v))
+	}
+	var buf [8]byte
+	i := len(buf) - 1
+	buf[i] = byte(v & 0x7f)
+	for v >>= 7; v > 0; v >>= 7 {
+		i--
+		buf[i] = byte(v&0x7f | 0x80)
+	}
+	return append(b, buf[i:]...)
+}
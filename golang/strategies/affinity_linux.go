@@ -0,0 +1,79 @@
+//go:build linux
+
+package strategies
+
+import (
+	"math/bits"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// cpuMask is a sched_setaffinity bitmask covering up to 1024 CPUs.
+type cpuMask [16]uint64
+
+// pinWorker locks the calling goroutine to its OS thread and restricts that
+// thread to a single core chosen from worker among the cores it may run on,
+// which taskset or a cgroup may have narrowed, so the hot loop is never
+// migrated away from its warm caches. It returns a function that restores
+// the thread's original affinity and unlocks it; callers defer it. Without
+// PinWorkers, or if the affinity calls fail, it does nothing.
+func (o StrategyOptions) pinWorker(worker int) func() {
+	if !o.PinWorkers {
+		return func() {}
+	}
+
+	runtime.LockOSThread()
+	var orig cpuMask
+	if err := schedAffinity(syscall.SYS_SCHED_GETAFFINITY, &orig); err != nil {
+		runtime.UnlockOSThread()
+		return func() {}
+	}
+
+	var mask cpuMask
+	cpu := orig.nth(worker % orig.count())
+	mask[cpu/64] |= 1 << (cpu % 64)
+	if err := schedAffinity(syscall.SYS_SCHED_SETAFFINITY, &mask); err != nil {
+		runtime.UnlockOSThread()
+		return func() {}
+	}
+
+	return func() {
+		schedAffinity(syscall.SYS_SCHED_SETAFFINITY, &orig)
+		runtime.UnlockOSThread()
+	}
+}
+
+// count returns the number of CPUs in the mask.
+func (m *cpuMask) count() int {
+	n := 0
+	for _, word := range m {
+		n += bits.OnesCount64(word)
+	}
+	return n
+}
+
+// nth returns the n-th CPU in the mask, counting from 0, which must be
+// less than its count.
+func (m *cpuMask) nth(n int) int {
+	for i, word := range m {
+		if c := bits.OnesCount64(word); n >= c {
+			n -= c
+			continue
+		}
+		for ; n > 0; n-- {
+			word &= word - 1
+		}
+		return i*64 + bits.TrailingZeros64(word)
+	}
+	return -1
+}
+
+// schedAffinity gets or sets the calling thread's CPU mask.
+func schedAffinity(trap uintptr, mask *cpuMask) error {
+	_, _, errno := syscall.RawSyscall(trap, 0, unsafe.Sizeof(*mask), uintptr(unsafe.Pointer(mask)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
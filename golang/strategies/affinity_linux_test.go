@@ -0,0 +1,44 @@
+package strategies
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestCPUMaskNth(t *testing.T) {
+	var m cpuMask
+	for _, cpu := range []int{2, 3, 63, 64, 130} {
+		m[cpu/64] |= 1 << (cpu % 64)
+	}
+	if got := m.count(); got != 5 {
+		t.Fatalf("count() = %d, want 5", got)
+	}
+	for n, want := range []int{2, 3, 63, 64, 130} {
+		if got := m.nth(n); got != want {
+			t.Errorf("nth(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestPinWorkerStaysInTheAllowedCPUs(t *testing.T) {
+	var allowed cpuMask
+	if err := schedAffinity(syscall.SYS_SCHED_GETAFFINITY, &allowed); err != nil {
+		t.Skip(err)
+	}
+	opts := StrategyOptions{PinWorkers: true}
+	for worker := range 2*allowed.count() + 1 {
+		func() {
+			defer opts.pinWorker(worker)()
+			var pinned cpuMask
+			if err := schedAffinity(syscall.SYS_SCHED_GETAFFINITY, &pinned); err != nil {
+				t.Fatal(err)
+			}
+			if pinned.count() != 1 {
+				t.Fatalf("worker %d runs on %d CPUs, want 1", worker, pinned.count())
+			}
+			if cpu := pinned.nth(0); allowed[cpu/64]&(1<<(cpu%64)) == 0 {
+				t.Errorf("worker %d pinned to CPU %d, outside the allowed set", worker, cpu)
+			}
+		}()
+	}
+}
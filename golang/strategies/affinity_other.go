@@ -0,0 +1,8 @@
+//go:build !linux
+
+package strategies
+
+// pinWorker is a no-op where thread affinity is not available.
+func (o StrategyOptions) pinWorker(worker int) func() {
+	return func() {}
+}
@@ -0,0 +1,40 @@
+package strategies
+
+// Aggregator accumulates one statistic of a station's measurements, one
+// value at a time. StationResult is the Aggregator every strategy runs,
+// for the minimum, maximum, sum and count; StrategyOptions.NewAggregator
+// adds another, such as a Distribution, to every station.
+type Aggregator interface {
+	// Add records one measurement, in the units of StationResult.
+	Add(value int64)
+
+	// Merge folds in another worker's aggregate of the same station,
+	// built by the same NewAggregator.
+	Merge(other Aggregator)
+}
+
+// Add records one measurement in r's minimum, maximum, sum and count. It
+// leaves r.Extra to the strategy hosting it, so that it stays small enough to
+// inline into the hot loops.
+func (r *StationResult) Add(value int64) {
+	r.Maximum = max(r.Maximum, value)
+	r.Minimum = min(r.Minimum, value)
+	r.Sum += value
+	r.Count++
+}
+
+// Merge folds other, a *StationResult for the same station, into r,
+// keeping r's name.
+func (r *StationResult) Merge(other Aggregator) {
+	*r = mergeResult(*r, *other.(*StationResult))
+}
+
+// newStation returns an empty StationResult for name, with an Extra from
+// newExtra if that is set.
+func newStation(name string, newExtra func() Aggregator) StationResult {
+	st := newSt(name)
+	if newExtra != nil {
+		st.Extra = newExtra()
+	}
+	return st
+}
@@ -0,0 +1,48 @@
+package strategies
+
+import "testing"
+
+// rangeCounter is an Aggregator counting the values at or above a
+// threshold, standing in for a histogram bucket.
+type rangeCounter struct {
+	from  int64
+	count int64
+}
+
+func (c *rangeCounter) Add(value int64) {
+	if value >= c.from {
+		c.count++
+	}
+}
+
+func (c *rangeCounter) Merge(other Aggregator) {
+	c.count += other.(*rangeCounter).count
+}
+
+func TestAggregatorHostsFeedEveryValue(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 40)
+	newCounter := func() Aggregator { return &rangeCounter{from: 0} }
+
+	for _, s := range strategiesWith(StrategyOptions{Workers: 4, ChunkSize: 4096, NewAggregator: newCounter}) {
+		host := s.HostsAggregator
+		t.Run(s.name, func(t *testing.T) {
+			results, err := s.strategy.Calculate(t.Context(), path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkResults(t, results, want)
+			for _, r := range results {
+				if !host {
+					if r.Extra != nil {
+						t.Fatalf("%s: Extra set by a strategy that does not host an Aggregator", r.StationID)
+					}
+					continue
+				}
+				// Every value in the dataset is at least 0.
+				if c, ok := r.Extra.(*rangeCounter); !ok || c.count != r.Count {
+					t.Fatalf("%s: Extra %+v, want a count of %d", r.StationID, r.Extra, r.Count)
+				}
+			}
+		})
+	}
+}
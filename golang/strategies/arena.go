@@ -0,0 +1,37 @@
+package strategies
+
+import "unsafe"
+
+// arenaBlockSize is the size of each block a nameArena allocates. It holds
+// thousands of typical station names.
+const arenaBlockSize = 64 * 1024
+
+// nameArena is a per-worker, append-only store for station names. Names are
+// copied in once, packed next to each other, and handed out as strings that
+// point straight into the arena, replacing one small heap allocation per
+// station with one allocation per block. Blocks are never reused or
+// written after a name is copied in, so the returned strings stay valid for
+// as long as they are referenced.
+type nameArena struct {
+	block []byte
+}
+
+// intern copies name into the arena and returns it as a string.
+func (a *nameArena) intern(name []byte) string {
+	if len(name) == 0 {
+		return ""
+	}
+	owned := a.own(name)
+	return unsafe.String(&owned[0], len(owned))
+}
+
+// own copies name into the arena and returns the copy, which is never
+// overwritten.
+func (a *nameArena) own(name []byte) []byte {
+	if cap(a.block)-len(a.block) < len(name) {
+		a.block = make([]byte, 0, max(arenaBlockSize, len(name)))
+	}
+	start := len(a.block)
+	a.block = append(a.block, name...)
+	return a.block[start:len(a.block):len(a.block)]
+}
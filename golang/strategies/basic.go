@@ -1,19 +1,67 @@
 package strategies
 
 import (
-	"bufio"
+	"context"
 	"math"
 	"os"
+	"slices"
+	"strings"
 )
 
+// Strategy aggregates the measurements file at filePath into one
+// StationResult per distinct station name, sorted by name byte for byte,
+// so every run and every strategy returns the same slice for a file.
+// Implementations stop early and return ctx.Err() once ctx is cancelled.
+//
+// A strategy value keeps per-run counters (see ProgressTracker and
+// MalformedLineCounter), so it must not run two Calculate calls at once;
+// build one per concurrent caller.
 type Strategy interface {
+	Calculate(ctx context.Context, filePath string) ([]StationResult, error)
+}
+
+// LegacyStrategy is the pre-context Strategy signature.
+type LegacyStrategy interface {
 	Calculate(filePath string) ([]StationResult, error)
 }
 
+// FromLegacy adapts a LegacyStrategy to Strategy. The wrapped strategy cannot
+// be interrupted, so cancellation is only observed before it starts and
+// after it returns.
+func FromLegacy(s LegacyStrategy) Strategy {
+	return legacyStrategy{s}
+}
+
+type legacyStrategy struct {
+	LegacyStrategy
+}
+
+func (l legacyStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	results, err := l.LegacyStrategy.Calculate(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// StationResult aggregates the measurements of one station. Temperatures
+// are fixed point in units of 10^-FractionDigits of the active RecordFormat,
+// so with the default format a Maximum of 123 means 12.3.
 type StationResult struct {
-	StationID                    string
+	StationID                    string // the station name, byte for byte
 	Maximum, Minimum, Sum, Count int64
-	Average                      float64
+	Average                      float64 // Sum / Count, in the same units
+
+	// Extra is the station's StrategyOptions.NewAggregator, when that is
+	// set and the strategy hosts it (see Entry.HostsAggregator), and nil
+	// otherwise.
+	Extra Aggregator
 }
 
 func newSt(name string) StationResult {
@@ -25,101 +73,141 @@ func newSt(name string) StationResult {
 	}
 }
 
-type BasicStrategy struct{}
+// BasicStrategy reads the file line by line on one goroutine and
+// aggregates into a map keyed by station name. It is the reference the
+// other strategies are checked against.
+type BasicStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	opts StrategyOptions
+}
+
+// NewBasicStrategy returns a BasicStrategy configured with opts. Only
+// BufferSize applies, sizing the line splitter's blocks.
+func NewBasicStrategy(opts StrategyOptions) *BasicStrategy {
+	return &BasicStrategy{opts: opts}
+}
 
-func (bs *BasicStrategy) Calculate(filePath string) ([]StationResult, error) {
-	file, _ := os.Open(filePath)
+func (bs *BasicStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	bs.resetProgress()
+	defer bs.reportProgress(bs.opts)()
+	bs.resetMalformed(bs.opts)
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
 	defer file.Close()
+	bs.opts.adviseSequential(file)
 
 	stationMap := make(map[string]StationResult)
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
+	lines := newLineSplitter(countingReader{file, &bs.progress}, bs.opts.bufferSize(defaultChunkBufSize))
+	rows := lineCounter{p: &bs.progress}
+	defer rows.flush()
+	count := 0
+	for lines.next() {
+		if count++; count%cancelCheckInterval == 0 && cancelled(ctx) {
+			return nil, ctx.Err()
+		}
+		rows.add()
+		line := string(lines.bytes())
 
-		scanner.Bytes()
 		name, value, err := parseLineBasic(line)
 		if err != nil {
-			return nil, err
+			if err := bs.reject(lines.offset(), lines.bytes()); err != nil {
+				return nil, locateParseError(filePath, err)
+			}
+			continue
 		}
 
-		if _, exists := stationMap[name]; !exists {
-			stationMap[name] = newSt(name)
+		res, exists := stationMap[name]
+		if !exists {
+			res = newStation(name, bs.opts.NewAggregator)
 		}
-
-		res := stationMap[name]
-		if value > res.Maximum {
-			res.Maximum = value
-		}
-
-		if value < res.Minimum {
-			res.Minimum = value
+		res.Add(value)
+		if res.Extra != nil {
+			res.Extra.Add(value)
 		}
-
-		res.Sum += int64(value)
-		res.Count++
 		stationMap[name] = res
 	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
 
-	return calcAverges(stationMap), nil
+	return emitResults(&bs.resultEmitter, stationMap), nil
 }
 
-func calcAverges[K comparable](stationMap map[K]StationResult) []StationResult {
-	results := make([]StationResult, 0, len(stationMap))
-
-	for _, res := range stationMap {
-		res.Average = float64(res.Sum) / 24.0
-		results = append(results, res)
+// calcAverges fills in each station's average and sorts results by name,
+// the order Strategy promises.
+func calcAverges(results []StationResult) []StationResult {
+	for i := range results {
+		results[i].Average = float64(results[i].Sum) / float64(results[i].Count)
 	}
+	slices.SortFunc(results, func(a, b StationResult) int {
+		return strings.Compare(a.StationID, b.StationID)
+	})
 	return results
 }
 
-type ByteReadingStrategy struct{}
-
-func (brs *ByteReadingStrategy) Calculate(filePath string) ([]StationResult, error) {
-	file, _ := os.Open(filePath)
-	defer file.Close()
+// ByteReadingStrategy is BasicStrategy without the string conversions: it
+// parses byte slices and keys its map by a hash of the station name.
+type ByteReadingStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	opts StrategyOptions
+}
 
-	scanner := bufio.NewScanner(file)
-	stationMap := make(map[uint32]StationResult)
+// NewByteReadingStrategy returns a ByteReadingStrategy configured with opts.
+// Only BufferSize applies, sizing the line splitter's blocks.
+func NewByteReadingStrategy(opts StrategyOptions) *ByteReadingStrategy {
+	return &ByteReadingStrategy{opts: opts}
+}
 
-	for scanner.Scan() {
-		line := scanner.Bytes()
+func (brs *ByteReadingStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	brs.resetProgress()
+	defer brs.reportProgress(brs.opts)()
+	brs.resetMalformed(brs.opts)
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	brs.opts.adviseSequential(file)
+
+	lines := newLineSplitter(countingReader{file, &brs.progress}, brs.opts.bufferSize(defaultChunkBufSize))
+	stationMap := make(StationMap)
+	hash := brs.opts.hash()
+
+	rows := lineCounter{p: &brs.progress}
+	defer rows.flush()
+	count := 0
+	for lines.next() {
+		if count++; count%cancelCheckInterval == 0 && cancelled(ctx) {
+			return nil, ctx.Err()
+		}
+		rows.add()
+		line := lines.bytes()
 
 		nameBytes, value, err := parseLineByte(line)
 		if err != nil {
-			return nil, err
+			if err := brs.reject(lines.offset(), line); err != nil {
+				return nil, locateParseError(filePath, err)
+			}
+			continue
 		}
 
-		hash := brs.hashFnv(nameBytes)
-		name := string(nameBytes)
-
-		if _, exists := stationMap[hash]; !exists {
-			stationMap[hash] = newSt(name)
-		}
-
-		res := stationMap[hash]
-		if value > res.Maximum {
-			res.Maximum = value
+		key, res, exists := stationKey(stationMap, hashKey(hash, nameBytes), nameBytes)
+		if !exists {
+			res = newSt(string(nameBytes))
 		}
-		if value < res.Minimum {
-			res.Minimum = value
-		}
-		res.Sum += int64(value)
-		res.Count++
-		stationMap[hash] = res
+		res.Add(value)
+		stationMap[key] = res
 	}
-
-	return calcAverges(stationMap), nil
-}
-
-func (brs *ByteReadingStrategy) hashFnv(name []byte) uint32 {
-	var hash uint32 = 2166136261
-	const prime32 = 16777619
-
-	for i := range name {
-		hash ^= uint32(name[i])
-		hash *= prime32
+	if err := lines.Err(); err != nil {
+		return nil, err
 	}
-	return hash
+
+	return emitResults(&brs.resultEmitter, stationMap), nil
 }
@@ -1,27 +1,75 @@
 package strategies
 
 import (
-	"bufio"
+	"context"
 	"os"
-	"runtime"
 	"sync"
+	"sync/atomic"
 )
 
-type BatchStrategy struct{}
+const (
+	defaultBatchSize = 100
 
-func (b *BatchStrategy) Calculate(filePath string) ([]StationResult, error) {
+	// maxBatchSize caps adaptive batches at about 100 KiB of Stations,
+	// which still fit in a core's L2 cache; past that, the batches the
+	// workers aggregate come from memory and runs slow down.
+	maxBatchSize = 1 << 12
+
+	// batchSizeWindow is how many sends an adaptive BatchStrategy watches
+	// before deciding whether to grow its batches.
+	batchSizeWindow = 32
+)
+
+// BatchSizeReporter is implemented by strategies that hand lines to their
+// workers in batches, reporting the batch size their last run ended with.
+type BatchSizeReporter interface {
+	BatchSize() int
+}
+
+// BatchStrategy splits lines on one goroutine and hands them in batches to
+// workers that parse and aggregate them. StrategyOptions.BatchSize sets the
+// number of lines per batch; with AdaptiveBatchSize the batches grow while
+// the workers keep waiting on the splitter. Batches own copies of their
+// names and are recycled through a pool, so the splitter reads into a
+// single buffer however many batches are in flight.
+type BatchStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	opts      StrategyOptions
+	batchSize atomic.Int64 // the batch size the last run ended with
+}
+
+// NewBatchStrategy returns a BatchStrategy configured with opts.
+func NewBatchStrategy(opts StrategyOptions) *BatchStrategy {
+	return &BatchStrategy{opts: opts}
+}
+
+func (b *BatchStrategy) BatchSize() int {
+	return int(b.batchSize.Load())
+}
+
+func (b *BatchStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	b.resetProgress()
+	defer b.reportProgress(b.opts)()
+	b.resetMalformed(b.opts)
 	f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
+	b.opts.adviseSequential(f)
+
+	lines := newLineSplitter(countingReader{f, &b.progress}, b.opts.bufferSize(defaultChunkBufSize))
 
-	scanner := bufio.NewScanner(f)
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
+	// Batches own copies of their names, so the splitter reuses its block,
+	// and go back to the pool once a worker has aggregated them.
+	sizer := newBatchSizer(b.opts)
+	defer func() { b.batchSize.Store(int64(sizer.size)) }()
+	batches := sync.Pool{New: func() any { return newStationBatch(sizer.size) }}
 
-	n := runtime.NumCPU()
-	resChan := make(chan []Station, n)
+	n := b.opts.workers()
+	resChan := make(chan *stationBatch, n)
 	finalBatch := make([]map[uint32]StationResult, n)
 
 	var wg sync.WaitGroup
@@ -29,31 +77,122 @@ func (b *BatchStrategy) Calculate(filePath string) ([]StationResult, error) {
 	for i := range n {
 		go func(i int) {
 			defer wg.Done()
+			defer b.opts.pinWorker(i)()
 			temp := make(map[uint32]StationResult, 1000)
-			for r := range resChan {
-				processBatch(r, temp)
+			var arena nameArena
+			for batch := range resChan {
+				processBatch(batch.stations, temp, b.opts.hash(), &arena)
+				batch.reset()
+				batches.Put(batch)
 			}
 			finalBatch[i] = temp
 		}(i)
 	}
 
-	batchSize := 100
-	batch := make([]Station, 0, batchSize)
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		nameBytes, value, err := parseLineByte(line)
+	batch := batches.Get().(*stationBatch)
+	rows := lineCounter{p: &b.progress}
+	defer rows.flush()
+	count := 0
+	var parseErr error
+	for lines.next() {
+		if count++; count%cancelCheckInterval == 0 && cancelled(ctx) {
+			break
+		}
+		rows.add()
+		nameBytes, value, err := parseLineByte(lines.bytes())
 		if err != nil {
-			return nil, err
+			if parseErr = b.reject(lines.offset(), lines.bytes()); parseErr != nil {
+				break
+			}
+			continue
 		}
 
-		batch = append(batch, Station{Station: nameBytes, Value: value})
-		if len(batch) >= batchSize {
+		batch.add(nameBytes, value)
+		if len(batch.stations) >= sizer.size {
+			sizer.sent(len(resChan))
 			resChan <- batch
-			batch = make([]Station, 0, batchSize)
+			batch = batches.Get().(*stationBatch)
 		}
 	}
+	if len(batch.stations) > 0 {
+		resChan <- batch
+	}
 
 	close(resChan)
 	wg.Wait()
-	return calcAverges(mergeMaps(finalBatch)), nil
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if parseErr != nil {
+		return nil, locateParseError(filePath, parseErr)
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+	return emitResults(&b.resultEmitter, finalBatch...), nil
+}
+
+// stationBatch is a batch of parsed lines that owns its names, copied into
+// a slab of its own, so the buffer the lines came from can be reused before
+// the batch is aggregated.
+type stationBatch struct {
+	stations []Station
+	names    []byte
+}
+
+func newStationBatch(size int) *stationBatch {
+	return &stationBatch{stations: make([]Station, 0, size)}
+}
+
+// add appends a line to the batch, copying its name into the slab.
+func (b *stationBatch) add(name []byte, value int64) {
+	start := len(b.names)
+	b.names = append(b.names, name...)
+	b.stations = append(b.stations, Station{Station: b.names[start:len(b.names):len(b.names)], Value: value})
+}
+
+// reset empties the batch, keeping its memory for the next one.
+func (b *stationBatch) reset() {
+	b.stations = b.stations[:0]
+	b.names = b.names[:0]
+}
+
+// batchSizer picks the size of the batches BatchStrategy sends. An adaptive
+// one doubles it while most sends find the channel empty, that is while the
+// workers sit waiting on the splitter and every send has to wake one, and
+// settles on it for good once the workers keep a backlog of batches.
+type batchSizer struct {
+	size     int
+	adaptive bool
+
+	sends, starved int // in the current window
+}
+
+func newBatchSizer(opts StrategyOptions) batchSizer {
+	size := opts.BatchSize
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+	return batchSizer{size: size, adaptive: opts.AdaptiveBatchSize && size < maxBatchSize}
+}
+
+// sent records a send of a full batch to a channel holding queued batches.
+func (s *batchSizer) sent(queued int) {
+	if !s.adaptive {
+		return
+	}
+	s.sends++
+	if queued == 0 {
+		s.starved++
+	}
+	if s.sends < batchSizeWindow {
+		return
+	}
+	if s.starved*2 > s.sends {
+		s.size = min(s.size*2, maxBatchSize)
+		s.adaptive = s.size < maxBatchSize
+	} else {
+		s.adaptive = false
+	}
+	s.sends, s.starved = 0, 0
 }
@@ -0,0 +1,66 @@
+package strategies
+
+import "testing"
+
+func TestBatchStrategyBatchSizes(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+
+	fixed := NewBatchStrategy(StrategyOptions{Workers: 4, BatchSize: 7})
+	checkAggregates(t, fixed, path, want)
+	if got := fixed.BatchSize(); got != 7 {
+		t.Errorf("fixed BatchSize() = %d, want 7", got)
+	}
+
+	adaptive := NewBatchStrategy(StrategyOptions{Workers: 4, BatchSize: 1, AdaptiveBatchSize: true})
+	checkAggregates(t, adaptive, path, want)
+	if got := adaptive.BatchSize(); got < 1 || got > maxBatchSize || got&(got-1) != 0 {
+		t.Errorf("adaptive BatchSize() = %d, want a power of two up to %d", got, maxBatchSize)
+	}
+}
+
+func TestBatchStrategyOwnsItsNames(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+
+	// The splitter refills its 64-byte buffer every line or two, long
+	// before the workers aggregate the batches, which are recycled every
+	// seven lines.
+	opts := StrategyOptions{Workers: 4, BufferSize: 64, BatchSize: 7}
+	checkAggregates(t, NewBatchStrategy(opts), path, want)
+}
+
+func TestBatchSizerGrowsWhileWorkersWait(t *testing.T) {
+	s := newBatchSizer(StrategyOptions{AdaptiveBatchSize: true})
+	for range 2 * batchSizeWindow {
+		s.sent(0)
+	}
+	if s.size != 4*defaultBatchSize {
+		t.Fatalf("size after two starved windows = %d, want %d", s.size, 4*defaultBatchSize)
+	}
+
+	// A window in which the workers kept a backlog settles the size.
+	for range batchSizeWindow {
+		s.sent(2)
+	}
+	for range 4 * batchSizeWindow {
+		s.sent(0)
+	}
+	if s.size != 4*defaultBatchSize {
+		t.Errorf("size after settling = %d, want %d", s.size, 4*defaultBatchSize)
+	}
+
+	s = newBatchSizer(StrategyOptions{BatchSize: maxBatchSize / 2, AdaptiveBatchSize: true})
+	for range 4 * batchSizeWindow {
+		s.sent(0)
+	}
+	if s.size != maxBatchSize {
+		t.Errorf("size = %d, want it capped at %d", s.size, maxBatchSize)
+	}
+
+	s = newBatchSizer(StrategyOptions{})
+	for range 4 * batchSizeWindow {
+		s.sent(0)
+	}
+	if s.size != defaultBatchSize {
+		t.Errorf("fixed size = %d, want %d", s.size, defaultBatchSize)
+	}
+}
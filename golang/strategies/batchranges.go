@@ -0,0 +1,91 @@
+package strategies
+
+import (
+	"context"
+	"io"
+)
+
+// rangeBatchSize is how many parsed lines a RangeBatchStrategy worker
+// collects before aggregating them.
+const rangeBatchSize = 1024
+
+// RangeBatchStrategy keeps the batches of BatchStrategy without its single
+// splitting goroutine and channel: every worker pulls byte ranges from the
+// shared queue and splits and parses their lines itself, into a batch it
+// aggregates into its own map whenever the batch fills up. Parsing and
+// aggregating thus run as two tight loops rather than one, on every core.
+// Unlike BatchStrategy it cannot read a pipe, as it needs to seek.
+type RangeBatchStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	opts StrategyOptions
+}
+
+// NewRangeBatchStrategy returns a RangeBatchStrategy configured with opts.
+func NewRangeBatchStrategy(opts StrategyOptions) *RangeBatchStrategy {
+	return &RangeBatchStrategy{opts: opts}
+}
+
+func (b *RangeBatchStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	return b.aggregate(ctx, pathInput(filePath))
+}
+
+func (b *RangeBatchStrategy) CalculateReaderAt(ctx context.Context, r io.ReaderAt, size int64) ([]StationResult, error) {
+	return b.aggregate(ctx, readerAtInput(r, size))
+}
+
+func (b *RangeBatchStrategy) aggregate(ctx context.Context, in input) ([]StationResult, error) {
+	// Go maps have no probe lengths worth reporting.
+	return runTableStrategy(ctx, in, b.opts, &b.progress, &b.malformedLines, &probeRecorder{}, &b.resultEmitter, func() stationTable {
+		return newBatchTable(b.opts)
+	})
+}
+
+// batchTable is the stationTable of a RangeBatchStrategy worker: lines are
+// parsed into a batch, and a full batch is aggregated into a Go map. The
+// read buffer a line came from may be refilled before its batch is
+// aggregated, so the batch owns its names.
+type batchTable struct {
+	batch    *stationBatch
+	stations StationMap
+	hash     HashFunc
+	arena    nameArena
+}
+
+func newBatchTable(opts StrategyOptions) *batchTable {
+	return &batchTable{
+		batch:    newStationBatch(rangeBatchSize),
+		stations: make(StationMap, opts.mapCapacity()),
+		hash:     opts.hash(),
+	}
+}
+
+func (t *batchTable) addLine(line []byte) bool {
+	name, value, err := parseLineByte(line)
+	if err != nil {
+		return false
+	}
+	t.batch.add(name, value)
+	if len(t.batch.stations) == rangeBatchSize {
+		t.flushBatch()
+	}
+	return true
+}
+
+// flushBatch aggregates the batch into the map and empties it.
+func (t *batchTable) flushBatch() {
+	processBatch(t.batch.stations, t.stations, t.hash, &t.arena)
+	t.batch.reset()
+}
+
+func (t *batchTable) flushInto(smap StationMap, names *internTable) {
+	t.flushBatch()
+	for key, st := range t.stations {
+		smap[key] = st // the name is the arena's, never overwritten
+	}
+}
+
+func (t *batchTable) probeStats() ProbeStats {
+	return ProbeStats{}
+}
@@ -0,0 +1,12 @@
+package strategies
+
+import "testing"
+
+func TestRangeBatchKeepsNamesAcrossRefills(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+
+	// Buffers of a few lines are refilled many times per batch, so every
+	// batch outlives the lines it was parsed from.
+	opts := StrategyOptions{Workers: 4, BufferSize: 64, ChunkSize: 16 << 10}
+	checkAggregates(t, NewRangeBatchStrategy(opts), path, want)
+}
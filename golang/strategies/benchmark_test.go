@@ -49,20 +49,36 @@ func getTestDataFile(b *testing.B) string {
 	return generateTempTestData(b, 100_000)
 }
 
-// strategyBenchmark holds a strategy and its name for benchmarking
+// strategyBenchmark holds a strategy, its name and its registry entry for
+// benchmarking
 type strategyBenchmark struct {
 	name     string
 	strategy Strategy
+	Entry
+}
+
+// strategiesWith builds every registered strategy this platform supports
+// with opts, named by its Metadata
+func strategiesWith(opts StrategyOptions) []strategyBenchmark {
+	var named []strategyBenchmark
+	for _, key := range Registered() {
+		if s := registeredStrategy(key, opts); Supported(s.strategy) {
+			named = append(named, s)
+		}
+	}
+	return named
+}
+
+// registeredStrategy builds the strategy registered under key with opts
+func registeredStrategy(key string, opts StrategyOptions) strategyBenchmark {
+	entry, _ := Lookup(key)
+	s := entry.New(opts)
+	return strategyBenchmark{s.(Metadata).Name(), s, entry}
 }
 
 // getAllStrategies returns all strategies to benchmark
 func getAllStrategies() []strategyBenchmark {
-	return []strategyBenchmark{
-		{"Basic", &BasicStrategy{}},
-		{"ByteReading", &ByteReadingStrategy{}},
-		{"Batch", &BatchStrategy{}},
-		{"MCMP", &MCMPStrategy{}},
-	}
+	return strategiesWith(StrategyOptions{})
 }
 
 // BenchmarkAllStrategies benchmarks all strategies
@@ -73,7 +89,7 @@ func BenchmarkAllStrategies(b *testing.B) {
 	for _, s := range strategies {
 		b.Run(s.name, func(b *testing.B) {
 			for b.Loop() {
-				_, err := s.strategy.Calculate(dataFile)
+				_, err := s.strategy.Calculate(b.Context(), dataFile)
 				if err != nil {
 					b.Fatalf("%s failed: %v", s.name, err)
 				}
@@ -82,7 +98,7 @@ func BenchmarkAllStrategies(b *testing.B) {
 	}
 }
 
-// BenchmarkParseLineFunctions compares all three parsing functions
+// BenchmarkParseLineFunctions compares all the line parsing functions
 func BenchmarkParseLineFunctions(b *testing.B) {
 	testLineString := "Hamburg;12.0"
 	testLineBytes := []byte("Hamburg;12.0")
@@ -122,25 +138,60 @@ func BenchmarkParseLineFunctions(b *testing.B) {
 			}
 		}
 	})
+
+	b.Run("SWAR", func(b *testing.B) {
+		// Lines normally sit inside a read buffer, which lets the 8-byte
+		// load run past the temperature without a copy.
+		line := []byte("Hamburg;12.0\nBulawayo;8.9\n")[:len(testLineBytes)]
+		for b.Loop() {
+			_, _, err := parseLineSWAR(line)
+			if err != nil {
+				b.Fatalf("parseLineSWAR failed: %v", err)
+			}
+		}
+	})
 }
 
-// BenchmarkHashFnv benchmarks the FNV hashing function
-func BenchmarkHashFnv(b *testing.B) {
+// BenchmarkHashFunctions benchmarks every selectable station hash
+func BenchmarkHashFunctions(b *testing.B) {
 	testName := []byte("Hamburg")
 
-	for b.Loop() {
-		_ = hashFnv(testName)
+	for _, name := range HashFunctions() {
+		hash := hashFuncs[name]
+		b.Run(name, func(b *testing.B) {
+			for b.Loop() {
+				_ = hash(testName)
+			}
+		})
 	}
 }
 
+// BenchmarkDelimiterSearch compares the one-pass delimiter search with two
+// IndexByte calls on a typical line followed by the rest of its buffer
+func BenchmarkDelimiterSearch(b *testing.B) {
+	buf := []byte("Palmerston North;12.0\nHamburg;-3.4\nBulawayo;8.9\n")
+
+	b.Run("OnePass", func(b *testing.B) {
+		for b.Loop() {
+			_, _ = indexSemicolonNewline(buf)
+		}
+	})
+
+	b.Run("Generic", func(b *testing.B) {
+		for b.Loop() {
+			_, _ = indexSemicolonNewlineGeneric(buf)
+		}
+	})
+}
+
 // BenchmarkStringToInt benchmarks string to integer conversion
 func BenchmarkStringToInt(b *testing.B) {
 	testString := "12.0"
 
 	for b.Loop() {
-		_, err := stringToInt(testString)
+		_, err := parseTemp(testString)
 		if err != nil {
-			b.Fatalf("stringToInt failed: %v", err)
+			b.Fatalf("parseTemp failed: %v", err)
 		}
 	}
 }
@@ -150,9 +201,9 @@ func BenchmarkByteToInt(b *testing.B) {
 	testBytes := []byte("12.0")
 
 	for b.Loop() {
-		_, err := byteToInt(testBytes)
+		_, err := parseTemp(testBytes)
 		if err != nil {
-			b.Fatalf("byteToInt failed: %v", err)
+			b.Fatalf("parseTemp failed: %v", err)
 		}
 	}
 }
@@ -167,7 +218,7 @@ func BenchmarkAllStrategiesMemory(b *testing.B) {
 			b.ReportAllocs()
 
 			for b.Loop() {
-				_, err := s.strategy.Calculate(dataFile)
+				_, err := s.strategy.Calculate(b.Context(), dataFile)
 				if err != nil {
 					b.Fatalf("%s failed: %v", s.name, err)
 				}
@@ -176,6 +227,27 @@ func BenchmarkAllStrategiesMemory(b *testing.B) {
 	}
 }
 
+// BenchmarkBufferSizes sweeps the read buffer size for the chunked strategies
+func BenchmarkBufferSizes(b *testing.B) {
+	dataFile := getTestDataFile(b)
+	bufferSizes := []int{4 * 1024, 64 * 1024, 1024 * 1024, 4 * 1024 * 1024}
+
+	for _, size := range bufferSizes {
+		opts := StrategyOptions{BufferSize: size}
+		for _, key := range []string{"mcmp", "mcmp-lp", "mcmp-lp-opt"} {
+			s := registeredStrategy(key, opts)
+			b.Run(fmt.Sprintf("%s/%dKiB", s.name, size/1024), func(b *testing.B) {
+				for b.Loop() {
+					_, err := s.strategy.Calculate(b.Context(), dataFile)
+					if err != nil {
+						b.Fatalf("%s failed: %v", s.name, err)
+					}
+				}
+			})
+		}
+	}
+}
+
 func formatCPUCount(n int) string {
 	if n == 1 {
 		return "1CPU"
@@ -204,7 +276,7 @@ func BenchmarkAllStrategiesWithCPUs(b *testing.B) {
 
 					b.ResetTimer()
 					for b.Loop() {
-						_, err := s.strategy.Calculate(dataFile)
+						_, err := s.strategy.Calculate(b.Context(), dataFile)
 						if err != nil {
 							b.Fatalf("%s failed: %v", s.name, err)
 						}
@@ -0,0 +1,409 @@
+package strategies
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// The binary format holds measurements already parsed, so repeated runs
+// over the same data skip text parsing altogether. A file is
+//
+//	magic, fraction digits (1 byte)
+//	blocks of records: uvarint station id, int16 value (little endian)
+//	footer: uvarint station count, then per station uvarint length and name;
+//	        uvarint block count, then per block uvarint size and row count
+//	footer offset (uint64, little endian), magic
+//
+// Station ids index the dictionary in the footer, in order of first
+// appearance. The block index lets workers split the file without scanning
+// it, and puts the footer last so the converter can write to a pipe.
+const (
+	binaryMagic      = "1BRCbin\x01"
+	binaryHeaderSize = len(binaryMagic) + 1
+	binaryTrailer    = 8 + len(binaryMagic)
+
+	// binaryBlockRows is the most records a block holds: at most 448 KiB
+	// with five-byte ids, and enough blocks to spread a large file over
+	// any number of workers.
+	binaryBlockRows = 1 << 16
+)
+
+// ErrNotBinary is returned when a file does not start and end like a file
+// written by ConvertToBinary.
+var ErrNotBinary = errors.New("not a binary measurements file")
+
+// ConvertStats summarises a ConvertToBinary run.
+type ConvertStats struct {
+	Rows      int64 // records written
+	Stations  int   // distinct station names
+	Malformed int64 // lines skipped in lenient mode
+	Bytes     int64 // size of the binary output
+}
+
+// ConvertToBinary reads text measurements in the current RecordFormat from
+// r and writes them to w in the binary format BinaryStrategy reads.
+// Malformed lines are skipped and counted, or abort the conversion with a
+// *ParseError in strict mode. Values must fit in an int16 in units of the
+// last fraction digit, as all 1BRC values do.
+func ConvertToBinary(ctx context.Context, w io.Writer, r io.Reader, mode ParseMode) (ConvertStats, error) {
+	var stats ConvertStats
+	bw := bufio.NewWriterSize(w, defaultBlockBufSize)
+	cw := &countingWriter{w: bw}
+	cw.Write(append([]byte(binaryMagic), byte(recordFormat.FractionDigits)))
+
+	ids := make(map[string]uint64)
+	var names []string
+	var blocks []binaryBlock
+	var block binaryBlock
+	endBlock := func() {
+		if block.rows > 0 {
+			blocks = append(blocks, block)
+			block = binaryBlock{}
+		}
+	}
+
+	br := bufio.NewReaderSize(r, defaultBlockBufSize)
+	var long []byte // a line longer than br's buffer, pieced together
+	var rec []byte
+	var lineNo, offset int64
+	for {
+		line, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			long = append(long, line...)
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return stats, err
+		}
+		if long != nil {
+			line = append(long, line...)
+			long = nil
+		}
+		if len(line) == 0 {
+			break
+		}
+		lineNo++
+		start := offset
+		offset += int64(len(line))
+		if lineNo%cancelCheckInterval == 0 && cancelled(ctx) {
+			return stats, ctx.Err()
+		}
+
+		text := bytes.TrimSuffix(line, newline)
+		name, value, perr := parseLineByte(text)
+		if perr != nil {
+			if mode == ParseStrict {
+				err := newParseError(start, text)
+				err.Line = lineNo
+				return stats, err
+			}
+			stats.Malformed++
+		} else {
+			if value < math.MinInt16 || value > math.MaxInt16 {
+				return stats, fmt.Errorf("line %d: value %d does not fit the binary format's 16 bits", lineNo, value)
+			}
+			id, ok := ids[string(name)]
+			if !ok {
+				id = uint64(len(names))
+				ids[string(name)] = id
+				names = append(names, string(name))
+			}
+			rec = binary.AppendUvarint(rec[:0], id)
+			rec = binary.LittleEndian.AppendUint16(rec, uint16(value))
+			cw.Write(rec)
+			block.size += int64(len(rec))
+			if block.rows++; block.rows == binaryBlockRows {
+				endBlock()
+			}
+			stats.Rows++
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	endBlock()
+
+	footerOffset := cw.n
+	var footer []byte
+	footer = binary.AppendUvarint(footer, uint64(len(names)))
+	for _, name := range names {
+		footer = binary.AppendUvarint(footer, uint64(len(name)))
+		footer = append(footer, name...)
+	}
+	footer = binary.AppendUvarint(footer, uint64(len(blocks)))
+	for _, b := range blocks {
+		footer = binary.AppendUvarint(footer, uint64(b.size))
+		footer = binary.AppendUvarint(footer, uint64(b.rows))
+	}
+	footer = binary.LittleEndian.AppendUint64(footer, uint64(footerOffset))
+	footer = append(footer, binaryMagic...)
+	cw.Write(footer)
+
+	stats.Stations = len(names)
+	stats.Bytes = cw.n
+	if cw.err != nil {
+		return stats, cw.err
+	}
+	return stats, bw.Flush()
+}
+
+// countingWriter counts the bytes written through it and keeps the first
+// error, so the converter checks once at the end.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+// binaryBlock locates a block of records in a binary file.
+type binaryBlock struct {
+	offset int64
+	size   int64
+	rows   int64
+}
+
+// binaryIndex is the decoded footer of a binary file.
+type binaryIndex struct {
+	fractionDigits int
+	names          []string
+	blocks         []binaryBlock
+}
+
+// readBinaryIndex checks the header and trailer of the size-byte file f and
+// decodes its footer. It returns ErrNotBinary if either magic is missing.
+func readBinaryIndex(f io.ReaderAt, size int64) (*binaryIndex, error) {
+	if size < int64(binaryHeaderSize+binaryTrailer) {
+		return nil, ErrNotBinary
+	}
+	header := make([]byte, binaryHeaderSize)
+	trailer := make([]byte, binaryTrailer)
+	if _, err := f.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	if _, err := f.ReadAt(trailer, size-int64(binaryTrailer)); err != nil {
+		return nil, err
+	}
+	if string(header[:len(binaryMagic)]) != binaryMagic || string(trailer[8:]) != binaryMagic {
+		return nil, ErrNotBinary
+	}
+
+	footerOffset := int64(binary.LittleEndian.Uint64(trailer))
+	if footerOffset < int64(binaryHeaderSize) || footerOffset > size-int64(binaryTrailer) {
+		return nil, errors.New("binary file: footer offset out of range")
+	}
+	footer := make([]byte, size-int64(binaryTrailer)-footerOffset)
+	if _, err := f.ReadAt(footer, footerOffset); err != nil {
+		return nil, err
+	}
+
+	idx := &binaryIndex{fractionDigits: int(header[len(binaryMagic)])}
+	d := uvarintDecoder{buf: footer}
+	idx.names = make([]string, d.next(uint64(len(footer))))
+	for i := range idx.names {
+		n := d.next(uint64(len(footer)))
+		idx.names[i] = string(d.bytes(n))
+	}
+	idx.blocks = make([]binaryBlock, d.next(uint64(len(footer))))
+	offset := int64(binaryHeaderSize)
+	for i := range idx.blocks {
+		size := int64(d.next(uint64(footerOffset)))
+		rows := int64(d.next(binaryBlockRows))
+		idx.blocks[i] = binaryBlock{offset, size, rows}
+		offset += size
+	}
+	if d.err != nil || offset != footerOffset {
+		return nil, errors.New("binary file: corrupt footer")
+	}
+	return idx, nil
+}
+
+// uvarintDecoder reads a footer, remembering the first error so the
+// decoding reads straight through. Every value is checked against a bound,
+// so a corrupt count cannot ask for a huge allocation.
+type uvarintDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *uvarintDecoder) next(bound uint64) uint64 {
+	v, n := binary.Uvarint(d.buf)
+	if d.err != nil || n <= 0 || v > bound {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *uvarintDecoder) varint() int64 {
+	v, n := binary.Varint(d.buf)
+	if d.err != nil || n <= 0 {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *uvarintDecoder) bytes(n uint64) []byte {
+	if d.err != nil || n > uint64(len(d.buf)) {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+// BinaryStrategy aggregates files written by ConvertToBinary. Workers pull
+// blocks from the footer's index and add each record to a slice indexed
+// by station id, so there is no text to parse and no name to hash. Files
+// without the binary header are read as text, like DoubleBufferedStrategy.
+type BinaryStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	opts StrategyOptions
+}
+
+// NewBinaryStrategy returns a BinaryStrategy configured with opts.
+func NewBinaryStrategy(opts StrategyOptions) *BinaryStrategy {
+	return &BinaryStrategy{opts: opts}
+}
+
+func (b *BinaryStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	b.resetProgress()
+	defer b.reportProgress(b.opts)()
+	b.resetMalformed(b.opts)
+	f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fsize, err := getFileSize(f)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := readBinaryIndex(f, fsize)
+	if errors.Is(err, ErrNotBinary) {
+		tempMaps := make([]StationMap, b.opts.workers())
+		err = scanChunks(ctx, &fileSource{f, fsize, b.opts}, b.opts, &b.progress, &b.malformedLines, func(worker int) lineSink {
+			tempMaps[worker] = make(StationMap, b.opts.mapCapacity())
+			return mapSink{tempMaps[worker], b.opts.hash()}
+		})
+		if err != nil {
+			return nil, locateParseError(filePath, err)
+		}
+		return emitResults(&b.resultEmitter, tempMaps...), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	if idx.fractionDigits != recordFormat.FractionDigits {
+		return nil, fmt.Errorf("%s: converted with %d fraction digits, the record format has %d",
+			filePath, idx.fractionDigits, recordFormat.FractionDigits)
+	}
+
+	// A corrupt block stops the others.
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	n := max(min(b.opts.workers(), len(idx.blocks)), 1)
+	tempMaps := make([]map[uint32]StationResult, n)
+	errs := make([]error, n)
+	var next atomic.Int64
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := range n {
+		go func(i int) {
+			defer wg.Done()
+			defer b.opts.pinWorker(i)()
+			stations := make([]StationResult, len(idx.names))
+			for id, name := range idx.names {
+				stations[id] = newSt(name)
+			}
+			var buf []byte
+
+			for !cancelled(runCtx) {
+				j := int(next.Add(1) - 1)
+				if j >= len(idx.blocks) {
+					break
+				}
+				blk := idx.blocks[j]
+				if int64(cap(buf)) < blk.size {
+					buf = make([]byte, blk.size)
+				}
+				buf = buf[:blk.size]
+				if _, err := f.ReadAt(buf, blk.offset); err != nil {
+					errs[i] = err
+					stop()
+					return
+				}
+				b.addProgress(len(buf))
+				if err := aggregateBinaryBlock(buf, blk.rows, stations); err != nil {
+					errs[i] = fmt.Errorf("%s: block at byte %d: %w", filePath, blk.offset, err)
+					stop()
+					return
+				}
+				b.rowsParsed.Add(blk.rows)
+			}
+
+			tempMaps[i] = make(map[uint32]StationResult, len(stations))
+			for id, st := range stations {
+				if st.Count > 0 {
+					tempMaps[i][uint32(id)] = st
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return emitResults(&b.resultEmitter, tempMaps...), nil
+}
+
+// aggregateBinaryBlock adds the rows records of block to stations.
+func aggregateBinaryBlock(block []byte, rows int64, stations []StationResult) error {
+	for range rows {
+		id, n := binary.Uvarint(block)
+		if n <= 0 || len(block) < n+2 || id >= uint64(len(stations)) {
+			return errors.New("corrupt record")
+		}
+		value := int64(int16(binary.LittleEndian.Uint16(block[n:])))
+		block = block[n+2:]
+
+		stations[id].Add(value)
+	}
+	if len(block) != 0 {
+		return errors.New("corrupt record")
+	}
+	return nil
+}
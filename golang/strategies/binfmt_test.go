@@ -0,0 +1,98 @@
+package strategies
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// convertDataset converts the text file at path to the binary format and
+// returns the new file's path.
+func convertDataset(t *testing.T, path string, mode ParseMode) (string, ConvertStats) {
+	t.Helper()
+
+	in, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	binPath := filepath.Join(t.TempDir(), "measurements.bin")
+	out, err := os.Create(binPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	stats, err := ConvertToBinary(t.Context(), out, in, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return binPath, stats
+}
+
+func TestBinaryStrategyReadsConvertedFile(t *testing.T) {
+	// More rows than fit one block.
+	path, want := writeRefillDataset(t, 3*binaryBlockRows+123, 300)
+	insertLines(t, path, []int{10, 5000}, []string{"Oslo;12,3", ""})
+	binPath, stats := convertDataset(t, path, ParseLenient)
+
+	if stats.Rows != 3*binaryBlockRows+123 || stats.Malformed != 2 || stats.Stations != len(want) {
+		t.Errorf("got %+v, want %d rows, 2 malformed, %d stations", stats, 3*binaryBlockRows+123, len(want))
+	}
+	if info, err := os.Stat(binPath); err != nil || info.Size() != stats.Bytes {
+		t.Errorf("file holds %v bytes, stats say %d", info, stats.Bytes)
+	}
+
+	for _, workers := range []int{1, 3, 8} {
+		s := NewBinaryStrategy(StrategyOptions{Workers: workers})
+		checkAggregates(t, s, binPath, want)
+		if got := s.RowsParsed(); got != stats.Rows {
+			t.Errorf("%d workers: parsed %d rows, want %d", workers, got, stats.Rows)
+		}
+	}
+}
+
+func TestConvertToBinaryStrictReportsLine(t *testing.T) {
+	path, _ := writeRefillDataset(t, 5_000, 200)
+	insertLines(t, path, []int{3210}, []string{"Oslo;12,3"})
+
+	in, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	_, err = ConvertToBinary(t.Context(), io.Discard, in, ParseStrict)
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 3211 {
+		t.Errorf("got error %v, want a *ParseError at line 3211", err)
+	}
+}
+
+func TestBinaryStrategyRejectsCorruptFile(t *testing.T) {
+	path, _ := writeRefillDataset(t, 5_000, 100)
+	binPath, _ := convertDataset(t, path, ParseLenient)
+	data, err := os.ReadFile(binPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A station id past the dictionary of 100.
+	data[binaryHeaderSize] = 0x7f
+	if err := os.WriteFile(binPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBinaryStrategy(StrategyOptions{}).Calculate(t.Context(), binPath); err == nil {
+		t.Error("read a record with an unknown station id without error")
+	}
+
+	// A footer offset that does not match the blocks.
+	data[len(data)-binaryTrailer]++
+	if err := os.WriteFile(binPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBinaryStrategy(StrategyOptions{}).Calculate(t.Context(), binPath); err == nil {
+		t.Error("read a corrupt footer without error")
+	}
+}
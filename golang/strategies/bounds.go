@@ -0,0 +1,73 @@
+package strategies
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// chunkQueue returns the queue workers pull chunks of f from. Every chunk
+// starts at a line and the last ends at fsize, so together they cover the
+// file exactly and no line straddles two of them. The boundaries are found
+// up front by planBounds or, with LineIndex set, taken from the file's
+// sidecar index, which is built the first time and rebuilt when the file
+// changes or the chunks get smaller than its stride. With SampleFraction
+// set, only a sample of the chunks is handed out.
+func (o StrategyOptions) chunkQueue(f *os.File, fsize, chunkSize int64) (*chunkQueue, error) {
+	if !o.LineIndex || fsize == 0 {
+		bounds, err := planBounds(f, fsize, chunkSize)
+		if err != nil {
+			return nil, err
+		}
+		return o.sampled(newAlignedChunkQueue(bounds)), nil
+	}
+	idx, err := loadLineIndex(f.Name(), chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	return o.sampled(newAlignedChunkQueue(idx.bounds(chunkSize))), nil
+}
+
+// planBounds returns the boundaries of chunks of about chunkSize bytes
+// covering the size bytes of r: 0, then the first line start at or after
+// each chunkSize past the previous boundary, then size. Finding them costs
+// a short read per chunk.
+func planBounds(r io.ReaderAt, size, chunkSize int64) ([]int64, error) {
+	bounds := []int64{0}
+	if size == 0 {
+		return bounds, nil
+	}
+	chunkSize = max(chunkSize, 1)
+	buf := make([]byte, minTailRead)
+	for pos := chunkSize; pos < size; pos = bounds[len(bounds)-1] + chunkSize {
+		start, err := nextLineStart(r, pos, buf)
+		if err != nil {
+			return nil, err
+		}
+		if start >= size {
+			break
+		}
+		bounds = append(bounds, start)
+	}
+	return append(bounds, size), nil
+}
+
+// nextLineStart returns the offset of the first line of r starting at or
+// after pos, which must be positive, reading through buf. Past the last
+// line, it returns the size of r.
+func nextLineStart(r io.ReaderAt, pos int64, buf []byte) (int64, error) {
+	// The line starting at pos is the one after the first newline at or
+	// after pos-1.
+	for off := pos - 1; ; off += int64(len(buf)) {
+		n, err := r.ReadAt(buf, off)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			return off + int64(i) + 1, nil
+		}
+		if err == io.EOF {
+			return off + int64(n), nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package strategies
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlanBoundsCoverEveryLine(t *testing.T) {
+	long := strings.Repeat("x", 300) + ";1.0\n"
+	inputs := map[string]string{
+		"short lines":     strings.Repeat("a;1.0\nbb;-2.5\n", 200),
+		"long lines":      strings.Repeat(long+"c;3.0\n", 20),
+		"no last newline": strings.Repeat("a;1.0\n", 100) + "z;9.9",
+		"one line":        "a;1.0\n",
+		"empty":           "",
+	}
+	for name, input := range inputs {
+		for _, chunkSize := range []int64{1, 7, 64, 1000, 1 << 20} {
+			bounds, err := planBounds(strings.NewReader(input), int64(len(input)), chunkSize)
+			if err != nil {
+				t.Fatalf("%s, chunk size %d: %v", name, chunkSize, err)
+			}
+			if bounds[0] != 0 || bounds[len(bounds)-1] != int64(len(input)) {
+				t.Errorf("%s, chunk size %d: bounds %v do not run from 0 to %d", name, chunkSize, bounds, len(input))
+			}
+			for i := 1; i < len(bounds)-1; i++ {
+				if b := bounds[i]; b <= bounds[i-1] || input[b-1] != '\n' {
+					t.Errorf("%s, chunk size %d: bound %d is not a line start after %d", name, chunkSize, b, bounds[i-1])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,12 @@
+//go:build darwin && arm64
+
+package strategies
+
+// Default read buffer sizes for bufio-based and block readers on Apple
+// silicon. Its 16 KiB pages make a 64 KiB buffer only four pages long, and
+// its NVMe storage is driven through large requests, so every read asks
+// for four times as much as elsewhere.
+const (
+	defaultChunkBufSize = 256 * 1024
+	defaultBlockBufSize = 4 * 1024 * 1024
+)
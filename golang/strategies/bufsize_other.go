@@ -0,0 +1,9 @@
+//go:build !darwin || !arm64
+
+package strategies
+
+// Default read buffer sizes for bufio-based and block readers.
+const (
+	defaultChunkBufSize = 64 * 1024
+	defaultBlockBufSize = 1024 * 1024
+)
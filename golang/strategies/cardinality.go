@@ -0,0 +1,143 @@
+package strategies
+
+import (
+	"context"
+	"math"
+	"math/bits"
+)
+
+const (
+	// hllPrecision is the number of hash bits that pick a HyperLogLog
+	// register. Its 16384 one-byte registers estimate with a standard
+	// error of 1.04/sqrt(16384), about 0.8%.
+	hllPrecision = 14
+	hllRegisters = 1 << hllPrecision
+
+	// sizedHeadroom is how far SizedOptions sizes past the estimate, several
+	// standard errors, since robin hood and Swiss tables never grow.
+	sizedHeadroom = 1.05
+
+	// sizedTableLoad is the share of slots SizedOptions lets the stations
+	// fill. Cuckoo tables, with two candidate slots per key, start
+	// stashing past about half.
+	sizedTableLoad = 0.5
+
+	// minSizedTable keeps tables for a handful of stations a few cache
+	// lines long.
+	minSizedTable = 64
+
+	// MaxDenseStations is the most stations SuggestStrategy hands to a
+	// dense array. Past it, each worker's array and the perfect hash's
+	// construction outgrow the caches, and a map that only holds the
+	// stations a worker meets is the better fit.
+	MaxDenseStations = 1 << 16
+)
+
+// hyperLogLog is Flajolet et al.'s cardinality sketch: a hash's first
+// hllPrecision bits pick a register, which keeps the longest run of
+// leading zeros seen in the remaining bits. The registers of two sketches
+// merge by taking the larger of each.
+type hyperLogLog struct {
+	registers [hllRegisters]uint8
+}
+
+func (h *hyperLogLog) add(hash uint64) {
+	i := hash >> (64 - hllPrecision)
+	// The marker bit bounds the run should every remaining bit be zero.
+	rank := uint8(bits.LeadingZeros64(hash<<hllPrecision|1<<(hllPrecision-1))) + 1
+	h.registers[i] = max(h.registers[i], rank)
+}
+
+func (h *hyperLogLog) merge(o *hyperLogLog) {
+	for i, r := range o.registers {
+		h.registers[i] = max(h.registers[i], r)
+	}
+}
+
+// estimate returns the harmonic-mean estimate, switching to linear
+// counting of the empty registers for small cardinalities, where it is
+// the more accurate of the two. A 64-bit hash needs no large-range
+// correction.
+func (h *hyperLogLog) estimate() int {
+	const m = float64(hllRegisters)
+	var sum float64
+	var zeros int
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	e := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		e = m * math.Log(m/float64(zeros))
+	}
+	return int(math.Round(e))
+}
+
+// hllSink hashes the name of every well-formed line into a sketch.
+type hllSink struct {
+	sketch *hyperLogLog
+}
+
+func (s hllSink) addLine(line []byte) bool {
+	name, _, err := parseLineByte(line)
+	if err != nil {
+		return false
+	}
+	s.sketch.add(hashXXH64(name))
+	return true
+}
+
+// EstimateStations approximates the number of distinct station names in
+// filePath, within a few percent, with a HyperLogLog sketch per worker.
+// It reads the file as the table strategies do, and with a sampled opts
+// only the sample, but only hashes each name, keeping 16 KiB per worker
+// whatever the cardinality, so it costs a fraction of an aggregation.
+// Malformed lines are skipped.
+func EstimateStations(ctx context.Context, filePath string, opts StrategyOptions) (int, error) {
+	src, err := pathInput(filePath).open(ctx, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	sketches := make([]hyperLogLog, opts.workers())
+	err = scanChunks(ctx, src, opts, &progress{}, &malformedLines{}, func(worker int) lineSink {
+		return hllSink{&sketches[worker]}
+	})
+	if err != nil {
+		return 0, err
+	}
+	for i := 1; i < len(sketches); i++ {
+		sketches[0].merge(&sketches[i])
+	}
+	return sketches[0].estimate(), nil
+}
+
+// SizedOptions returns opts with hash tables and maps sized for about
+// stations distinct names, as EstimateStations counts them, rather than
+// the defaults' 131072 slots and 100000 entries: tables of the power of
+// two that stays at most half full, maps with room for every station.
+// Fields already set in opts are kept.
+func SizedOptions(opts StrategyOptions, stations int) StrategyOptions {
+	room := int(math.Ceil(float64(stations) * sizedHeadroom))
+	if opts.TableSize == 0 {
+		slots := max(int(math.Ceil(float64(room)/sizedTableLoad)), minSizedTable)
+		opts.TableSize = 1 << bits.Len(uint(slots-1))
+	}
+	if opts.MapCapacity == 0 {
+		opts.MapCapacity = max(room, 1)
+	}
+	return opts
+}
+
+// SuggestStrategy returns the key of the strategy suited to stations
+// distinct names: "perfect-hash", which aggregates into a dense array, up
+// to MaxDenseStations, and "mcmp", which aggregates into Go maps, beyond.
+func SuggestStrategy(stations int) string {
+	if stations <= MaxDenseStations {
+		return "perfect-hash"
+	}
+	return "mcmp"
+}
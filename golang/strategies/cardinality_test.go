@@ -0,0 +1,45 @@
+package strategies
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateStationsIsClose(t *testing.T) {
+	for _, stations := range []int{1, 40, 5_000, 60_000} {
+		path, want := writeRefillDataset(t, 3*stations+1_000, stations)
+		got, err := EstimateStations(t.Context(), path, StrategyOptions{Workers: 4, ChunkSize: 8192})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Six standard errors of a 2^14-register sketch.
+		if math.Abs(float64(got-len(want))) > 0.05*float64(len(want)) {
+			t.Errorf("EstimateStations() = %d, want about %d", got, len(want))
+		}
+	}
+}
+
+func TestSizedOptionsHoldEveryStation(t *testing.T) {
+	path, want := writeRefillDataset(t, 100_000, 20_000)
+	stations, err := EstimateStations(t.Context(), path, StrategyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := SizedOptions(StrategyOptions{Workers: 4, ChunkSize: 1 << 16}, stations)
+	if opts.tableSize() >= defaultTableSize || opts.tableSize() < 2*len(want) {
+		t.Fatalf("tableSize() = %d for %d stations", opts.tableSize(), len(want))
+	}
+	if kept := SizedOptions(StrategyOptions{TableSize: 512, MapCapacity: 7}, stations); kept.TableSize != 512 || kept.MapCapacity != 7 {
+		t.Errorf("SizedOptions replaced set fields: %+v", kept)
+	}
+
+	for _, s := range strategiesWith(opts) {
+		t.Run(s.name, func(t *testing.T) {
+			results, err := s.strategy.Calculate(t.Context(), path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkResults(t, results, want)
+		})
+	}
+}
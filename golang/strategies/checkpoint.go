@@ -0,0 +1,289 @@
+package strategies
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// checkpointMagic opens a checkpoint file.
+const checkpointMagic = "1BRCckp\x02"
+
+// checkpointSumBytes is how much of the head and of the tail of the input
+// a checkpoint checksums, to tell the input apart from one rewritten in
+// place at the same size.
+const checkpointSumBytes = 64 << 10
+
+const defaultCheckpointInterval = time.Minute
+
+func (o StrategyOptions) checkpointInterval() time.Duration {
+	if o.CheckpointInterval > 0 {
+		return o.CheckpointInterval
+	}
+	return defaultCheckpointInterval
+}
+
+// checkpoint is the progress of a run saved to StrategyOptions.Checkpoint:
+// the stations of the chunks done so far and which chunks those are, with
+// the input and chunk size that make the indexes name the same chunks
+// again.
+type checkpoint struct {
+	input     checkpointInput
+	chunkSize int64
+	chunks    []int64 // indexes of the chunks done, ascending
+	stations  []StationResult
+}
+
+// checkpointInput identifies the input a checkpoint was saved for.
+type checkpointInput struct {
+	size    int64
+	modTime int64  // in Unix nanoseconds, or 0 for inputs without one
+	sum     uint64 // FNV-1a of the first and last checkpointSumBytes
+}
+
+// identifyInput reads what identifies src's input: its size, when it was
+// last modified if src knows, and a checksum of its head and tail.
+func identifyInput(ctx context.Context, src chunkSource) (checkpointInput, error) {
+	id := checkpointInput{size: src.size()}
+	if m, ok := src.(modTimer); ok {
+		t, err := m.modTime()
+		if err != nil {
+			return id, err
+		}
+		id.modTime = t.UnixNano()
+	}
+
+	h := fnv.New64a()
+	head := min(id.size, checkpointSumBytes)
+	tail := max(id.size-checkpointSumBytes, head)
+	for _, r := range [][2]int64{{0, head}, {tail, id.size}} {
+		rc, err := src.readFrom(ctx, r[0], r[1])
+		if err != nil {
+			return id, err
+		}
+		_, err = io.CopyN(h, rc, r[1]-r[0])
+		rc.Close()
+		if err != nil {
+			return id, err
+		}
+	}
+	id.sum = h.Sum64()
+	return id, nil
+}
+
+// check returns an error naming how input differs from the one c was saved
+// for, if it does.
+func (c *checkpoint) check(input checkpointInput) error {
+	switch saved := c.input; {
+	case saved.size != input.size:
+		return fmt.Errorf("is of a %d-byte input, not this %d-byte one", saved.size, input.size)
+	case saved.modTime != input.modTime:
+		return fmt.Errorf("is of an input last modified at %v, not at %v",
+			time.Unix(0, saved.modTime).Format(time.RFC3339Nano), time.Unix(0, input.modTime).Format(time.RFC3339Nano))
+	case saved.sum != input.sum:
+		return errors.New("is of an input of the same size but other contents")
+	}
+	return nil
+}
+
+// readCheckpoint reads the checkpoint at path, or returns nil if there is
+// none.
+func readCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte(checkpointMagic)) {
+		return nil, fmt.Errorf("%s is not a checkpoint", path)
+	}
+
+	d := uvarintDecoder{buf: data[len(checkpointMagic):]}
+	c := &checkpoint{
+		input: checkpointInput{
+			size:    int64(d.next(math.MaxInt64)),
+			modTime: d.varint(),
+			sum:     d.next(math.MaxUint64),
+		},
+		chunkSize: int64(d.next(math.MaxInt64)),
+	}
+	// Chunk indexes are stored as the differences between them.
+	c.chunks = make([]int64, d.next(uint64(len(data))))
+	var chunk int64
+	for i := range c.chunks {
+		chunk += int64(d.next(math.MaxInt64))
+		c.chunks[i] = chunk
+	}
+	if d.err != nil || c.chunkSize == 0 {
+		return nil, fmt.Errorf("corrupt checkpoint %s", path)
+	}
+	if c.stations, err = ReadPartial(bytes.NewReader(d.buf)); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// write saves c to path, replacing the previous checkpoint only once the
+// new one is complete.
+func (c *checkpoint) write(path string) error {
+	var buf bytes.Buffer
+	header := []byte(checkpointMagic)
+	header = binary.AppendUvarint(header, uint64(c.input.size))
+	header = binary.AppendVarint(header, c.input.modTime)
+	header = binary.AppendUvarint(header, c.input.sum)
+	header = binary.AppendUvarint(header, uint64(c.chunkSize))
+	header = binary.AppendUvarint(header, uint64(len(c.chunks)))
+	var prev int64
+	for _, chunk := range c.chunks {
+		header = binary.AppendUvarint(header, uint64(chunk-prev))
+		prev = chunk
+	}
+	buf.Write(header)
+	if err := WritePartial(&buf, c.stations); err != nil {
+		return err
+	}
+	return replaceFile(path, buf.Bytes())
+}
+
+// resume leaves q handing out only the chunks c has not done, and returns
+// how many bytes the done ones hold.
+func (c *checkpoint) resume(q *chunkQueue) (int64, error) {
+	n := q.chunks()
+	for i, chunk := range c.chunks {
+		if chunk < 0 || chunk >= n || i > 0 && chunk <= c.chunks[i-1] {
+			return 0, errors.New("checkpoint does not match the input's chunks")
+		}
+	}
+
+	var done int64
+	q.picked = make([]int64, 0, n-int64(len(c.chunks)))
+	for i := range n {
+		if _, found := slices.BinarySearch(c.chunks, i); found {
+			start, end := q.chunk(i)
+			done += end - start
+		} else {
+			q.picked = append(q.picked, i)
+		}
+	}
+	return done, nil
+}
+
+// checkpointer saves the progress of a scan to a checkpoint file. Every
+// worker snapshots its table between chunks once CheckpointInterval has
+// passed since its last snapshot, and the file is rewritten with the
+// stations of the latest snapshots of all workers, on top of those of the
+// runs before.
+type checkpointer struct {
+	path     string
+	interval time.Duration
+	tables   []stationTable
+	base     *checkpoint // the progress of the runs before
+
+	// done and saved are each worker's own: the chunks it has aggregated
+	// into its table, and when it last snapshotted that.
+	done  [][]int64
+	saved []time.Time
+
+	mu        sync.Mutex
+	snapshots []checkpoint // the latest of each worker, without a file or chunk size
+}
+
+// chunkDone records that the worker has aggregated the chunk into its
+// table, and saves the checkpoint if the worker is due a snapshot.
+func (c *checkpointer) chunkDone(worker int, chunk int64) error {
+	c.done[worker] = append(c.done[worker], chunk)
+	if time.Since(c.saved[worker]) < c.interval {
+		return nil
+	}
+	c.saved[worker] = time.Now()
+	smap := make(StationMap)
+	c.tables[worker].flushInto(smap, newInternTable())
+	snapshot := checkpoint{
+		chunks:   slices.Clone(c.done[worker]),
+		stations: slices.Collect(maps.Values(smap)),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.snapshots[worker] = snapshot
+	saved := checkpoint{
+		input:     c.base.input,
+		chunkSize: c.base.chunkSize,
+		chunks:    slices.Clone(c.base.chunks),
+	}
+	sets := [][]StationResult{c.base.stations}
+	for _, s := range c.snapshots {
+		saved.chunks = append(saved.chunks, s.chunks...)
+		sets = append(sets, s.stations)
+	}
+	slices.Sort(saved.chunks)
+	saved.stations = MergeResults(sets...)
+	if err := saved.write(c.path); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	return nil
+}
+
+// scanCheckpointed is scanChunks saving its progress to opts.Checkpoint
+// and, if that holds the progress of an earlier run on the same input,
+// skipping the chunks that run saved. It returns the stations of those
+// chunks, which the caller merges into its own, also if the scan is
+// interrupted. The tables are those newSink hands out.
+func scanCheckpointed(ctx context.Context, src chunkSource, opts StrategyOptions, p *progress, m *malformedLines,
+	tables []stationTable, newSink func(worker int) lineSink) ([]StationResult, error) {
+	if opts.sampling() {
+		return nil, errors.New("a sampled run cannot be checkpointed")
+	}
+	input, err := identifyInput(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	base, err := readCheckpoint(opts.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	if base == nil {
+		base = &checkpoint{input: input, chunkSize: opts.chunkSize(input.size, opts.workers())}
+	} else if err := base.check(input); err != nil {
+		return nil, fmt.Errorf("checkpoint %s %w", opts.Checkpoint, err)
+	}
+
+	queue, err := src.queue(base.chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	done, err := base.resume(queue)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opts.Checkpoint, err)
+	}
+	p.addProgress(int(done))
+
+	n := opts.workers()
+	c := &checkpointer{
+		path:      opts.Checkpoint,
+		interval:  opts.checkpointInterval(),
+		tables:    tables,
+		base:      base,
+		done:      make([][]int64, n),
+		saved:     make([]time.Time, n),
+		snapshots: make([]checkpoint, n),
+	}
+	for i := range c.saved {
+		c.saved[i] = time.Now()
+	}
+	err = scanQueue(ctx, src, queue, base.chunkSize, opts, p, m, newSink, c.chunkDone)
+	return base.stations, err
+}
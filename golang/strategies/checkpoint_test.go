@@ -0,0 +1,132 @@
+package strategies
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckpointResumesAnInterruptedRun(t *testing.T) {
+	const rows = 20_000
+	path, want := writeRefillDataset(t, rows, 300)
+	plan, err := planChunks(path, StrategyOptions{Workers: 4, ChunkSize: 16 << 10}, 0, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range Registered() {
+		opts := StrategyOptions{
+			Workers:            4,
+			ChunkSize:          16 << 10,
+			Checkpoint:         filepath.Join(t.TempDir(), "run.ckpt"),
+			CheckpointInterval: time.Nanosecond,
+		}
+		s := registeredStrategy(key, opts)
+		if !s.Checkpoints {
+			continue
+		}
+		t.Run(s.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+			tracer := &cancelAtChunk{n: 8, cancel: cancel}
+			if _, twoPass := s.strategy.(*PerfectHashStrategy); twoPass {
+				// Its first pass only collects names.
+				tracer.n += int64(len(plan.Chunks))
+			}
+			interrupted := opts
+			interrupted.Tracer = tracer
+			if _, err := registeredStrategy(key, interrupted).strategy.Calculate(ctx, path); !errors.Is(err, context.Canceled) {
+				t.Fatalf("interrupted run returned %v, want context.Canceled", err)
+			}
+			if c, err := readCheckpoint(opts.Checkpoint); err != nil || c == nil || len(c.chunks) == 0 {
+				t.Fatalf("got checkpoint %v, %v; want the chunks done", c, err)
+			}
+
+			checkAggregates(t, s.strategy, path, want)
+			if parsed := s.strategy.(ProgressTracker).RowsParsed(); parsed >= rows {
+				t.Errorf("resumed run parsed %d rows, want fewer than %d", parsed, rows)
+			}
+			if _, err := os.Stat(opts.Checkpoint); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("checkpoint left behind by a complete run: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckpointRejectsAnotherInput(t *testing.T) {
+	path, _ := writeRefillDataset(t, 5_000, 200)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewritten := slices.Clone(data)
+	rewritten[bytes.IndexByte(rewritten, ';')+1] ^= 1 // another digit, at the same size
+
+	tests := []struct {
+		name    string
+		rewrite func(t *testing.T)
+	}{
+		{"rewritten at the same size", func(t *testing.T) {
+			if err := os.WriteFile(path, rewritten, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, time.Time{}, info.ModTime().Add(time.Second)); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"rewritten with its old modification time", func(t *testing.T) {
+			if err := os.WriteFile(path, rewritten, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, time.Time{}, info.ModTime()); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"of another size", func(t *testing.T) {
+			if err := os.WriteFile(path, data[:len(data)/2], 0644); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, time.Time{}, info.ModTime()); err != nil {
+				t.Fatal(err)
+			}
+			src, err := openChunkSource(t.Context(), path, StrategyOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			input, err := identifyInput(t.Context(), src)
+			src.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			saved := filepath.Join(t.TempDir(), "run.ckpt")
+			c := &checkpoint{input: input, chunkSize: 1 << 10, chunks: []int64{0, 3}}
+			if err := c.write(saved); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.rewrite(t)
+			opts := StrategyOptions{Workers: 4, Checkpoint: saved}
+			if _, err := NewSwissTableStrategy(opts).Calculate(t.Context(), path); err == nil || !strings.Contains(err.Error(), "checkpoint") {
+				t.Fatalf("got error %v, want the checkpoint rejected", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package strategies
+
+import (
+	"slices"
+	"sync/atomic"
+)
+
+const (
+	minChunkSize = 64 * 1024
+	maxChunkSize = 16 * 1024 * 1024
+
+	// chunksPerWorker is how many chunks each worker should get on average
+	// when the chunk size is derived from the file size, leaving enough
+	// slack in the queue for fast workers to pick up a straggler's share.
+	chunksPerWorker = 8
+)
+
+// chunkQueue hands out consecutive [start, end) byte ranges of a file to
+// workers on demand. Workers that finish early simply pull more chunks, so
+// a slow region of the file or an unlucky thread no longer holds up the
+// whole run the way a static fileSize/NumCPU split does.
+//
+// A queue over a local file or an io.ReaderAt is aligned: its boundaries
+// were snapped to line starts up front (see StrategyOptions.chunkQueue),
+// so each chunk holds whole lines. A remote object's queue hands out raw
+// byte offsets instead, sparing a request per boundary, and a line then
+// belongs to the chunk that contains its first byte.
+type chunkQueue struct {
+	next      atomic.Int64
+	fileSize  int64
+	chunkSize int64
+	bounds    []int64 // chunk boundaries of an aligned queue
+	picked    []int64 // indexes of the chunks a sampled queue hands out
+}
+
+func newChunkQueue(fileSize, chunkSize int64) *chunkQueue {
+	return &chunkQueue{
+		fileSize:  fileSize,
+		chunkSize: max(chunkSize, 1),
+	}
+}
+
+// newAlignedChunkQueue returns a queue of the chunks between consecutive
+// bounds, which run from 0 to the file size and are otherwise line starts.
+func newAlignedChunkQueue(bounds []int64) *chunkQueue {
+	return &chunkQueue{fileSize: bounds[len(bounds)-1], bounds: bounds}
+}
+
+// pop claims the next chunk. It returns ok == false once the file, or a
+// sampled queue's picks, are exhausted.
+func (q *chunkQueue) pop() (start, end int64, ok bool) {
+	if q.picked != nil {
+		j := q.next.Add(1) - 1
+		if j >= int64(len(q.picked)) {
+			return 0, 0, false
+		}
+		start, end = q.chunk(q.picked[j])
+		return start, end, true
+	}
+	if q.bounds != nil {
+		i := q.next.Add(1)
+		if i >= int64(len(q.bounds)) {
+			return 0, 0, false
+		}
+		return q.bounds[i-1], q.bounds[i], true
+	}
+	start = q.next.Add(q.chunkSize) - q.chunkSize
+	if start >= q.fileSize {
+		return 0, 0, false
+	}
+	return start, min(start+q.chunkSize, q.fileSize), true
+}
+
+// chunks returns the number of chunks in the queue's file.
+func (q *chunkQueue) chunks() int64 {
+	if q.bounds != nil {
+		return int64(len(q.bounds) - 1)
+	}
+	return (q.fileSize + q.chunkSize - 1) / q.chunkSize
+}
+
+// index returns the index of the chunk starting at start.
+func (q *chunkQueue) index(start int64) int64 {
+	if q.bounds != nil {
+		i, _ := slices.BinarySearch(q.bounds, start)
+		return int64(i)
+	}
+	return start / q.chunkSize
+}
+
+// chunk returns the byte range of the i-th chunk.
+func (q *chunkQueue) chunk(i int64) (start, end int64) {
+	if q.bounds != nil {
+		return q.bounds[i], q.bounds[i+1]
+	}
+	start = i * q.chunkSize
+	return start, min(start+q.chunkSize, q.fileSize)
+}
+
+// chunkSize returns ChunkSize if set, and otherwise sizes chunks so that
+// each of the workers gets around chunksPerWorker of them, or of the chunks
+// sampled.
+func (o StrategyOptions) chunkSize(fileSize int64, workers int) int64 {
+	if o.ChunkSize > 0 {
+		return int64(o.ChunkSize)
+	}
+	if o.sampling() {
+		// Keep the sampled chunks, rather than all of them, around
+		// chunksPerWorker per worker.
+		fileSize = int64(float64(fileSize) * o.SampleFraction)
+	}
+	size := fileSize / int64(workers*chunksPerWorker)
+	return min(max(size, minChunkSize), maxChunkSize)
+}
@@ -0,0 +1,431 @@
+package strategies
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+// rangesPerClusterWorker is how many byte ranges each worker machine gets
+// on average, so a fast machine can take a slow one's share and a machine
+// that drops out only loses a range in flight.
+const rangesPerClusterWorker = 8
+
+// clusterTokenKey is the gRPC metadata key coordinators send the shared
+// token under.
+const clusterTokenKey = "authorization"
+
+// clusterMaxMessage bounds the partials a coordinator accepts: one holds
+// every station of its range, more than gRPC's 4 MiB default allows.
+const clusterMaxMessage = 1 << 30
+
+// ClusterRange asks a cluster worker to aggregate the lines of Path that
+// start in [Start, End). It and ClusterPartial are the messages
+// ClusterStrategy and ServeClusterWorker exchange over gRPC.
+type ClusterRange struct {
+	Path       string
+	Start, End int64
+	Size       int64 // size of Path on the coordinator
+	Format     RecordFormat
+	ParseMode  ParseMode
+}
+
+// ClusterPartial is a worker's aggregates of one ClusterRange. In strict
+// mode a malformed line is reported in the Bad fields instead of an error,
+// so the coordinator can rebuild the *ParseError.
+type ClusterPartial struct {
+	Stations    []StationResult
+	Bytes, Rows int64
+	Malformed   int64
+
+	Bad              bool
+	BadLine, BadByte int64
+	BadText          string
+}
+
+// ClusterWorkerConfig limits which coordinators a cluster worker answers
+// and what they may have it read.
+type ClusterWorkerConfig struct {
+	// Token is the secret coordinators must send; it is required. It
+	// travels in the clear, so workers belong on a trusted network.
+	Token string
+
+	// DataRoot is the directory the worker reads files from; it is
+	// required. Relative paths are taken from it, and absolute ones that
+	// lead outside it, symbolic links included, are refused.
+	DataRoot string
+
+	// AllowURLs lets coordinators have the worker fetch http(s), s3 and
+	// gs URLs. Without it only files under DataRoot are read.
+	AllowURLs bool
+}
+
+// clusterCodec carries the cluster messages as gob, so the service needs
+// no generated protobuf code.
+type clusterCodec struct{}
+
+func (clusterCodec) Marshal(v any) ([]byte, error) {
+	var b bytes.Buffer
+	err := gob.NewEncoder(&b).Encode(v)
+	return b.Bytes(), err
+}
+
+func (clusterCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (clusterCodec) Name() string { return "gob" }
+
+// clusterService is the handler type of clusterServiceDesc.
+type clusterService interface {
+	aggregate(ctx context.Context, r ClusterRange) (*ClusterPartial, error)
+}
+
+// clusterServiceDesc describes the one-method gRPC service of a cluster
+// worker, written out by hand in place of protoc output.
+var clusterServiceDesc = grpc.ServiceDesc{
+	ServiceName: "onebillion.ClusterWorker",
+	HandlerType: (*clusterService)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Aggregate",
+		Handler: func(srv any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+			var r ClusterRange
+			if err := dec(&r); err != nil {
+				return nil, err
+			}
+			return srv.(clusterService).aggregate(ctx, r)
+		},
+	}},
+}
+
+// clusterAggregateMethod is the full name coordinators call.
+const clusterAggregateMethod = "/onebillion.ClusterWorker/Aggregate"
+
+// clusterWorker is the gRPC service behind ServeClusterWorker.
+type clusterWorker struct {
+	cfg  ClusterWorkerConfig
+	root string // cfg.DataRoot, absolute and with symbolic links resolved
+	opts StrategyOptions
+}
+
+// ServeClusterWorker answers ClusterStrategy coordinators on lis until ctx
+// is cancelled, aggregating every range it is sent with the workers, buffer
+// and chunk sizes of opts. Only coordinators sending cfg.Token are
+// answered, and only paths under cfg.DataRoot (or, with cfg.AllowURLs,
+// URLs) are read, so they must name the same file as on the coordinator,
+// on a shared filesystem or a copy. The worker must parse the same
+// RecordFormat as the coordinator.
+func ServeClusterWorker(ctx context.Context, lis net.Listener, cfg ClusterWorkerConfig, opts StrategyOptions) error {
+	if cfg.Token == "" {
+		return errors.New("cluster worker needs a token")
+	}
+	if cfg.DataRoot == "" {
+		return errors.New("cluster worker needs a data root")
+	}
+	root, err := filepath.Abs(cfg.DataRoot)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		return err
+	}
+
+	server := grpc.NewServer(grpc.ForceServerCodec(clusterCodec{}))
+	server.RegisterService(&clusterServiceDesc, &clusterWorker{cfg, root, opts})
+	stop := context.AfterFunc(ctx, server.Stop)
+	defer stop()
+	if err := server.Serve(lis); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// authorize checks that the caller sent the worker's token.
+func (w *clusterWorker) authorize(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	want := []byte("Bearer " + w.cfg.Token)
+	for _, got := range md.Get(clusterTokenKey) {
+		if subtle.ConstantTimeCompare([]byte(got), want) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or wrong cluster token")
+}
+
+// resolve returns the file or URL a coordinator's path names on this
+// worker, refusing URLs unless they are allowed and files outside the
+// data root.
+func (w *clusterWorker) resolve(path string) (string, error) {
+	if IsRemote(path) {
+		if !w.cfg.AllowURLs {
+			return "", status.Errorf(codes.PermissionDenied, "%s: this worker does not read URLs", path)
+		}
+		return path, nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(w.root, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", status.Error(codes.NotFound, err.Error())
+	}
+	if rel, err := filepath.Rel(w.root, resolved); err != nil || !filepath.IsLocal(rel) {
+		return "", status.Errorf(codes.PermissionDenied, "%s is outside this worker's data root", path)
+	}
+	return resolved, nil
+}
+
+func (w *clusterWorker) aggregate(ctx context.Context, r ClusterRange) (*ClusterPartial, error) {
+	if err := w.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if r.Format != recordFormat {
+		return nil, status.Errorf(codes.FailedPrecondition, "worker reads %q with %d decimals, coordinator %q with %d; start both with the same format",
+			recordFormat.Delimiter, recordFormat.FractionDigits, r.Format.Delimiter, r.Format.FractionDigits)
+	}
+	path, err := w.resolve(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	opts := w.opts
+	opts.ParseMode = r.ParseMode
+
+	src, err := openChunkSource(ctx, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	if src.size() != r.Size {
+		return nil, status.Errorf(codes.FailedPrecondition, "%s is %d bytes on this worker and %d on the coordinator", r.Path, src.size(), r.Size)
+	}
+
+	var p progress
+	var m malformedLines
+	m.resetMalformed(opts)
+	tempMaps := make([]StationMap, opts.workers())
+	err = scanChunks(ctx, rangeSource{src, r.Start, r.End}, opts, &p, &m, func(worker int) lineSink {
+		tempMaps[worker] = make(StationMap, opts.mapCapacity())
+		return mapSink{tempMaps[worker], opts.hash()}
+	})
+	partial := &ClusterPartial{}
+	var perr *ParseError
+	if errors.As(err, &perr) {
+		locateParseError(path, perr)
+		partial.Bad = true
+		partial.BadLine, partial.BadByte, partial.BadText = perr.Line, perr.Offset, perr.Text
+		return partial, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, res := range mergeMaps(tempMaps) {
+		partial.Stations = append(partial.Stations, res)
+	}
+	partial.Bytes, partial.Rows, partial.Malformed = p.BytesRead(), p.RowsParsed(), m.MalformedLines()
+	return partial, nil
+}
+
+// rangeSource limits a chunkSource to the lines starting in [start, end).
+type rangeSource struct {
+	chunkSource
+	start, end int64
+}
+
+func (s rangeSource) size() int64 { return s.end - s.start }
+
+func (s rangeSource) queue(chunkSize int64) (*chunkQueue, error) {
+	q := newChunkQueue(s.end, chunkSize)
+	q.next.Store(s.start)
+	return q, nil
+}
+
+// ClusterStrategy coordinates worker processes on other machines, started
+// with ServeClusterWorker, so a file can be aggregated with more memory
+// bandwidth than one machine has. It splits the file into byte ranges,
+// hands them out to the workers as they finish the previous one, and
+// merges the partial aggregates they send back by station name.
+//
+// A worker that cannot be reached or drops its connection is left out and
+// its range given to another; the run fails only when none are left. Any
+// other error, including a malformed line in strict mode or a worker
+// refusing the token or path, ends the run.
+type ClusterStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	addrs []string
+	token string
+	opts  StrategyOptions
+}
+
+// NewClusterStrategy returns a ClusterStrategy sending ranges to the
+// workers listening at addrs with their shared token, configured with
+// opts. Only ChunkSize and ParseMode apply on the coordinator; ChunkSize,
+// if set, is the size of the ranges.
+func NewClusterStrategy(addrs []string, token string, opts StrategyOptions) *ClusterStrategy {
+	return &ClusterStrategy{addrs: addrs, token: token, opts: opts}
+}
+
+func (c *ClusterStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	c.resetProgress()
+	defer c.reportProgress(c.opts)()
+	c.resetMalformed(c.opts)
+
+	if len(c.addrs) == 0 {
+		return nil, errors.New("no cluster workers")
+	}
+	fsize, err := clusterFileSize(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	rangeSize := int64(c.opts.ChunkSize)
+	if rangeSize <= 0 {
+		rangeSize = max(fsize/int64(len(c.addrs)*rangesPerClusterWorker), minChunkSize)
+	}
+	pending := make(chan ClusterRange, fsize/rangeSize+1)
+	for start := int64(0); start < fsize; start += rangeSize {
+		pending <- ClusterRange{
+			Path:      filePath,
+			Start:     start,
+			End:       min(start+rangeSize, fsize),
+			Size:      fsize,
+			Format:    recordFormat,
+			ParseMode: c.opts.ParseMode,
+		}
+	}
+	var left atomic.Int64
+	left.Store(int64(len(pending)))
+	done := make(chan struct{})
+	if left.Load() == 0 {
+		close(done)
+	}
+
+	// A fatal error on one worker stops the others.
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	tempMaps := make([]map[string]StationResult, len(c.addrs))
+	errs := make([]error, len(c.addrs))
+	var fatal atomic.Pointer[error]
+
+	var wg sync.WaitGroup
+	wg.Add(len(c.addrs))
+	for i, addr := range c.addrs {
+		tempMaps[i] = make(map[string]StationResult)
+		go func(i int, addr string) {
+			defer wg.Done()
+			err := c.drive(runCtx, addr, pending, done, &left, tempMaps[i])
+			var perr *ParseError
+			switch {
+			case err == nil || runCtx.Err() != nil:
+			case errors.As(err, &perr):
+				fatal.CompareAndSwap(nil, &err)
+				stop()
+			case status.Code(err) == codes.Unavailable:
+				// The worker is gone; the others take its ranges.
+				errs[i] = fmt.Errorf("cluster worker %s: %w", addr, err)
+			default:
+				err = fmt.Errorf("cluster worker %s: %w", addr, err)
+				fatal.CompareAndSwap(nil, &err)
+				stop()
+			}
+		}(i, addr)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := fatal.Load(); err != nil {
+		return nil, *err
+	}
+	if left.Load() > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return emitResults(&c.resultEmitter, tempMaps...), nil
+}
+
+// drive sends ranges from pending to the worker at addr until every range
+// is done, merging the worker's partials into stationMap. A range the
+// worker did not answer goes back to pending.
+func (c *ClusterStrategy) drive(ctx context.Context, addr string, pending chan ClusterRange, done chan struct{}, left *atomic.Int64, stationMap map[string]StationResult) error {
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(clusterCodec{}), grpc.MaxCallRecvMsgSize(clusterMaxMessage)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx = metadata.AppendToOutgoingContext(ctx, clusterTokenKey, "Bearer "+c.token)
+
+	for {
+		var r ClusterRange
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-done:
+			return nil
+		case r = <-pending:
+		}
+
+		var partial ClusterPartial
+		if err := conn.Invoke(ctx, clusterAggregateMethod, r, &partial); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if status.Code(err) == codes.Unavailable {
+				pending <- r
+			}
+			return err
+		}
+		if partial.Bad {
+			err := newParseError(partial.BadByte, []byte(partial.BadText))
+			err.Line = partial.BadLine
+			return err
+		}
+
+		for _, res := range partial.Stations {
+			if existing, ok := stationMap[res.StationID]; ok {
+				res = mergeResult(existing, res)
+			}
+			stationMap[res.StationID] = res
+		}
+		c.bytesRead.Add(partial.Bytes)
+		c.rowsParsed.Add(partial.Rows)
+		c.count.Add(partial.Malformed)
+		if left.Add(-1) == 0 {
+			close(done)
+		}
+	}
+}
+
+// clusterFileSize returns the size of the local file or remote object the
+// workers are to read.
+func clusterFileSize(ctx context.Context, filePath string) (int64, error) {
+	if IsRemote(filePath) {
+		return RemoteSize(ctx, filePath)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0, err
+	}
+	if !info.Mode().IsRegular() {
+		return 0, fmt.Errorf("%s: %w", filePath, ErrNotSeekable)
+	}
+	return info.Size(), nil
+}
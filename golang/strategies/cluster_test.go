@@ -0,0 +1,136 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testClusterToken is the token the test workers and coordinators share.
+const testClusterToken = "test-token"
+
+// startClusterWorker serves a cluster worker reading files under root on
+// a loopback port for the rest of the test and returns its address.
+func startClusterWorker(t *testing.T, root string, opts StrategyOptions) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() {
+		served <- ServeClusterWorker(ctx, lis, ClusterWorkerConfig{Token: testClusterToken, DataRoot: root}, opts)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-served; err != nil {
+			t.Error(err)
+		}
+	})
+	return lis.Addr().String()
+}
+
+// deadAddr returns a loopback address nothing listens on.
+func deadAddr(t *testing.T) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	return addr
+}
+
+func TestClusterStrategyMergesWorkerRanges(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+	workerOpts := StrategyOptions{Workers: 2, BufferSize: 256, ChunkSize: 1000}
+	root := filepath.Dir(path)
+	addrs := []string{startClusterWorker(t, root, workerOpts), startClusterWorker(t, root, workerOpts), startClusterWorker(t, root, workerOpts)}
+
+	s := NewClusterStrategy(addrs, testClusterToken, StrategyOptions{ChunkSize: 4096})
+	checkAggregates(t, s, path, want)
+	if got := s.RowsParsed(); got != 20_000 {
+		t.Errorf("parsed %d rows, want 20000", got)
+	}
+
+	// Ranges of a worker that is down go to the others.
+	s = NewClusterStrategy([]string{deadAddr(t), addrs[0]}, testClusterToken, StrategyOptions{ChunkSize: 4096})
+	checkAggregates(t, s, path, want)
+
+	s = NewClusterStrategy([]string{deadAddr(t)}, testClusterToken, StrategyOptions{})
+	if _, err := s.Calculate(t.Context(), path); err == nil {
+		t.Error("no error with every worker down")
+	}
+}
+
+func TestClusterStrategyReportsWorkerParseErrors(t *testing.T) {
+	path, _ := writeRefillDataset(t, 5_000, 200)
+	insertLines(t, path, []int{3210}, []string{"Oslo;12,3"})
+	addr := startClusterWorker(t, filepath.Dir(path), StrategyOptions{Workers: 2, ChunkSize: 1000})
+
+	s := NewClusterStrategy([]string{addr}, testClusterToken, StrategyOptions{ChunkSize: 8192, ParseMode: ParseStrict})
+	_, err := s.Calculate(t.Context(), path)
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("got error %v, want a *ParseError", err)
+	}
+	if perr.Line != 3211 || perr.Text != "Oslo;12,3" {
+		t.Errorf("got line %d %q, want line 3211 %q", perr.Line, perr.Text, "Oslo;12,3")
+	}
+
+	// Lenient workers count the line instead.
+	s = NewClusterStrategy([]string{addr}, testClusterToken, StrategyOptions{ChunkSize: 8192})
+	if _, err := s.Calculate(t.Context(), path); err != nil || s.MalformedLines() != 1 {
+		t.Errorf("got %d malformed lines, %v; want 1", s.MalformedLines(), err)
+	}
+}
+
+func TestClusterWorkerRefusesUnauthorizedRequests(t *testing.T) {
+	path, _ := writeRefillDataset(t, 1_000, 50)
+	root := filepath.Dir(path)
+	addr := startClusterWorker(t, root, StrategyOptions{Workers: 2})
+
+	outside := filepath.Join(t.TempDir(), "outside.txt")
+	if err := os.WriteFile(outside, []byte("Oslo;1.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(root, "link.txt")
+	if err := os.Symlink(outside, link); err != nil {
+		link = outside // no symbolic links here, as on Windows without privileges
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, path)
+	}))
+	defer server.Close()
+	// Relative paths name the same file on the coordinator and the worker.
+	t.Chdir(root)
+
+	tests := []struct {
+		name, token, path, want string
+	}{
+		{"no token", "", path, "token"},
+		{"wrong token", "guess", path, "token"},
+		{"file outside the data root", testClusterToken, outside, "outside"},
+		{"relative path out of the data root", testClusterToken, filepath.Join("..", filepath.Base(filepath.Dir(outside)), "outside.txt"), "outside"},
+		{"symbolic link out of the data root", testClusterToken, link, "outside"},
+		{"URL", testClusterToken, server.URL + "/measurements.txt", "URLs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewClusterStrategy([]string{addr}, tt.token, StrategyOptions{})
+			if _, err := s.Calculate(t.Context(), tt.path); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got error %v, want one about %s", err, tt.want)
+			}
+		})
+	}
+}
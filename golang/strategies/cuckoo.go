@@ -0,0 +1,230 @@
+package strategies
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+const (
+	// cuckooMaxKicks bounds how many entries one insert may displace
+	// before the last one evicted is parked in the overflow stash instead.
+	cuckooMaxKicks = 256
+
+	// cuckooMaxStash is how many keys the stash holds before the table
+	// doubles and places them again.
+	cuckooMaxStash = 8
+
+	// cuckooMaxLoadFactor caps opts.maxLoadFactor(): with two candidate
+	// slots per key, inserts start failing soon after half the slots fill.
+	cuckooMaxLoadFactor = 0.5
+)
+
+// CuckooStrategy aggregates into per-worker cuckoo hash tables. Every key
+// has exactly two candidate slots, one per hash function, so a lookup
+// touches at most two slots no matter how full the table is. Inserts evict
+// occupants to their alternate slot, up to cuckooMaxKicks times, after
+// which the homeless entry goes to a small stash that is searched last.
+// The table doubles when the stash or the load factor outgrows its bound.
+type CuckooStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	probeRecorder
+	opts StrategyOptions
+}
+
+// NewCuckooStrategy returns a CuckooStrategy configured with opts.
+func NewCuckooStrategy(opts StrategyOptions) *CuckooStrategy {
+	return &CuckooStrategy{opts: opts}
+}
+
+func (c *CuckooStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	return c.aggregate(ctx, pathInput(filePath))
+}
+
+func (c *CuckooStrategy) CalculateReaderAt(ctx context.Context, r io.ReaderAt, size int64) ([]StationResult, error) {
+	return c.aggregate(ctx, readerAtInput(r, size))
+}
+
+func (c *CuckooStrategy) aggregate(ctx context.Context, in input) ([]StationResult, error) {
+	return runTableStrategy(ctx, in, c.opts, &c.progress, &c.malformedLines, &c.probeRecorder, &c.resultEmitter, func() stationTable {
+		return newCuckooTable(c.opts)
+	})
+}
+
+type cuckooSlot struct {
+	name                 []byte
+	h1, h2               uint32
+	used                 bool
+	sum, count, min, max int64
+}
+
+// cuckooTable starts at opts.tableSize() slots and doubles once
+// opts.maxLoadFactor(), at most cuckooMaxLoadFactor, of them are in use, or
+// once more than cuckooMaxStash keys are stashed.
+type cuckooTable struct {
+	slots  []cuckooSlot
+	stash  []cuckooSlot
+	mask   uint32
+	keys   nameArena
+	hash   HashFunc
+	used   int
+	growAt int
+	opts   StrategyOptions
+}
+
+func newCuckooTable(opts StrategyOptions) *cuckooTable {
+	t := &cuckooTable{hash: opts.hash(), opts: opts}
+	t.resize(opts.tableSize())
+	return t
+}
+
+// hashCuckoo is the second, independent hash function: a multiplicative
+// mix in the style of MurmurHash2.
+func hashCuckoo(name []byte) uint32 {
+	h := uint32(0x9747b28c)
+	for _, c := range name {
+		h = (h ^ uint32(c)) * 0x5bd1e995
+		h ^= h >> 15
+	}
+	return h
+}
+
+func (t *cuckooTable) addLine(line []byte) bool {
+	name, value, err := parseLineByte(line)
+	if err != nil {
+		return false
+	}
+	t.add(name, value)
+	return true
+}
+
+func (t *cuckooTable) add(name []byte, value int64) {
+	h1 := hashKey(t.hash, name)
+	if s := &t.slots[h1&t.mask]; s.used && s.h1 == h1 && bytes.Equal(s.name, name) {
+		s.update(value)
+		return
+	}
+	h2 := hashCuckoo(name)
+	if s := &t.slots[h2&t.mask]; s.used && s.h1 == h1 && bytes.Equal(s.name, name) {
+		s.update(value)
+		return
+	}
+	for i := range t.stash {
+		if s := &t.stash[i]; s.h1 == h1 && bytes.Equal(s.name, name) {
+			s.update(value)
+			return
+		}
+	}
+
+	t.insert(cuckooSlot{
+		name: t.keys.own(name), h1: h1, h2: h2, used: true,
+		sum: value, count: 1, min: value, max: value,
+	})
+}
+
+// insert places a new key, doubling the table once it is full enough or
+// its stash is. A stash that overflows in a mostly empty table holds keys
+// whose two hashes both collide, which a larger table cannot tell apart,
+// so it is left to grow instead.
+func (t *cuckooTable) insert(e cuckooSlot) {
+	t.place(e)
+	t.used++
+	if t.used >= t.growAt || len(t.stash) > cuckooMaxStash && 4*t.used > len(t.slots) {
+		t.resize(2 * len(t.slots))
+	}
+}
+
+// resize moves every key, stashed ones included, into a fresh table of
+// size slots, which must be a power of two.
+func (t *cuckooTable) resize(size int) {
+	old, stash := t.slots, t.stash
+	t.slots, t.stash = make([]cuckooSlot, size), nil
+	adviseHugePages(t.opts, t.slots)
+	t.mask = uint32(size - 1)
+	t.growAt = max(int(float64(size)*min(t.opts.maxLoadFactor(), cuckooMaxLoadFactor)), 1)
+
+	for _, e := range old {
+		if e.used {
+			t.place(e)
+		}
+	}
+	for _, e := range stash {
+		t.place(e)
+	}
+}
+
+// place puts e in one of its two slots, evicting occupants to their other
+// slot, or in the stash.
+func (t *cuckooTable) place(e cuckooSlot) {
+	pos := e.h1 & t.mask
+	if t.slots[pos].used {
+		if alt := e.h2 & t.mask; !t.slots[alt].used {
+			pos = alt
+		}
+	}
+
+	for range cuckooMaxKicks {
+		slot := &t.slots[pos]
+		if !slot.used {
+			*slot = e
+			return
+		}
+		*slot, e = e, *slot
+		if pos == e.h1&t.mask {
+			pos = e.h2 & t.mask
+		} else {
+			pos = e.h1 & t.mask
+		}
+	}
+	t.stash = append(t.stash, e)
+}
+
+func (s *cuckooSlot) update(value int64) {
+	s.sum += value
+	s.count++
+	s.min = min(s.min, value)
+	s.max = max(s.max, value)
+}
+
+func (t *cuckooTable) flushInto(smap StationMap, names *internTable) {
+	flush := func(s *cuckooSlot) {
+		storeStation(smap, s.h1, StationResult{
+			StationID: names.intern(s.name),
+			Sum:       s.sum,
+			Count:     s.count,
+			Maximum:   s.max,
+			Minimum:   s.min,
+		})
+	}
+	for i := range t.slots {
+		if t.slots[i].used {
+			flush(&t.slots[i])
+		}
+	}
+	for i := range t.stash {
+		flush(&t.stash[i])
+	}
+}
+
+// probeStats counts slots checked by a lookup: 1 for a key in its first
+// slot, 2 in its second, and 3 plus its position for stashed keys.
+func (t *cuckooTable) probeStats() ProbeStats {
+	c := collectProbeStats{slots: len(t.slots)}
+	for i := range t.slots {
+		s := &t.slots[i]
+		if !s.used {
+			continue
+		}
+		if uint32(i) == s.h1&t.mask {
+			c.add(1)
+		} else {
+			c.add(2)
+		}
+	}
+	for i := range t.stash {
+		c.add(3 + i)
+	}
+	return c.stats()
+}
@@ -0,0 +1,20 @@
+package strategies
+
+import "testing"
+
+func TestCuckooGrowsInsteadOfStashing(t *testing.T) {
+	path, want := writeRefillDataset(t, 50_000, 500)
+
+	// 16 slots for 500 keys: the table must double rather than stash
+	// the keys it cannot place.
+	s := NewCuckooStrategy(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 8192, TableSize: 16})
+	checkAggregates(t, s, path, want)
+
+	stats := s.ProbeStats()
+	if lf := stats.LoadFactor(); lf > cuckooMaxLoadFactor {
+		t.Errorf("load factor = %.2f, want at most %.2f", lf, cuckooMaxLoadFactor)
+	}
+	if stats.Max > 2+cuckooMaxStash {
+		t.Errorf("max probe = %d, want at most %d", stats.Max, 2+cuckooMaxStash)
+	}
+}
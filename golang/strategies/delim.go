@@ -0,0 +1,42 @@
+package strategies
+
+import "bytes"
+
+// indexSemicolonNewline returns the index of the first ';' and the first
+// '\n' in b, each -1 when absent. On amd64 with AVX2 and on arm64 both are
+// found in a single pass over 32-byte vectors that stops once both have
+// turned up; elsewhere it falls back to indexSemicolonNewlineGeneric. A
+// line without a ';' shows up as semi > newline or semi == -1.
+func indexSemicolonNewline(b []byte) (semi, newline int) {
+	return indexSemicolonNewlineArch(b)
+}
+
+// indexSemicolonNewlineGeneric is the portable version: two IndexByte
+// calls, each vectorised by the runtime but each a separate pass.
+func indexSemicolonNewlineGeneric(b []byte) (semi, newline int) {
+	return bytes.IndexByte(b, ';'), bytes.IndexByte(b, '\n')
+}
+
+// indexDelimiterNewline is indexSemicolonNewline for the configured field
+// delimiter: the one-pass search for the default ';', two IndexByte calls
+// for any other.
+func indexDelimiterNewline(b []byte) (sep, newline int) {
+	if recordFormat.Delimiter == ';' {
+		return indexSemicolonNewline(b)
+	}
+	return bytes.IndexByte(b, recordFormat.Delimiter), bytes.IndexByte(b, '\n')
+}
+
+// nextLine cuts the first line off b, without its newline, and returns it
+// with the index of its field delimiter (-1 if it has none) and the index
+// of the newline, which is -1 when b holds no complete line.
+func nextLine(b []byte) (line []byte, sep, newline int) {
+	sep, newline = indexDelimiterNewline(b)
+	if newline == -1 {
+		return b, sep, -1
+	}
+	if sep > newline {
+		sep = -1
+	}
+	return b[:newline], sep, newline
+}
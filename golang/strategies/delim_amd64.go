@@ -0,0 +1,39 @@
+package strategies
+
+// hasAVX2 reports whether the CPU supports AVX2 and the OS saves the YMM
+// registers across context switches.
+var hasAVX2 = detectAVX2()
+
+func detectAVX2() bool {
+	maxLeaf, _, _, _ := cpuid(0, 0)
+	if maxLeaf < 7 {
+		return false
+	}
+	_, _, ecx1, _ := cpuid(1, 0)
+	const osxsave, avx = 1 << 27, 1 << 28
+	if ecx1&osxsave == 0 || ecx1&avx == 0 {
+		return false
+	}
+	// XCR0 bits 1 and 2: XMM and YMM state enabled by the OS.
+	if xcr0, _ := xgetbv(); xcr0&6 != 6 {
+		return false
+	}
+	_, ebx7, _, _ := cpuid(7, 0)
+	return ebx7&(1<<5) != 0
+}
+
+func indexSemicolonNewlineArch(b []byte) (semi, newline int) {
+	if !hasAVX2 || len(b) < 32 {
+		return indexSemicolonNewlineGeneric(b)
+	}
+	return indexSemicolonNewlineAVX2(b)
+}
+
+// indexSemicolonNewlineAVX2 requires len(b) >= 32.
+//
+//go:noescape
+func indexSemicolonNewlineAVX2(b []byte) (semi, newline int)
+
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+func xgetbv() (eax, edx uint32)
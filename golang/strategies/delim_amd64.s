@@ -0,0 +1,86 @@
+#include "textflag.h"
+
+// func indexSemicolonNewlineAVX2(b []byte) (semi, newline int)
+//
+// Compares 32 bytes at a time against ';' and '\n' and keeps the first hit
+// of each. The last, partial block is handled by rescanning the final 32
+// bytes: everything before the previous block end is known to hold no
+// unmatched delimiter, so the first hit in the overlap is still the first
+// in b.
+TEXT ·indexSemicolonNewlineAVX2(SB), NOSPLIT, $0-40
+	MOVQ b_base+0(FP), SI
+	MOVQ b_len+8(FP), BX
+	MOVQ $-1, R8 // semi
+	MOVQ $-1, R9 // newline
+	XORQ DI, DI  // offset of the current block
+
+	MOVL $0x3b, AX
+	VMOVQ AX, X0
+	VPBROADCASTB X0, Y0
+	MOVL $0x0a, AX
+	VMOVQ AX, X1
+	VPBROADCASTB X1, Y1
+
+block:
+	VMOVDQU (SI)(DI*1), Y2
+
+	TESTQ R8, R8
+	JNS   semidone
+	VPCMPEQB  Y0, Y2, Y3
+	VPMOVMSKB Y3, DX
+	TESTL     DX, DX
+	JZ        semidone
+	BSFL      DX, DX
+	ADDQ      DI, DX
+	MOVQ      DX, R8
+
+semidone:
+	TESTQ R9, R9
+	JNS   nldone
+	VPCMPEQB  Y1, Y2, Y3
+	VPMOVMSKB Y3, DX
+	TESTL     DX, DX
+	JZ        nldone
+	BSFL      DX, DX
+	ADDQ      DI, DX
+	MOVQ      DX, R9
+
+nldone:
+	MOVQ R8, AX
+	ORQ  R9, AX
+	JNS  done // both found
+
+	ADDQ $32, DI
+	CMPQ DI, BX
+	JAE  done
+	LEAQ 32(DI), AX
+	CMPQ AX, BX
+	JBE  block
+	MOVQ BX, DI
+	SUBQ $32, DI // overlap the final block with the previous one
+	JMP  block
+
+done:
+	VZEROUPPER
+	MOVQ R8, semi+24(FP)
+	MOVQ R9, newline+32(FP)
+	RET
+
+// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+TEXT ·cpuid(SB), NOSPLIT, $0-24
+	MOVL eaxArg+0(FP), AX
+	MOVL ecxArg+4(FP), CX
+	CPUID
+	MOVL AX, eax+8(FP)
+	MOVL BX, ebx+12(FP)
+	MOVL CX, ecx+16(FP)
+	MOVL DX, edx+20(FP)
+	RET
+
+// func xgetbv() (eax, edx uint32)
+TEXT ·xgetbv(SB), NOSPLIT, $0-8
+	MOVL $0, CX
+	XGETBV
+	MOVL AX, eax+0(FP)
+	MOVL DX, edx+4(FP)
+	RET
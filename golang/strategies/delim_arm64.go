@@ -0,0 +1,13 @@
+package strategies
+
+func indexSemicolonNewlineArch(b []byte) (semi, newline int) {
+	if len(b) < 32 {
+		return indexSemicolonNewlineGeneric(b)
+	}
+	return indexSemicolonNewlineNEON(b)
+}
+
+// indexSemicolonNewlineNEON requires len(b) >= 32.
+//
+//go:noescape
+func indexSemicolonNewlineNEON(b []byte) (semi, newline int)
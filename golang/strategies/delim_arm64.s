@@ -0,0 +1,125 @@
+#include "textflag.h"
+
+// func indexSemicolonNewlineNEON(b []byte) (semi, newline int)
+//
+// Compares 32 bytes at a time, as two 16-byte vectors, against ';' and
+// '\n' and keeps the first hit of each. A matching lane is 0xff, so the
+// index of the first hit within a 64-bit half is its trailing zero count
+// divided by eight. The last, partial block is handled by rescanning the
+// final 32 bytes: everything before the previous block end is known to
+// hold no unmatched delimiter, so the first hit in the overlap is still the
+// first in b.
+TEXT ·indexSemicolonNewlineNEON(SB), NOSPLIT, $0-40
+	MOVD b_base+0(FP), R0
+	MOVD b_len+8(FP), R1
+	MOVD $-1, R8 // semi
+	MOVD $-1, R9 // newline
+	MOVD $0, R2  // offset of the current block
+
+	MOVD $0x3b, R3
+	VMOV R3, V0.B16
+	MOVD $0x0a, R3
+	VMOV R3, V1.B16
+
+block:
+	ADD  R0, R2, R4
+	VLD1 (R4), [V2.B16, V3.B16]
+
+	TBZ   $63, R8, semidone
+	VCMEQ V0.B16, V2.B16, V4.B16
+	VCMEQ V0.B16, V3.B16, V5.B16
+	VMOV  V4.D[0], R5
+	CBZ   R5, semilane1
+	RBIT  R5, R5
+	CLZ   R5, R5
+	ADD   R5>>3, R2, R5
+	MOVD  R5, R8
+	B     semidone
+
+semilane1:
+	VMOV  V4.D[1], R5
+	CBZ   R5, semilane2
+	RBIT  R5, R5
+	CLZ   R5, R5
+	ADD   R5>>3, R2, R5
+	ADD   $8, R5, R5
+	MOVD  R5, R8
+	B     semidone
+
+semilane2:
+	VMOV  V5.D[0], R5
+	CBZ   R5, semilane3
+	RBIT  R5, R5
+	CLZ   R5, R5
+	ADD   R5>>3, R2, R5
+	ADD   $16, R5, R5
+	MOVD  R5, R8
+	B     semidone
+
+semilane3:
+	VMOV  V5.D[1], R5
+	CBZ   R5, semidone
+	RBIT  R5, R5
+	CLZ   R5, R5
+	ADD   R5>>3, R2, R5
+	ADD   $24, R5, R5
+	MOVD  R5, R8
+
+semidone:
+	TBZ   $63, R9, nldone
+	VCMEQ V1.B16, V2.B16, V4.B16
+	VCMEQ V1.B16, V3.B16, V5.B16
+	VMOV  V4.D[0], R5
+	CBZ   R5, nllane1
+	RBIT  R5, R5
+	CLZ   R5, R5
+	ADD   R5>>3, R2, R5
+	MOVD  R5, R9
+	B     nldone
+
+nllane1:
+	VMOV  V4.D[1], R5
+	CBZ   R5, nllane2
+	RBIT  R5, R5
+	CLZ   R5, R5
+	ADD   R5>>3, R2, R5
+	ADD   $8, R5, R5
+	MOVD  R5, R9
+	B     nldone
+
+nllane2:
+	VMOV  V5.D[0], R5
+	CBZ   R5, nllane3
+	RBIT  R5, R5
+	CLZ   R5, R5
+	ADD   R5>>3, R2, R5
+	ADD   $16, R5, R5
+	MOVD  R5, R9
+	B     nldone
+
+nllane3:
+	VMOV  V5.D[1], R5
+	CBZ   R5, nldone
+	RBIT  R5, R5
+	CLZ   R5, R5
+	ADD   R5>>3, R2, R5
+	ADD   $24, R5, R5
+	MOVD  R5, R9
+
+nldone:
+	ORR R8, R9, R3
+	TBZ $63, R3, done // both found
+
+	ADD $32, R2, R2
+	CMP R1, R2
+	BHS done
+	ADD $32, R2, R3
+	CMP R1, R3
+	BLS block
+	SUB $32, R1, R2 // overlap the final block with the previous one
+	B   block
+
+done:
+	MOVD R8, semi+24(FP)
+	MOVD R9, newline+32(FP)
+	RET
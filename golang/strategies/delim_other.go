@@ -0,0 +1,7 @@
+//go:build !amd64 && !arm64
+
+package strategies
+
+func indexSemicolonNewlineArch(b []byte) (semi, newline int) {
+	return indexSemicolonNewlineGeneric(b)
+}
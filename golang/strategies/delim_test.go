@@ -0,0 +1,62 @@
+package strategies
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestIndexSemicolonNewlineMatchesGeneric(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	alphabet := []byte("abcdefgh;\n")
+
+	for n := range 200 {
+		for range 50 {
+			b := make([]byte, n)
+			for i := range b {
+				// Mostly letters, so delimiters land in every block position.
+				b[i] = alphabet[rng.Intn(len(alphabet)-2)]
+				if rng.Intn(n+1) == 0 {
+					b[i] = alphabet[len(alphabet)-2+rng.Intn(2)]
+				}
+			}
+			semi, newline := indexSemicolonNewline(b)
+			wantSemi, wantNewline := indexSemicolonNewlineGeneric(b)
+			if semi != wantSemi || newline != wantNewline {
+				t.Fatalf("indexSemicolonNewline(%q) = %d, %d, want %d, %d", b, semi, newline, wantSemi, wantNewline)
+			}
+		}
+	}
+}
+
+func TestNextLineParsesLikeParseLineByte(t *testing.T) {
+	defer SetRecordFormat(DefaultRecordFormat())
+	lines := []string{"Abha;12.3", "\xef\xbb\xbfBern;-1.0\r", "no delimiter", ";1.0", "São Paulo;4.5;6.7", "", "Oslo;"}
+
+	for _, delim := range []byte{';', ','} {
+		if err := SetRecordFormat(RecordFormat{Delimiter: delim, FractionDigits: 1}); err != nil {
+			t.Fatal(err)
+		}
+		var data []byte
+		for _, line := range lines {
+			data = append(data, strings.ReplaceAll(line, ";", string(delim))+"\n"...)
+		}
+		data = append(data, "tail"...)
+
+		for _, want := range lines {
+			line, sep, newline := nextLine(data)
+			if newline != len(line) {
+				t.Fatalf("%q: newline at %d, line %q", delim, newline, line)
+			}
+			name, value, err := parseSplitLine(line, sep)
+			wantName, wantValue, wantErr := parseLineByte(line)
+			if string(name) != string(wantName) || value != wantValue || (err == nil) != (wantErr == nil) {
+				t.Errorf("%q: line %q parsed as %q, %d, %v, want %q, %d, %v", delim, want, name, value, err, wantName, wantValue, wantErr)
+			}
+			data = data[newline+1:]
+		}
+		if line, _, newline := nextLine(data); newline != -1 || string(line) != "tail" {
+			t.Errorf("%q: incomplete line cut as %q, newline %d", delim, line, newline)
+		}
+	}
+}
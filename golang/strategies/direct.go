@@ -0,0 +1,158 @@
+//go:build linux || darwin
+
+package strategies
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"unsafe"
+)
+
+// directAlignment is the buffer, offset and length alignment O_DIRECT
+// requires. 4 KiB satisfies every common block device and filesystem.
+const directAlignment = 4096
+
+// DirectIOStrategy opens the file with O_DIRECT, or F_NOCACHE on macOS,
+// and reads it through aligned buffers, bypassing the page cache. Every
+// run therefore measures cold-disk performance without having to drop
+// caches first; on macOS, pages cached before the run are still used.
+// Work is scheduled from the shared chunk queue like the MCMP strategies.
+type DirectIOStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	opts StrategyOptions
+}
+
+// NewDirectIOStrategy returns a DirectIOStrategy configured with opts.
+func NewDirectIOStrategy(opts StrategyOptions) *DirectIOStrategy {
+	return &DirectIOStrategy{opts: opts}
+}
+
+func (d *DirectIOStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	d.resetProgress()
+	defer d.reportProgress(d.opts)()
+	d.resetMalformed(d.opts)
+	f, err := openDirect(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fsize, err := getFileSize(f)
+	if err != nil {
+		return nil, err
+	}
+	n := d.opts.workers()
+	chunkSize := d.opts.chunkSize(fsize, n)
+	// The short reads that snap chunk boundaries to lines break O_DIRECT's
+	// alignment rules, so they go through a handle of their own.
+	probe, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	queue, err := d.opts.chunkQueue(probe, fsize, chunkSize)
+	probe.Close()
+	if err != nil {
+		return nil, err
+	}
+	bufSize := directBufferSize(d.opts, chunkSize)
+
+	tempMaps := make([]StationMap, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := range n {
+		tempMaps[i] = make(StationMap, d.opts.mapCapacity())
+		go func(i int) {
+			defer wg.Done()
+			defer d.opts.pinWorker(i)()
+			buf := alignedBuffer(int(bufSize), directAlignment)
+
+			for start, end, ok := queue.pop(); ok; start, end, ok = queue.pop() {
+				src := newDirectSource(f, max(start-1, 0), buf)
+				if errs[i] = inChunk(consumeChunk(ctx, src, start, end, d.opts.sink(tempMaps[i]), &d.progress, &d.malformedLines), queue.index(start), i); errs[i] != nil {
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := firstError(errs); err != nil {
+		return nil, locateParseError(filePath, err)
+	}
+	return emitResults(&d.resultEmitter, tempMaps...), nil
+}
+
+// directSource is a blockSource issuing aligned reads. The first block is
+// trimmed so that it starts exactly at the requested offset.
+type directSource struct {
+	f      *os.File
+	buf    []byte
+	offset int64 // aligned offset of the next read
+	skip   int   // bytes to drop from the front of the next block
+	eof    bool
+}
+
+func newDirectSource(f *os.File, offset int64, buf []byte) *directSource {
+	aligned := offset &^ (directAlignment - 1)
+	return &directSource{f: f, buf: buf, offset: aligned, skip: int(offset - aligned)}
+}
+
+func (s *directSource) next() ([]byte, error) {
+	if s.eof {
+		return nil, io.EOF
+	}
+
+	n, err := s.f.ReadAt(s.buf, s.offset)
+	if err == io.EOF || (err == nil && n < len(s.buf)) {
+		s.eof = true
+	} else if err != nil {
+		return nil, err
+	}
+	s.offset += int64(n)
+
+	if n <= s.skip {
+		return nil, io.EOF
+	}
+	data := s.buf[s.skip:n]
+	s.skip = 0
+	return data, nil
+}
+
+func (s *directSource) release([]byte) {}
+
+// directBufferSize is the size of each worker's aligned read buffer: the
+// configured size, but no more than a chunk and its tail.
+func directBufferSize(opts StrategyOptions, chunkSize int64) int64 {
+	return alignUp(min(int64(opts.bufferSize(defaultBlockBufSize)), chunkSize+minTailRead), directAlignment)
+}
+
+func alignUp(n, align int64) int64 {
+	return (n + align - 1) &^ (align - 1)
+}
+
+// alignedBuffer returns a size-byte slice whose first byte sits on an
+// align-byte boundary, as O_DIRECT requires.
+func alignedBuffer(size, align int) []byte {
+	raw := make([]byte, size+align)
+	shift := 0
+	if rem := int(uintptr(unsafe.Pointer(&raw[0])) & uintptr(align-1)); rem != 0 {
+		shift = align - rem
+	}
+	return raw[shift : shift+size : shift+size]
+}
+
+// planDirectIO is the Entry.Plan of DirectIOStrategy.
+func planDirectIO(filePath string, opts StrategyOptions) (ChunkPlan, error) {
+	return planChunks(filePath, opts, 1, func(chunkSize int64) int64 {
+		return directBufferSize(opts, chunkSize)
+	}, opts.mapCapacity())
+}
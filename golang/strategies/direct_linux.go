@@ -0,0 +1,18 @@
+//go:build linux
+
+package strategies
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// openDirect opens filePath with O_DIRECT.
+func openDirect(filePath string) (*os.File, error) {
+	f, err := os.OpenFile(filePath, os.O_RDONLY|syscall.O_DIRECT, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open with O_DIRECT: %w", err)
+	}
+	return f, nil
+}
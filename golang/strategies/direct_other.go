@@ -0,0 +1,26 @@
+//go:build !linux && !darwin
+
+package strategies
+
+import "context"
+
+// DirectIOStrategy bypasses the page cache with O_DIRECT, which is only
+// wired up on Linux and macOS. On other platforms Calculate always fails.
+type DirectIOStrategy struct {
+	platformStub
+	progress
+	opts StrategyOptions
+}
+
+// NewDirectIOStrategy returns a DirectIOStrategy configured with opts.
+func NewDirectIOStrategy(opts StrategyOptions) *DirectIOStrategy {
+	return &DirectIOStrategy{opts: opts}
+}
+
+func (d *DirectIOStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	return nil, platformError("O_DIRECT strategy requires Linux or macOS")
+}
+
+func planDirectIO(filePath string, opts StrategyOptions) (ChunkPlan, error) {
+	return ChunkPlan{}, platformError("O_DIRECT strategy requires Linux or macOS")
+}
@@ -0,0 +1,248 @@
+package strategies
+
+import (
+	"cmp"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"math"
+	"slices"
+)
+
+// Distribution is an Aggregator describing how a station's measurements
+// spread around their mean: the standard deviation, kept exactly with
+// Welford's online algorithm, and quantiles such as the median, estimated
+// by a t-digest. Values are in the same units as the station's aggregates.
+type Distribution struct {
+	n        int64
+	mean, m2 float64 // Welford's running mean and sum of squared deviations
+	digest   tDigest
+}
+
+// NewDistribution returns an empty Distribution, for
+// StrategyOptions.NewAggregator.
+func NewDistribution() Aggregator {
+	return new(Distribution)
+}
+
+// A Distribution in StationResult.Extra travels over net/rpc, as
+// ClusterPartial does, behind the Aggregator interface.
+func init() {
+	gob.Register(new(Distribution))
+}
+
+func (d *Distribution) Add(value int64) {
+	x := float64(value)
+	d.n++
+	delta := x - d.mean
+	d.mean += delta / float64(d.n)
+	d.m2 += delta * (x - d.mean)
+	d.digest.add(x, 1)
+}
+
+// Merge folds o, another *Distribution, into d, combining the two running
+// moments as Chan et al. do for parallel variance.
+func (d *Distribution) Merge(other Aggregator) {
+	o := other.(*Distribution)
+	if o.n == 0 {
+		return
+	}
+	n := d.n + o.n
+	delta := o.mean - d.mean
+	d.mean += delta * float64(o.n) / float64(n)
+	d.m2 += o.m2 + delta*delta*float64(d.n)*float64(o.n)/float64(n)
+	d.n = n
+	d.digest.merge(&o.digest)
+}
+
+// StdDev returns the population standard deviation.
+func (d *Distribution) StdDev() float64 {
+	if d.n == 0 {
+		return 0
+	}
+	return math.Sqrt(d.m2 / float64(d.n))
+}
+
+// Quantile estimates the q-quantile, 0 <= q <= 1: 0.5 is the median, 0.99
+// the value 99% of measurements are at or below. Estimates are closest
+// near the tails; Quantile(0) and Quantile(1) are the exact extremes.
+func (d *Distribution) Quantile(q float64) float64 {
+	return d.digest.quantile(q)
+}
+
+// Median estimates the middle measurement, Quantile(0.5).
+func (d *Distribution) Median() float64 {
+	return d.Quantile(0.5)
+}
+
+// GobEncode lets a Distribution, which has no exported fields, travel
+// through encoding/gob.
+func (d *Distribution) GobEncode() ([]byte, error) {
+	t := d.digest.compressed()
+	buf := binary.AppendVarint(nil, d.n)
+	for _, v := range []float64{d.mean, d.m2, t.weight, t.min, t.max} {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(t.centroids)))
+	for _, c := range t.centroids {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(c.mean))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(c.weight))
+	}
+	return buf, nil
+}
+
+// GobDecode is the inverse of GobEncode.
+func (d *Distribution) GobDecode(data []byte) error {
+	n, k := binary.Varint(data)
+	if k <= 0 || len(data[k:]) < 5*8 {
+		return errors.New("corrupt distribution")
+	}
+	data = data[k:]
+	float := func() float64 {
+		v := math.Float64frombits(binary.LittleEndian.Uint64(data))
+		data = data[8:]
+		return v
+	}
+	*d = Distribution{n: n, mean: float(), m2: float()}
+	t := &d.digest
+	t.weight, t.min, t.max = float(), float(), float()
+	count, k := binary.Uvarint(data)
+	if k <= 0 || uint64(len(data[k:])) != count*16 {
+		return errors.New("corrupt distribution")
+	}
+	data = data[k:]
+	t.centroids = make([]centroid, count)
+	for i := range t.centroids {
+		t.centroids[i] = centroid{float(), float()}
+	}
+	return nil
+}
+
+// tDigestCompression bounds a t-digest to about this many centroids. A
+// larger value is more accurate at the cost of memory and merge time.
+const tDigestCompression = 100
+
+// tDigestBuffer is how many added values wait unsorted before they are
+// merged into the centroids.
+const tDigestBuffer = 4 * tDigestCompression
+
+// centroid is a cluster of values in a t-digest: their mean and count.
+type centroid struct {
+	mean, weight float64
+}
+
+// tDigest is Dunning's merging t-digest: values are clustered into
+// centroids sorted by mean, and a centroid may only grow large where it
+// sits far from both tails, so the extreme quantiles stay precise while
+// the digest stays small however many values it sees.
+type tDigest struct {
+	centroids []centroid // merged, sorted by mean
+	buffer    []centroid // added since the last compress, unsorted
+	weight    float64    // of centroids and buffer together
+	min, max  float64
+}
+
+func (t *tDigest) add(x, weight float64) {
+	if t.weight == 0 {
+		t.min, t.max = x, x
+	}
+	t.min = min(t.min, x)
+	t.max = max(t.max, x)
+	t.weight += weight
+	t.buffer = append(t.buffer, centroid{x, weight})
+	if len(t.buffer) >= tDigestBuffer {
+		t.compress()
+	}
+}
+
+// merge adds every centroid of o to t.
+func (t *tDigest) merge(o *tDigest) {
+	if o.weight == 0 {
+		return
+	}
+	if t.weight == 0 {
+		t.min, t.max = o.min, o.max
+	}
+	t.min = min(t.min, o.min)
+	t.max = max(t.max, o.max)
+	t.weight += o.weight
+	t.buffer = append(t.buffer, o.centroids...)
+	t.buffer = append(t.buffer, o.buffer...)
+	t.compress()
+}
+
+// compress merges the buffer into the centroids. Walking them in order,
+// each joins its predecessor while the pair's weight stays within the
+// limit at their quantile q, 4·weight·q·(1-q)/compression.
+func (t *tDigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	all := make([]centroid, 0, len(t.centroids)+len(t.buffer))
+	all = append(append(all, t.centroids...), t.buffer...)
+	slices.SortFunc(all, func(a, b centroid) int { return cmp.Compare(a.mean, b.mean) })
+
+	merged := t.centroids[:0]
+	cur := all[0]
+	var before float64 // weight of the centroids merged before cur
+	for _, c := range all[1:] {
+		w := cur.weight + c.weight
+		q := (before + w/2) / t.weight
+		if w <= max(1, 4*t.weight*q*(1-q)/tDigestCompression) {
+			cur.mean += (c.mean - cur.mean) * c.weight / w
+			cur.weight = w
+			continue
+		}
+		merged = append(merged, cur)
+		before += cur.weight
+		cur = c
+	}
+	t.centroids = append(merged, cur)
+	t.buffer = t.buffer[:0]
+}
+
+// compressed returns t if it has no buffered values, and otherwise a copy
+// with them merged, leaving t as it is so that readers never write to it.
+func (t *tDigest) compressed() *tDigest {
+	if len(t.buffer) == 0 {
+		return t
+	}
+	c := &tDigest{
+		centroids: slices.Clone(t.centroids),
+		buffer:    slices.Clone(t.buffer),
+		weight:    t.weight,
+		min:       t.min,
+		max:       t.max,
+	}
+	c.compress()
+	return c
+}
+
+// quantile interpolates between the centroids' centres, taking each
+// centroid's weight to be spread evenly around its mean, and between the
+// outermost centres and the exact minimum and maximum.
+func (t *tDigest) quantile(q float64) float64 {
+	t = t.compressed()
+	if t.weight == 0 {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return t.min
+	}
+	if q >= 1 {
+		return t.max
+	}
+
+	target := q * t.weight
+	lastMean, lastCentre := t.min, 0.0
+	var cum float64
+	for _, c := range t.centroids {
+		centre := cum + c.weight/2
+		if target < centre {
+			return lastMean + (c.mean-lastMean)*(target-lastCentre)/(centre-lastCentre)
+		}
+		lastMean, lastCentre = c.mean, centre
+		cum += c.weight
+	}
+	return lastMean + (t.max-lastMean)*(target-lastCentre)/(t.weight-lastCentre)
+}
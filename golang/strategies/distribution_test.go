@@ -0,0 +1,105 @@
+package strategies
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestDistributionMatchesExactStatistics(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	values := make([]int64, 100_000)
+	var whole, left, right Distribution
+	for i := range values {
+		// A skewed spread, so the tails differ.
+		values[i] = int64(rng.NormFloat64()*150) + rng.Int63n(300)
+		whole.Add(values[i])
+		if i%3 == 0 {
+			left.Add(values[i])
+		} else {
+			right.Add(values[i])
+		}
+	}
+	left.Merge(&right)
+
+	// As a cluster worker sends it.
+	var wire bytes.Buffer
+	if err := gob.NewEncoder(&wire).Encode(StationResult{Extra: &whole}); err != nil {
+		t.Fatal(err)
+	}
+	var decoded StationResult
+	if err := gob.NewDecoder(&wire).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += float64(v)
+	}
+	mean := sum / float64(len(values))
+	var squares float64
+	for _, v := range values {
+		squares += (float64(v) - mean) * (float64(v) - mean)
+	}
+	stddev := math.Sqrt(squares / float64(len(values)))
+	slices.Sort(values)
+
+	for name, d := range map[string]*Distribution{"added": &whole, "merged": &left, "decoded": decoded.Extra.(*Distribution)} {
+		if got := d.StdDev(); math.Abs(got-stddev) > 1e-6*stddev {
+			t.Errorf("%s: StdDev() = %f, want %f", name, got, stddev)
+		}
+		if d.Quantile(0) != float64(values[0]) || d.Quantile(1) != float64(values[len(values)-1]) {
+			t.Errorf("%s: extremes %f and %f, want %d and %d", name, d.Quantile(0), d.Quantile(1), values[0], values[len(values)-1])
+		}
+		for _, q := range []float64{0.01, 0.1, 0.5, 0.9, 0.99, 0.999} {
+			exact := float64(values[int(q*float64(len(values)))])
+			// Within a fraction of a percent of the rank.
+			lo := float64(values[int((q-0.005)*float64(len(values)))])
+			hi := float64(values[min(int((q+0.005)*float64(len(values))), len(values)-1)])
+			if got := d.Quantile(q); got < lo || got > hi {
+				t.Errorf("%s: Quantile(%g) = %f, exact %f, want within [%f, %f]", name, q, got, exact, lo, hi)
+			}
+		}
+	}
+}
+
+func TestAggregatorHostsAgreeOnDistributions(t *testing.T) {
+	path, want := writeRefillDataset(t, 50_000, 50)
+
+	reference, err := NewBasicStrategy(StrategyOptions{NewAggregator: NewDistribution}).Calculate(t.Context(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range strategiesWith(StrategyOptions{Workers: 4, ChunkSize: 4096, NewAggregator: NewDistribution}) {
+		if !s.HostsAggregator {
+			continue
+		}
+		t.Run(s.name, func(t *testing.T) {
+			results, err := s.strategy.Calculate(t.Context(), path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkResults(t, results, want)
+			for i, r := range results {
+				d, ok := r.Extra.(*Distribution)
+				if !ok {
+					t.Fatalf("%s: Extra is %T, want a *Distribution", r.StationID, r.Extra)
+				}
+				ref := reference[i].Extra.(*Distribution)
+				if math.Abs(d.StdDev()-ref.StdDev()) > 1e-6*ref.StdDev() {
+					t.Errorf("%s: StdDev() = %f, want %f", r.StationID, d.StdDev(), ref.StdDev())
+				}
+				if d.Quantile(0) != float64(r.Minimum) || d.Quantile(1) != float64(r.Maximum) {
+					t.Errorf("%s: extremes %f and %f, want %d and %d", r.StationID, d.Quantile(0), d.Quantile(1), r.Minimum, r.Maximum)
+				}
+				// Values span 0-999, so the median is that of a roughly uniform spread.
+				if m := d.Median(); math.Abs(m-ref.Median()) > 30 {
+					t.Errorf("%s: Median() = %f, want about %f", r.StationID, m, ref.Median())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package strategies aggregates One Billion Row Challenge measurement files:
+// lines of "name;temperature" reduced to the minimum, mean and maximum
+// temperature per station name.
+//
+// Every aggregator implements Strategy and is built by a NewXxxStrategy
+// constructor taking StrategyOptions, whose zero value is a sensible
+// default:
+//
+//	s := strategies.NewMmapStrategy(strategies.StrategyOptions{})
+//	results, err := s.Calculate(ctx, "/var/data/measurements.txt")
+//
+// The strategies differ in how they read the file (buffered reads, mmap,
+// preadv, io_uring, O_DIRECT) and in the table they aggregate into (Go
+// maps, linear probing, Robin Hood, Swiss, cuckoo, perfect hashing), and
+// all of them return the same results. The multi-core table strategies
+// such as NewSwissTableStrategy or NewShortKeyStrategy are usually the
+// fastest portable choice; NewPreadvStrategy and NewIOURingStrategy only
+// work on Linux, NewDirectIOStrategy on Linux and macOS, NewMmapStrategy
+// on Linux, macOS and Windows, and they fail elsewhere (see Supported).
+// Which one wins depends on the machine, so benchmark on your own data
+// with the runner in the parent directory. NewZstdStrategy also reads
+// zstd-compressed files, decompressing their frames in parallel.
+//
+// Every strategy is also registered under a short key such as "swiss" or
+// "mcmp-lp" (Registered, Lookup). Its Entry also says which options it
+// honors, such as Checkpoint or SampleFraction, and how to plan a run.
+// Register adds your own, which makes it selectable by key in the
+// benchmark runner and its tests.
+//
+// Stream and Results hand the results over one station at a time instead,
+// as the per-worker tables are merged, for callers that pipe them elsewhere
+// and do not want the whole slice in memory.
+//
+// Long runs can be observed through StrategyOptions.Progress, which is
+// called periodically with the bytes read and lines parsed so far, and
+// timed through StrategyOptions.PhaseTimer, which splits their time
+// between opening, reading, parsing, aggregating and merging. The
+// strategies registered with Checkpoints also save their progress to
+// StrategyOptions.Checkpoint, so a run that is interrupted can resume.
+// Cancelling the context stops a run, and the strategies reading through
+// the shared chunk driver then hand back what they had aggregated in a
+// *PartialError.
+//
+// Temperatures are returned as fixed-point integers in tenths by default;
+// see StationResult. SetRecordFormat changes the delimiter and the number
+// of fraction digits; it is process-wide and must not be called while a
+// Calculate is running. StrategyOptions.Hash picks the station name hash.
+//
+// Other per-station statistics plug in as an Aggregator: set
+// StrategyOptions.NewAggregator, as NewDistribution does for the spread of
+// the measurements, and each StationResult carries one in Extra from the
+// strategies registered with HostsAggregator.
+//
+// Malformed lines are skipped and counted (MalformedLineCounter), and
+// passed to StrategyOptions.Quarantine if that is set, unless
+// StrategyOptions.ParseMode is ParseStrict, in which case Calculate fails
+// with a *ParseError naming the line. A well-formed value far outside the
+// plausible range is aggregated like any other; ScanRange finds those.
+package strategies
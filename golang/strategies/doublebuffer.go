@@ -0,0 +1,242 @@
+package strategies
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"unsafe"
+)
+
+// prefetchDepth is the number of read buffers each worker cycles through.
+// Two is enough to keep one read in flight while the other buffer is parsed.
+const prefetchDepth = 2
+
+// DoubleBufferedStrategy pulls chunks from a shared queue like the MCMP
+// strategies, but each worker overlaps I/O with parsing: a dedicated
+// goroutine reads the next buffer from disk while the current one is being
+// parsed, and buffers are recycled rather than reallocated.
+type DoubleBufferedStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	opts StrategyOptions
+}
+
+// NewDoubleBufferedStrategy returns a DoubleBufferedStrategy configured with opts.
+func NewDoubleBufferedStrategy(opts StrategyOptions) *DoubleBufferedStrategy {
+	return &DoubleBufferedStrategy{opts: opts}
+}
+
+func (d *DoubleBufferedStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	return d.aggregate(ctx, pathInput(filePath))
+}
+
+func (d *DoubleBufferedStrategy) CalculateReaderAt(ctx context.Context, r io.ReaderAt, size int64) ([]StationResult, error) {
+	return d.aggregate(ctx, readerAtInput(r, size))
+}
+
+func (d *DoubleBufferedStrategy) aggregate(ctx context.Context, in input) ([]StationResult, error) {
+	d.resetProgress()
+	defer d.reportProgress(d.opts)()
+	d.resetMalformed(d.opts)
+	src, err := in.open(ctx, d.opts)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	tempMaps := make([]StationMap, d.opts.workers())
+	err = scanChunks(ctx, src, d.opts, &d.progress, &d.malformedLines, func(worker int) lineSink {
+		tempMaps[worker] = make(StationMap, d.opts.mapCapacity())
+		return d.opts.sink(tempMaps[worker])
+	})
+	if interrupted(ctx, err) {
+		return nil, partialResults(err, &d.progress, tempMaps...)
+	}
+	if err != nil {
+		return nil, in.locate(err)
+	}
+	_, end := d.opts.startSpan(ctx, SpanMerge)
+	defer end()
+	return emitResults(&d.resultEmitter, tempMaps...), nil
+}
+
+// blockSource yields consecutive buffers of a file starting at the offset it
+// was created with. Every buffer returned by next must be released before
+// the source can reuse it.
+type blockSource interface {
+	next() ([]byte, error)
+	release(buf []byte)
+}
+
+// lineSink receives complete lines (without their newline). addLine
+// reports whether the line was a well-formed record; malformed lines are
+// left out of the aggregates.
+type lineSink interface {
+	addLine(line []byte) bool
+}
+
+// mapSink aggregates lines into a StationMap keyed by hash.
+type mapSink struct {
+	fileMap StationMap
+	hash    HashFunc
+}
+
+func (m mapSink) addLine(line []byte) bool {
+	return addLineKeyed(line, m.fileMap, m.hash, copyName)
+}
+
+// aggregatorSink is a mapSink giving each new station an Extra from
+// newExtra.
+type aggregatorSink struct {
+	fileMap  StationMap
+	hash     HashFunc
+	newExtra func() Aggregator
+}
+
+func (s aggregatorSink) addLine(line []byte) bool {
+	name, value, err := parseLineByte(line)
+	if err != nil {
+		return false
+	}
+
+	key, st, exists := stationKey(s.fileMap, hashKey(s.hash, name), name)
+	if !exists {
+		st = newStation(copyName(name), s.newExtra)
+	}
+	st.Add(value)
+	st.Extra.Add(value)
+	s.fileMap[key] = st
+	return true
+}
+
+// sink returns the lineSink aggregating into fileMap: an aggregatorSink
+// under NewAggregator, otherwise a plain mapSink.
+func (o StrategyOptions) sink(fileMap StationMap) lineSink {
+	if o.NewAggregator != nil {
+		return aggregatorSink{fileMap, o.hash(), o.NewAggregator}
+	}
+	return mapSink{fileMap, o.hash()}
+}
+
+// consumeChunk aggregates every line whose first byte lies in [start, end)
+// from blocks that must begin at max(start-1, 0) into sink. Malformed
+// lines are passed to m.
+func consumeChunk(ctx context.Context, src blockSource, start, end int64, sink lineSink, p *progress, m *malformedLines) error {
+	pos := max(start-1, 0) // file offset of the next unconsumed byte
+	skipping := start > 0  // still discarding the predecessor's last line
+	var leftover []byte    // partial line carried across buffers
+	rows := lineCounter{p: p}
+	defer rows.flush()
+
+	for pos < end || len(leftover) > 0 {
+		if cancelled(ctx) {
+			return ctx.Err()
+		}
+
+		buf, err := src.next()
+		if err == io.EOF {
+			// The file's last line has no newline.
+			if len(leftover) > 0 {
+				rows.add()
+				return feedLine(sink, m, leftover, pos-int64(len(leftover)))
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+		p.addProgress(len(buf))
+
+		data := buf
+		if skipping {
+			idx := bytes.IndexByte(data, '\n')
+			if idx == -1 {
+				pos += int64(len(data))
+				src.release(buf)
+				continue
+			}
+			pos += int64(idx + 1)
+			data = data[idx+1:]
+			skipping = false
+		}
+
+		if len(leftover) > 0 {
+			idx := bytes.IndexByte(data, '\n')
+			if idx == -1 {
+				leftover = append(leftover, data...)
+				pos += int64(len(data))
+				src.release(buf)
+				continue
+			}
+			lineStart := pos - int64(len(leftover))
+			leftover = append(leftover, data[:idx]...)
+			rows.add()
+			if err := feedLine(sink, m, leftover, lineStart); err != nil {
+				src.release(buf)
+				return err
+			}
+			leftover = leftover[:0]
+			pos += int64(idx + 1)
+			data = data[idx+1:]
+		}
+
+		for len(data) > 0 && pos < end {
+			idx := bytes.IndexByte(data, '\n')
+			if idx == -1 {
+				leftover = append(leftover, data...)
+				pos += int64(len(data))
+				break
+			}
+			rows.add()
+			if err := feedLine(sink, m, data[:idx], pos); err != nil {
+				src.release(buf)
+				return err
+			}
+			pos += int64(idx + 1)
+			data = data[idx+1:]
+		}
+		src.release(buf)
+	}
+	return nil
+}
+
+// addLine parses a single line (without its newline) into fileMap, keyed
+// by hash, copying the station name only the first time it is seen. It reports
+// whether the line was well formed.
+func addLine(line []byte, fileMap StationMap, hash HashFunc) bool {
+	return addLineKeyed(line, fileMap, hash, copyName)
+}
+
+// addLineKeyed is addLine with the station name turned into a map key by
+// key, which runs once per new station.
+func addLineKeyed(line []byte, fileMap StationMap, hash HashFunc, key func([]byte) string) bool {
+	return addSplitLineKeyed(line, bytes.IndexByte(line, recordFormat.Delimiter), fileMap, hash, key)
+}
+
+// addSplitLineKeyed is addLineKeyed for a line whose field delimiter is
+// at sep, as nextLine returns it.
+func addSplitLineKeyed(line []byte, sep int, fileMap StationMap, hash HashFunc, key func([]byte) string) bool {
+	name, value, err := parseSplitLine(line, sep)
+	if err != nil {
+		return false
+	}
+
+	k, st, exists := stationKey(fileMap, hashKey(hash, name), name)
+	if !exists {
+		st = newSt(key(name))
+	}
+	st.Add(value)
+	fileMap[k] = st
+	return true
+}
+
+func copyName(name []byte) string {
+	return string(name)
+}
+
+// viewName returns a string sharing name's memory. The bytes must outlive
+// the string and never change.
+func viewName(name []byte) string {
+	return unsafe.String(unsafe.SliceData(name), len(name))
+}
@@ -0,0 +1,38 @@
+package strategies_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+)
+
+func Example() {
+	dir, err := os.MkdirTemp("", "measurements")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "measurements.txt")
+	data := "Hamburg;12.0\nKraków;-3.4\nHamburg;8.9\nKraków;11.1\nHamburg;-0.5\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		log.Fatal(err)
+	}
+
+	s := strategies.NewSwissTableStrategy(strategies.StrategyOptions{})
+	results, err := s.Calculate(context.Background(), path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, r := range results {
+		fmt.Printf("%s=%.1f/%.1f/%.1f\n", r.StationID,
+			float64(r.Minimum)/10, r.Average/10, float64(r.Maximum)/10)
+	}
+	// Output:
+	// Hamburg=-0.5/6.8/12.0
+	// Kraków=-3.4/3.9/11.1
+}
@@ -0,0 +1,33 @@
+//go:build darwin
+
+package strategies
+
+import (
+	"math"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// fadvise maps the posix_fadvise hints, which macOS lacks, onto fcntl:
+// adviceSequential turns read-ahead on with F_RDAHEAD, and adviceWillNeed
+// starts reading [offset, offset+length) in with F_RDADVISE. F_RDADVISE
+// takes at most 2 GiB at a time, which is far more than any chunk.
+func fadvise(f *os.File, offset, length int64, advice int) {
+	switch advice {
+	case adviceSequential:
+		fcntl(f.Fd(), syscall.F_RDAHEAD, 1)
+	case adviceWillNeed:
+		ra := syscall.Radvisory_t{Offset: offset, Count: int32(min(length, math.MaxInt32))}
+		syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), syscall.F_RDADVISE, uintptr(unsafe.Pointer(&ra)))
+	}
+}
+
+// fcntl runs an fcntl command that takes an integer argument.
+func fcntl(fd uintptr, cmd, arg int) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_FCNTL, fd, uintptr(cmd), uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !darwin && (!linux || !(amd64 || arm64 || riscv64 || loong64 || ppc64 || ppc64le))
+
+package strategies
+
+import "os"
+
+// fadvise is a no-op where posix_fadvise is not wired up.
+func fadvise(f *os.File, offset, length int64, advice int) {}
@@ -0,0 +1,80 @@
+package strategies
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// feedFIFO creates a FIFO and writes data into it from another goroutine
+// once a reader opens it, returning the FIFO's path.
+func feedFIFO(t *testing.T, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "measurements.fifo")
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		f.Write(data) // fails once a strategy that cannot seek gives up
+	}()
+	t.Cleanup(func() {
+		// Release the writer if the strategy never opened the FIFO.
+		if f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0); err == nil {
+			io.Copy(io.Discard, f)
+			f.Close()
+		}
+		<-done
+	})
+	return path
+}
+
+func TestSequentialReadersReadFIFO(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range strategiesWith(StrategyOptions{Workers: 4, BufferSize: 256}) {
+		t.Run(s.name, func(t *testing.T) {
+			fifo := feedFIFO(t, data)
+			if s.ReadsSequentially {
+				checkAggregates(t, s.strategy, fifo, want)
+				return
+			}
+			if _, err := s.strategy.Calculate(t.Context(), fifo); err == nil {
+				t.Error("read a FIFO without error")
+			}
+		})
+	}
+}
+
+func TestStrictModeReportsByteOffsetInFIFO(t *testing.T) {
+	fifo := feedFIFO(t, []byte("Oslo;1.0\nBergen;12,3\n"))
+
+	_, err := NewPipelineStrategy(StrategyOptions{ParseMode: ParseStrict}).Calculate(t.Context(), fifo)
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Offset != 9 || perr.Line != 0 || !strings.HasPrefix(perr.Error(), "byte 9, chunk 0 on worker 0:") {
+		t.Errorf("got error %v, want a *ParseError at byte 9", err)
+	}
+}
+
+func TestOffsetStrategiesRejectFIFO(t *testing.T) {
+	fifo := feedFIFO(t, []byte("Oslo;1.0\n"))
+
+	if _, err := NewMCMPStrategy(StrategyOptions{}).Calculate(t.Context(), fifo); !errors.Is(err, ErrNotSeekable) {
+		t.Errorf("got error %v, want ErrNotSeekable", err)
+	}
+}
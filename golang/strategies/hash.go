@@ -0,0 +1,201 @@
+package strategies
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"slices"
+	"strings"
+)
+
+// HashFunc hashes a station name. 32-bit functions return their value
+// zero-extended.
+type HashFunc func(name []byte) uint64
+
+var hashFuncs = map[string]HashFunc{
+	"fnv32":  hashFnv32,
+	"fnv64":  hashFnv64,
+	"xxhash": hashXXH64,
+	"wyhash": hashWy,
+}
+
+// LookupHashFunction returns the station hash named name (see
+// HashFunctions), for StrategyOptions.Hash.
+func LookupHashFunction(name string) (HashFunc, error) {
+	h, ok := hashFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown hash function %q (available: %s)", name, strings.Join(HashFunctions(), ", "))
+	}
+	return h, nil
+}
+
+// HashFunctions lists the names accepted by LookupHashFunction.
+func HashFunctions() []string {
+	names := make([]string, 0, len(hashFuncs))
+	for name := range hashFuncs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// hash returns the station hash of o: Hash, or fnv32 if it is nil.
+func (o StrategyOptions) hash() HashFunc {
+	if o.Hash != nil {
+		return o.Hash
+	}
+	return hashFnv32
+}
+
+// hashKey is hash of name folded to the 32 bits the tables and
+// StationMaps store. Folding leaves fnv32 unchanged.
+func hashKey(hash HashFunc, name []byte) uint32 {
+	h := hash(name)
+	return uint32(h ^ h>>32)
+}
+
+func hashFnv32(name []byte) uint64 {
+	var hash uint32 = 2166136261
+	const prime32 = 16777619
+
+	for i := range name {
+		hash ^= uint32(name[i])
+		hash *= prime32
+	}
+	return uint64(hash)
+}
+
+func hashFnv64(name []byte) uint64 {
+	hash := uint64(14695981039346656037)
+	for _, c := range name {
+		hash ^= uint64(c)
+		hash *= 1099511628211
+	}
+	return hash
+}
+
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+// hashXXH64 is XXH64 with seed 0.
+func hashXXH64(name []byte) uint64 {
+	n := len(name)
+	p := name
+	var h uint64
+
+	if n >= 32 {
+		p1 := xxPrime1 // a variable, so the initial sums may wrap
+		v1 := p1 + xxPrime2
+		v2 := xxPrime2
+		v3 := uint64(0)
+		v4 := -p1
+		for len(p) >= 32 {
+			v1 = xxRound(v1, binary.LittleEndian.Uint64(p))
+			v2 = xxRound(v2, binary.LittleEndian.Uint64(p[8:]))
+			v3 = xxRound(v3, binary.LittleEndian.Uint64(p[16:]))
+			v4 = xxRound(v4, binary.LittleEndian.Uint64(p[24:]))
+			p = p[32:]
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxMergeRound(h, v1)
+		h = xxMergeRound(h, v2)
+		h = xxMergeRound(h, v3)
+		h = xxMergeRound(h, v4)
+	} else {
+		h = xxPrime5
+	}
+	h += uint64(n)
+
+	for ; len(p) >= 8; p = p[8:] {
+		h ^= xxRound(0, binary.LittleEndian.Uint64(p))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+	if len(p) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(p)) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		p = p[4:]
+	}
+	for _, c := range p {
+		h ^= uint64(c) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+	return h
+}
+
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	return bits.RotateLeft64(acc, 31) * xxPrime1
+}
+
+func xxMergeRound(acc, val uint64) uint64 {
+	acc ^= xxRound(0, val)
+	return acc*xxPrime1 + xxPrime4
+}
+
+// wyhash's default secret.
+var wyp = [4]uint64{0x2d358dccaa6c78a5, 0x8bb84b93962eacc9, 0x4b33a62ed433d4a3, 0x4d5a2da51de1aa47}
+
+// hashWy is wyhash (final version 4) with seed 0 and the default secret.
+func hashWy(name []byte) uint64 {
+	return wyhash(name, 0)
+}
+
+// wyhash is wyhash (final version 4) with the default secret.
+func wyhash(name []byte, seed uint64) uint64 {
+	n := len(name)
+	p := name
+	seed ^= wymix(seed^wyp[0], wyp[1])
+	var a, b uint64
+
+	switch {
+	case n > 16:
+		// The final 16 bytes may overlap ones already mixed, so track an
+		// offset into name rather than reslicing it.
+		off, i := 0, n
+		if i > 48 {
+			see1, see2 := seed, seed
+			for i > 48 {
+				seed = wymix(binary.LittleEndian.Uint64(p[off:])^wyp[1], binary.LittleEndian.Uint64(p[off+8:])^seed)
+				see1 = wymix(binary.LittleEndian.Uint64(p[off+16:])^wyp[2], binary.LittleEndian.Uint64(p[off+24:])^see1)
+				see2 = wymix(binary.LittleEndian.Uint64(p[off+32:])^wyp[3], binary.LittleEndian.Uint64(p[off+40:])^see2)
+				off += 48
+				i -= 48
+			}
+			seed ^= see1 ^ see2
+		}
+		for i > 16 {
+			seed = wymix(binary.LittleEndian.Uint64(p[off:])^wyp[1], binary.LittleEndian.Uint64(p[off+8:])^seed)
+			off += 16
+			i -= 16
+		}
+		a = binary.LittleEndian.Uint64(p[n-16:])
+		b = binary.LittleEndian.Uint64(p[n-8:])
+	case n >= 4:
+		off := (n >> 3) << 2
+		a = uint64(binary.LittleEndian.Uint32(p))<<32 | uint64(binary.LittleEndian.Uint32(p[off:]))
+		b = uint64(binary.LittleEndian.Uint32(p[n-4:]))<<32 | uint64(binary.LittleEndian.Uint32(p[n-4-off:]))
+	case n > 0:
+		a = uint64(p[0])<<16 | uint64(p[n>>1])<<8 | uint64(p[n-1])
+	}
+
+	a ^= wyp[1]
+	b ^= seed
+	hi, lo := bits.Mul64(a, b)
+	return wymix(lo^wyp[0]^uint64(n), hi^wyp[1])
+}
+
+func wymix(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return hi ^ lo
+}
@@ -0,0 +1,87 @@
+package strategies
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHashFunctionsMatchReferenceVectors(t *testing.T) {
+	tests := []struct {
+		hash  string
+		input string
+		want  uint64
+	}{
+		{"fnv32", "", 0x811c9dc5},
+		{"fnv32", "a", 0xe40c292c},
+		{"fnv64", "", 0xcbf29ce484222325},
+		{"fnv64", "a", 0xaf63dc4c8601ec8c},
+		{"xxhash", "", 0xef46db3751d8e999},
+		{"xxhash", "a", 0xd24ec4f1a98c6e5b},
+		{"xxhash", "abc", 0x44bc2cf5ad770999},
+		{"xxhash", "abcdefghijklmnopqrstuvwxyz", 0xcfe1f278fa89835c},
+		{"wyhash", "", 0x93228a4de0eec5a2},
+	}
+	for _, tt := range tests {
+		if got := hashFuncs[tt.hash]([]byte(tt.input)); got != tt.want {
+			t.Errorf("%s(%q) = %#x, want %#x", tt.hash, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestStrategiesAgreeUnderEveryHash(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+
+	for _, name := range HashFunctions() {
+		hash, err := LookupHashFunction(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(name, func(t *testing.T) {
+			opts := StrategyOptions{Workers: 2, ChunkSize: 8192, Hash: hash}
+			checkAggregates(t, NewSwissTableStrategy(opts), path, want)
+			checkAggregates(t, NewPerfectHashStrategy(opts), path, want)
+		})
+	}
+	if _, err := LookupHashFunction("crc32"); err == nil {
+		t.Error("LookupHashFunction accepted an unknown name")
+	}
+}
+
+func TestStrategiesTellStationsWithTheSameKeyApart(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+
+	// Distinct 64-bit hashes whose halves cancel, so every station has
+	// key 0 and the workers' maps each hold them in another order.
+	hash := func(name []byte) uint64 {
+		h := hashFnv32(name)
+		return h<<32 | h
+	}
+	for _, s := range strategiesWith(StrategyOptions{Workers: 4, ChunkSize: 4096, Hash: hash}) {
+		t.Run(s.name, func(t *testing.T) {
+			checkAggregates(t, s.strategy, path, want)
+
+			var streamed []string
+			err := Stream(t.Context(), s.strategy, path, func(r StationResult) bool {
+				streamed = append(streamed, r.StationID)
+				return true
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if slices.Sort(streamed); len(slices.Compact(streamed)) != len(want) {
+				t.Errorf("streamed %d distinct stations, want %d", len(slices.Compact(streamed)), len(want))
+			}
+		})
+	}
+}
+
+func TestPerfectHashIgnoresEqualStationHashes(t *testing.T) {
+	same := func([]byte) uint64 { return 1 }
+	mph, err := newPerfectHash([]string{"Oslo", "Rome"}, same)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mph.index([]byte("Oslo")) == mph.index([]byte("Rome")) {
+		t.Error("two names with the same station hash share a slot")
+	}
+}
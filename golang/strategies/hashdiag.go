@@ -0,0 +1,61 @@
+package strategies
+
+import (
+	"context"
+	"slices"
+)
+
+// maxReportedCollisions caps how many colliding name groups a HashReport
+// lists.
+const maxReportedCollisions = 10
+
+// HashReport describes how a station hash spreads a dataset's names over
+// the 32-bit keys that tables and result maps store. Names that share a
+// key are told apart by comparing them, so a collision costs every lookup
+// of those stations a probe further, not correctness.
+type HashReport struct {
+	Names int
+	Keys  int
+
+	// NamesPerKey[i] counts keys shared by i+1 distinct names.
+	NamesPerKey []int
+
+	// Collisions lists up to maxReportedCollisions groups of names that
+	// share a key.
+	Collisions [][]string
+}
+
+// DiagnoseHash reads every station name in filePath and reports how the
+// hash function of opts maps them to keys.
+func DiagnoseHash(ctx context.Context, filePath string, opts StrategyOptions) (HashReport, error) {
+	var p progress
+	names, err := distinctStations(ctx, pathInput(filePath), opts, &p)
+	if err != nil {
+		return HashReport{}, err
+	}
+
+	byKey := make(map[uint32][]string, len(names))
+	for _, name := range names {
+		key := hashKey(opts.hash(), []byte(name))
+		byKey[key] = append(byKey[key], name)
+	}
+
+	report := HashReport{Names: len(names), Keys: len(byKey)}
+	for _, group := range byKey {
+		for len(report.NamesPerKey) < len(group) {
+			report.NamesPerKey = append(report.NamesPerKey, 0)
+		}
+		report.NamesPerKey[len(group)-1]++
+		if len(group) > 1 {
+			report.Collisions = append(report.Collisions, group)
+		}
+	}
+
+	// names was sorted, so each group is too; order groups by first name
+	// to keep reports stable between runs.
+	slices.SortFunc(report.Collisions, func(a, b []string) int { return slices.Compare(a, b) })
+	if len(report.Collisions) > maxReportedCollisions {
+		report.Collisions = report.Collisions[:maxReportedCollisions]
+	}
+	return report, nil
+}
@@ -0,0 +1,29 @@
+package strategies
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestDiagnoseHashFindsCollisions(t *testing.T) {
+	names := []string{"Oslo", "Rome", "Nuuk", "Bo", "Abidjan"}
+	path, _ := writeDataset(t, rand.New(rand.NewSource(1)), 1_000, names)
+
+	// Key by length, so the three four-letter names collide.
+	byLength := func(name []byte) uint64 { return uint64(len(name)) }
+
+	report, err := DiagnoseHash(t.Context(), path, StrategyOptions{Workers: 2, ChunkSize: 1024, Hash: byLength})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Names != 5 || report.Keys != 3 {
+		t.Errorf("got %d names over %d keys, want 5 over 3", report.Names, report.Keys)
+	}
+	if want := []int{2, 0, 1}; !slices.Equal(report.NamesPerKey, want) {
+		t.Errorf("NamesPerKey = %v, want %v", report.NamesPerKey, want)
+	}
+	if len(report.Collisions) != 1 || !slices.Equal(report.Collisions[0], []string{"Nuuk", "Oslo", "Rome"}) {
+		t.Errorf("Collisions = %v", report.Collisions)
+	}
+}
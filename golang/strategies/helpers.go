@@ -1,81 +1,218 @@
 package strategies
 
-import "os"
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
 
+// cancelCheckInterval is how many lines hot loops process between
+// cancellation checks, keeping the check off the per-line fast path.
+const cancelCheckInterval = 1 << 16
+
+// StationMap holds per-worker results keyed by station name hash. Names
+// are checked on every hit, and a station whose hash another already
+// holds goes under a later key; see stationKey.
 type StationMap = map[uint32]StationResult
 
+// stationKey returns the key name has in smap, with its result, or else
+// the key to store it under and false. That is its hash unless another
+// station already holds it, as a name with the same hash does; then it is
+// the first key after it that is free or holds name. Keys past a collision
+// depend on the order stations arrived in, so mergeMaps checks names.
+func stationKey[N string | []byte](smap StationMap, hash uint32, name N) (key uint32, res StationResult, ok bool) {
+	for key = hash; ; key++ {
+		if res, ok = smap[key]; !ok || res.StationID == string(name) {
+			return key, res, ok
+		}
+	}
+}
+
+// storeStation adds res, a station not yet in smap, whose name hashes to
+// hash.
+func storeStation(smap StationMap, hash uint32, res StationResult) {
+	key, _, _ := stationKey(smap, hash, res.StationID)
+	smap[key] = res
+}
+
+// Station is one parsed measurement: a station name and its fixed-point
+// value.
 type Station struct {
 	Station []byte
 	Value   int64
 }
 
-func processBatch(results []Station, stationMap map[uint32]StationResult) {
+// processBatch aggregates results into stationMap, keyed by hash,
+// copying each station name into arena the first time it is seen.
+func processBatch(results []Station, stationMap StationMap, hash HashFunc, arena *nameArena) {
 	for _, r := range results {
-		hash := hashFnv(r.Station)
-		name := string(r.Station)
-		if _, exists := stationMap[hash]; !exists {
-			stationMap[hash] = newSt(name)
+		key, res, exists := stationKey(stationMap, hashKey(hash, r.Station), r.Station)
+		if !exists {
+			res = newSt(arena.intern(r.Station))
 		}
+		res.Add(r.Value)
+		stationMap[key] = res
+	}
+}
 
-		res := stationMap[hash]
-		if r.Value > res.Maximum {
-			res.Maximum = r.Value
-		}
+// parallelMergeMinKeys is how many keys the maps must hold between them
+// before mergeMaps merges them in parallel. Below it, starting the
+// goroutines costs more than merging on one core.
+const parallelMergeMinKeys = 1 << 14
 
-		if r.Value < res.Minimum {
-			res.Minimum = r.Value
+// mergeMaps merges per-worker maps and returns their stations, in no
+// particular order. Given more than two maps with enough keys between
+// them, it merges them as a tree, in parallel, and reuses the maps for the
+// result. A station is only merged with one of the same name: past a hash
+// collision, maps can hold different stations under a key, so those are
+// set aside and, if there were any, everything is merged again by name.
+func mergeMaps[K comparable](maps []map[K]StationResult) []StationResult {
+	keyCount := 0
+	for _, m := range maps {
+		keyCount += len(m)
+	}
+	var merged map[K]StationResult
+	var clashes []StationResult
+	if len(maps) > 2 && keyCount >= parallelMergeMinKeys {
+		merged, clashes = treeMerge(maps)
+	} else {
+		merged = make(map[K]StationResult, keyCount)
+		for _, m := range maps {
+			clashes = mergeInto(merged, m, clashes)
 		}
+	}
 
-		res.Sum += int64(r.Value)
-		res.Count++
-		stationMap[hash] = res
+	results := make([]StationResult, 0, len(merged)+len(clashes))
+	for _, res := range merged {
+		results = append(results, res)
 	}
+	if len(clashes) == 0 {
+		return results
+	}
+	byName := mergeByName(append(results, clashes...))
+	results = results[:0]
+	for _, res := range byName {
+		results = append(results, res)
+	}
+	return results
 }
 
-func hashFnv(name []byte) uint32 {
-	var hash uint32 = 2166136261
-	const prime32 = 16777619
-
-	for i := range name {
-		hash ^= uint32(name[i])
-		hash *= prime32
+// mergeInto merges src into dst and returns clashes with the entries of
+// src whose key dst holds for another station appended.
+func mergeInto[K comparable](dst, src map[K]StationResult, clashes []StationResult) []StationResult {
+	for key, res := range src {
+		if existing, exists := dst[key]; !exists {
+			dst[key] = res
+		} else if existing.StationID == res.StationID {
+			dst[key] = mergeResult(existing, res)
+		} else {
+			clashes = append(clashes, res)
+		}
 	}
-	return hash
+	return clashes
 }
 
-func mergeMaps(maps []StationMap) StationMap {
-	keyCount := 0
-	for _, m := range maps {
-		keyCount += len(m)
+// mergeByName merges results that share a station name.
+func mergeByName(results []StationResult) map[string]StationResult {
+	m := make(map[string]StationResult, len(results))
+	for _, res := range results {
+		if existing, ok := m[res.StationID]; ok {
+			res = mergeResult(existing, res)
+		}
+		m[res.StationID] = res
 	}
+	return m
+}
 
-	merged := make(StationMap, keyCount)
-	for _, m := range maps {
-		for hash, res := range m {
-			if existing, exists := merged[hash]; exists {
-				if res.Maximum > existing.Maximum {
-					existing.Maximum = res.Maximum
+// treeMerge merges maps pairwise in rounds, every pair of a round on a
+// goroutine of its own, so n maps take log2(n) rounds instead of n-1
+// merges one after the other. Each pair is merged into its first map, and
+// the map left at the end is returned with the clashes of every merge.
+func treeMerge[K comparable](maps []map[K]StationResult) (map[K]StationResult, []StationResult) {
+	maps = slices.Clone(maps)
+	clashes := make([][]StationResult, len(maps))
+	for stride := 1; stride < len(maps); stride *= 2 {
+		var wg sync.WaitGroup
+		for i := 0; i+stride < len(maps); i += 2 * stride {
+			wg.Add(1)
+			go func(dst, src *map[K]StationResult, clashes *[]StationResult) {
+				defer wg.Done()
+				if *dst == nil {
+					*dst = *src
+					return
 				}
+				*clashes = mergeInto(*dst, *src, *clashes)
+			}(&maps[i], &maps[i+stride], &clashes[i])
+		}
+		wg.Wait()
+	}
+	return maps[0], slices.Concat(clashes...)
+}
 
-				if res.Minimum < existing.Minimum {
-					existing.Minimum = res.Minimum
-				}
+// mergeResult combines two partial results for the same station, keeping
+// the name of the first.
+func mergeResult(existing, res StationResult) StationResult {
+	if res.Maximum > existing.Maximum {
+		existing.Maximum = res.Maximum
+	}
 
-				existing.Sum += res.Sum
-				existing.Count += res.Count
-				merged[hash] = existing
-			} else {
-				merged[hash] = res
-			}
-		}
+	if res.Minimum < existing.Minimum {
+		existing.Minimum = res.Minimum
 	}
-	return merged
+
+	existing.Sum += res.Sum
+	existing.Count += res.Count
+	if existing.Extra == nil {
+		existing.Extra = res.Extra
+	} else if res.Extra != nil {
+		existing.Extra.Merge(res.Extra)
+	}
+	return existing
 }
 
+// getFileSize returns the size of f, which strategies that split their
+// input by offset need, or ErrNotSeekable if f is not a regular file.
 func getFileSize(f *os.File) (int64, error) {
 	info, err := f.Stat()
 	if err != nil {
 		return 0, err
 	}
+	if !info.Mode().IsRegular() {
+		return 0, fmt.Errorf("%s: %w", f.Name(), ErrNotSeekable)
+	}
 	return info.Size(), nil
 }
+
+// cancelled reports whether ctx is done without blocking.
+func cancelled(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// replaceFile writes data to path through a temporary file renamed over
+// it, so that a concurrent reader never sees half of it and a write cut
+// short leaves the previous file in place.
+func replaceFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err = tmp.Chmod(0644); err == nil {
+		_, err = tmp.Write(data)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,160 @@
+package strategies
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestTreeMergeMatchesSequentialMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	maps := make([]StationMap, 13) // not a power of two, so a map sits out some rounds
+	want := make(map[string]StationResult)
+	for i := range maps {
+		if i == 5 {
+			continue // a worker that aggregated nothing
+		}
+		maps[i] = make(StationMap)
+		for range parallelMergeMinKeys / 4 {
+			key := uint32(rng.Intn(parallelMergeMinKeys))
+			value := rng.Int63n(2000) - 1000
+			one := StationResult{StationID: strconv.Itoa(int(key)), Minimum: value, Maximum: value, Sum: value, Count: 1}
+			if existing, ok := maps[i][key]; ok {
+				maps[i][key] = mergeResult(existing, one)
+			} else {
+				maps[i][key] = one
+			}
+			if existing, ok := want[one.StationID]; ok {
+				want[one.StationID] = mergeResult(existing, one)
+			} else {
+				want[one.StationID] = one
+			}
+		}
+	}
+
+	checkMerged(t, mergeMaps(maps), want)
+}
+
+func TestMergeMapsTellsStationsUnderOneKeyApart(t *testing.T) {
+	oslo := StationResult{StationID: "Oslo", Minimum: -10, Maximum: -10, Sum: -10, Count: 1}
+	rome := StationResult{StationID: "Rome", Minimum: 20, Maximum: 20, Sum: 20, Count: 1}
+
+	// Both names hash to 7 and each worker met them in another order.
+	maps := []StationMap{{7: oslo, 8: rome}, {7: rome, 8: oslo}, {7: oslo}}
+	want := map[string]StationResult{
+		"Oslo": {StationID: "Oslo", Minimum: -10, Maximum: -10, Sum: -30, Count: 3},
+		"Rome": {StationID: "Rome", Minimum: 20, Maximum: 20, Sum: 40, Count: 2},
+	}
+	checkMerged(t, mergeMaps(maps), want)
+	if !keysClash(maps) {
+		t.Error("keysClash missed a key that holds two stations")
+	}
+}
+
+// checkMerged compares the stations mergeMaps returned with want.
+func checkMerged(t *testing.T, got []StationResult, want map[string]StationResult) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("merged %d stations, want %d", len(got), len(want))
+	}
+	for _, res := range got {
+		if res != want[res.StationID] {
+			t.Errorf("%s: got %+v, want %+v", res.StationID, res, want[res.StationID])
+		}
+	}
+}
+
+// expectedStation is the reference aggregate for one station, in tenths.
+type expectedStation struct {
+	sum, count, min, max int64
+}
+
+// writeRefillDataset writes rows for many stations whose names vary in
+// length, so that with a tiny read buffer names regularly straddle refills.
+// It returns the path and the aggregates a correct strategy must produce.
+func writeRefillDataset(t *testing.T, rows, stations int) (string, map[string]expectedStation) {
+	t.Helper()
+
+	rng := rand.New(rand.NewSource(1))
+	names := make([]string, stations)
+	for i := range names {
+		names[i] = fmt.Sprintf("Station-%d-%s", i, "xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"[:rng.Intn(40)])
+	}
+	return writeDataset(t, rng, rows, names)
+}
+
+// writeDataset writes rows for stations drawn at random from names and
+// returns the path and the expected aggregates.
+func writeDataset(t *testing.T, rng *rand.Rand, rows int, names []string) (string, map[string]expectedStation) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "refills.txt")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := bufio.NewWriter(f)
+
+	want := make(map[string]expectedStation, len(names))
+	for range rows {
+		name := names[rng.Intn(len(names))]
+		value := rng.Int63n(1000)
+		fmt.Fprintf(w, "%s;%d.%d\n", name, value/10, value%10)
+
+		st, ok := want[name]
+		if !ok {
+			st = expectedStation{min: value, max: value}
+		}
+		st.sum += value
+		st.count++
+		st.min = min(st.min, value)
+		st.max = max(st.max, value)
+		want[name] = st
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path, want
+}
+
+// checkAggregates runs s on path and compares every station with want.
+func checkAggregates(t *testing.T, s Strategy, path string, want map[string]expectedStation) {
+	t.Helper()
+
+	results, err := s.Calculate(t.Context(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResults(t, results, want)
+}
+
+// checkResults compares every station of results with want, and checks
+// that they come sorted by name.
+func checkResults(t *testing.T, results []StationResult, want map[string]expectedStation) {
+	t.Helper()
+
+	if len(results) != len(want) {
+		t.Fatalf("got %d stations, want %d", len(results), len(want))
+	}
+	for i, r := range results {
+		if i > 0 && results[i-1].StationID >= r.StationID {
+			t.Fatalf("station %q follows %q", r.StationID, results[i-1].StationID)
+		}
+		exp, ok := want[r.StationID]
+		if !ok {
+			t.Fatalf("unexpected station %q", r.StationID)
+		}
+		got := expectedStation{sum: r.Sum, count: r.Count, min: r.Minimum, max: r.Maximum}
+		if got != exp {
+			t.Errorf("%s: got %+v, want %+v", r.StationID, got, exp)
+		}
+	}
+}
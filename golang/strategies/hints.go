@@ -0,0 +1,26 @@
+package strategies
+
+import "os"
+
+// Readahead advice values shared by posix_fadvise and madvise.
+const (
+	adviceSequential = 2 // POSIX_FADV_SEQUENTIAL / MADV_SEQUENTIAL
+	adviceWillNeed   = 3 // POSIX_FADV_WILLNEED / MADV_WILLNEED
+)
+
+// adviseSequential tells the kernel f will be read front to back, which
+// typically doubles the readahead window. Hints are best effort: failures
+// and unsupported platforms are silently ignored.
+func (o StrategyOptions) adviseSequential(f *os.File) {
+	if !o.DisableIOHints {
+		fadvise(f, 0, 0, adviceSequential)
+	}
+}
+
+// adviseWillNeed asks the kernel to start reading [offset, offset+length)
+// into the page cache in the background, ahead of a worker reaching it.
+func (o StrategyOptions) adviseWillNeed(f *os.File, offset, length int64) {
+	if !o.DisableIOHints {
+		fadvise(f, offset, length, adviceWillNeed)
+	}
+}
@@ -0,0 +1,28 @@
+package strategies
+
+import (
+	"os"
+	"unsafe"
+)
+
+// adviseHugePages asks the kernel to back s with transparent huge pages
+// when HugePages is set. Large tables probed at random addresses otherwise
+// spend a noticeable share of their time on TLB misses. Only the
+// page-aligned interior of s is advised, and the hint must be given before
+// the memory is first touched to take effect.
+func adviseHugePages[T any](o StrategyOptions, s []T) {
+	if !o.HugePages || len(s) == 0 {
+		return
+	}
+	size := uintptr(len(s)) * unsafe.Sizeof(s[0])
+	mem := unsafe.Slice((*byte)(unsafe.Pointer(&s[0])), size)
+
+	page := uintptr(os.Getpagesize())
+	start := uintptr(unsafe.Pointer(&s[0]))
+	lo := (start+page-1)&^(page-1) - start
+	hi := (start+size)&^(page-1) - start
+	if hi <= lo || hi > size {
+		return
+	}
+	madviseHugePages(mem[lo:hi])
+}
@@ -0,0 +1,9 @@
+//go:build linux
+
+package strategies
+
+import "syscall"
+
+func madviseHugePages(b []byte) {
+	syscall.Madvise(b, syscall.MADV_HUGEPAGE)
+}
@@ -0,0 +1,6 @@
+//go:build !linux
+
+package strategies
+
+// madviseHugePages is a no-op where MADV_HUGEPAGE does not exist.
+func madviseHugePages(b []byte) {}
@@ -0,0 +1,74 @@
+package strategies
+
+import (
+	"context"
+	"io"
+)
+
+// ReaderAtCalculator is implemented by strategies that also aggregate an
+// io.ReaderAt, such as a file uploaded to a browser or a byte slice
+// wrapped in bytes.NewReader, where there is no file path to open. Their
+// workers read it in chunks through ReadAt, like a file.
+type ReaderAtCalculator interface {
+	CalculateReaderAt(ctx context.Context, r io.ReaderAt, size int64) ([]StationResult, error)
+}
+
+// AggregateReaderAt aggregates the first size bytes of r with the Swiss
+// table strategy configured with opts. It touches no file system, so it
+// also runs under GOOS=js.
+func AggregateReaderAt(ctx context.Context, r io.ReaderAt, size int64, opts StrategyOptions) ([]StationResult, error) {
+	return NewSwissTableStrategy(opts).CalculateReaderAt(ctx, r, size)
+}
+
+// input is what a chunked strategy reads: a file or remote object named by
+// path, or the first size bytes of r.
+type input struct {
+	path string
+	r    io.ReaderAt
+	size int64
+}
+
+func pathInput(path string) input { return input{path: path} }
+
+func readerAtInput(r io.ReaderAt, size int64) input { return input{r: r, size: size} }
+
+// open returns the chunkSource reading in.
+func (in input) open(ctx context.Context, opts StrategyOptions) (chunkSource, error) {
+	if in.r == nil {
+		return openChunkSource(ctx, in.path, opts)
+	}
+	_, end := opts.startSpan(ctx, SpanOpen)
+	defer end()
+	return readerAtSource{in.r, in.size}, nil
+}
+
+// locate fills in the line number of a *ParseError, as locateParseError
+// does for a file.
+func (in input) locate(err error) error {
+	if in.r == nil {
+		return locateParseError(in.path, err)
+	}
+	return locateParseErrorIn(io.NewSectionReader(in.r, 0, in.size), err)
+}
+
+// readerAtSource reads the first n bytes of an io.ReaderAt.
+type readerAtSource struct {
+	r io.ReaderAt
+	n int64
+}
+
+func (s readerAtSource) size() int64 { return s.n }
+
+func (s readerAtSource) queue(chunkSize int64) (*chunkQueue, error) {
+	bounds, err := planBounds(io.NewSectionReader(s.r, 0, s.n), s.n, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	return newAlignedChunkQueue(bounds), nil
+}
+
+func (s readerAtSource) readFrom(_ context.Context, offset, end int64) (io.ReadCloser, error) {
+	return io.NopCloser(io.NewSectionReader(s.r, offset, s.n-offset)), nil
+}
+
+func (s readerAtSource) Close() error { return nil }
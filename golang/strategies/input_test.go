@@ -0,0 +1,58 @@
+package strategies
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestStrategiesAggregateReaderAt(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Bytes past size are not part of the input.
+	padded := append(data, "Oslo;99.9\n"...)
+
+	tested := 0
+	for _, s := range strategiesWith(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 4096}) {
+		calc, ok := s.strategy.(ReaderAtCalculator)
+		if !ok {
+			continue
+		}
+		tested++
+		t.Run(s.name, func(t *testing.T) {
+			results, err := calc.CalculateReaderAt(t.Context(), bytes.NewReader(padded), int64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkResults(t, results, want)
+		})
+	}
+	if tested == 0 {
+		t.Fatal("no strategy reads an io.ReaderAt")
+	}
+
+	results, err := AggregateReaderAt(t.Context(), bytes.NewReader(data), int64(len(data)), StrategyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResults(t, results, want)
+}
+
+func TestAggregateReaderAtReportsLine(t *testing.T) {
+	path, _ := writeRefillDataset(t, 5_000, 200)
+	insertLines(t, path, []int{3210}, []string{"Oslo;12,3"})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = AggregateReaderAt(t.Context(), bytes.NewReader(data), int64(len(data)), StrategyOptions{Workers: 4, ChunkSize: 8192, ParseMode: ParseStrict})
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 3211 {
+		t.Errorf("got %v, want a *ParseError on line 3211", err)
+	}
+}
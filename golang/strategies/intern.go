@@ -0,0 +1,36 @@
+package strategies
+
+import "sync"
+
+// internTable converts station names to strings once per run and shares
+// the result between all workers. Without it every worker allocates its
+// own copy of every name, and the merge phase juggles N duplicates of each.
+// Lookups take a read lock and do not allocate; only the first sighting of
+// a name anywhere in the run takes the write lock.
+type internTable struct {
+	mu    sync.RWMutex
+	names map[string]string
+}
+
+func newInternTable() *internTable {
+	return &internTable{names: make(map[string]string, 1024)}
+}
+
+// intern returns the run-wide string for name.
+func (t *internTable) intern(name []byte) string {
+	t.mu.RLock()
+	s, ok := t.names[string(name)]
+	t.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s, ok := t.names[string(name)]; ok {
+		return s
+	}
+	s = string(name)
+	t.names[s] = s
+	return s
+}
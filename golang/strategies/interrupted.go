@@ -0,0 +1,45 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// PartialError is returned by the strategies reading chunks through the
+// shared chunk driver (the hash-table strategies, double-buffer and
+// sharded-map) when the context is cancelled or expires mid-run. Rather than discard their
+// work, they merge what every worker had aggregated by then into Results,
+// marked partial by the error. Those stations cover only some of the
+// input's lines, so counts fall short and extremes may be missed. Err is
+// the context's error, so errors.Is(err, context.DeadlineExceeded) still
+// holds.
+type PartialError struct {
+	Results []StationResult
+
+	// BytesRead is how much of the input had been read into Results,
+	// including the chunks a Checkpoint had saved.
+	BytesRead int64
+
+	Err error
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%v after %d bytes, with partial results for %d stations", e.Err, e.BytesRead, len(e.Results))
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// interrupted reports whether err is the error of ctx having been
+// cancelled or expired.
+func interrupted(ctx context.Context, err error) bool {
+	return ctx.Err() != nil && errors.Is(err, ctx.Err())
+}
+
+// partialResults returns a *PartialError for err with the stations of
+// maps merged.
+func partialResults[K comparable](err error, p *progress, maps ...map[K]StationResult) *PartialError {
+	return &PartialError{Results: calcAverges(mergeMaps(maps)), BytesRead: p.BytesRead(), Err: err}
+}
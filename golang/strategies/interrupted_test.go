@@ -0,0 +1,101 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cancelAtChunk is a Tracer cancelling a run as its workers start their
+// nth chunk.
+type cancelAtChunk struct {
+	n      int64
+	chunks atomic.Int64
+	cancel context.CancelFunc
+}
+
+func (c *cancelAtChunk) StartSpan(ctx context.Context, name string, _ ...SpanAttr) (context.Context, func()) {
+	if name == SpanChunk && c.chunks.Add(1) == c.n {
+		c.cancel()
+	}
+	return ctx, func() {}
+}
+
+func TestCancelledRunReturnsPartialResults(t *testing.T) {
+	const rows = 20_000
+	path, want := writeRefillDataset(t, rows, 300)
+	opts := StrategyOptions{Workers: 1, ChunkSize: 64 << 10}
+	plan, err := planChunks(path, opts, 0, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range Registered() {
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		tracer := &cancelAtChunk{n: 4, cancel: cancel}
+		opts.Tracer = tracer
+		s := registeredStrategy(key, opts)
+		if _, twoPass := s.strategy.(*PerfectHashStrategy); twoPass {
+			// Its first pass only collects names.
+			tracer.n += int64(len(plan.Chunks))
+		}
+		checkpoints := s.Checkpoints
+		_, doubleBuffered := s.strategy.(*DoubleBufferedStrategy)
+		_, sharded := s.strategy.(*ShardedMapStrategy)
+		if !checkpoints && !doubleBuffered && !sharded || !Supported(s.strategy) {
+			continue
+		}
+		t.Run(s.name, func(t *testing.T) {
+			_, err := s.strategy.Calculate(ctx, path)
+			var partial *PartialError
+			if !errors.As(err, &partial) || !errors.Is(err, context.Canceled) {
+				t.Fatalf("got error %v, want a *PartialError of context.Canceled", err)
+			}
+			var counted int64
+			for _, r := range partial.Results {
+				w, ok := want[r.StationID]
+				if !ok || r.Count > w.count || r.Minimum < w.min || r.Maximum > w.max {
+					t.Errorf("station %q: got %+v, not part of %+v", r.StationID, r, w)
+				}
+				counted += r.Count
+			}
+			if counted == 0 || counted >= rows {
+				t.Errorf("partial results count %d rows, want some of the %d", counted, rows)
+			}
+			if partial.BytesRead == 0 {
+				t.Error("partial results read no bytes")
+			}
+		})
+	}
+}
+
+func TestCancelledContextStopsEveryStrategy(t *testing.T) {
+	path, _ := writeRefillDataset(t, 500_000, 300)
+	for _, key := range Registered() {
+		// Cancel as soon as the run has read something, so that it must
+		// notice mid-read rather than only before it starts.
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		s := registeredStrategy(key, StrategyOptions{
+			Workers:          2,
+			ChunkSize:        64 << 10,
+			ProgressInterval: time.Millisecond,
+			Progress: ProgressFunc(func(bytesRead, _ int64) {
+				if bytesRead > 0 {
+					cancel()
+				}
+			}),
+		})
+		if !Supported(s.strategy) {
+			continue
+		}
+		t.Run(s.name, func(t *testing.T) {
+			if _, err := s.strategy.Calculate(ctx, path); !errors.Is(err, context.Canceled) {
+				t.Errorf("got error %v, want context.Canceled", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+//go:build linux
+
+package strategies
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// IOURingStrategy pulls chunks from the shared queue and reads them with
+// io_uring, keeping several reads in flight per worker so the kernel can
+// overlap them with parsing and with each other. It is only available on
+// Linux 5.6+ (IORING_OP_READ).
+type IOURingStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	opts StrategyOptions
+}
+
+// NewIOURingStrategy returns an IOURingStrategy configured with opts.
+func NewIOURingStrategy(opts StrategyOptions) *IOURingStrategy {
+	return &IOURingStrategy{opts: opts}
+}
+
+func (u *IOURingStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	u.resetProgress()
+	defer u.reportProgress(u.opts)()
+	u.resetMalformed(u.opts)
+	f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fsize, err := getFileSize(f)
+	if err != nil {
+		return nil, err
+	}
+	n := u.opts.workers()
+	chunkSize := u.opts.chunkSize(fsize, n)
+	queue, err := u.opts.chunkQueue(f, fsize, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	bufSize := uringBufferSize(u.opts, chunkSize)
+
+	tempMaps := make([]StationMap, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := range n {
+		tempMaps[i] = make(StationMap, u.opts.mapCapacity())
+		go func(i int) {
+			defer wg.Done()
+			defer u.opts.pinWorker(i)()
+
+			ring, err := newURing(uringDefaultQueueSize)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer ring.close()
+
+			bufs := make([][]byte, uringDefaultQueueSize)
+			for j := range bufs {
+				bufs[j] = make([]byte, bufSize)
+			}
+
+			for start, end, ok := queue.pop(); ok; start, end, ok = queue.pop() {
+				u.opts.adviseWillNeed(f, start, end-start)
+				src := newURingSource(ring, int(f.Fd()), fsize, max(start-1, 0), bufs)
+				errs[i] = inChunk(consumeChunk(ctx, src, start, end, u.opts.sink(tempMaps[i]), &u.progress, &u.malformedLines), queue.index(start), i)
+				if err := src.close(); errs[i] == nil {
+					errs[i] = err
+				}
+				if errs[i] != nil {
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := firstError(errs); err != nil {
+		return nil, locateParseError(filePath, err)
+	}
+	return emitResults(&u.resultEmitter, tempMaps...), nil
+}
+
+// readRing is the part of a uring a uringSource drives.
+type readRing interface {
+	queueRead(fd int, buf []byte, off int64, userData uint64)
+	submitAndWait(toSubmit, minComplete uint32) error
+	reap() (uringCQE, bool)
+}
+
+// uringSource is a blockSource that reads a file region through io_uring.
+// Block seq always uses bufs[seq%len(bufs)], so a block can only be
+// submitted once the block len(bufs) before it has been released. A read
+// may complete short of its block, in which case the rest of the block is
+// requested before the block is handed out.
+type uringSource struct {
+	ring     readRing
+	fd       int
+	fileSize int64
+	bufs     [][]byte
+
+	nextOffset int64  // file offset of the next block to submit
+	nextSeq    uint64 // sequence number of the next block to submit
+	wantSeq    uint64 // sequence number of the next block to hand out
+	queued     uint32 // requests queued but not yet passed to the kernel
+	inflight   int    // requests submitted whose completion is not reaped
+
+	// Per slot: the block being read into its buffer, the block's file
+	// offset, and the bytes of it read so far or the negated errno.
+	blocks  [][]byte
+	offsets []int64
+	results []int32
+	ready   []bool
+}
+
+func newURingSource(ring readRing, fd int, fileSize, offset int64, bufs [][]byte) *uringSource {
+	s := &uringSource{
+		ring:       ring,
+		fd:         fd,
+		fileSize:   fileSize,
+		bufs:       bufs,
+		nextOffset: offset,
+		blocks:     make([][]byte, len(bufs)),
+		offsets:    make([]int64, len(bufs)),
+		results:    make([]int32, len(bufs)),
+		ready:      make([]bool, len(bufs)),
+	}
+	for range bufs {
+		s.submitNext()
+	}
+	return s
+}
+
+func (s *uringSource) submitNext() {
+	if s.nextOffset >= s.fileSize {
+		return
+	}
+	slot := s.nextSeq % uint64(len(s.bufs))
+	buf := s.bufs[slot]
+	block := buf[:min(int64(len(buf)), s.fileSize-s.nextOffset)]
+	s.blocks[slot], s.offsets[slot], s.results[slot] = block, s.nextOffset, 0
+	s.queue(block, s.nextOffset, s.nextSeq)
+	s.nextOffset += int64(len(block))
+	s.nextSeq++
+}
+
+func (s *uringSource) queue(buf []byte, offset int64, seq uint64) {
+	s.ring.queueRead(s.fd, buf, offset, seq)
+	s.queued++
+	s.inflight++
+}
+
+// wait submits pending requests and collects at least one completion.
+func (s *uringSource) wait() error {
+	if err := s.ring.submitAndWait(s.queued, 1); err != nil {
+		return err
+	}
+	s.queued = 0
+	for {
+		cqe, ok := s.ring.reap()
+		if !ok {
+			return nil
+		}
+		s.inflight--
+		slot := cqe.userData % uint64(len(s.bufs))
+		if cqe.res < 0 {
+			s.results[slot] = cqe.res
+			s.ready[slot] = true
+			continue
+		}
+		got := s.results[slot] + cqe.res
+		s.results[slot] = got
+		if cqe.res > 0 && int(got) < len(s.blocks[slot]) {
+			// A short read: ask for the rest of the block, or the next
+			// block would start at bytes no read has covered.
+			s.queue(s.blocks[slot][got:], s.offsets[slot]+int64(got), cqe.userData)
+			continue
+		}
+		s.ready[slot] = true
+	}
+}
+
+func (s *uringSource) next() ([]byte, error) {
+	if s.wantSeq >= s.nextSeq {
+		return nil, io.EOF
+	}
+
+	slot := s.wantSeq % uint64(len(s.bufs))
+	for !s.ready[slot] {
+		if err := s.wait(); err != nil {
+			return nil, err
+		}
+	}
+
+	res := s.results[slot]
+	switch {
+	case res < 0:
+		return nil, fmt.Errorf("io_uring read: %w", syscall.Errno(-res))
+	case res == 0:
+		return nil, io.EOF
+	}
+	return s.blocks[slot][:res], nil
+}
+
+// release hands the current block back and reuses its buffer for the next
+// block in the file.
+func (s *uringSource) release([]byte) {
+	s.ready[s.wantSeq%uint64(len(s.bufs))] = false
+	s.wantSeq++
+	s.submitNext()
+}
+
+// close waits for every outstanding read to complete so that the buffers
+// and the ring can be reused for the next chunk.
+func (s *uringSource) close() error {
+	for s.inflight > 0 {
+		if err := s.wait(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uringBufferSize is the size of each of a worker's uringDefaultQueueSize
+// read buffers: the configured size, but no more than a chunk spread over
+// them.
+func uringBufferSize(opts StrategyOptions, chunkSize int64) int64 {
+	return min(int64(opts.bufferSize(defaultChunkBufSize)), max(chunkSize/uringDefaultQueueSize, minTailRead))
+}
+
+// planIOURing is the Entry.Plan of IOURingStrategy.
+func planIOURing(filePath string, opts StrategyOptions) (ChunkPlan, error) {
+	return planChunks(filePath, opts, uringDefaultQueueSize, func(chunkSize int64) int64 {
+		return uringBufferSize(opts, chunkSize)
+	}, opts.mapCapacity())
+}
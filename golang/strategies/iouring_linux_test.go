@@ -0,0 +1,68 @@
+package strategies
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// shortReadRing completes every read at once from data, but never with
+// more than limit bytes, as a kernel may.
+type shortReadRing struct {
+	data  []byte
+	limit int
+	done  []uringCQE
+}
+
+func (r *shortReadRing) queueRead(fd int, buf []byte, off int64, userData uint64) {
+	n := copy(buf[:min(len(buf), r.limit)], r.data[min(int(off), len(r.data)):])
+	r.done = append(r.done, uringCQE{userData: userData, res: int32(n)})
+}
+
+func (r *shortReadRing) submitAndWait(toSubmit, minComplete uint32) error {
+	return nil
+}
+
+func (r *shortReadRing) reap() (uringCQE, bool) {
+	if len(r.done) == 0 {
+		return uringCQE{}, false
+	}
+	cqe := r.done[0]
+	r.done = r.done[1:]
+	return cqe, true
+}
+
+func TestURingSourceCompletesShortReads(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	bufs := make([][]byte, 4)
+	for i := range bufs {
+		bufs[i] = make([]byte, 64)
+	}
+
+	// Every read stops 7 bytes in, so each 64-byte block takes ten.
+	src := newURingSource(&shortReadRing{data: data, limit: 7}, 0, int64(len(data)), 10, bufs)
+	var got []byte
+	for {
+		block, err := src.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(block) != min(64, len(data)-10-len(got)) {
+			t.Fatalf("block at %d has %d bytes, want a whole one", 10+len(got), len(block))
+		}
+		got = append(got, block...)
+		src.release(block)
+	}
+	if err := src.close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data[10:]) {
+		t.Errorf("read %d bytes that differ from the file's %d", len(got), len(data)-10)
+	}
+}
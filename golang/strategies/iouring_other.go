@@ -0,0 +1,26 @@
+//go:build !linux
+
+package strategies
+
+import "context"
+
+// IOURingStrategy reads chunks with io_uring, which only exists on Linux.
+// On other platforms Calculate always fails.
+type IOURingStrategy struct {
+	platformStub
+	progress
+	opts StrategyOptions
+}
+
+// NewIOURingStrategy returns an IOURingStrategy configured with opts.
+func NewIOURingStrategy(opts StrategyOptions) *IOURingStrategy {
+	return &IOURingStrategy{opts: opts}
+}
+
+func (u *IOURingStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	return nil, platformError("io_uring strategy requires Linux")
+}
+
+func planIOURing(filePath string, opts StrategyOptions) (ChunkPlan, error) {
+	return ChunkPlan{}, platformError("io_uring strategy requires Linux")
+}
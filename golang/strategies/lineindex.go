@@ -0,0 +1,133 @@
+package strategies
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"slices"
+)
+
+// lineIndexMagic opens a line index sidecar.
+const lineIndexMagic = "1BRCidx\x01"
+
+// lineIndexSuffix is appended to a data file's path to name its sidecar.
+const lineIndexSuffix = ".lineidx"
+
+// lineIndex lists offsets at which lines of a file start, at least stride
+// bytes apart, so chunks can be cut at exact line boundaries without
+// probing the file. It records the size and modification time of the file
+// it was built for, and is rebuilt when either changes.
+type lineIndex struct {
+	size    int64
+	modTime int64 // UnixNano
+	stride  int64
+	offsets []int64 // line starts, ascending, excluding 0
+}
+
+// loadLineIndex returns the line index of filePath with a stride of at
+// most chunkSize, reading it from the sidecar if that is current and
+// building and saving it otherwise. A sidecar that cannot be written, as in
+// a read-only directory, only costs the next run a rebuild.
+func loadLineIndex(filePath string, chunkSize int64) (*lineIndex, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	sidecar := filePath + lineIndexSuffix
+	if idx, err := readLineIndex(sidecar); err == nil &&
+		idx.size == info.Size() && idx.modTime == info.ModTime().UnixNano() && idx.stride <= chunkSize {
+		return idx, nil
+	}
+
+	idx, err := buildLineIndex(filePath, min(chunkSize, minChunkSize))
+	if err != nil {
+		return nil, err
+	}
+	idx.size, idx.modTime = info.Size(), info.ModTime().UnixNano()
+	idx.write(sidecar)
+	return idx, nil
+}
+
+// buildLineIndex finds the first line starting at or after every stride
+// bytes of filePath. It reads a few hundred bytes per entry rather than the
+// whole file.
+func buildLineIndex(filePath string, stride int64) (*lineIndex, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fsize, err := getFileSize(f)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &lineIndex{stride: stride}
+	buf := make([]byte, minTailRead)
+	for pos := stride; pos < fsize; pos += stride {
+		pos, err = nextLineStart(f, pos, buf)
+		if err != nil {
+			return nil, err
+		}
+		if pos >= fsize {
+			break
+		}
+		idx.offsets = append(idx.offsets, pos)
+	}
+	return idx, nil
+}
+
+// bounds returns chunk boundaries of about chunkSize bytes, from 0 to the
+// file size, each but the last a line start from the index.
+func (idx *lineIndex) bounds(chunkSize int64) []int64 {
+	bounds := []int64{0}
+	for {
+		last := bounds[len(bounds)-1]
+		i, _ := slices.BinarySearch(idx.offsets, last+chunkSize)
+		if i == len(idx.offsets) {
+			return append(bounds, idx.size)
+		}
+		bounds = append(bounds, idx.offsets[i])
+	}
+}
+
+// readLineIndex decodes the sidecar at path.
+func readLineIndex(path string) (*lineIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	const headerSize = len(lineIndexMagic) + 4*8
+	if len(data) < headerSize || string(data[:len(lineIndexMagic)]) != lineIndexMagic {
+		return nil, errors.New("not a line index")
+	}
+	header := data[len(lineIndexMagic):]
+	idx := &lineIndex{
+		size:    int64(binary.LittleEndian.Uint64(header)),
+		modTime: int64(binary.LittleEndian.Uint64(header[8:])),
+		stride:  int64(binary.LittleEndian.Uint64(header[16:])),
+	}
+	count := binary.LittleEndian.Uint64(header[24:])
+	body := data[headerSize:]
+	if count != uint64(len(body)/8) || len(body)%8 != 0 {
+		return nil, errors.New("truncated line index")
+	}
+	idx.offsets = make([]int64, count)
+	for i := range idx.offsets {
+		idx.offsets[i] = int64(binary.LittleEndian.Uint64(body[8*i:]))
+	}
+	return idx, nil
+}
+
+// write saves the index to path, through a temporary file so that a
+// concurrent run never reads half of it.
+func (idx *lineIndex) write(path string) error {
+	data := []byte(lineIndexMagic)
+	for _, v := range []int64{idx.size, idx.modTime, idx.stride, int64(len(idx.offsets))} {
+		data = binary.LittleEndian.AppendUint64(data, uint64(v))
+	}
+	for _, off := range idx.offsets {
+		data = binary.LittleEndian.AppendUint64(data, uint64(off))
+	}
+	return replaceFile(path, data)
+}
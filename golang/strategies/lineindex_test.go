@@ -0,0 +1,74 @@
+package strategies
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLineIndexCutsChunksAtLines(t *testing.T) {
+	path, _ := writeRefillDataset(t, 20_000, 300)
+	// A line longer than several strides.
+	insertLines(t, path, []int{700}, []string{"Oslo;" + strings.Repeat("1", 10_000)})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, chunkSize := range []int64{1000, 4096, 64 << 10, 1 << 30} {
+		idx, err := loadLineIndex(path, chunkSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bounds := idx.bounds(chunkSize)
+		if bounds[0] != 0 || bounds[len(bounds)-1] != int64(len(data)) {
+			t.Fatalf("chunk size %d: bounds run from %d to %d, want 0 to %d", chunkSize, bounds[0], bounds[len(bounds)-1], len(data))
+		}
+		for i, b := range bounds[1 : len(bounds)-1] {
+			if data[b-1] != '\n' || b <= bounds[i] {
+				t.Fatalf("chunk size %d: bound %d at byte %d is not a line start after %d", chunkSize, i+1, b, bounds[i])
+			}
+		}
+	}
+}
+
+func TestStrategiesReadWithLineIndex(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+	sidecar := path + lineIndexSuffix
+
+	for _, s := range strategiesWith(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 4096, LineIndex: true}) {
+		t.Run(s.name, func(t *testing.T) {
+			checkAggregates(t, s.strategy, path, want)
+		})
+	}
+	info, err := os.Stat(sidecar)
+	if err != nil {
+		t.Fatalf("no sidecar index: %v", err)
+	}
+
+	// A second run reuses the index.
+	checkAggregates(t, NewMCMPStrategy(StrategyOptions{Workers: 4, ChunkSize: 4096, LineIndex: true}), path, want)
+	if again, err := os.Stat(sidecar); err != nil || !again.ModTime().Equal(info.ModTime()) {
+		t.Error("sidecar index rewritten for an unchanged file")
+	}
+
+	// A changed file gets a new one.
+	path, want = writeRefillDataset(t, 5_000, 50)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := path + lineIndexSuffix
+	if err := os.Rename(sidecar, old); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	checkAggregates(t, NewSwissTableStrategy(StrategyOptions{Workers: 4, ChunkSize: 4096, LineIndex: true}), path, want)
+	if idx, err := readLineIndex(old); err != nil || idx.size != int64(len(data)) {
+		t.Errorf("stale index not rebuilt: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package strategies
+
+import (
+	"math"
+	"math/bits"
+)
+
+const (
+	// lowMemoryStations is how many stations LowMemoryOptions sizes tables
+	// and maps for: the 10,000 the 1BRC rules allow.
+	lowMemoryStations = 10000
+
+	// minWorkerMemory is the least of the budget LowMemoryOptions gives a
+	// worker; below it, it runs fewer workers instead.
+	minWorkerMemory = 8 << 20
+
+	// stationEntryBytes bounds the size of one table slot or map entry.
+	stationEntryBytes = 80
+)
+
+// LowMemoryOptions returns opts adjusted so that a run stays within budget
+// bytes, trading speed for memory: hash tables start at the size each
+// worker's share of the budget allows, maps are sized for the 1BRC's 10,000
+// stations rather than ten times that, read buffers shrink to what is left
+// of the share, and workers are dropped once a share would fall below
+// 8 MiB. Half the budget is left to the Go runtime for garbage and stacks,
+// so pair it with a GOMEMLIMIT of budget. Fields already set in opts are
+// kept.
+func LowMemoryOptions(opts StrategyOptions, budget int64) StrategyOptions {
+	working := budget / 2
+	opts.Workers = int(max(1, min(int64(opts.workers()), working/minWorkerMemory)))
+	share := working / int64(opts.Workers)
+	if opts.TableSize == 0 {
+		opts.TableSize = lowMemoryTableSize(share, opts.maxLoadFactor())
+	}
+	if opts.MapCapacity == 0 {
+		opts.MapCapacity = lowMemoryStations
+	}
+
+	if opts.BufferSize == 0 {
+		// A worker holds a table, a map and prefetchDepth read buffers.
+		share -= int64(opts.tableSize()+opts.MapCapacity) * stationEntryBytes
+		buf := min(max(share/prefetchDepth, defaultChunkBufSize), defaultBlockBufSize)
+		opts.BufferSize = 1 << (bits.Len64(uint64(buf)) - 1)
+	}
+	return opts
+}
+
+// lowMemoryTableSize returns the slots, a power of two, that a worker with
+// share bytes starts its tables at: the most that fit an eighth of the
+// share, which leaves room for them to double a few times within it, but
+// no more than the usual default, and no fewer than hold lowMemoryStations
+// below loadFactor.
+func lowMemoryTableSize(share int64, loadFactor float64) int {
+	fit := max(share/8/stationEntryBytes, 1)
+	size := min(1<<(bits.Len64(uint64(fit))-1), defaultTableSize)
+	need := int(math.Ceil(lowMemoryStations / loadFactor))
+	return max(size, 1<<bits.Len(uint(need-1)))
+}
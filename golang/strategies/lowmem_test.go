@@ -0,0 +1,52 @@
+package strategies
+
+import "testing"
+
+func TestLowMemoryOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        StrategyOptions
+		budget      int64
+		wantWorkers int
+		wantBuffer  int
+		wantTable   int
+	}{
+		{"roomy", StrategyOptions{Workers: 8}, 8 << 30, 8, defaultBlockBufSize, defaultTableSize},
+		{"tight", StrategyOptions{Workers: 8}, 48 << 20, 3, defaultBlockBufSize, 16384},
+		{"small", StrategyOptions{Workers: 8}, 6 << 20, 1, 256 << 10, 16384},
+		{"tiny", StrategyOptions{Workers: 8}, 1 << 20, 1, defaultChunkBufSize, 16384},
+		{"explicit", StrategyOptions{Workers: 2, BufferSize: 12345}, 8 << 30, 2, 12345, defaultTableSize},
+		{"middling", StrategyOptions{Workers: 2}, 128 << 20, 2, defaultBlockBufSize, 32768},
+		{"sparse", StrategyOptions{Workers: 1, MaxLoadFactor: 0.5}, 16 << 20, 1, defaultBlockBufSize, 32768},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LowMemoryOptions(tt.opts, tt.budget)
+			if got.Workers != tt.wantWorkers || got.BufferSize != tt.wantBuffer {
+				t.Errorf("got %d workers with %d-byte buffers, want %d with %d",
+					got.Workers, got.BufferSize, tt.wantWorkers, tt.wantBuffer)
+			}
+			if got.TableSize != tt.wantTable || got.MapCapacity != lowMemoryStations {
+				t.Errorf("got table size %d and map capacity %d", got.TableSize, got.MapCapacity)
+			}
+		})
+	}
+}
+
+func TestStrategiesWithLowMemoryOptions(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+	opts := LowMemoryOptions(StrategyOptions{Workers: 4, ChunkSize: 4096}, 32<<20)
+	for _, s := range strategiesWith(opts) {
+		t.Run(s.name, func(t *testing.T) {
+			checkAggregates(t, s.strategy, path, want)
+
+			// Tables start at the low-memory size, which holds these
+			// stations without growing; short-key tables come in pairs.
+			if r, ok := s.strategy.(ProbeStatsReporter); ok {
+				if slots := r.ProbeStats().Slots; slots > 2*opts.Workers*opts.TableSize {
+					t.Errorf("tables hold %d slots, want at most %d", slots, 2*opts.Workers*opts.TableSize)
+				}
+			}
+		})
+	}
+}
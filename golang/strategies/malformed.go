@@ -0,0 +1,215 @@
+package strategies
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// ParseMode selects what strategies do with a line that is not a valid
+// "name;temperature" record.
+type ParseMode int
+
+const (
+	// ParseLenient skips malformed lines and counts them. It is the
+	// default.
+	ParseLenient ParseMode = iota
+
+	// ParseStrict aborts the run with a *ParseError at the first malformed
+	// line a strategy reaches.
+	ParseStrict
+)
+
+// errMalformedLine is returned by the parsers for a line that is not a
+// valid record. It is a sentinel so rejecting a line never allocates.
+var errMalformedLine = errors.New("malformed line")
+
+// maxParseSnippet bounds the bytes of a malformed line a ParseError
+// keeps, so that a run into binary garbage does not carry it all.
+const maxParseSnippet = 64
+
+// ParseError reports a malformed line in strict mode. Parallel strategies
+// stop at the first malformed line any worker reaches; of those the
+// workers reached before stopping, Calculate returns the one nearest the
+// start of the file, which need not be the first in it.
+type ParseError struct {
+	Line int64 // 1-based line number, or 0 if the input could not be reread
+
+	// Offset is the byte offset of the line, from which Line is derived;
+	// for compressed input, in the decoded input.
+	Offset int64
+
+	// Chunk is the 0-based index, in file order, of the chunk the line
+	// lies in, and Worker that of the worker that read it. Both are -1
+	// for strategies that do not split their input into chunks.
+	Chunk, Worker int
+
+	// Text is the line, cut to its first 64 bytes.
+	Text string
+}
+
+func (e *ParseError) Error() string {
+	where := fmt.Sprintf("byte %d", e.Offset)
+	if e.Chunk >= 0 {
+		where += fmt.Sprintf(", chunk %d on worker %d", e.Chunk, e.Worker)
+	}
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: malformed record %q", where, e.Text)
+	}
+	return fmt.Sprintf("line %d (%s): malformed record %q", e.Line, where, e.Text)
+}
+
+// newParseError returns the *ParseError for line, found at offset by a
+// strategy that does not chunk its input, or by a worker that inChunk
+// then places.
+func newParseError(offset int64, line []byte) *ParseError {
+	text := line[:min(len(line), maxParseSnippet)]
+	return &ParseError{Offset: offset, Chunk: -1, Worker: -1, Text: string(text)}
+}
+
+// inChunk records in err, if it is a *ParseError, the chunk and worker it
+// arose in, and returns it.
+func inChunk(err error, chunk int64, worker int) error {
+	var perr *ParseError
+	if errors.As(err, &perr) {
+		perr.Chunk, perr.Worker = int(chunk), worker
+	}
+	return err
+}
+
+// firstError picks the error a run reports from those of its workers: the
+// first that is not a *ParseError, which is likely to have caused the
+// others, or else the *ParseError nearest the start of the file.
+func firstError(errs []error) error {
+	var first error
+	var firstOffset int64
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			return err
+		}
+		if first == nil || perr.Offset < firstOffset {
+			first, firstOffset = err, perr.Offset
+		}
+	}
+	return first
+}
+
+// Quarantine receives the malformed lines strategies skip in lenient mode,
+// set through StrategyOptions.Quarantine, so that they can be inspected
+// rather than only counted. offset is where the line starts in its file,
+// and line, without its newline, is only valid during the call. It is
+// called from every worker at once.
+type Quarantine interface {
+	QuarantineLine(offset int64, line []byte)
+}
+
+// QuarantineFunc adapts a function to Quarantine.
+type QuarantineFunc func(offset int64, line []byte)
+
+func (f QuarantineFunc) QuarantineLine(offset int64, line []byte) {
+	f(offset, line)
+}
+
+// MalformedLineCounter is implemented by strategies that count the
+// malformed lines they skipped in lenient mode.
+type MalformedLineCounter interface {
+	MalformedLines() int64
+}
+
+// malformedLines is embedded in strategies to satisfy
+// MalformedLineCounter and to apply their ParseMode.
+type malformedLines struct {
+	count      atomic.Int64
+	mode       ParseMode
+	quarantine Quarantine
+}
+
+func (m *malformedLines) MalformedLines() int64 {
+	return m.count.Load()
+}
+
+// resetMalformed clears the count and takes the mode and quarantine of
+// opts for the next run.
+func (m *malformedLines) resetMalformed(opts StrategyOptions) {
+	m.count.Store(0)
+	m.mode = opts.ParseMode
+	m.quarantine = opts.Quarantine
+}
+
+// reject handles a malformed line starting at the given file offset. In
+// lenient mode it counts the line, passes it to the quarantine if there is
+// one and returns nil; in strict mode it returns a *ParseError whose Line
+// is filled in by locateParseError.
+func (m *malformedLines) reject(offset int64, line []byte) error {
+	if m.mode == ParseStrict {
+		return newParseError(offset, line)
+	}
+	m.count.Add(1)
+	if m.quarantine != nil {
+		m.quarantine.QuarantineLine(offset, line)
+	}
+	return nil
+}
+
+// feedLine passes a line found at offset to sink, rejecting it if the sink
+// could not parse it.
+func feedLine(sink lineSink, m *malformedLines, line []byte, offset int64) error {
+	if sink.addLine(line) {
+		return nil
+	}
+	return m.reject(offset, line)
+}
+
+// locateParseError turns the byte offset of a *ParseError into a line
+// number by counting the newlines before it in filePath. Workers only know
+// where their chunk starts in bytes, so this runs once, on the way out,
+// rather than every worker counting lines. Input that cannot be read twice,
+// such as a FIFO, keeps Line 0. Other errors pass through.
+func locateParseError(filePath string, err error) error {
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 0 {
+		return err
+	}
+	if info, serr := os.Stat(filePath); serr != nil || !info.Mode().IsRegular() {
+		return err
+	}
+
+	f, ferr := os.Open(filePath)
+	if ferr != nil {
+		return err
+	}
+	defer f.Close()
+	return locateParseErrorIn(f, err)
+}
+
+// locateParseErrorIn is locateParseError for input read from r, from its
+// first byte.
+func locateParseErrorIn(r io.Reader, err error) error {
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 0 {
+		return err
+	}
+
+	buf := make([]byte, defaultBlockBufSize)
+	line := int64(1)
+	for remaining := perr.Offset; remaining > 0; {
+		n, rerr := r.Read(buf[:min(int64(len(buf)), remaining)])
+		line += int64(bytes.Count(buf[:n], newline))
+		remaining -= int64(n)
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return err
+		}
+	}
+	perr.Line = line
+	return perr
+}
@@ -0,0 +1,160 @@
+package strategies
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseTemp(t *testing.T) {
+	valid := map[string]int64{"0.0": 0, "1.2": 12, "12.3": 123, "-4.5": -45, "-99.9": -999, "123.4": 1234}
+	for in, want := range valid {
+		if got, err := parseTemp(in); err != nil || got != want {
+			t.Errorf("parseTemp(%q) = %d, %v; want %d", in, got, err, want)
+		}
+		if got, err := parseTemp([]byte(in)); err != nil || got != want {
+			t.Errorf("parseTemp([]byte(%q)) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "-", ".", "1", "12", "1.", ".5", "1.23", "--1.2", "1-.2", "ab.c", "12.3 ", " 1.2", "1,2"} {
+		if _, err := parseTemp(in); err == nil {
+			t.Errorf("parseTemp(%q) accepted garbage", in)
+		}
+	}
+}
+
+// insertLines rewrites path with extra inserted before the 0-based line
+// numbers in at.
+func insertLines(t *testing.T, path string, at []int, extra []string) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.SplitAfter(data, newline)
+	for i := len(at) - 1; i >= 0; i-- {
+		lines = slices.Insert(lines, at[i], []byte(extra[i]+"\n"))
+	}
+	if err := os.WriteFile(path, bytes.Join(lines, nil), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStrategiesCountMalformedLines(t *testing.T) {
+	path, want := writeRefillDataset(t, 5_000, 200)
+	bad := []string{"no separator", "Oslo;", ";12.3", "Oslo;12.34", "Oslo;abc", "Oslo;1.2.3", "", "Oslo;-"}
+	at := []int{0, 17, 600, 601, 2500, 3333, 4000, 5000}
+	insertLines(t, path, at, bad)
+
+	for _, s := range strategiesWith(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 8192}) {
+		t.Run(s.name, func(t *testing.T) {
+			checkAggregates(t, s.strategy, path, want)
+			if got := s.strategy.(MalformedLineCounter).MalformedLines(); got != int64(len(bad)) {
+				t.Errorf("counted %d malformed lines, want %d", got, len(bad))
+			}
+		})
+	}
+}
+
+func TestStrategiesQuarantineMalformedLines(t *testing.T) {
+	path, want := writeRefillDataset(t, 5_000, 200)
+	bad := []string{"no separator", "Oslo;abc", "Oslo;1.2.3", "Oslo;-"}
+	insertLines(t, path, []int{0, 601, 3333, 5000}, bad)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wantLines []string
+	for _, line := range bad {
+		wantLines = append(wantLines, fmt.Sprintf("%d %s", bytes.Index(data, []byte(line+"\n")), line))
+	}
+
+	var mu sync.Mutex
+	var got []string
+	quarantine := QuarantineFunc(func(offset int64, line []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, fmt.Sprintf("%d %s", offset, line))
+	})
+	for _, s := range strategiesWith(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 8192, Quarantine: quarantine}) {
+		t.Run(s.name, func(t *testing.T) {
+			got = nil
+			checkAggregates(t, s.strategy, path, want)
+			slices.SortFunc(got, func(a, b string) int {
+				var x, y int
+				fmt.Sscan(a, &x)
+				fmt.Sscan(b, &y)
+				return x - y
+			})
+			if !slices.Equal(got, wantLines) {
+				t.Errorf("quarantined %q, want %q", got, wantLines)
+			}
+		})
+	}
+}
+
+func TestParseErrorCarriesContext(t *testing.T) {
+	path, _ := writeRefillDataset(t, 5_000, 200)
+	garbage := "Oslo;" + strings.Repeat("\x00\xff", 100)
+	insertLines(t, path, []int{3210}, []string{garbage})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset := int64(bytes.Index(data, []byte(garbage)))
+
+	for _, s := range strategiesWith(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 8192, ParseMode: ParseStrict}) {
+		t.Run(s.name, func(t *testing.T) {
+			_, err := s.strategy.Calculate(t.Context(), path)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("got error %v, want a *ParseError", err)
+			}
+			if perr.Offset != offset || perr.Text != garbage[:maxParseSnippet] {
+				t.Errorf("got %q at byte %d, want %q at byte %d", perr.Text, perr.Offset, garbage[:maxParseSnippet], offset)
+			}
+			if (perr.Chunk < 0) != (perr.Worker < 0) || perr.Worker >= 4 {
+				t.Errorf("got chunk %d on worker %d", perr.Chunk, perr.Worker)
+			}
+		})
+	}
+}
+
+func TestFirstErrorPrefersTheEarliestLine(t *testing.T) {
+	late, early := newParseError(900, []byte("x")), newParseError(100, []byte("y"))
+	if got := firstError([]error{nil, late, fmt.Errorf("wrapped: %w", early)}); !errors.Is(got, early) {
+		t.Errorf("firstError() = %v, want the line at byte 100", got)
+	}
+	io := errors.New("read failed")
+	if got := firstError([]error{early, io, late}); got != io {
+		t.Errorf("firstError() = %v, want the read error", got)
+	}
+	if got := firstError([]error{nil, nil}); got != nil {
+		t.Errorf("firstError() = %v, want nil", got)
+	}
+}
+
+func TestStrictModeReportsMalformedLine(t *testing.T) {
+	path, _ := writeRefillDataset(t, 5_000, 200)
+	insertLines(t, path, []int{3210}, []string{"Oslo;12,3"})
+
+	for _, s := range strategiesWith(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 8192, ParseMode: ParseStrict}) {
+		t.Run(s.name, func(t *testing.T) {
+			_, err := s.strategy.Calculate(t.Context(), path)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("got error %v, want a *ParseError", err)
+			}
+			if perr.Line != 3211 || perr.Text != "Oslo;12,3" {
+				t.Errorf("got line %d %q, want line 3211 %q", perr.Line, perr.Text, "Oslo;12,3")
+			}
+		})
+	}
+}
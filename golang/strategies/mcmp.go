@@ -3,113 +3,166 @@ package strategies
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"io"
 	"os"
-	"runtime"
 	"sync"
 )
 
-type MCMPStrategy struct{}
+// MCMPStrategy splits the file into chunks that workers pull from a shared
+// queue, each reading its chunks through its own file handle and buffered
+// reader into its own map. The maps are merged at the end.
+type MCMPStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	opts StrategyOptions
+}
+
+// NewMCMPStrategy returns an MCMPStrategy configured with opts.
+func NewMCMPStrategy(opts StrategyOptions) *MCMPStrategy {
+	return &MCMPStrategy{opts: opts}
+}
 
-func (m *MCMPStrategy) Calculate(filePath string) ([]StationResult, error) {
+func (m *MCMPStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	m.resetProgress()
+	defer m.reportProgress(m.opts)()
+	m.resetMalformed(m.opts)
+	_, endOpen := m.opts.startSpan(ctx, SpanOpen, SpanAttr{"path", filePath})
 	f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
 	if err != nil {
+		endOpen()
 		return nil, err
 	}
 	defer f.Close()
 
 	fsize, err := getFileSize(f)
+	if err != nil {
+		endOpen()
+		return nil, err
+	}
+	n := m.opts.workers()
+	queue, err := m.opts.chunkQueue(f, fsize, m.opts.chunkSize(fsize, n))
+	endOpen()
 	if err != nil {
 		return nil, err
 	}
-	n := runtime.NumCPU()
-	chunkSize := fsize / int64(n)
 	tempMaps := make([]StationMap, n)
+	errs := make([]error, n)
 
 	for i := range n {
-		tempMaps[i] = make(StationMap, 100000)
+		tempMaps[i] = make(StationMap, m.opts.mapCapacity())
 	}
 
 	var wg sync.WaitGroup
 	wg.Add(n)
 
 	for i := range n {
-		start := int64(i) * chunkSize
-		end := min(start+chunkSize, fsize)
-		go func(start, end int64, fileMap StationMap) {
+		go func(fileMap StationMap) {
 			defer wg.Done()
-			m.processChunk(start, end, filePath, 64*1024, fileMap)
-		}(start, end, tempMaps[i])
+			defer m.opts.pinWorker(i)()
+			f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer f.Close()
+
+			var arena nameArena
+			reader := bufio.NewReaderSize(countingReader{f, &m.progress}, m.opts.bufferSize(defaultChunkBufSize))
+			for start, end, ok := queue.pop(); ok; start, end, ok = queue.pop() {
+				_, endSpan := m.opts.startSpan(ctx, SpanChunk, SpanAttr{"worker", int64(i)}, SpanAttr{"offset", start}, SpanAttr{"bytes", end - start})
+				m.opts.adviseWillNeed(f, start, end-start)
+				errs[i] = inChunk(m.processChunk(ctx, f, reader, start, end, fileMap, &arena), queue.index(start), i)
+				endSpan()
+				if errs[i] != nil {
+					return
+				}
+			}
+		}(tempMaps[i])
 	}
 
 	wg.Wait()
-
-	return calcAverges(mergeMaps(tempMaps)), nil
-}
-
-func (m *MCMPStrategy) processChunk(start, end int64, filePath string, bufferSize int, fileMap StationMap) error {
-	f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
-	if err != nil {
-		return err
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	defer f.Close()
-
-	shouldSkipFirstLine, err := shouldSkipFirstLine(start, f)
-	if err != nil {
-		return err
+	if err := firstError(errs); err != nil {
+		return nil, locateParseError(filePath, err)
 	}
 
-	_, err = f.Seek(start, 0)
+	_, end := m.opts.startSpan(ctx, SpanMerge)
+	defer end()
+	return emitResults(&m.resultEmitter, tempMaps...), nil
+}
+
+// processChunk aggregates the lines of [start, end), which starts at a
+// line like every chunk the queue hands out.
+func (m *MCMPStrategy) processChunk(ctx context.Context, f *os.File, reader *bufio.Reader, start, end int64, fileMap StationMap, arena *nameArena) error {
+	_, err := f.Seek(start, 0)
 	if err != nil {
 		return err
 	}
 
-	reader := bufio.NewReaderSize(f, bufferSize)
+	reader.Reset(countingReader{f, &m.progress})
 	currentPos := start
+	hash := m.opts.hash()
 
-	if shouldSkipFirstLine {
-		skipped, _ := reader.ReadBytes('\n')
-		currentPos += int64(len(skipped))
-	}
-
+	rows := lineCounter{p: &m.progress}
+	defer rows.flush()
 	count := 0
 	for {
 		if currentPos >= end {
 			break
 		}
+		if count%cancelCheckInterval == 0 && cancelled(ctx) {
+			return ctx.Err()
+		}
 
-		line, err := reader.ReadBytes('\n')
-		if err != nil {
+		line, err := readLine(reader)
+		if err == io.EOF {
 			break
 		}
+		if err != nil {
+			return err
+		}
+		lineStart := currentPos
 		currentPos += int64(len(line))
+		count++
+		rows.add()
+
+		line = bytes.TrimSuffix(line, newline)
 		name, value, err := parseLineByte(line)
 		if err != nil {
+			if err := m.reject(lineStart, line); err != nil {
+				return err
+			}
 			continue
 		}
-		hash := hashFnv(name)
-		st, exists := fileMap[hash]
+		key, st, exists := stationKey(fileMap, hashKey(hash, name), name)
 		if !exists {
-			st = newSt(string(name))
+			st = newSt(arena.intern(name))
 		}
 
-		st.Sum += int64(value)
-		if value > st.Maximum {
-			st.Maximum = value
-		}
-		if value < st.Minimum {
-			st.Minimum = value
-		}
-		fileMap[hash] = st
-		count++
-
-		if err == io.EOF {
-			break
-		}
+		st.Add(value)
+		fileMap[key] = st
 	}
 	return nil
 }
 
+var newline = []byte{'\n'}
+
+// readLine is reader.ReadBytes('\n'), except that a final line without a
+// newline comes back with a nil error; io.EOF is only returned once there
+// is nothing left to read.
+func readLine(reader *bufio.Reader) ([]byte, error) {
+	line, err := reader.ReadBytes('\n')
+	if err == io.EOF && len(line) > 0 {
+		return line, nil
+	}
+	return line, err
+}
+
+// StationTableItem is one slot of the linear-probing station tables.
 type StationTableItem struct {
 	Name                         []byte
 	Hash                         uint32
@@ -117,209 +170,283 @@ type StationTableItem struct {
 	Occupied                     bool
 }
 
-const (
-	tableSize = 131072
-	tableMask = tableSize - 1
-)
+// MCMPLinearProbing is MCMPStrategy with each worker aggregating into an
+// open-addressing table with linear probing instead of a Go map.
+type MCMPLinearProbing struct {
+	progress
+	malformedLines
+	resultEmitter
+	probeRecorder
+	opts StrategyOptions
+}
 
-type MCMPLinearProbing struct{}
+// NewMCMPLinearProbing returns an MCMPLinearProbing configured with opts.
+func NewMCMPLinearProbing(opts StrategyOptions) *MCMPLinearProbing {
+	return &MCMPLinearProbing{opts: opts}
+}
 
-func (m *MCMPLinearProbing) Calculate(filePath string) ([]StationResult, error) {
+func (m *MCMPLinearProbing) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	m.resetProgress()
+	defer m.reportProgress(m.opts)()
+	m.resetMalformed(m.opts)
+	_, endOpen := m.opts.startSpan(ctx, SpanOpen, SpanAttr{"path", filePath})
 	f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
 	if err != nil {
+		endOpen()
 		return nil, err
 	}
 	defer f.Close()
 	fSize, err := getFileSize(f)
 	if err != nil {
+		endOpen()
 		return nil, err
 	}
-	_ = fSize
 
-	n := runtime.NumCPU()
-	chunkSize := fSize / int64(n)
+	n := m.opts.workers()
+	queue, err := m.opts.chunkQueue(f, fSize, m.opts.chunkSize(fSize, n))
+	endOpen()
+	if err != nil {
+		return nil, err
+	}
+	names := newInternTable()
+	tables := make([]stationTable, n)
 	smaps := make([]StationMap, n)
+	errs := make([]error, n)
 
 	for i := range n {
-		smaps[i] = make(StationMap, 100000)
+		smaps[i] = make(StationMap, m.opts.mapCapacity())
 	}
 
 	var wg sync.WaitGroup
 	wg.Add(n)
 
 	for i := range n {
-		start := int64(i) * chunkSize
-		end := min(start+chunkSize, fSize)
-
-		go func(start, end int64, smap StationMap) {
+		go func(smap StationMap) {
 			defer wg.Done()
-			m.processChunkLP(start, end, filePath, 64*1024, smap)
-		}(start, end, smaps[i])
+			defer m.opts.pinWorker(i)()
+			f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer f.Close()
+
+			table := newLPTable(m.opts)
+			tables[i] = table
+			defer table.flushInto(smap, names)
+
+			reader := bufio.NewReaderSize(countingReader{f, &m.progress}, m.opts.bufferSize(defaultChunkBufSize))
+			for start, end, ok := queue.pop(); ok; start, end, ok = queue.pop() {
+				_, endSpan := m.opts.startSpan(ctx, SpanChunk, SpanAttr{"worker", int64(i)}, SpanAttr{"offset", start}, SpanAttr{"bytes", end - start})
+				m.opts.adviseWillNeed(f, start, end-start)
+				errs[i] = inChunk(m.processChunkLP(ctx, f, reader, start, end, table), queue.index(start), i)
+				endSpan()
+				if errs[i] != nil {
+					return
+				}
+			}
+		}(smaps[i])
 	}
 
 	wg.Wait()
-	mergedMap := mergeMaps(smaps)
-	return calcAverges(mergedMap), nil
-}
-
-func (m *MCMPLinearProbing) processChunkLP(start, end int64, filePath string, bufferSize int, smap StationMap) error {
-	f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
-	if err != nil {
-		return err
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	defer f.Close()
-	items := make([]StationTableItem, tableSize)
-	occupiedIndexes := make([]int, 0, 10000)
-
-	reader := bufio.NewReaderSize(f, bufferSize)
-	skipFirst, err := shouldSkipFirstLine(start, f)
-	if err != nil {
-		return err
+	if err := firstError(errs); err != nil {
+		return nil, locateParseError(filePath, err)
 	}
+	m.recordProbes(tables)
+	_, end := m.opts.startSpan(ctx, SpanMerge)
+	defer end()
+	return emitResults(&m.resultEmitter, smaps...), nil
+}
 
-	_, err = f.Seek(start, 0)
+func (m *MCMPLinearProbing) processChunkLP(ctx context.Context, f *os.File, reader *bufio.Reader, start, end int64, table *lpTable) error {
+	_, err := f.Seek(start, 0)
 	if err != nil {
 		return err
 	}
 
+	reader.Reset(countingReader{f, &m.progress})
 	currentPos := start
 
-	if skipFirst {
-		skipped, _ := reader.ReadBytes('\n')
-		currentPos += int64(len(skipped))
-	}
-
+	rows := lineCounter{p: &m.progress}
+	defer rows.flush()
+	lines := 0
 	for {
 		if currentPos >= end {
 			break
 		}
+		if lines++; lines%cancelCheckInterval == 0 && cancelled(ctx) {
+			return ctx.Err()
+		}
 
-		line, err := reader.ReadBytes('\n')
-		if err != nil {
+		line, err := readLine(reader)
+		if err == io.EOF {
 			break
 		}
+		if err != nil {
+			return err
+		}
+		rows.add()
 
+		lineStart := currentPos
 		currentPos += int64(len(line))
+		line = bytes.TrimSuffix(line, newline)
 		name, val, err := parseLineByte(line)
-
 		if err != nil {
-			return err
+			if err := m.reject(lineStart, line); err != nil {
+				return err
+			}
+			continue
 		}
 
-		occ, idx := linearProbe(items, name, int64(val))
-		if occ {
-			occupiedIndexes = append(occupiedIndexes, idx)
-		}
+		table.add(name, int64(val))
 	}
-
-	createStationMap(items, occupiedIndexes, smap)
 	return nil
 }
 
-type MCMPLinearProbingOptimized struct{}
+// MCMPLinearProbingOptimized is MCMPLinearProbing reading each chunk in
+// large blocks into a reused buffer instead of through a bufio.Reader.
+type MCMPLinearProbingOptimized struct {
+	progress
+	malformedLines
+	resultEmitter
+	probeRecorder
+	opts StrategyOptions
+}
+
+// NewMCMPLinearProbingOptimized returns an MCMPLinearProbingOptimized configured with opts.
+func NewMCMPLinearProbingOptimized(opts StrategyOptions) *MCMPLinearProbingOptimized {
+	return &MCMPLinearProbingOptimized{opts: opts}
+}
 
-func (m *MCMPLinearProbingOptimized) Calculate(filePath string) ([]StationResult, error) {
+func (m *MCMPLinearProbingOptimized) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	m.resetProgress()
+	defer m.reportProgress(m.opts)()
+	m.resetMalformed(m.opts)
+	_, endOpen := m.opts.startSpan(ctx, SpanOpen, SpanAttr{"path", filePath})
 	f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
 	if err != nil {
+		endOpen()
 		return nil, err
 	}
 	defer f.Close()
 
 	fsize, err := getFileSize(f)
 	if err != nil {
+		endOpen()
 		return nil, err
 	}
-	n := runtime.NumCPU()
-	chunkSize := fsize / int64(n)
+	n := m.opts.workers()
+	queue, err := m.opts.chunkQueue(f, fsize, m.opts.chunkSize(fsize, n))
+	endOpen()
+	if err != nil {
+		return nil, err
+	}
+	names := newInternTable()
+	tables := make([]stationTable, n)
 	tempMaps := make([]StationMap, n)
+	errs := make([]error, n)
 
 	for i := range n {
-		tempMaps[i] = make(StationMap, 100000)
+		tempMaps[i] = make(StationMap, m.opts.mapCapacity())
 	}
 
 	var wg sync.WaitGroup
 	wg.Add(n)
 
 	for i := range n {
-		start := int64(i) * chunkSize
-		end := min(start+chunkSize, fsize)
-
-		go func(start, end int64, fileMap StationMap) {
+		go func(fileMap StationMap) {
 			defer wg.Done()
-			m.processChunk(start, end, filePath, fileMap)
-		}(start, end, tempMaps[i])
+			defer m.opts.pinWorker(i)()
+			f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer f.Close()
+
+			table := newLPTable(m.opts)
+			tables[i] = table
+			defer table.flushInto(fileMap, names)
+
+			buf := make([]byte, m.opts.bufferSize(defaultBlockBufSize))
+			for start, end, ok := queue.pop(); ok; start, end, ok = queue.pop() {
+				_, endSpan := m.opts.startSpan(ctx, SpanChunk, SpanAttr{"worker", int64(i)}, SpanAttr{"offset", start}, SpanAttr{"bytes", end - start})
+				m.opts.adviseWillNeed(f, start, end-start)
+				errs[i] = inChunk(m.processChunk(ctx, f, buf, start, end, table), queue.index(start), i)
+				endSpan()
+				if errs[i] != nil {
+					return
+				}
+			}
+		}(tempMaps[i])
 	}
 
 	wg.Wait()
-	return calcAverges(mergeMaps(tempMaps)), nil
-}
-
-func (m *MCMPLinearProbingOptimized) processChunk(start, end int64, filePath string, fileMap StationMap) error {
-	f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
-	if err != nil {
-		return err
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	defer f.Close()
-
-	// --- FIX 2: Remove bufio. Handle skipping manually with f.Read ---
-	if start > 0 {
-		_, err = f.Seek(start-1, 0)
-		if err != nil {
-			return err
-		}
-
-		// Check if we are already at a newline
-		tempBuf := make([]byte, 1)
-		_, err = f.Read(tempBuf)
-		if err != nil {
-			return err
-		}
-
-		if tempBuf[0] != '\n' {
-			// Read byte-by-byte until we find the start of the next line
-			// (Optimization: You could read a small chunk here, but this runs once per core)
-			b := make([]byte, 1)
-			for {
-				_, err := f.Read(b)
-				if err != nil {
-					return err
-				}
-				start++ // Keep track of how much we advanced
-				if b[0] == '\n' {
-					break
-				}
-			}
-		}
+	if err := firstError(errs); err != nil {
+		return nil, locateParseError(filePath, err)
 	}
+	m.recordProbes(tables)
+	_, end := m.opts.startSpan(ctx, SpanMerge)
+	defer end()
+	return emitResults(&m.resultEmitter, tempMaps...), nil
+}
 
-	// Seek to the exact start position
-	_, err = f.Seek(start, 0)
+// processChunk aggregates the lines of [start, end), which starts at a
+// line like every chunk the queue hands out.
+func (m *MCMPLinearProbingOptimized) processChunk(ctx context.Context, f *os.File, buf []byte, start, end int64, table *lpTable) error {
+	_, err := f.Seek(start, 0)
 	if err != nil {
 		return err
 	}
 
-	return m.read(1024*1024, start, end, f, fileMap)
+	return m.read(ctx, buf, start, end, f, table)
 }
 
-func (m *MCMPLinearProbingOptimized) read(bufferSize int, start, end int64, f *os.File, smap StationMap) error {
-	items := make([]StationTableItem, tableSize)
-	occupiedIndexes := make([]int, 0, 10000)
+// minTailRead is the smallest read issued near the end of a chunk, enough
+// to finish any line that straddles the boundary in one or two reads.
+const minTailRead = 256
 
-	buf := make([]byte, bufferSize)
+// read aggregates every line that starts in [start, end), reading past end
+// only as far as needed to finish the last of those lines.
+func (m *MCMPLinearProbingOptimized) read(ctx context.Context, buf []byte, start, end int64, f *os.File, table *lpTable) error {
 	var leftover []byte
+	pos := start // file offset of filledBuf[0]
+	rows := lineCounter{p: &m.progress}
+	defer rows.flush()
 
 	for {
-		if start >= end {
+		if pos >= end {
 			break
 		}
+		if cancelled(ctx) {
+			return ctx.Err()
+		}
 
-		n, err := f.Read(buf)
+		// Don't read far beyond end: the rest belongs to other chunks.
+		readLen := min(int64(len(buf)), max(end-pos, minTailRead))
+		n, err := f.Read(buf[:readLen])
 		if n == 0 || err == io.EOF {
+			// The file's last line has no newline.
+			if len(leftover) > 0 {
+				rows.add()
+				name, value, err := parseLineByte(leftover)
+				if err != nil {
+					return m.reject(pos, leftover)
+				}
+				table.add(name, value)
+			}
 			break
 		}
 		if err != nil {
 			return err
 		}
+		m.addProgress(n)
 
 		filledBuf := buf[:n]
 		if len(leftover) > 0 {
@@ -330,60 +457,38 @@ func (m *MCMPLinearProbingOptimized) read(bufferSize int, start, end int64, f *o
 		buffIdx := 0
 
 		for {
-			if buffIdx >= len(filledBuf) {
+			if buffIdx >= len(filledBuf) || pos+int64(buffIdx) >= end {
 				break
 			}
 
-			lineEndIdx := bytes.IndexByte(filledBuf[buffIdx:], '\n')
+			line, sep, lineEndIdx := nextLine(filledBuf[buffIdx:])
 			if lineEndIdx == -1 {
-				leftover = append(leftover, filledBuf[buffIdx:]...)
+				leftover = append(leftover, line...)
 				break
 			}
 
-			line := filledBuf[buffIdx : buffIdx+lineEndIdx]
+			lineStart := pos + int64(buffIdx)
 			buffIdx += lineEndIdx + 1
+			rows.add()
 
-			name, value, err := parseLineByte(line)
+			name, value, err := parseSplitLine(line, sep)
 			if err != nil {
+				if err := m.reject(lineStart, line); err != nil {
+					return err
+				}
 				continue
 			}
 
-			occ, idx := linearProbe(items, name, int64(value))
-			if occ {
-				occupiedIndexes = append(occupiedIndexes, idx)
-			}
-
+			table.add(name, int64(value))
 		}
-		start += int64(n)
+		pos += int64(buffIdx)
 	}
-	createStationMap(items, occupiedIndexes, smap)
 	return nil
 }
 
-// checks if we need to skip the first line in the chunk
-// this is for a edge case where we start at the begining of a line
-func shouldSkipFirstLine(start int64, f *os.File) (bool, error) {
-	if start == 0 {
-		return false, nil
-	}
-
-	_, err := f.Seek(start-1, 0)
-	if err != nil {
-		return false, err
-	}
-
-	buf := make([]byte, 1)
-	_, err = f.Read(buf)
-	if err != nil {
-		return false, err
-	}
-
-	return buf[0] != '\n', nil
-}
-
-func linearProbe(items []StationTableItem, name []byte, value int64) (newOcc bool, occIndex int) {
-	hash := hashFnv(name)
-	index := hash & tableMask
+func linearProbe(items []StationTableItem, hash uint32, name []byte, value int64) (newOcc bool, occIndex int) {
+	mask := uint32(len(items) - 1)
+	index := hash & mask
 
 	for {
 		if !items[index].Occupied {
@@ -412,21 +517,101 @@ func linearProbe(items []StationTableItem, name []byte, value int64) (newOcc boo
 			break
 		}
 
-		index = (index + 1) & tableMask
+		index = (index + 1) & mask
 	}
 
 	return newOcc, int(index)
 }
 
-func createStationMap(items []StationTableItem, occupiedIndexes []int, smap StationMap) {
+// lpTable is a worker's private open-addressing table. It lives for the
+// whole run so that a worker can process many chunks before its contents are
+// copied into a StationMap for merging. Keys are copied into the table's own
+// arena on insert, so callers may pass names that alias a read buffer they
+// are about to refill. The table starts at opts.tableSize() slots and
+// doubles once opts.maxLoadFactor() of them are in use: linear probing
+// degrades sharply as the table fills, and a full table would probe
+// forever.
+type lpTable struct {
+	items           []StationTableItem
+	occupiedIndexes []int
+	keys            nameArena
+	growAt          int
+	opts            StrategyOptions
+}
+
+func newLPTable(opts StrategyOptions) *lpTable {
+	t := &lpTable{
+		occupiedIndexes: make([]int, 0, 10000),
+		opts:            opts,
+	}
+	t.resize(opts.tableSize())
+	return t
+}
+
+func (t *lpTable) addLine(line []byte) bool {
+	name, value, err := parseLineByte(line)
+	if err != nil {
+		return false
+	}
+	t.add(name, value)
+	return true
+}
+
+func (t *lpTable) add(name []byte, value int64) {
+	if occ, idx := linearProbe(t.items, hashKey(t.opts.hash(), name), name, value); occ {
+		t.items[idx].Name = t.keys.own(name)
+		t.occupiedIndexes = append(t.occupiedIndexes, idx)
+		if len(t.occupiedIndexes) >= t.growAt {
+			t.resize(2 * len(t.items))
+		}
+	}
+}
+
+// resize moves every station into a fresh table of size slots, which must
+// be a power of two. Items keep their hash, so names are not rehashed.
+func (t *lpTable) resize(size int) {
+	old := t.items
+	t.items = make([]StationTableItem, size)
+	adviseHugePages(t.opts, t.items)
+	t.growAt = int(float64(size) * t.opts.maxLoadFactor())
+
+	mask := uint32(size - 1)
+	for i, idx := range t.occupiedIndexes {
+		it := old[idx]
+		pos := it.Hash & mask
+		for t.items[pos].Occupied {
+			pos = (pos + 1) & mask
+		}
+		t.items[pos] = it
+		t.occupiedIndexes[i] = int(pos)
+	}
+}
+
+// flushInto copies the table's stations into smap, taking their names from
+// the run-wide intern table.
+func (t *lpTable) flushInto(smap StationMap, names *internTable) {
+	createStationMap(t.items, t.occupiedIndexes, smap, names)
+}
+
+func (t *lpTable) probeStats() ProbeStats {
+	mask := len(t.items) - 1
+	c := collectProbeStats{slots: len(t.items)}
+	for _, idx := range t.occupiedIndexes {
+		home := int(t.items[idx].Hash) & mask
+		c.add((idx-home)&mask + 1)
+	}
+	return c.stats()
+}
+
+func createStationMap(items []StationTableItem, occupiedIndexes []int, smap StationMap, names *internTable) {
 	for _, idx := range occupiedIndexes {
 		it := items[idx]
-		smap[it.Hash] = StationResult{
-			StationID: string(it.Name),
+		storeStation(smap, it.Hash, StationResult{
+			StationID: names.intern(it.Name),
 			Sum:       it.Sum,
 			Count:     it.Count,
 			Maximum:   it.Maximum,
 			Minimum:   it.Minimum,
-		}
+		})
 	}
 }
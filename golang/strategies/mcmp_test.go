@@ -0,0 +1,44 @@
+package strategies
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLinearProbingOptimizedKeysSurviveRefills(t *testing.T) {
+	path, want := writeRefillDataset(t, 50_000, 500)
+
+	for _, bufSize := range []int{64, 257, 4096} {
+		t.Run(fmt.Sprintf("buffer=%d", bufSize), func(t *testing.T) {
+			s := NewMCMPLinearProbingOptimized(StrategyOptions{Workers: 4, BufferSize: bufSize, ChunkSize: 8192})
+			checkAggregates(t, s, path, want)
+		})
+	}
+}
+
+func TestLinearProbeTableGrowsPastInitialSize(t *testing.T) {
+	path, want := writeRefillDataset(t, 50_000, 500)
+
+	// 16 slots hold a fraction of the 500 stations: without growth the
+	// table fills up and probes forever.
+	opts := StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 8192, TableSize: 16}
+	s := NewLinearProbeTableStrategy(opts)
+	checkAggregates(t, s, path, want)
+	checkAggregates(t, NewMCMPLinearProbingOptimized(opts), path, want)
+
+	if stats := s.ProbeStats(); stats.LoadFactor() > defaultMaxLoadFactor {
+		t.Errorf("load factor %.2f exceeds %.2f after growth", stats.LoadFactor(), defaultMaxLoadFactor)
+	}
+}
+
+func TestLinearProbeTableHonoursMaxLoadFactor(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+
+	for _, lf := range []float64{0.25, 1} {
+		s := NewLinearProbeTableStrategy(StrategyOptions{Workers: 1, ChunkSize: 8192, TableSize: 16, MaxLoadFactor: lf})
+		checkAggregates(t, s, path, want)
+		if got := s.ProbeStats().LoadFactor(); got >= lf || got < lf/2 {
+			t.Errorf("MaxLoadFactor %.2f: final load factor %.2f", lf, got)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package strategies
+
+// Metadata is implemented by every strategy in this package so runners can
+// label and explain them without keeping their own tables of names.
+type Metadata interface {
+	// Name is a short display name, e.g. "Swiss Table".
+	Name() string
+
+	// Describe lists how the strategy reads the file and what it
+	// aggregates into, e.g. "parallel chunks, double-buffered reads, Swiss
+	// table".
+	Describe() string
+}
+
+func (*BasicStrategy) Name() string { return "Basic Strategy" }
+func (*BasicStrategy) Describe() string {
+	return "sequential, buffered reads, string-keyed Go map"
+}
+
+func (*ByteReadingStrategy) Name() string { return "Byte Strategy" }
+func (*ByteReadingStrategy) Describe() string {
+	return "sequential, buffered reads, hash-keyed Go map"
+}
+
+func (*BatchStrategy) Name() string { return "Batch Strategy" }
+func (*BatchStrategy) Describe() string {
+	return "one reader, parallel batch aggregation, Go maps"
+}
+
+func (*RangeBatchStrategy) Name() string { return "Range Batch Strategy" }
+func (*RangeBatchStrategy) Describe() string {
+	return "parallel chunks, double-buffered reads, batched parse then aggregate, Go maps"
+}
+
+func (*MCMPStrategy) Name() string { return "MCMP Strategy" }
+func (*MCMPStrategy) Describe() string {
+	return "parallel chunks, bufio reads, Go maps"
+}
+
+func (*MCMPLinearProbing) Name() string { return "MCMP Linear Probing" }
+func (*MCMPLinearProbing) Describe() string {
+	return "parallel chunks, bufio reads, linear probing"
+}
+
+func (*MCMPLinearProbingOptimized) Name() string { return "MCMP Linear Probing Optimized" }
+func (*MCMPLinearProbingOptimized) Describe() string {
+	return "parallel chunks, block reads, linear probing"
+}
+
+func (*LinearProbeTableStrategy) Name() string { return "Linear Probing Table" }
+func (*LinearProbeTableStrategy) Describe() string {
+	return "parallel chunks, double-buffered reads, linear probing"
+}
+
+func (*RobinHoodStrategy) Name() string { return "Robin Hood Hashing" }
+func (*RobinHoodStrategy) Describe() string {
+	return "parallel chunks, double-buffered reads, Robin Hood hashing"
+}
+
+func (*SwissTableStrategy) Name() string { return "Swiss Table" }
+func (*SwissTableStrategy) Describe() string {
+	return "parallel chunks, double-buffered reads, Swiss table"
+}
+
+func (*CuckooStrategy) Name() string { return "Cuckoo Hashing" }
+func (*CuckooStrategy) Describe() string {
+	return "parallel chunks, double-buffered reads, cuckoo hashing"
+}
+
+func (*PerfectHashStrategy) Name() string { return "Two-Pass Perfect Hash" }
+func (*PerfectHashStrategy) Describe() string {
+	return "two passes, parallel chunks, double-buffered reads, minimal perfect hash"
+}
+
+func (*ShortKeyStrategy) Name() string { return "Short-Key Fast Path" }
+func (*ShortKeyStrategy) Describe() string {
+	return "parallel chunks, double-buffered reads, integer keys for short names"
+}
+
+func (*SoATableStrategy) Name() string { return "Struct-of-Arrays Table" }
+func (*SoATableStrategy) Describe() string {
+	return "parallel chunks, double-buffered reads, struct-of-arrays linear probing"
+}
+
+func (*DoubleBufferedStrategy) Name() string { return "Double Buffered" }
+func (*DoubleBufferedStrategy) Describe() string {
+	return "parallel chunks, double-buffered reads, Go maps"
+}
+
+func (*ShardedMapStrategy) Name() string { return "Sharded Map" }
+func (*ShardedMapStrategy) Describe() string {
+	return "parallel chunks, double-buffered reads, one Go map in locked shards"
+}
+
+func (*IOURingStrategy) Name() string { return "io_uring Strategy" }
+func (*IOURingStrategy) Describe() string {
+	return "parallel chunks, io_uring reads, Go maps, Linux only"
+}
+
+func (*MmapStrategy) Name() string { return "Mmap Strategy" }
+func (*MmapStrategy) Describe() string {
+	return "parallel chunks, mmap, Go maps, Linux, macOS and Windows only"
+}
+
+func (*DirectIOStrategy) Name() string { return "O_DIRECT Strategy" }
+func (*DirectIOStrategy) Describe() string {
+	return "parallel chunks, uncached O_DIRECT/F_NOCACHE reads, Go maps, Linux and macOS only"
+}
+
+func (*PipelineStrategy) Name() string { return "Pipeline Strategy" }
+func (*PipelineStrategy) Describe() string {
+	return "one reader, ring buffer, parallel parsers, Go maps"
+}
+
+func (*PreadvStrategy) Name() string { return "Preadv Pipeline" }
+func (*PreadvStrategy) Describe() string {
+	return "one preadv reader, ring buffer, parallel parsers, Go maps, Linux only"
+}
+
+func (*ZstdStrategy) Name() string { return "Zstd Strategy" }
+func (*ZstdStrategy) Describe() string {
+	return "parallel zstd frame decompression, Go maps"
+}
+
+func (*ShardStrategy) Name() string { return "Shard per Worker" }
+func (*ShardStrategy) Describe() string {
+	return "pre-split files, one whole shard per worker, double-buffered reads, Go maps"
+}
+
+func (*BinaryStrategy) Name() string { return "Binary Format" }
+func (*BinaryStrategy) Describe() string {
+	return "pre-parsed varint ids and int16 values, parallel blocks, slices indexed by id"
+}
+
+func (*ClusterStrategy) Name() string { return "Cluster" }
+func (*ClusterStrategy) Describe() string {
+	return "byte ranges on worker machines over net/rpc, partials merged by name"
+}
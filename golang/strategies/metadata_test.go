@@ -0,0 +1,16 @@
+package strategies
+
+import "testing"
+
+func TestStrategyNamesAreUniqueAndDescribed(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, s := range getAllStrategies() {
+		if s.name == "" || seen[s.name] {
+			t.Errorf("strategy %T has empty or duplicate name %q", s.strategy, s.name)
+		}
+		seen[s.name] = true
+		if s.strategy.(Metadata).Describe() == "" {
+			t.Errorf("%s has no description", s.name)
+		}
+	}
+}
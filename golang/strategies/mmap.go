@@ -0,0 +1,159 @@
+//go:build linux || windows || darwin
+
+package strategies
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+)
+
+// MmapStrategy maps the whole file into memory and lets workers parse
+// chunks from the shared queue straight out of the mapping, with no read
+// syscalls or copies. Unless disabled, the mapping is advised as
+// sequential and every chunk is prefetched as it is claimed, with madvise
+// on Linux and macOS and PrefetchVirtualMemory on Windows. With
+// ZeroCopyKeys, station names are not even copied on first sight: keys are
+// views into the mapping until the results are built.
+type MmapStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	opts StrategyOptions
+}
+
+// NewMmapStrategy returns an MmapStrategy configured with opts.
+func NewMmapStrategy(opts StrategyOptions) *MmapStrategy {
+	return &MmapStrategy{opts: opts}
+}
+
+func (m *MmapStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	m.resetProgress()
+	defer m.reportProgress(m.opts)()
+	m.resetMalformed(m.opts)
+	_, endOpen := m.opts.startSpan(ctx, SpanOpen, SpanAttr{"path", filePath})
+	f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
+	if err != nil {
+		endOpen()
+		return nil, err
+	}
+	defer f.Close()
+
+	fsize, err := getFileSize(f)
+	if err != nil {
+		endOpen()
+		return nil, err
+	}
+	if fsize == 0 {
+		endOpen()
+		return []StationResult{}, nil
+	}
+
+	data, unmap, err := mapFile(f, fsize)
+	if err != nil {
+		endOpen()
+		return nil, err
+	}
+	defer unmap()
+	m.opts.adviseMapping(data, adviceSequential)
+	adviseHugePages(m.opts, data)
+
+	key := newInternTable().intern
+	if m.opts.ZeroCopyKeys {
+		key = viewName
+	}
+
+	n := m.opts.workers()
+	queue, err := m.opts.chunkQueue(f, fsize, m.opts.chunkSize(fsize, n))
+	endOpen()
+	if err != nil {
+		return nil, err
+	}
+	tempMaps := make([]StationMap, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := range n {
+		tempMaps[i] = make(StationMap, m.opts.mapCapacity())
+		go func(fileMap StationMap) {
+			defer wg.Done()
+			defer m.opts.pinWorker(i)()
+			for start, end, ok := queue.pop(); ok; start, end, ok = queue.pop() {
+				if cancelled(ctx) {
+					return
+				}
+				_, endSpan := m.opts.startSpan(ctx, SpanChunk, SpanAttr{"worker", int64(i)}, SpanAttr{"offset", start}, SpanAttr{"bytes", end - start})
+				m.opts.adviseMapping(pageAligned(data, start, end), adviceWillNeed)
+				errs[i] = inChunk(m.parseMappedChunk(ctx, data, start, end, fileMap, key), queue.index(start), i)
+				endSpan()
+				if errs[i] != nil {
+					return
+				}
+				m.addProgress(int(end - start))
+			}
+		}(tempMaps[i])
+	}
+
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := firstError(errs); err != nil {
+		return nil, locateParseError(filePath, err)
+	}
+
+	_, end := m.opts.startSpan(ctx, SpanMerge)
+	defer end()
+	if m.opts.ZeroCopyKeys {
+		// The views die with the mapping; give callers real strings.
+		for _, fileMap := range tempMaps {
+			for hash, res := range fileMap {
+				res.StationID = strings.Clone(res.StationID)
+				fileMap[hash] = res
+			}
+		}
+	}
+	return emitResults(&m.resultEmitter, tempMaps...), nil
+}
+
+// parseMappedChunk aggregates the lines of [start, end), which starts at a
+// line like every chunk the queue hands out, naming new stations with key.
+// It returns ctx.Err() if ctx was cancelled part way through, or the error
+// for a malformed line in strict mode.
+func (m *MmapStrategy) parseMappedChunk(ctx context.Context, data []byte, start, end int64, fileMap StationMap, key func([]byte) string) error {
+	pos := start
+	rows := lineCounter{p: &m.progress}
+	defer rows.flush()
+	for lines := 1; pos < end; lines++ {
+		if lines%cancelCheckInterval == 0 && cancelled(ctx) {
+			return ctx.Err()
+		}
+		rows.add()
+		line, sep, idx := nextLine(data[pos:])
+		if !addSplitLineKeyed(line, sep, fileMap, m.opts.hash(), key) {
+			if err := m.reject(pos, line); err != nil {
+				return err
+			}
+		}
+		if idx == -1 {
+			break
+		}
+		pos += int64(idx + 1)
+	}
+	return nil
+}
+
+// pageAligned widens data[start:end] down to a page boundary, since
+// madvise rejects unaligned addresses. The mapping itself is page aligned.
+func pageAligned(data []byte, start, end int64) []byte {
+	page := int64(os.Getpagesize())
+	return data[start&^(page-1) : end]
+}
+
+// planMmap is the Entry.Plan of MmapStrategy, which maps the file
+// instead of reading it into buffers.
+func planMmap(filePath string, opts StrategyOptions) (ChunkPlan, error) {
+	return planChunks(filePath, opts, 0, nil, opts.mapCapacity())
+}
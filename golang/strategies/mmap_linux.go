@@ -0,0 +1,27 @@
+//go:build linux
+
+package strategies
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// mapFile maps the first size bytes of f read-only. unmap releases the
+// mapping.
+func mapFile(f *os.File, size int64) (data []byte, unmap func(), err error) {
+	data, err = syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mmap: %w", err)
+	}
+	return data, func() { syscall.Munmap(data) }, nil
+}
+
+// adviseMapping passes a madvise hint for b unless hints are disabled.
+// Like the fadvise hints it is best effort and errors are ignored.
+func (o StrategyOptions) adviseMapping(b []byte, advice int) {
+	if !o.DisableIOHints && len(b) > 0 {
+		syscall.Madvise(b, advice)
+	}
+}
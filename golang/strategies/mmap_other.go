@@ -0,0 +1,23 @@
+//go:build !linux && !windows && !darwin
+
+package strategies
+
+import "context"
+
+// MmapStrategy parses the file through a memory mapping, which is only
+// wired up on Linux, macOS and Windows. On other platforms Calculate
+// always fails.
+type MmapStrategy struct {
+	platformStub
+	progress
+	opts StrategyOptions
+}
+
+// NewMmapStrategy returns an MmapStrategy configured with opts.
+func NewMmapStrategy(opts StrategyOptions) *MmapStrategy {
+	return &MmapStrategy{opts: opts}
+}
+
+func (m *MmapStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	return nil, platformError("mmap strategy requires Linux, macOS or Windows")
+}
@@ -0,0 +1,46 @@
+//go:build windows
+
+package strategies
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var procPrefetchVirtualMemory = syscall.NewLazyDLL("kernel32.dll").NewProc("PrefetchVirtualMemory")
+
+// mapFile maps the first size bytes of f read-only with CreateFileMapping
+// and MapViewOfFile. unmap releases the view; the mapping object itself is
+// closed at once, as the view keeps it alive.
+func mapFile(f *os.File, size int64) (data []byte, unmap func(), err error) {
+	h, err := syscall.CreateFileMapping(syscall.Handle(f.Fd()), nil, syscall.PAGE_READONLY, uint32(size>>32), uint32(size), nil)
+	if err != nil {
+		return nil, nil, os.NewSyscallError("CreateFileMapping", err)
+	}
+	defer syscall.CloseHandle(h)
+
+	addr, err := syscall.MapViewOfFile(h, syscall.FILE_MAP_READ, 0, 0, uintptr(size))
+	if err != nil {
+		return nil, nil, os.NewSyscallError("MapViewOfFile", err)
+	}
+	data = unsafe.Slice((*byte)(*(*unsafe.Pointer)(unsafe.Pointer(&addr))), size)
+	return data, func() { syscall.UnmapViewOfFile(addr) }, nil
+}
+
+// adviseMapping asks Windows to read b in ahead of use with
+// PrefetchVirtualMemory for adviceWillNeed unless hints are disabled;
+// Windows has nothing like MADV_SEQUENTIAL. It is best effort:
+// errors are ignored, and before Windows 8, which lacks the call, it does
+// nothing.
+func (o StrategyOptions) adviseMapping(b []byte, advice int) {
+	if o.DisableIOHints || len(b) == 0 || advice != adviceWillNeed || procPrefetchVirtualMemory.Find() != nil {
+		return
+	}
+	entry := struct {
+		addr unsafe.Pointer
+		size uintptr
+	}{unsafe.Pointer(&b[0]), uintptr(len(b))}
+	process, _ := syscall.GetCurrentProcess()
+	procPrefetchVirtualMemory.Call(uintptr(process), 1, uintptr(unsafe.Pointer(&entry)), 0)
+}
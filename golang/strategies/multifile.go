@@ -0,0 +1,167 @@
+package strategies
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// MultiFileStrategy aggregates several files into one result set, as for
+// a dataset sharded into measurements-part-*.txt. It runs a strategy built
+// by its factory on each file and merges the results. Files are processed
+// several at a time, largest first, with the workers split between them,
+// so small shards run side by side while a big one still uses the
+// strategy's own parallelism.
+//
+// The filePath given to Calculate is a list of files or glob patterns
+// separated by os.PathListSeparator; see ExpandFiles.
+type MultiFileStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	factory Factory
+	opts    StrategyOptions
+}
+
+// NewMultiFileStrategy returns a MultiFileStrategy running the strategies
+// factory builds, configured with opts.
+func NewMultiFileStrategy(factory Factory, opts StrategyOptions) *MultiFileStrategy {
+	return &MultiFileStrategy{factory: factory, opts: opts}
+}
+
+// ExpandFiles returns the files a MultiFileStrategy reads for pathList:
+// every element of the os.PathListSeparator-separated list, with glob
+// patterns replaced by their matches in sorted order. A pattern matching
+// nothing is an error.
+func ExpandFiles(pathList string) ([]string, error) {
+	var files []string
+	for _, p := range filepath.SplitList(pathList) {
+		if !strings.ContainsAny(p, "*?[") {
+			files = append(files, p)
+			continue
+		}
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", p)
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files in %q", pathList)
+	}
+	return files, nil
+}
+
+func (m *MultiFileStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	m.resetProgress()
+	defer m.reportProgress(m.opts)()
+	m.resetMalformed(m.opts)
+
+	files, err := ExpandFiles(filePath)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[string]int64, len(files))
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		sizes[path] = info.Size()
+	}
+	slices.SortStableFunc(files, func(a, b string) int {
+		return cmp.Compare(sizes[b], sizes[a])
+	})
+
+	workers := m.opts.workers()
+	n := min(len(files), workers)
+
+	// The first file to fail stops the others.
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	tempMaps := make([]map[string]StationResult, n)
+	errs := make([]error, n)
+	var next atomic.Int64
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := range n {
+		tempMaps[i] = make(map[string]StationResult)
+		go func(i int) {
+			defer wg.Done()
+			var lastBytes, lastRows int64
+			opts := m.opts
+			opts.Workers = max(workers/n, 1)
+			opts.Progress = ProgressFunc(func(bytesRead, rowsParsed int64) {
+				m.bytesRead.Add(bytesRead - lastBytes)
+				m.rowsParsed.Add(rowsParsed - lastRows)
+				lastBytes, lastRows = bytesRead, rowsParsed
+			})
+			s := m.factory(opts)
+
+			for !cancelled(runCtx) {
+				j := int(next.Add(1) - 1)
+				if j >= len(files) {
+					return
+				}
+				lastBytes, lastRows = 0, 0
+				results, err := s.Calculate(runCtx, files[j])
+				if err != nil {
+					// Files cut short by another's failure are not errors.
+					if runCtx.Err() == nil {
+						errs[i] = fmt.Errorf("%s: %w", files[j], err)
+						stop()
+					}
+					return
+				}
+				if c, ok := s.(MalformedLineCounter); ok {
+					m.count.Add(c.MalformedLines())
+				}
+				for _, res := range results {
+					if existing, ok := tempMaps[i][res.StationID]; ok {
+						res = mergeResult(existing, res)
+					}
+					tempMaps[i][res.StationID] = res
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return emitResults(&m.resultEmitter, tempMaps...), nil
+}
+
+// Name is the name of the strategy the factory builds, and Describe adds to
+// its description.
+func (m *MultiFileStrategy) Name() string {
+	s := m.factory(m.opts)
+	if md, ok := s.(Metadata); ok {
+		return md.Name()
+	}
+	return fmt.Sprintf("%T", s)
+}
+
+func (m *MultiFileStrategy) Describe() string {
+	if md, ok := m.factory(m.opts).(Metadata); ok {
+		return md.Describe() + ", several files at once"
+	}
+	return "several files at once"
+}
@@ -0,0 +1,80 @@
+package strategies
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// shardDataset splits the lines of path over shards files named
+// part-N.txt in a new directory, in uneven sizes, and returns the
+// directory.
+func shardDataset(t *testing.T, path string, shards int) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.SplitAfter(data, newline)
+	dir := t.TempDir()
+	for i := range shards {
+		// Shard i gets lines i, i+shards, ... of the first half, and the
+		// last shard the whole second half.
+		var shard []byte
+		for j := i; j < len(lines)/2; j += shards {
+			shard = append(shard, lines[j]...)
+		}
+		if i == shards-1 {
+			shard = append(shard, bytes.Join(lines[len(lines)/2:], nil)...)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("part-%d.txt", i)), shard, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMultiFileStrategyMergesShards(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+	dir := shardDataset(t, path, 5)
+	list := strings.Join([]string{
+		filepath.Join(dir, "part-[0-2].txt"),
+		filepath.Join(dir, "part-3.txt"),
+		filepath.Join(dir, "part-4.txt"),
+	}, string(os.PathListSeparator))
+
+	for _, workers := range []int{1, 3, 8} {
+		for _, key := range Registered() {
+			entry, _ := Lookup(key)
+			m := NewMultiFileStrategy(entry.New, StrategyOptions{Workers: workers, BufferSize: 256, ChunkSize: 4096})
+			t.Run(fmt.Sprintf("%s/%d workers", m.Name(), workers), func(t *testing.T) {
+				checkAggregates(t, m, filepath.Join(dir, "part-*.txt"), want)
+				checkAggregates(t, m, list, want)
+				if got, want := m.RowsParsed(), int64(20_000); got != want {
+					t.Errorf("parsed %d rows, want %d", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestMultiFileStrategyReportsFailingFile(t *testing.T) {
+	path, _ := writeRefillDataset(t, 5_000, 200)
+	dir := shardDataset(t, path, 3)
+	insertLines(t, filepath.Join(dir, "part-1.txt"), []int{10}, []string{"Oslo;12,3"})
+
+	entry, _ := Lookup("mcmp")
+	m := NewMultiFileStrategy(entry.New, StrategyOptions{Workers: 3, ParseMode: ParseStrict})
+	_, err := m.Calculate(t.Context(), filepath.Join(dir, "*.txt"))
+	if err == nil || !strings.Contains(err.Error(), "part-1.txt: line 11") {
+		t.Errorf("got error %v, want one naming part-1.txt line 11", err)
+	}
+
+	if _, err := m.Calculate(t.Context(), filepath.Join(dir, "missing-*.txt")); err == nil {
+		t.Error("a pattern matching nothing did not fail")
+	}
+}
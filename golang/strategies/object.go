@@ -0,0 +1,171 @@
+package strategies
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IsRemote reports whether path names an object read over the network: an
+// http:// or https:// URL, or an s3://bucket/key or gs://bucket/object
+// name. Only the strategies registered with ReadsURLs can read these.
+func IsRemote(path string) bool {
+	for _, scheme := range []string{"http://", "https://", "s3://", "gs://"} {
+		if strings.HasPrefix(path, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// objectURL resolves a remote path to the HTTP URL its bytes are fetched
+// from and a function that adds credentials to each request, nil for none.
+//
+// s3:// names use the AWS_REGION (or AWS_DEFAULT_REGION) and AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN variables, and AWS_ENDPOINT_URL
+// for S3-compatible stores such as MinIO. gs:// names use a bearer token
+// from GOOGLE_OAUTH_ACCESS_TOKEN, and STORAGE_EMULATOR_HOST if set. Without
+// credentials the object must be public.
+func objectURL(path string) (string, func(*http.Request), error) {
+	scheme, rest, _ := strings.Cut(path, "://")
+	if scheme == "http" || scheme == "https" {
+		return path, nil, nil
+	}
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" || key == "" {
+		return "", nil, fmt.Errorf("%s: want %s://bucket/object", path, scheme)
+	}
+
+	switch scheme {
+	case "s3":
+		region := getenv("us-east-1", "AWS_REGION", "AWS_DEFAULT_REGION")
+		u := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, awsURIEncode(key))
+		// Custom endpoints rarely have wildcard DNS, so address the bucket
+		// in the path.
+		if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+			u = fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(endpoint, "/"), bucket, awsURIEncode(key))
+		}
+		creds := awsCredentials{
+			accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+			region:       region,
+		}
+		if creds.accessKey == "" {
+			return u, nil, nil
+		}
+		return u, func(req *http.Request) { creds.sign(req, time.Now()) }, nil
+
+	case "gs":
+		host := "https://storage.googleapis.com"
+		if emulator := os.Getenv("STORAGE_EMULATOR_HOST"); emulator != "" {
+			host = emulator
+			if !strings.Contains(host, "://") {
+				host = "http://" + host
+			}
+		}
+		u := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(host, "/"), bucket, (&url.URL{Path: key}).EscapedPath())
+		token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+		if token == "" {
+			return u, nil, nil
+		}
+		return u, func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }, nil
+	}
+	return "", nil, fmt.Errorf("%s: unsupported scheme %q", path, scheme)
+}
+
+// getenv returns the first of the named environment variables that is set,
+// or def.
+func getenv(def string, names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return def
+}
+
+// awsCredentials signs S3 requests with AWS Signature Version 4.
+type awsCredentials struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	region       string
+}
+
+// emptyPayloadHash is the SHA-256 of the empty body of a GET.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// sign adds the x-amz-* headers and the Authorization header for a
+// bodiless request made at now. The Range header is signed with the rest,
+// so the signature covers exactly the bytes asked for.
+func (c awsCredentials) sign(req *http.Request, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := amzDate[:8] + "/" + c.region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if c.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	// The object URLs built here carry no query string.
+	fmt.Fprintf(&canonical, "%s\n%s\n%s\n", req.Method, req.URL.EscapedPath(), req.URL.RawQuery)
+	for _, name := range names {
+		fmt.Fprintf(&canonical, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+	fmt.Fprintf(&canonical, "\n%s\n%s", signedHeaders, emptyPayloadHash)
+
+	digest := sha256.Sum256([]byte(canonical.String()))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(digest[:])
+
+	key := []byte("AWS4" + c.secretKey)
+	for _, part := range []string{amzDate[:8], c.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, toSign))))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsURIEncode percent-encodes s as SigV4 requires: everything but
+// unreserved characters, keeping '/' so object keys stay paths.
+func awsURIEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,195 @@
+package strategies
+
+import (
+	"runtime"
+	"time"
+)
+
+const (
+	defaultTableSize     = 131072
+	defaultMaxLoadFactor = 0.75
+	defaultMapCapacity   = 100000
+
+	defaultProgressInterval = 100 * time.Millisecond
+)
+
+// StrategyOptions holds the tunables that strategies otherwise hardcode.
+// The zero value reproduces each strategy's original behaviour, so any
+// field left at zero falls back to that strategy's default.
+type StrategyOptions struct {
+	// Workers is the number of goroutines parallel strategies fan out to.
+	// Zero means runtime.NumCPU().
+	Workers int
+
+	// BufferSize is the read buffer size in bytes. Zero keeps each
+	// strategy's own default (64 KiB for bufio-based readers, 1 MiB for
+	// block readers, and four times that on Apple silicon).
+	BufferSize int
+
+	// ChunkSize is the size in bytes of the work units parallel strategies
+	// pull from their shared queue. Zero derives it from the file size.
+	ChunkSize int
+
+	// TableSize is the number of slots in the per-worker hash tables. It is
+	// rounded up to a power of two. Zero means 131072. The linear-probing,
+	// Swiss, Robin Hood, SoA, short-key and cuckoo tables treat it as their
+	// initial size and double at MaxLoadFactor; cuckoo tables also double
+	// when more than a few keys overflow into their stash.
+	TableSize int
+
+	// MapCapacity is the number of stations each worker's Go map is sized
+	// for up front. Maps grow past it as needed. Zero means 100000.
+	MapCapacity int
+
+	// Hash is the station name hash the strategies key their tables and
+	// maps by; see LookupHashFunction. Nil means fnv32.
+	Hash HashFunc
+
+	// MaxLoadFactor is the share of slots a growing hash table fills
+	// before doubling, in (0, 1]. Zero means 0.75.
+	MaxLoadFactor float64
+
+	// MapShards is the number of locked shards ShardedMapStrategy splits
+	// its shared map into, rounded up to a power of two, at most 65536.
+	// Zero means 64.
+	MapShards int
+
+	// BatchSize is the number of lines BatchStrategy sends its workers at
+	// a time, and the size it starts from with AdaptiveBatchSize. Zero
+	// means 100.
+	BatchSize int
+
+	// AdaptiveBatchSize makes BatchStrategy double its batches while its
+	// workers keep waiting on them, up to 4096 lines; see
+	// BatchSizeReporter for the size it settles on. Off by default.
+	AdaptiveBatchSize bool
+
+	// DisableIOHints turns off the fadvise/madvise readahead hints that
+	// strategies otherwise pass to the kernel where it supports them.
+	DisableIOHints bool
+
+	// HugePages requests transparent huge pages (MADV_HUGEPAGE) for the
+	// linear-probing tables and memory mappings. Off by default.
+	HugePages bool
+
+	// PinWorkers locks every worker goroutine to its own OS thread and
+	// pins that thread to one core (Linux only). Off by default.
+	PinWorkers bool
+
+	// ZeroCopyKeys lets the mmap strategy key stations by strings that
+	// point into the mapped file instead of copies, cloning them only when
+	// building the results. Off by default.
+	ZeroCopyKeys bool
+
+	// LineIndex makes strategies that split the file into chunks cut them
+	// at line starts read from a sidecar index, filePath + ".lineidx",
+	// instead of probing every chunk's first bytes for the end of the
+	// previous line. The index is built on first use and rebuilt when the
+	// file changes. Off by default.
+	LineIndex bool
+
+	// ParseMode chooses between skipping and counting malformed lines
+	// (ParseLenient, the default) and aborting at the first one
+	// (ParseStrict).
+	ParseMode ParseMode
+
+	// Quarantine, if set, receives every malformed line skipped in
+	// lenient mode, with its offset; see Quarantine.
+	Quarantine Quarantine
+
+	// Checkpoint, if set, is a file the strategies registered with
+	// Checkpoints save their progress to as they go, and resume from if
+	// it holds the progress of an earlier run on the same input; see
+	// Entry.Checkpoints. It is removed once a run completes.
+	Checkpoint string
+
+	// CheckpointInterval is how often each worker adds its progress to
+	// Checkpoint. Zero means a minute.
+	CheckpointInterval time.Duration
+
+	// SampleFraction, in (0, 1), makes the strategies registered with
+	// SamplesChunks aggregate a random sample of about that share of a
+	// file's chunks instead of all of them. Zero reads everything.
+	SampleFraction float64
+
+	// SampleSeed picks the chunks sampled. Runs with the same seed, file
+	// and chunk size sample the same chunks.
+	SampleSeed uint64
+
+	// NewAggregator, if set, makes the strategies registered with
+	// HostsAggregator give every station's StationResult.Extra an
+	// Aggregator of its own, such as NewDistribution's, and feed it every
+	// measurement. It costs a call per row and an Aggregator per station
+	// and worker.
+	NewAggregator func() Aggregator
+
+	// Progress, if set, is called periodically with the bytes read and
+	// lines parsed so far; see ProgressReporter.
+	Progress ProgressReporter
+
+	// ProgressInterval is how often Progress is called. Zero means 100ms.
+	ProgressInterval time.Duration
+
+	// Tracer, if set, receives spans for opening the input, each chunk a
+	// worker aggregates and the final merge; see Tracer.
+	Tracer Tracer
+
+	// PhaseTimer, if set, adds up the time spent opening, reading,
+	// parsing, aggregating and merging; see PhaseTimer.
+	PhaseTimer *PhaseTimer
+}
+
+// DefaultOptions returns the options every strategy used before they were
+// configurable.
+func DefaultOptions() StrategyOptions {
+	return StrategyOptions{
+		Workers:   runtime.NumCPU(),
+		TableSize: defaultTableSize,
+	}
+}
+
+func (o StrategyOptions) workers() int {
+	if o.Workers > 0 {
+		return o.Workers
+	}
+	return runtime.NumCPU()
+}
+
+func (o StrategyOptions) progressInterval() time.Duration {
+	if o.ProgressInterval > 0 {
+		return o.ProgressInterval
+	}
+	return defaultProgressInterval
+}
+
+func (o StrategyOptions) bufferSize(def int) int {
+	if o.BufferSize > 0 {
+		return o.BufferSize
+	}
+	return def
+}
+
+func (o StrategyOptions) tableSize() int {
+	if o.TableSize <= 0 {
+		return defaultTableSize
+	}
+	size := 1
+	for size < o.TableSize {
+		size <<= 1
+	}
+	return size
+}
+
+func (o StrategyOptions) mapCapacity() int {
+	if o.MapCapacity > 0 {
+		return o.MapCapacity
+	}
+	return defaultMapCapacity
+}
+
+func (o StrategyOptions) maxLoadFactor() float64 {
+	if o.MaxLoadFactor > 0 && o.MaxLoadFactor <= 1 {
+		return o.MaxLoadFactor
+	}
+	return defaultMaxLoadFactor
+}
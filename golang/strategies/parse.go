@@ -2,36 +2,133 @@ package strategies
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
+	"math/bits"
 	"strings"
+	"unicode/utf8"
 )
 
+const (
+	// maxFractionDigits and maxIntegerDigits bound the values parseTemp
+	// accepts to 18 digits, so no value overflows the int64 aggregates.
+	maxFractionDigits = 6
+	maxIntegerDigits  = 18 - maxFractionDigits
+)
+
+// pow10 scales a value by the fraction digits it is missing.
+var pow10 = [maxFractionDigits + 1]int64{1, 10, 100, 1_000, 10_000, 100_000, 1_000_000}
+
+// RecordFormat describes the lines of the input file.
+type RecordFormat struct {
+	// Delimiter separates a station name from its value.
+	Delimiter byte
+
+	// FractionDigits is the number of digits after the decimal point, at
+	// most 6. Values are aggregated as integers in units of the last
+	// digit, so StationResult sums and extremes are in those units.
+	FractionDigits int
+
+	// TolerantNumbers also accepts values with fewer fraction digits, or
+	// no decimal point at all, and scales them up to FractionDigits: with
+	// two digits, 12, 12.0 and 12.05 become 1200, 1200 and 1205. A value
+	// with more digits than FractionDigits is still malformed.
+	TolerantNumbers bool
+}
+
+// DefaultRecordFormat returns the 1BRC format: a ';' and exactly one
+// fraction digit.
+func DefaultRecordFormat() RecordFormat {
+	return RecordFormat{Delimiter: ';', FractionDigits: 1}
+}
+
+// recordFormat is the format every parser reads.
+var recordFormat = DefaultRecordFormat()
+
+// SetRecordFormat selects the format of the input. Like the hash, it
+// describes the file rather than a strategy, so it must be set before any
+// strategy runs.
+func SetRecordFormat(f RecordFormat) error {
+	switch d := f.Delimiter; {
+	case d == '\n' || d == '\r' || d == '-' || d == '.' || '0' <= d && d <= '9':
+		return fmt.Errorf("delimiter %q cannot separate a name from a number", d)
+	case d >= utf8.RuneSelf:
+		// Every byte of a multi-byte UTF-8 character is at least 0x80, so
+		// only ASCII delimiters leave names intact.
+		return fmt.Errorf("delimiter %q is not ASCII and could split a UTF-8 name", d)
+	case f.FractionDigits < 0 || f.FractionDigits > maxFractionDigits:
+		return fmt.Errorf("fraction digits must be between 0 and %d, got %d", maxFractionDigits, f.FractionDigits)
+	}
+	recordFormat = f
+	return nil
+}
+
+// utf8BOM is the byte order mark some Windows tools write at the start of
+// a UTF-8 file.
+const utf8BOM = "\xef\xbb\xbf"
+
+// trimLine strips what Windows tools add around a record: the '\r' of a
+// CRLF line ending and a UTF-8 BOM before the first line. For LF files
+// this costs two well-predicted byte compares per line, and no strategy
+// has to normalise its input up front.
+func trimLine(line []byte) []byte {
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	if len(line) >= 3 && line[0] == utf8BOM[0] && line[1] == utf8BOM[1] && line[2] == utf8BOM[2] {
+		line = line[3:]
+	}
+	return line
+}
+
+// parseLineBasic is parseLineByte for strings. Like it, it keeps the name's
+// bytes exactly as they are, without trimming spaces, so every strategy
+// agrees on which names are distinct.
 func parseLineBasic(line string) (string, int64, error) {
-	parts := strings.Split(line, ";")
-	if len(parts) != 2 {
-		return "", 0, fmt.Errorf("invalid line format")
+	line = strings.TrimPrefix(strings.TrimSuffix(line, "\r"), utf8BOM)
+	sep := strings.IndexByte(line, recordFormat.Delimiter)
+	if sep == -1 || strings.IndexByte(line[sep+1:], recordFormat.Delimiter) != -1 {
+		return "", 0, errMalformedLine
 	}
 
-	name := strings.TrimSpace(parts[0])
-	val, err := stringToInt(strings.TrimSpace(parts[1]))
+	name := line[:sep]
+	if name == "" {
+		return "", 0, errMalformedLine
+	}
+	val, err := parseTemp(line[sep+1:])
 
 	return name, val, err
 }
 
+// parseLineByte splits a record into its name and value. It returns
+// errMalformedLine unless the line is a non-empty name, the field
+// delimiter and a value parseTemp accepts.
 func parseLineByte(line []byte) (name []byte, value int64, err error) {
-	colonIndex := bytes.IndexByte(line, ';')
-	if colonIndex == -1 {
-		return nil, -1, fmt.Errorf("invalid line format")
-	}
+	return parseSplitLine(line, bytes.IndexByte(line, recordFormat.Delimiter))
+}
 
-	name = line[:colonIndex]
-	valueBytes := line[colonIndex+1:]
+// parseSplitLine is parseLineByte for a line whose first field delimiter
+// the caller already found at sep, or -1 when it has none, as nextLine
+// does in the same pass that finds the line's end. It trims the line as
+// trimLine does, but in place, so that sep keeps pointing at the delimiter.
+func parseSplitLine(line []byte, sep int) (name []byte, value int64, err error) {
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	start := 0
+	if len(line) >= 3 && line[0] == utf8BOM[0] && line[1] == utf8BOM[1] && line[2] == utf8BOM[2] {
+		start = 3
+	}
+	if sep <= start || sep >= len(line) {
+		return nil, -1, errMalformedLine
+	}
 
-	value, err = byteToInt(valueBytes)
-	return name, value, err
+	value, err = parseTemp(line[sep+1:])
+	return line[start:sep], value, err
 }
 
 func parseLineAdvanced(line []byte) (name []byte, value int64, err error) {
+	line = trimLine(line)
 	semiColIdx := -1
 	for i := range line {
 		if line[i] == ';' {
@@ -41,7 +138,7 @@ func parseLineAdvanced(line []byte) (name []byte, value int64, err error) {
 	}
 
 	if semiColIdx == -1 {
-		return nil, -1, fmt.Errorf("invalid line format")
+		return nil, -1, errMalformedLine
 	}
 
 	name = line[:semiColIdx]
@@ -70,9 +167,10 @@ func parseLineAdvanced(line []byte) (name []byte, value int64, err error) {
 }
 
 func parseLineUltra(line []byte) (name []byte, value int64, err error) {
+	line = trimLine(line)
 	semiColIdx := bytes.IndexByte(line, ';')
 	if semiColIdx == -1 {
-		return nil, -1, fmt.Errorf("invalid line format")
+		return nil, -1, errMalformedLine
 	}
 
 	name = line[:semiColIdx]
@@ -100,25 +198,130 @@ func parseLineUltra(line []byte) (name []byte, value int64, err error) {
 	return name, val, nil
 }
 
-func byteToInt(b []byte) (int64, error) {
+// parseLineSWAR is parseLineUltra with the temperature decoded by
+// parseTempSWAR.
+func parseLineSWAR(line []byte) (name []byte, value int64, err error) {
+	line = trimLine(line)
+	semiColIdx := bytes.IndexByte(line, ';')
+	if semiColIdx == -1 {
+		return nil, -1, errMalformedLine
+	}
+	value, err = parseTempSWAR(line[semiColIdx+1:])
+	if err != nil {
+		return nil, -1, err
+	}
+	return line[:semiColIdx], value, nil
+}
+
+// parseTempSWAR decodes a temperature of the form -?\d?\d\.\d into tenths
+// without a branch per character. All eight bytes starting at the sign are
+// loaded as one little-endian word; the decimal point is the only one of
+// bytes 1-3 with bit 4 clear, which locates it, after which the digits are
+// shifted into fixed positions and combined by a single multiply. Bytes
+// past the temperature are ignored, so the load may run into the next line
+// whenever b's capacity allows; only at the very end of a buffer is b
+// copied and zero-padded. A value without its decimal point where the form
+// puts it, one digit before the end, is rejected with errMalformedLine; the
+// digits themselves are not checked.
+func parseTempSWAR(b []byte) (int64, error) {
+	var word uint64
+	if cap(b) >= 8 {
+		word = binary.LittleEndian.Uint64(b[:8])
+	} else {
+		var buf [8]byte
+		copy(buf[:], b)
+		word = binary.LittleEndian.Uint64(buf[:])
+	}
+
+	dotPos := bits.TrailingZeros64(^word & 0x10101000)
+	if dot := dotPos / 8; dotPos > 28 || len(b) != dot+2 || b[dot] != '.' {
+		return 0, errMalformedLine
+	}
+	// signed is -1 when the first byte is '-' (bit 4 clear), else 0.
+	signed := int64(^word<<59) >> 63
+	designMask := ^uint64(signed & 0xFF)
+	// Align so the tenths digit lands in byte 4 and mask off everything but
+	// the three digit nibbles.
+	digits := ((word & designMask) << (28 - dotPos)) & 0x0F000F0F00
+	abs := int64(((digits * 0x640a0001) >> 32) & 0x3FF)
+	return (abs ^ signed) - signed, nil
+}
+
+// parseTemp decodes a value with exactly FractionDigits digits after the
+// decimal point, such as -12.3 in the 1BRC format, into an integer in units
+// of the last digit. Without fraction digits there is no decimal point.
+// Anything else, including surrounding bytes, is rejected with
+// errMalformedLine, so garbage never reaches the aggregates.
+func parseTemp[T string | []byte](b T) (int64, error) {
+	if recordFormat.TolerantNumbers {
+		return parseTempTolerant(b)
+	}
+	i := 0
+	if len(b) > 0 && b[0] == '-' {
+		i = 1
+	}
+	dot := len(b) // index of the decimal point
+	if digits := recordFormat.FractionDigits; digits > 0 {
+		dot -= digits + 1
+		if dot < 0 || b[dot] != '.' {
+			return 0, errMalformedLine
+		}
+	}
+	if n := dot - i; n < 1 || n > maxIntegerDigits {
+		return 0, errMalformedLine
+	}
+
 	var result int64
-	for i := range b {
-		if b[i] == '.' {
+	for ; i < len(b); i++ {
+		if i == dot {
 			continue
 		}
-		result = result*10 + int64(b[i]-'0')
+		d := b[i] - '0'
+		if d > 9 {
+			return 0, errMalformedLine
+		}
+		result = result*10 + int64(d)
+	}
+	if b[0] == '-' {
+		result = -result
 	}
 	return result, nil
 }
 
-func stringToInt(s string) (int64, error) {
+// parseTempTolerant is parseTemp for TolerantNumbers. It tracks how many
+// fraction digits the value has, from none (without a decimal point) to
+// FractionDigits, and scales the result by the ones that are missing.
+func parseTempTolerant[T string | []byte](b T) (int64, error) {
+	i := 0
+	if len(b) > 0 && b[0] == '-' {
+		i = 1
+	}
 	var result int64
-
-	for i := 0; i < len(s); i++ {
-		if s[i] == '.' {
+	intDigits, fracDigits, dot := 0, 0, false
+	for ; i < len(b); i++ {
+		if b[i] == '.' && !dot {
+			dot = true
 			continue
 		}
-		result = result*10 + int64(s[i]-'0')
+		d := b[i] - '0'
+		if d > 9 {
+			return 0, errMalformedLine
+		}
+		if dot {
+			fracDigits++
+		} else {
+			intDigits++
+		}
+		result = result*10 + int64(d)
+	}
+
+	missing := recordFormat.FractionDigits - fracDigits
+	if intDigits < 1 || intDigits > maxIntegerDigits || missing < 0 || dot && fracDigits == 0 {
+		return 0, errMalformedLine
+	}
+	result *= pow10[missing]
+	if b[0] == '-' {
+		result = -result
 	}
 	return result, nil
 }
@@ -0,0 +1,196 @@
+package strategies
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTempSWARMatchesDigitLoop(t *testing.T) {
+	for tenths := -999; tenths <= 999; tenths++ {
+		sign := ""
+		if tenths < 0 {
+			sign = "-"
+		}
+		abs := max(tenths, -tenths)
+		temp := fmt.Sprintf("%s%d.%d", sign, abs/10, abs%10)
+
+		// Without spare capacity the temperature is zero-padded; with it,
+		// the load runs into the following line.
+		short := []byte("X;" + temp)
+		inBuffer := []byte("Hamburg;" + temp + "\nBulawayo;8.9\n")
+		inBuffer = inBuffer[:len("Hamburg;")+len(temp)]
+
+		for _, line := range [][]byte{short, inBuffer} {
+			_, got, err := parseLineSWAR(line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != int64(tenths) {
+				t.Fatalf("parseLineSWAR(%q) = %d, want %d", line, got, tenths)
+			}
+		}
+	}
+}
+
+func TestParseTempSWARRejectsMissingDot(t *testing.T) {
+	for _, temp := range []string{"1234", "12", "-5", "", "1.23", "12.", ".5", "1\n2.3"} {
+		for _, line := range [][]byte{[]byte("Hamburg;" + temp), []byte("Hamburg;" + temp + "\nBulawayo;8.9\n")[:len("Hamburg;")+len(temp)]} {
+			if _, _, err := parseLineSWAR(line); err != errMalformedLine {
+				t.Errorf("parseLineSWAR(%q) error = %v, want %v", line, err, errMalformedLine)
+			}
+		}
+	}
+}
+
+func TestParseTempHonoursFractionDigits(t *testing.T) {
+	defer SetRecordFormat(DefaultRecordFormat())
+
+	tests := []struct {
+		digits int
+		valid  map[string]int64
+		bad    []string
+	}{
+		{0, map[string]int64{"0": 0, "7": 7, "-12": -12, "104": 104}, []string{"1.0", "1.", "-", ""}},
+		{2, map[string]int64{"0.00": 0, "1.25": 125, "-12.50": -1250}, []string{"1.2", "1.234", "12", ".25"}},
+		{3, map[string]int64{"-0.001": -1, "40.125": 40125}, []string{"40.12", "40.1250"}},
+	}
+	for _, tt := range tests {
+		if err := SetRecordFormat(RecordFormat{Delimiter: ';', FractionDigits: tt.digits}); err != nil {
+			t.Fatal(err)
+		}
+		for in, want := range tt.valid {
+			if got, err := parseTemp(in); err != nil || got != want {
+				t.Errorf("%d digits: parseTemp(%q) = %d, %v; want %d", tt.digits, in, got, err, want)
+			}
+		}
+		for _, in := range tt.bad {
+			if _, err := parseTemp(in); err == nil {
+				t.Errorf("%d digits: parseTemp(%q) accepted a malformed value", tt.digits, in)
+			}
+		}
+	}
+}
+
+func TestParseTempTolerantScalesShortFractions(t *testing.T) {
+	defer SetRecordFormat(DefaultRecordFormat())
+	if err := SetRecordFormat(RecordFormat{Delimiter: ';', FractionDigits: 2, TolerantNumbers: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := map[string]int64{"12": 1200, "12.0": 1200, "12.05": 1205, "-3": -300, "-3.5": -350, "0.01": 1}
+	for in, want := range valid {
+		if got, err := parseTemp(in); err != nil || got != want {
+			t.Errorf("parseTemp(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "-", "12.", ".5", "12.345", "1.2.3", "1e3", "--1"} {
+		if _, err := parseTemp(in); err == nil {
+			t.Errorf("parseTemp(%q) accepted a malformed value", in)
+		}
+	}
+}
+
+func TestStrategiesAggregateMixedPrecisionInTolerantMode(t *testing.T) {
+	defer SetRecordFormat(DefaultRecordFormat())
+	if err := SetRecordFormat(RecordFormat{Delimiter: ';', FractionDigits: 2, TolerantNumbers: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	path := filepath.Join(t.TempDir(), "sensors.txt")
+	var b strings.Builder
+	want := make(map[string]expectedStation)
+	for range 5_000 {
+		name := fmt.Sprintf("Sensor %d", rng.Intn(200))
+		value := rng.Int63n(10_000) // hundredths, 0 to 99.99
+		switch rng.Intn(3) {
+		case 0:
+			value -= value % 100
+			fmt.Fprintf(&b, "%s;%d\n", name, value/100)
+		case 1:
+			value -= value % 10
+			fmt.Fprintf(&b, "%s;%d.%d\n", name, value/100, value%100/10)
+		default:
+			fmt.Fprintf(&b, "%s;%d.%02d\n", name, value/100, value%100)
+		}
+
+		st, ok := want[name]
+		if !ok {
+			st = expectedStation{min: value, max: value}
+		}
+		st.sum += value
+		st.count++
+		st.min = min(st.min, value)
+		st.max = max(st.max, value)
+		want[name] = st
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range strategiesWith(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 8192}) {
+		t.Run(s.name, func(t *testing.T) {
+			checkAggregates(t, s.strategy, path, want)
+		})
+	}
+}
+
+func TestSetRecordFormatRejectsAmbiguousFormats(t *testing.T) {
+	defer SetRecordFormat(DefaultRecordFormat())
+
+	for _, delim := range []byte{'\n', '\r', '.', '-', '5', 0xC3} {
+		if err := SetRecordFormat(RecordFormat{Delimiter: delim, FractionDigits: 1}); err == nil {
+			t.Errorf("SetRecordFormat accepted delimiter %q", delim)
+		}
+	}
+	for _, digits := range []int{-1, maxFractionDigits + 1} {
+		if err := SetRecordFormat(RecordFormat{Delimiter: ',', FractionDigits: digits}); err == nil {
+			t.Errorf("SetRecordFormat accepted %d fraction digits", digits)
+		}
+	}
+}
+
+func TestStrategiesParseCustomRecordFormat(t *testing.T) {
+	if err := SetRecordFormat(RecordFormat{Delimiter: ',', FractionDigits: 2}); err != nil {
+		t.Fatal(err)
+	}
+	defer SetRecordFormat(DefaultRecordFormat())
+
+	rng := rand.New(rand.NewSource(1))
+	path := filepath.Join(t.TempDir(), "weather.csv")
+	var b strings.Builder
+	want := make(map[string]expectedStation)
+	for range 5_000 {
+		name := fmt.Sprintf("Station %d", rng.Intn(200))
+		value := rng.Int63n(20_000) - 10_000 // -100.00 to 99.99
+		sign := ""
+		if value < 0 {
+			sign = "-"
+		}
+		abs := max(value, -value)
+		fmt.Fprintf(&b, "%s,%s%d.%02d\n", name, sign, abs/100, abs%100)
+
+		st, ok := want[name]
+		if !ok {
+			st = expectedStation{min: value, max: value}
+		}
+		st.sum += value
+		st.count++
+		st.min = min(st.min, value)
+		st.max = max(st.max, value)
+		want[name] = st
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range strategiesWith(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 8192}) {
+		t.Run(s.name, func(t *testing.T) {
+			checkAggregates(t, s.strategy, path, want)
+		})
+	}
+}
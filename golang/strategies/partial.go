@@ -0,0 +1,96 @@
+package strategies
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// partialMagic opens a partial-result file.
+const partialMagic = "1BRCprt\x01"
+
+// ErrNotPartial is returned when a file does not start like a file written
+// by WritePartial.
+var ErrNotPartial = errors.New("not a partial-result file")
+
+// WritePartial writes results to w as a partial-result file, so that the
+// results of runs on separate shards or machines can be combined later
+// with ReadPartial and MergeResults. Aggregates are kept exactly, in units
+// of the current RecordFormat's last fraction digit, which the file
+// records.
+func WritePartial(w io.Writer, results []StationResult) error {
+	buf := append([]byte(partialMagic), byte(recordFormat.FractionDigits))
+	buf = binary.AppendUvarint(buf, uint64(len(results)))
+	for _, r := range results {
+		buf = binary.AppendUvarint(buf, uint64(len(r.StationID)))
+		buf = append(buf, r.StationID...)
+		buf = binary.AppendVarint(buf, r.Minimum)
+		buf = binary.AppendVarint(buf, r.Maximum)
+		buf = binary.AppendVarint(buf, r.Sum)
+		buf = binary.AppendUvarint(buf, uint64(r.Count))
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// ReadPartial reads a partial-result file written by WritePartial. The
+// file must have been written with the current RecordFormat's fraction
+// digits.
+func ReadPartial(r io.Reader) ([]StationResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) <= len(partialMagic) || string(data[:len(partialMagic)]) != partialMagic {
+		return nil, ErrNotPartial
+	}
+	if digits := int(data[len(partialMagic)]); digits != recordFormat.FractionDigits {
+		return nil, fmt.Errorf("partial written with %d fraction digits, the record format has %d",
+			digits, recordFormat.FractionDigits)
+	}
+
+	// A station takes at least five bytes: four varints and its name.
+	d := uvarintDecoder{buf: data[len(partialMagic)+1:]}
+	results := make([]StationResult, d.next(uint64(len(data)/5)))
+	for i := range results {
+		res := &results[i]
+		res.StationID = string(d.bytes(d.next(uint64(len(data)))))
+		res.Minimum = d.varint()
+		res.Maximum = d.varint()
+		res.Sum = d.varint()
+		res.Count = int64(d.next(math.MaxInt64))
+		if d.err == nil && res.Count == 0 {
+			d.err = fmt.Errorf("station %q has no measurements", res.StationID)
+		}
+		if d.err != nil {
+			break
+		}
+		res.Average = float64(res.Sum) / float64(res.Count)
+	}
+	if d.err == nil && len(d.buf) > 0 {
+		d.err = errors.New("trailing bytes")
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("corrupt partial: %w", d.err)
+	}
+	return results, nil
+}
+
+// MergeResults combines result sets of the same file format, such as the
+// partials of several shards, into one StationResult per station name,
+// merged exactly as the parallel strategies merge their workers' maps.
+func MergeResults(sets ...[]StationResult) []StationResult {
+	maps := make([]map[string]StationResult, len(sets))
+	for i, set := range sets {
+		maps[i] = make(map[string]StationResult, len(set))
+		for _, res := range set {
+			if existing, ok := maps[i][res.StationID]; ok {
+				res = mergeResult(existing, res)
+			}
+			maps[i][res.StationID] = res
+		}
+	}
+	return calcAverges(mergeMaps(maps))
+}
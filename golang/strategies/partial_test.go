@@ -0,0 +1,69 @@
+package strategies
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergedPartialsMatchWholeFile(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+	shards, err := SplitFile(path, t.TempDir(), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each shard's results go through a partial file and back.
+	var sets [][]StationResult
+	for _, shard := range shards {
+		results, err := NewMCMPStrategy(StrategyOptions{Workers: 2}).Calculate(t.Context(), shard)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := WritePartial(&buf, results); err != nil {
+			t.Fatal(err)
+		}
+		read, err := ReadPartial(&buf)
+		if err != nil {
+			t.Fatalf("%s: %v", filepath.Base(shard), err)
+		}
+		sets = append(sets, read)
+	}
+
+	merged := MergeResults(sets...)
+	if len(merged) != len(want) {
+		t.Fatalf("got %d stations, want %d", len(merged), len(want))
+	}
+	for _, r := range merged {
+		exp := want[r.StationID]
+		if r.Count != exp.count || r.Sum != exp.sum || r.Minimum != exp.min || r.Maximum != exp.max {
+			t.Errorf("%s: count=%d sum=%d min=%d max=%d, want %+v", r.StationID, r.Count, r.Sum, r.Minimum, r.Maximum, exp)
+		}
+	}
+}
+
+func TestReadPartialRejectsOtherFiles(t *testing.T) {
+	var buf bytes.Buffer
+	results := []StationResult{{StationID: "Oslo", Minimum: -52, Maximum: 301, Sum: 1234, Count: 17}}
+	if err := WritePartial(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	if _, err := ReadPartial(bytes.NewReader([]byte("Oslo;1.0\n"))); !errors.Is(err, ErrNotPartial) {
+		t.Errorf("text file: got %v, want ErrNotPartial", err)
+	}
+	if _, err := ReadPartial(bytes.NewReader(data[:len(data)-1])); err == nil {
+		t.Error("no error for a truncated partial")
+	}
+
+	defer SetRecordFormat(DefaultRecordFormat())
+	if err := SetRecordFormat(RecordFormat{Delimiter: ';', FractionDigits: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadPartial(bytes.NewReader(data)); err == nil {
+		t.Error("no error for a partial of other units")
+	}
+}
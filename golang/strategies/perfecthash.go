@@ -0,0 +1,297 @@
+package strategies
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/bits"
+	"slices"
+)
+
+const (
+	// mphKeysPerBucket is the average bucket size of the hash-and-displace
+	// construction. Larger buckets mean fewer seeds to store but a longer
+	// search for the first, fullest buckets.
+	mphKeysPerBucket = 4
+
+	// mphMaxSeed bounds the seed search for one bucket.
+	mphMaxSeed = 1 << 24
+
+	// mphMaxSalts bounds how often the construction starts over with the
+	// name hash re-seeded, when two names share a 64-bit hash or a bucket
+	// finds no seed.
+	mphMaxSalts = 8
+)
+
+// PerfectHashStrategy makes two passes over the file. The first collects
+// the set of station names; from it a minimal perfect hash is built that
+// maps every name to a distinct index in [0, stations). The second pass
+// then aggregates into a dense array indexed by that hash, with no probing
+// and no key comparisons at all. Because the first pass reads the whole
+// file, every name seen in the second pass is guaranteed to be known.
+type PerfectHashStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	probeRecorder
+	opts StrategyOptions
+}
+
+// NewPerfectHashStrategy returns a PerfectHashStrategy configured with opts.
+func NewPerfectHashStrategy(opts StrategyOptions) *PerfectHashStrategy {
+	return &PerfectHashStrategy{opts: opts}
+}
+
+func (p *PerfectHashStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	return p.aggregate(ctx, pathInput(filePath))
+}
+
+func (p *PerfectHashStrategy) CalculateReaderAt(ctx context.Context, r io.ReaderAt, size int64) ([]StationResult, error) {
+	return p.aggregate(ctx, readerAtInput(r, size))
+}
+
+func (p *PerfectHashStrategy) aggregate(ctx context.Context, in input) ([]StationResult, error) {
+	p.resetProgress()
+	defer p.reportProgress(p.opts)()
+	names, err := distinctStations(ctx, in, p.opts, &p.progress)
+	if interrupted(ctx, err) {
+		// Cancelled while collecting names, before aggregating any line.
+		return nil, &PartialError{Err: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return []StationResult{}, nil
+	}
+
+	mph, err := newPerfectHash(names, p.opts.hash())
+	if err != nil {
+		return nil, err
+	}
+	return runTableStrategy(ctx, in, p.opts, &p.progress, &p.malformedLines, &p.probeRecorder, &p.resultEmitter, func() stationTable {
+		return &denseTable{mph: mph, aggs: make([]denseAgg, len(names))}
+	})
+}
+
+// distinctStations is the perfect hash's first pass: it collects the names
+// of every well-formed line and returns the distinct ones in sorted order.
+// Malformed lines are skipped here and left for the second pass to count
+// or report.
+func distinctStations(ctx context.Context, in input, opts StrategyOptions, p *progress) ([]string, error) {
+	src, err := in.open(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	sets := make([]nameSet, opts.workers())
+	err = scanChunks(ctx, src, opts, p, &malformedLines{}, func(worker int) lineSink {
+		sets[worker] = make(nameSet)
+		return sets[worker]
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	all := make(nameSet)
+	for _, set := range sets {
+		for name := range set {
+			all[name] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// nameSet collects distinct station names. Lookups by string(name) do not
+// allocate, so only new names are copied.
+type nameSet map[string]struct{}
+
+func (s nameSet) addLine(line []byte) bool {
+	name, _, err := parseLineByte(line)
+	if err != nil {
+		return false
+	}
+	if _, ok := s[string(name)]; !ok {
+		s[string(name)] = struct{}{}
+	}
+	return true
+}
+
+// perfectHash is a minimal perfect hash over a fixed key set, built with
+// the hash-and-displace (CHD) scheme: keys are split into buckets by one
+// hash, and each bucket gets a seed under which all of its keys land in
+// slots no other bucket uses. names is stored in slot order.
+//
+// Keys are hashed with a 64-bit wyhash seeded by salt, not with the -hash
+// function, whose 32-bit variants collide on station sets of realistic
+// size. hash is only used to key the merged results like every strategy.
+type perfectHash struct {
+	names []string
+	seeds []uint32
+	salt  uint64
+	hash  HashFunc
+}
+
+// newPerfectHash builds a perfect hash over names, whose results are keyed
+// by hash. Should two names share a 64-bit hash, or a bucket find no seed,
+// it starts over with the name hash re-seeded.
+func newPerfectHash(names []string, hash HashFunc) (*perfectHash, error) {
+	for salt := range uint64(mphMaxSalts) {
+		if mph := buildPerfectHash(names, salt); mph != nil {
+			mph.hash = hash
+			return mph, nil
+		}
+	}
+	return nil, fmt.Errorf("perfect hash: no construction found for %d stations", len(names))
+}
+
+// buildPerfectHash builds a perfect hash over names with the name hash
+// seeded by salt, or returns nil if two names share that hash or a bucket
+// finds no seed.
+func buildPerfectHash(names []string, salt uint64) *perfectHash {
+	n := len(names)
+	numBuckets := max(n/mphKeysPerBucket, 1)
+	hashes := make([]uint64, n)
+	buckets := make([][]int, numBuckets)
+	for i, name := range names {
+		hashes[i] = mphHash([]byte(name), salt)
+		b := reduceRange(hashes[i], numBuckets)
+		buckets[b] = append(buckets[b], i)
+	}
+
+	// Place the largest buckets first, while most slots are still free.
+	order := make([]int, numBuckets)
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int { return len(buckets[b]) - len(buckets[a]) })
+
+	mph := &perfectHash{names: make([]string, n), seeds: make([]uint32, numBuckets), salt: salt}
+	taken := make([]bool, n)
+	slots := make([]int, 0, mphKeysPerBucket*4)
+
+	for _, b := range order {
+		keys := buckets[b]
+		if len(keys) == 0 {
+			break
+		}
+		// Keys with the same hash share a slot under every seed.
+		for j, k := range keys {
+			for _, other := range keys[:j] {
+				if hashes[k] == hashes[other] {
+					return nil
+				}
+			}
+		}
+	search:
+		for seed := uint32(0); ; seed++ {
+			if seed == mphMaxSeed {
+				return nil
+			}
+			slots = slots[:0]
+			for _, k := range keys {
+				slot := mphSlot(hashes[k], seed, n)
+				if taken[slot] || slices.Contains(slots, slot) {
+					continue search
+				}
+				slots = append(slots, slot)
+			}
+			for j, slot := range slots {
+				taken[slot] = true
+				mph.names[slot] = names[keys[j]]
+			}
+			mph.seeds[b] = seed
+			break
+		}
+	}
+	return mph
+}
+
+// index returns name's slot. It is only meaningful for names in the set
+// the hash was built from.
+func (h *perfectHash) index(name []byte) int {
+	hash := mphHash(name, h.salt)
+	seed := h.seeds[reduceRange(hash, len(h.seeds))]
+	return mphSlot(hash, seed, len(h.names))
+}
+
+func mphSlot(hash uint64, seed uint32, n int) int {
+	return reduceRange(mix64(hash^(uint64(seed)*0x9e3779b97f4a7c15)), n)
+}
+
+// mphHash is the 64-bit name hash the perfect hash is built on.
+func mphHash(name []byte, salt uint64) uint64 {
+	return wyhash(name, salt)
+}
+
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	return x
+}
+
+// reduceRange maps x uniformly onto [0, n) without a division.
+func reduceRange(x uint64, n int) int {
+	hi, _ := bits.Mul64(x, uint64(n))
+	return int(hi)
+}
+
+type denseAgg struct {
+	sum, count, min, max int64
+}
+
+// denseTable aggregates into one array slot per known station.
+type denseTable struct {
+	mph  *perfectHash
+	aggs []denseAgg
+}
+
+func (t *denseTable) addLine(line []byte) bool {
+	name, value, err := parseLineByte(line)
+	if err != nil {
+		return false
+	}
+	a := &t.aggs[t.mph.index(name)]
+	if a.count == 0 {
+		a.min, a.max = value, value
+	}
+	a.sum += value
+	a.count++
+	a.min = min(a.min, value)
+	a.max = max(a.max, value)
+	return true
+}
+
+func (t *denseTable) flushInto(smap StationMap, _ *internTable) {
+	for i, a := range t.aggs {
+		if a.count == 0 {
+			continue
+		}
+		name := t.mph.names[i]
+		storeStation(smap, hashKey(t.mph.hash, []byte(name)), StationResult{
+			StationID: name,
+			Sum:       a.sum,
+			Count:     a.count,
+			Maximum:   a.max,
+			Minimum:   a.min,
+		})
+	}
+}
+
+// probeStats reports one probe per key: a perfect hash never collides.
+func (t *denseTable) probeStats() ProbeStats {
+	c := collectProbeStats{slots: len(t.aggs)}
+	for _, a := range t.aggs {
+		if a.count > 0 {
+			c.add(1)
+		}
+	}
+	return c.stats()
+}
@@ -0,0 +1,61 @@
+package strategies
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestPerfectHashIsMinimalAndCollisionFree(t *testing.T) {
+	for _, n := range []int{1, 7, 500, 10_000} {
+		names := make([]string, n)
+		for i := range names {
+			names[i] = fmt.Sprintf("station-%d", i)
+		}
+		mph, err := newPerfectHash(names, hashFnv32)
+		if err != nil {
+			t.Fatalf("n=%d: %v", n, err)
+		}
+
+		seen := make([]bool, n)
+		for _, name := range names {
+			idx := mph.index([]byte(name))
+			if seen[idx] {
+				t.Fatalf("n=%d: slot %d assigned twice", n, idx)
+			}
+			seen[idx] = true
+			if mph.names[idx] != name {
+				t.Fatalf("n=%d: %q maps to slot of %q", n, name, mph.names[idx])
+			}
+		}
+	}
+}
+
+func TestPerfectHashTellsApartNamesWithTheSame32BitHash(t *testing.T) {
+	a, b := []byte("Station-640488"), []byte("Station-1091540")
+	if hashFnv32(a) != hashFnv32(b) {
+		t.Fatal("the names no longer share an fnv32 hash")
+	}
+	mph, err := newPerfectHash([]string{string(a), string(b)}, hashFnv32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ia, ib := mph.index(a), mph.index(b); ia == ib || mph.names[ia] != string(a) || mph.names[ib] != string(b) {
+		t.Errorf("slots %d and %d hold %q", ia, ib, mph.names)
+	}
+
+	// The strategy runs under the 32-bit hash without failing.
+	path, want := writeDataset(t, rand.New(rand.NewSource(1)), 1000, []string{string(a), string(b), "Bern"})
+	checkAggregates(t, NewPerfectHashStrategy(StrategyOptions{Workers: 2, Hash: hashFnv32}), path, want)
+}
+
+func TestPerfectHashStrategy(t *testing.T) {
+	path, want := writeRefillDataset(t, 50_000, 500)
+	s := NewPerfectHashStrategy(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 8192})
+	checkAggregates(t, s, path, want)
+
+	// Every worker's array has one slot per station and no key probes.
+	if stats := s.ProbeStats(); stats.Max != 1 || stats.Slots != 4*len(want) {
+		t.Errorf("got %+v, want one probe per key over %d slots", stats, 4*len(want))
+	}
+}
@@ -0,0 +1,211 @@
+package strategies
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// phaseSampleEvery is how many lines a worker aggregates per line it
+	// times, to split its time between parsing and table updates.
+	phaseSampleEvery = 64
+
+	// phaseSampleMax is the longest a sampled line may take to parse or to
+	// aggregate. Longer ones were interrupted, by the scheduler or the
+	// collector, and would swamp the hundreds of lines sampled around them.
+	phaseSampleMax = 20 * time.Microsecond
+)
+
+// PhaseTimes is where the time of a run went. Open and Merge are
+// wall-clock. Read, Parse and Aggregate are summed over the Workers that
+// read chunks, so they add up to about Workers times the time spent
+// scanning.
+type PhaseTimes struct {
+	Open time.Duration // opening the input
+
+	// Read is the time workers waited for their read buffers.
+	Read time.Duration
+
+	// Parse is the time workers spent finding and parsing lines, and
+	// Aggregate the time they spent updating their tables. Only one line
+	// in 64 is timed, so the split between them is an estimate.
+	Parse     time.Duration
+	Aggregate time.Duration
+
+	Merge time.Duration // merging the workers' results
+
+	Workers int
+}
+
+// PhaseTimer, set as StrategyOptions.PhaseTimer, adds up where the time of
+// runs goes. Every strategy that emits SpanOpen and SpanMerge times those;
+// the strategies reading through the shared chunk driver (the hash-table
+// strategies, double-buffer and sharded-map) also split their workers'
+// time between reading, parsing and aggregating. It costs a clock reading
+// per read buffer and three per 64 lines. A PhaseTimer keeps adding up run
+// after run until Reset.
+type PhaseTimer struct {
+	mu    sync.Mutex
+	times PhaseTimes
+}
+
+// Times returns the times added up since the last Reset.
+func (t *PhaseTimer) Times() PhaseTimes {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.times
+}
+
+// Reset forgets the times added up so far.
+func (t *PhaseTimer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.times = PhaseTimes{}
+}
+
+// timeSpan times a span named name if it is a phase of its own, and
+// returns the function ending it.
+func (t *PhaseTimer) timeSpan(name string) func() {
+	if t == nil || name != SpanOpen && name != SpanMerge {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		d := time.Since(start)
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if name == SpanOpen {
+			t.times.Open += d
+		} else {
+			t.times.Merge += d
+		}
+	}
+}
+
+// worker returns a clock for one worker of a scan, or nil if t is.
+func (t *PhaseTimer) worker() *phaseClock {
+	if t == nil {
+		return nil
+	}
+	return &phaseClock{timer: t}
+}
+
+// phaseClock times one worker's reads and lines, then adds them to its
+// PhaseTimer.
+type phaseClock struct {
+	timer *PhaseTimer
+
+	read, scan time.Duration
+	got        time.Time // when the worker got its buffer; zero between chunks
+
+	// lines counts the lines the worker aggregated, and parse and add the
+	// time the sampled ones took to parse alone and to aggregate. extra is
+	// the time of every parse alone, interrupted ones included.
+	lines, sampled    int64
+	parse, add, extra time.Duration
+}
+
+// timedBlocks is a blockSource whose reads a phaseClock times. The time
+// from getting a buffer to asking for the next is spent on its lines.
+type timedBlocks struct {
+	blockSource
+	c *phaseClock
+}
+
+func (b timedBlocks) next() ([]byte, error) {
+	now := time.Now()
+	if !b.c.got.IsZero() {
+		b.c.scan += now.Sub(b.c.got)
+	}
+	buf, err := b.blockSource.next()
+	b.c.got = time.Now()
+	b.c.read += b.c.got.Sub(now)
+	return buf, err
+}
+
+// blocks returns src timed by c, or src itself if c is nil.
+func (c *phaseClock) blocks(src blockSource) blockSource {
+	if c == nil {
+		return src
+	}
+	return timedBlocks{src, c}
+}
+
+// chunkDone ends the time spent on the lines of a chunk.
+func (c *phaseClock) chunkDone() {
+	if c == nil || c.got.IsZero() {
+		return
+	}
+	c.scan += time.Since(c.got)
+	c.got = time.Time{}
+}
+
+// timedSink is a lineSink timing one line in phaseSampleEvery for a
+// phaseClock: parsing it alone, and aggregating it as usual. Whichever of
+// the two runs first is charged for warming up after the clock reading, so
+// sampled lines alternate which goes first.
+type timedSink struct {
+	lineSink
+	c *phaseClock
+}
+
+func (s timedSink) addLine(line []byte) bool {
+	s.c.lines++
+	if s.c.lines%phaseSampleEvery != 0 {
+		return s.lineSink.addLine(line)
+	}
+	var ok bool
+	var parse, add time.Duration
+	start := time.Now()
+	if s.c.lines/phaseSampleEvery%2 == 0 {
+		parseLineByte(line)
+		parsed := time.Now()
+		ok = s.lineSink.addLine(line)
+		parse, add = parsed.Sub(start), time.Since(parsed)
+	} else {
+		ok = s.lineSink.addLine(line)
+		added := time.Now()
+		parseLineByte(line)
+		add, parse = added.Sub(start), time.Since(added)
+	}
+	s.c.extra += parse
+	if parse <= phaseSampleMax && add <= phaseSampleMax {
+		s.c.parse += parse
+		s.c.add += add
+		s.c.sampled++
+	}
+	return ok
+}
+
+// sink returns sink timed by c, or sink itself if c is nil.
+func (c *phaseClock) sink(sink lineSink) lineSink {
+	if c == nil {
+		return sink
+	}
+	return timedSink{sink, c}
+}
+
+// done adds the worker's times to its PhaseTimer. The lines sampled were
+// parsed twice, so one parse of each comes off the time spent on lines.
+// The rest is split by how much longer aggregating the sampled lines took
+// than parsing them, scaled to every line.
+func (c *phaseClock) done() {
+	if c == nil {
+		return
+	}
+	c.chunkDone()
+	scan := max(c.scan-c.extra, 0)
+	var aggregate time.Duration
+	if c.sampled > 0 {
+		perLine := max(c.add-c.parse, 0) / time.Duration(c.sampled)
+		aggregate = min(perLine*time.Duration(c.lines), scan)
+	}
+
+	t := c.timer
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.times.Read += c.read
+	t.times.Parse += scan - aggregate
+	t.times.Aggregate += aggregate
+	t.times.Workers++
+}
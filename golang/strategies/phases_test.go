@@ -0,0 +1,25 @@
+package strategies
+
+import "testing"
+
+func TestPhaseTimerSplitsWorkerTime(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+	timer := &PhaseTimer{}
+	opts := StrategyOptions{Workers: 4, ChunkSize: 16 << 10, PhaseTimer: timer}
+
+	for _, s := range []Strategy{NewSwissTableStrategy(opts), NewDoubleBufferedStrategy(opts)} {
+		timer.Reset()
+		checkAggregates(t, s, path, want)
+		times := timer.Times()
+		if times.Workers != opts.Workers {
+			t.Errorf("%T: timed %d workers, want %d", s, times.Workers, opts.Workers)
+		}
+		if times.Parse <= 0 || times.Aggregate <= 0 {
+			t.Errorf("%T: lines not split between parsing and aggregating: %+v", s, times)
+		}
+	}
+	timer.Reset()
+	if times := timer.Times(); times != (PhaseTimes{}) {
+		t.Errorf("Reset left %+v", times)
+	}
+}
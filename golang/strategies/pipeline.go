@@ -0,0 +1,211 @@
+package strategies
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// slotsPerWorker is how many ring slots exist per parser, so the reader can
+// run ahead while every parser is busy.
+const slotsPerWorker = 2
+
+// PipelineStrategy is a staged design: a single reader goroutine fills the
+// fixed-size slots of a ring buffer with whole lines, and N parser
+// goroutines consume filled slots and hand them back. Slots are allocated
+// once up front and station names are only copied on first insert, so the
+// steady state performs no per-line allocation.
+type PipelineStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	syscallCount
+	opts StrategyOptions
+}
+
+// NewPipelineStrategy returns a PipelineStrategy configured with opts.
+func NewPipelineStrategy(opts StrategyOptions) *PipelineStrategy {
+	return &PipelineStrategy{opts: opts}
+}
+
+// ringSlot is one buffer of the ring. data always ends on a line boundary
+// and starts at file offset offset.
+type ringSlot struct {
+	buf    []byte
+	data   []byte
+	offset int64
+	seq    int64 // index of the slot's data among those filled, in file order
+}
+
+// slotRing hands slot indices between the reader and the parsers: free
+// slots flow to the reader, filled slots to the parsers.
+type slotRing struct {
+	slots []ringSlot
+	free  chan int
+	full  chan int
+}
+
+func newSlotRing(count, size int) *slotRing {
+	r := &slotRing{
+		slots: make([]ringSlot, count),
+		free:  make(chan int, count),
+		full:  make(chan int, count),
+	}
+	for i := range r.slots {
+		r.slots[i].buf = make([]byte, size)
+		r.free <- i
+	}
+	return r
+}
+
+func (p *PipelineStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	p.resetProgress()
+	defer p.reportProgress(p.opts)()
+	p.resetMalformed(p.opts)
+	p.resetSyscalls()
+	f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p.opts.adviseSequential(f)
+
+	// A parser that hits a malformed line in strict mode stops the reader.
+	readCtx, stopReading := context.WithCancel(ctx)
+	defer stopReading()
+
+	n := p.opts.workers()
+	ring := newSlotRing(n*slotsPerWorker, p.opts.bufferSize(defaultBlockBufSize))
+	tempMaps, errs, wg := startParsers(ring, p.opts, &p.progress, &p.malformedLines, stopReading)
+
+	readErr := p.fill(readCtx, f, ring)
+	close(ring.full)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := firstError(errs); err != nil {
+		return nil, locateParseError(filePath, err)
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return emitResults(&p.resultEmitter, tempMaps...), nil
+}
+
+// startParsers launches opts.workers() parser goroutines, each aggregating
+// filled slots into its own map and counting lines in p. The WaitGroup
+// completes once ring.full is closed and drained. A parser that fails
+// records its error, calls stop and from then on only recycles slots, so
+// the reader never blocks.
+func startParsers(ring *slotRing, opts StrategyOptions, p *progress, m *malformedLines, stop func()) ([]StationMap, []error, *sync.WaitGroup) {
+	n := opts.workers()
+	tempMaps := make([]StationMap, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := range n {
+		tempMaps[i] = make(StationMap, opts.mapCapacity())
+		go func(i int) {
+			defer wg.Done()
+			defer opts.pinWorker(i)()
+			sink := opts.sink(tempMaps[i])
+			rows := lineCounter{p: p}
+			defer rows.flush()
+			for idx := range ring.full {
+				if errs[i] == nil {
+					slot := &ring.slots[idx]
+					if errs[i] = inChunk(parseLines(slot.data, slot.offset, sink, &rows, m), slot.seq, i); errs[i] != nil {
+						stop()
+					}
+				}
+				ring.free <- idx
+			}
+		}(i)
+	}
+	return tempMaps, errs, &wg
+}
+
+// fill is the reader stage. Each slot is filled with as much of the file as
+// fits, cut at the last newline; the partial line after it is carried to the
+// front of the next slot.
+func (p *PipelineStrategy) fill(ctx context.Context, f *os.File, ring *slotRing) error {
+	carry := 0
+	var carried []byte
+	var offset int64 // file offset of the next read
+	var seq int64
+
+	for {
+		if cancelled(ctx) {
+			return ctx.Err()
+		}
+
+		idx := <-ring.free
+		slot := &ring.slots[idx]
+		copy(slot.buf, carried[:carry])
+
+		n, err := io.ReadFull(syscallReader{f, &p.syscallCount}, slot.buf[carry:])
+		p.addProgress(n)
+		slot.offset = offset - int64(carry)
+		offset += int64(n)
+		filled := slot.buf[:carry+n]
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			return err
+		}
+
+		if eof {
+			slot.data, slot.seq = filled, seq
+			if len(filled) > 0 {
+				ring.full <- idx
+			} else {
+				ring.free <- idx
+			}
+			return nil
+		}
+
+		cut := bytes.LastIndexByte(filled, '\n')
+		if cut == -1 {
+			ring.free <- idx
+			return fmt.Errorf("line longer than %d-byte pipeline slot", len(slot.buf))
+		}
+
+		// The tail must be copied out before the slot is handed to a
+		// parser, which may recycle it at any time.
+		carried = append(carried[:0], filled[cut+1:]...)
+		carry = len(carried)
+		slot.data, slot.seq = filled[:cut+1], seq
+		seq++
+		ring.full <- idx
+	}
+}
+
+// parseLines aggregates every newline-separated line in data, which starts
+// at file offset offset, into sink, counting them in rows. A final line
+// without a trailing newline is included. Malformed lines are passed to m.
+func parseLines(data []byte, offset int64, sink lineSink, rows *lineCounter, m *malformedLines) error {
+	for len(data) > 0 {
+		rows.add()
+		line := data
+		idx := bytes.IndexByte(data, '\n')
+		if idx >= 0 {
+			line = data[:idx]
+		}
+		if !sink.addLine(line) {
+			if err := m.reject(offset, line); err != nil {
+				return err
+			}
+		}
+		if idx == -1 {
+			return nil
+		}
+		data = data[idx+1:]
+		offset += int64(idx + 1)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package strategies
+
+import (
+	"cmp"
+	"os"
+	"slices"
+)
+
+// Chunk is the byte range [Start, End) of a file that a worker aggregates
+// in one go.
+type Chunk struct {
+	Start, End int64
+}
+
+// ChunkPlan is how a strategy would lay out a run over a file: the chunks
+// its workers pull, in queue order, and what each worker holds meanwhile.
+type ChunkPlan struct {
+	FileSize int64
+	Workers  int
+	Chunks   []Chunk
+
+	// BufferSize is the size of each read buffer, and Buffers how many a
+	// worker holds. Both are zero for strategies that map the file.
+	BufferSize int64
+	Buffers    int
+
+	// TableSlots is the initial size of each worker's hash table or map.
+	TableSlots int
+}
+
+// Memory estimates the bytes the workers' buffers and tables take up. A
+// mapped file is left out, since the page cache holds it.
+func (p ChunkPlan) Memory() int64 {
+	perWorker := int64(p.Buffers)*p.BufferSize + int64(p.TableSlots)*stationEntryBytes
+	return int64(p.Workers) * perWorker
+}
+
+// Gaps returns the ranges of the file that no chunk covers, in file order.
+// Lines starting there would never be aggregated, so a plan without
+// sampling should have none.
+func (p ChunkPlan) Gaps() []Chunk {
+	chunks := slices.Clone(p.Chunks)
+	slices.SortFunc(chunks, func(a, b Chunk) int { return cmp.Compare(a.Start, b.Start) })
+	var gaps []Chunk
+	var covered int64
+	for _, c := range chunks {
+		if c.Start > covered {
+			gaps = append(gaps, Chunk{covered, c.Start})
+		}
+		covered = max(covered, c.End)
+	}
+	if covered < p.FileSize {
+		gaps = append(gaps, Chunk{covered, p.FileSize})
+	}
+	return gaps
+}
+
+// planChunks plans a run of opts over filePath whose workers each hold
+// buffers read buffers, sized by bufSize from the chunk size, and a table
+// of slots entries.
+func planChunks(filePath string, opts StrategyOptions, buffers int, bufSize func(chunkSize int64) int64, slots int) (ChunkPlan, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return ChunkPlan{}, err
+	}
+	defer f.Close()
+	fsize, err := getFileSize(f)
+	if err != nil {
+		return ChunkPlan{}, err
+	}
+	n := opts.workers()
+	chunkSize := opts.chunkSize(fsize, n)
+	queue, err := opts.chunkQueue(f, fsize, chunkSize)
+	if err != nil {
+		return ChunkPlan{}, err
+	}
+
+	plan := ChunkPlan{FileSize: fsize, Workers: n, Buffers: buffers, TableSlots: slots}
+	if buffers > 0 {
+		plan.BufferSize = bufSize(chunkSize)
+	}
+	for start, end, ok := queue.pop(); ok; start, end, ok = queue.pop() {
+		plan.Chunks = append(plan.Chunks, Chunk{start, end})
+	}
+	return plan, nil
+}
+
+// fixedBuffer is a bufSize for planChunks that ignores the chunk size.
+func fixedBuffer(size int) func(int64) int64 {
+	return func(int64) int64 { return int64(size) }
+}
+
+// chunkReaderPlan is the Entry.Plan of strategies whose workers read each
+// chunk through one buffer of opts.bufferSize(defaultBuf) bytes into a
+// table of slots(opts) entries.
+func chunkReaderPlan(defaultBuf int, slots func(StrategyOptions) int) func(string, StrategyOptions) (ChunkPlan, error) {
+	return func(filePath string, opts StrategyOptions) (ChunkPlan, error) {
+		return planChunks(filePath, opts, 1, fixedBuffer(opts.bufferSize(defaultBuf)), slots(opts))
+	}
+}
+
+// scanPlan is the Entry.Plan of strategies reading through scanChunks
+// into a table of slots(opts) entries per worker.
+func scanPlan(slots func(StrategyOptions) int) func(string, StrategyOptions) (ChunkPlan, error) {
+	return func(filePath string, opts StrategyOptions) (ChunkPlan, error) {
+		return planScan(filePath, opts, slots(opts))
+	}
+}
+
+// planScan plans a run through scanChunks.
+func planScan(filePath string, opts StrategyOptions, slots int) (ChunkPlan, error) {
+	return planChunks(filePath, opts, prefetchDepth, func(chunkSize int64) int64 {
+		return scanBufferSize(opts, chunkSize)
+	}, slots)
+}
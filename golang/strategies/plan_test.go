@@ -0,0 +1,48 @@
+package strategies
+
+import "testing"
+
+func TestPlansCoverTheFile(t *testing.T) {
+	path, _ := writeRefillDataset(t, 20_000, 300)
+	for _, opts := range []StrategyOptions{
+		{Workers: 3},
+		{Workers: 4, ChunkSize: 4096},
+		{Workers: 7, ChunkSize: 5000, LineIndex: true},
+	} {
+		for _, s := range strategiesWith(opts) {
+			if s.Plan == nil {
+				continue
+			}
+			plan, err := s.Plan(path, opts)
+			if err != nil {
+				t.Fatalf("%s: %v", s.name, err)
+			}
+			if gaps := plan.Gaps(); len(gaps) > 0 {
+				t.Errorf("%s with %+v leaves %v unassigned", s.name, opts, gaps)
+			}
+			if plan.Workers != opts.Workers || plan.Memory() <= 0 {
+				t.Errorf("%s: %d workers, %d bytes", s.name, plan.Workers, plan.Memory())
+			}
+		}
+	}
+}
+
+func TestPlanGaps(t *testing.T) {
+	plan := ChunkPlan{FileSize: 100, Chunks: []Chunk{{40, 60}, {0, 20}, {50, 70}}}
+	want := []Chunk{{20, 40}, {70, 100}}
+	got := plan.Gaps()
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got gaps %v, want %v", got, want)
+	}
+
+	sampled := StrategyOptions{Workers: 2, ChunkSize: 4096, SampleFraction: 0.3, SampleSeed: 1}
+	path, _ := writeRefillDataset(t, 20_000, 300)
+	swiss, _ := Lookup("swiss")
+	plan, err := swiss.Plan(path, sampled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Gaps()) == 0 {
+		t.Error("a sampled plan covers the whole file")
+	}
+}
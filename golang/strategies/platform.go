@@ -0,0 +1,25 @@
+package strategies
+
+import "errors"
+
+// platformStub is embedded in the stand-ins for strategies that are not
+// wired up on this operating system.
+type platformStub struct{}
+
+func (platformStub) unsupportedPlatform() {}
+
+// Supported reports whether s can run on this operating system. Strategies
+// built on Linux-only system calls are registered everywhere, so their keys
+// stay valid, but elsewhere they are stand-ins whose Calculate fails with
+// an error matching errors.ErrUnsupported.
+func Supported(s Strategy) bool {
+	_, stub := s.(interface{ unsupportedPlatform() })
+	return !stub
+}
+
+// platformError is what a stand-in's Calculate returns.
+type platformError string
+
+func (e platformError) Error() string { return string(e) }
+
+func (platformError) Is(target error) bool { return target == errors.ErrUnsupported }
@@ -0,0 +1,24 @@
+package strategies
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestUnsupportedStrategiesFailWithErrUnsupported(t *testing.T) {
+	for _, key := range Registered() {
+		s := registeredStrategy(key, StrategyOptions{})
+		if Supported(s.strategy) {
+			continue
+		}
+		if _, err := s.strategy.Calculate(t.Context(), "measurements.txt"); !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("%s: got %v, want an error matching errors.ErrUnsupported", key, err)
+		}
+	}
+
+	mapped := runtime.GOOS == "linux" || runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+	if got := Supported(NewMmapStrategy(StrategyOptions{})); got != mapped {
+		t.Errorf("mmap supported on %s = %v, want %v", runtime.GOOS, got, mapped)
+	}
+}
@@ -0,0 +1,185 @@
+//go:build linux
+
+package strategies
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// preadvMaxSlots caps how many ring slots a single preadv call fills.
+	preadvMaxSlots = 8
+
+	// preadvCarryRoom is reserved at the front of every slot so the partial
+	// line left over from the previous slot can be prepended without moving
+	// the freshly read bytes. It also bounds the longest supported line.
+	preadvCarryRoom = 4 << 10
+)
+
+// PreadvStrategy is the pipeline strategy with a vectored reader: instead
+// of one read per slot, the reader grabs every free slot it can (up to
+// preadvMaxSlots) and fills them all with a single preadv call, cutting the
+// syscall count when slots are small.
+type PreadvStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	syscallCount
+	opts StrategyOptions
+}
+
+// NewPreadvStrategy returns a PreadvStrategy configured with opts.
+func NewPreadvStrategy(opts StrategyOptions) *PreadvStrategy {
+	return &PreadvStrategy{opts: opts}
+}
+
+func (p *PreadvStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	p.resetProgress()
+	defer p.reportProgress(p.opts)()
+	p.resetMalformed(p.opts)
+	p.resetSyscalls()
+	f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := getFileSize(f); err != nil {
+		return nil, err
+	}
+	p.opts.adviseSequential(f)
+
+	// A parser that hits a malformed line in strict mode stops the reader.
+	readCtx, stopReading := context.WithCancel(ctx)
+	defer stopReading()
+
+	n := p.opts.workers()
+	ring := newSlotRing(n*slotsPerWorker, preadvCarryRoom+p.opts.bufferSize(defaultChunkBufSize))
+	tempMaps, errs, wg := startParsers(ring, p.opts, &p.progress, &p.malformedLines, stopReading)
+
+	readErr := p.fill(readCtx, f, ring)
+	close(ring.full)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := firstError(errs); err != nil {
+		return nil, locateParseError(filePath, err)
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return emitResults(&p.resultEmitter, tempMaps...), nil
+}
+
+// fill is the reader stage. Each preadv lands the next stretch of the file
+// in the bodies of a batch of slots; every slot is then cut at its last
+// newline and the remainder is carried into the front of the next one.
+func (p *PreadvStrategy) fill(ctx context.Context, f *os.File, ring *slotRing) error {
+	fd := int(f.Fd())
+	var offset, seq int64
+	var carried []byte
+	batch := make([]int, 0, preadvMaxSlots)
+	iovs := make([]syscall.Iovec, 0, preadvMaxSlots)
+
+	for {
+		if cancelled(ctx) {
+			return ctx.Err()
+		}
+
+		batch = append(batch[:0], <-ring.free)
+	more:
+		for len(batch) < preadvMaxSlots {
+			select {
+			case idx := <-ring.free:
+				batch = append(batch, idx)
+			default:
+				break more
+			}
+		}
+
+		iovs = iovs[:0]
+		for _, idx := range batch {
+			body := ring.slots[idx].buf[preadvCarryRoom:]
+			iov := syscall.Iovec{Base: &body[0]}
+			iov.SetLen(len(body))
+			iovs = append(iovs, iov)
+		}
+
+		n, err := preadv(fd, iovs, offset)
+		p.addSyscall()
+		if err != nil {
+			return fmt.Errorf("preadv: %w", err)
+		}
+		bodyOffset := offset // file offset of the next slot's body
+		offset += int64(n)
+		p.addProgress(n)
+
+		if n == 0 {
+			if len(carried) > 0 {
+				slot := &ring.slots[batch[0]]
+				slot.offset = offset - int64(len(carried))
+				slot.data = slot.buf[preadvCarryRoom-len(carried) : preadvCarryRoom]
+				slot.seq = seq
+				copy(slot.data, carried)
+				ring.full <- batch[0]
+				batch = batch[1:]
+			}
+			for _, idx := range batch {
+				ring.free <- idx
+			}
+			return nil
+		}
+
+		rest := n
+		for _, idx := range batch {
+			slot := &ring.slots[idx]
+			got := min(rest, len(slot.buf)-preadvCarryRoom)
+			rest -= got
+
+			start := preadvCarryRoom - len(carried)
+			copy(slot.buf[start:], carried)
+			slot.offset = bodyOffset - int64(len(carried))
+			bodyOffset += int64(got)
+			filled := slot.buf[start : preadvCarryRoom+got]
+
+			cut := bytes.LastIndexByte(filled, '\n')
+			tail := filled[cut+1:]
+			if len(tail) > preadvCarryRoom {
+				ring.free <- idx
+				return fmt.Errorf("line longer than %d bytes", preadvCarryRoom)
+			}
+			carried = append(carried[:0], tail...)
+
+			if cut == -1 {
+				ring.free <- idx
+				continue
+			}
+			slot.data, slot.seq = filled[:cut+1], seq
+			seq++
+			ring.full <- idx
+		}
+	}
+}
+
+// preadv reads into iovs starting at offset without moving the file
+// position, retrying on EINTR.
+func preadv(fd int, iovs []syscall.Iovec, offset int64) (int, error) {
+	for {
+		n, _, errno := syscall.Syscall6(syscall.SYS_PREADV, uintptr(fd),
+			uintptr(unsafe.Pointer(&iovs[0])), uintptr(len(iovs)),
+			uintptr(offset), uintptr(offset>>32), 0)
+		if errno == syscall.EINTR {
+			continue
+		}
+		if errno != 0 {
+			return 0, errno
+		}
+		return int(n), nil
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package strategies
+
+import "context"
+
+// PreadvStrategy fills several pipeline slots per preadv call, which is
+// only wired up on Linux. On other platforms Calculate always fails.
+type PreadvStrategy struct {
+	platformStub
+	progress
+	syscallCount
+	opts StrategyOptions
+}
+
+// NewPreadvStrategy returns a PreadvStrategy configured with opts.
+func NewPreadvStrategy(opts StrategyOptions) *PreadvStrategy {
+	return &PreadvStrategy{opts: opts}
+}
+
+func (p *PreadvStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	return nil, platformError("preadv strategy requires Linux")
+}
@@ -0,0 +1,92 @@
+package strategies
+
+import "io"
+
+// block is one filled read buffer handed from the prefetch goroutine to
+// the parser.
+type block struct {
+	data []byte
+	err  error
+}
+
+// blockPrefetcher reads its input sequentially in a background goroutine,
+// so that the next buffer is being filled while the caller parses the
+// current one. Buffers circulate between the two sides and are never
+// reallocated: the caller must release every buffer it receives before the
+// prefetcher can reuse it.
+type blockPrefetcher struct {
+	full chan block
+	free chan []byte
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newBlockPrefetcher starts reading r into bufs. With two buffers this is
+// classic double buffering; more buffers allow deeper read-ahead.
+func newBlockPrefetcher(r io.Reader, bufs [][]byte) *blockPrefetcher {
+	p := &blockPrefetcher{
+		full: make(chan block, len(bufs)+1),
+		free: make(chan []byte, len(bufs)),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	for _, b := range bufs {
+		p.free <- b
+	}
+	go p.run(r)
+	return p
+}
+
+func (p *blockPrefetcher) run(r io.Reader) {
+	defer close(p.done)
+	defer close(p.full)
+
+	for {
+		var buf []byte
+		select {
+		case buf = <-p.free:
+		case <-p.stop:
+			return
+		}
+
+		// One Read per buffer: a network body hands over what has arrived
+		// rather than waiting for the buffer to fill.
+		n, err := r.Read(buf[:cap(buf)])
+		if n > 0 {
+			p.full <- block{data: buf[:n]}
+		} else {
+			p.free <- buf
+		}
+
+		if err != nil {
+			if err != io.EOF {
+				p.full <- block{err: err}
+			}
+			return
+		}
+	}
+}
+
+// next returns the next filled buffer, or io.EOF once the file is
+// exhausted.
+func (p *blockPrefetcher) next() ([]byte, error) {
+	b, ok := <-p.full
+	if !ok {
+		return nil, io.EOF
+	}
+	return b.data, b.err
+}
+
+// release hands a buffer obtained from next back for reuse.
+func (p *blockPrefetcher) release(buf []byte) {
+	p.free <- buf
+}
+
+// close stops the background reader and waits for it to exit. Any
+// buffers still in flight are drained so the caller can reuse them.
+func (p *blockPrefetcher) close() {
+	close(p.stop)
+	for range p.full {
+	}
+	<-p.done
+}
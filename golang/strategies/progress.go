@@ -0,0 +1,122 @@
+package strategies
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// ProgressTracker is implemented by strategies that expose how many bytes of
+// the input file they have consumed and how many lines they have parsed so
+// far. It is safe to call both from another goroutine while Calculate is
+// running. Lines are counted in batches, so RowsParsed lags a little behind.
+type ProgressTracker interface {
+	BytesRead() int64
+	RowsParsed() int64
+}
+
+// ProgressReporter receives progress from a running strategy, set through
+// StrategyOptions.Progress. Strategies call ReportProgress every
+// StrategyOptions.ProgressInterval from a goroutine of their own and once
+// more, with the final counts, before Calculate returns. Calls never
+// overlap, but a slow reporter delays the next one.
+type ProgressReporter interface {
+	ReportProgress(bytesRead, rowsParsed int64)
+}
+
+// ProgressFunc adapts a function to ProgressReporter.
+type ProgressFunc func(bytesRead, rowsParsed int64)
+
+func (f ProgressFunc) ReportProgress(bytesRead, rowsParsed int64) {
+	f(bytesRead, rowsParsed)
+}
+
+// progress is embedded in strategies to satisfy ProgressTracker.
+type progress struct {
+	bytesRead  atomic.Int64
+	rowsParsed atomic.Int64
+	reporting  atomic.Bool
+}
+
+func (p *progress) BytesRead() int64 {
+	return p.bytesRead.Load()
+}
+
+func (p *progress) RowsParsed() int64 {
+	return p.rowsParsed.Load()
+}
+
+func (p *progress) resetProgress() {
+	p.bytesRead.Store(0)
+	p.rowsParsed.Store(0)
+}
+
+// reportProgress starts calling opts.Progress every opts.progressInterval()
+// and returns a function that stops the calls and reports the final counts.
+// It does nothing without a reporter, or when a report loop for the run is
+// already going, as for the perfect hash's second pass.
+func (p *progress) reportProgress(opts StrategyOptions) (stop func()) {
+	r := opts.Progress
+	if r == nil || !p.reporting.CompareAndSwap(false, true) {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(opts.progressInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				r.ReportProgress(p.BytesRead(), p.RowsParsed())
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+		r.ReportProgress(p.BytesRead(), p.RowsParsed())
+		p.reporting.Store(false)
+	}
+}
+
+func (p *progress) addProgress(n int) {
+	p.bytesRead.Add(int64(n))
+}
+
+// lineCounter counts a worker's lines locally and adds them to its
+// strategy's progress every cancelCheckInterval lines, so hot loops do not
+// touch the shared atomic per line. Workers flush it when they finish.
+type lineCounter struct {
+	p *progress
+	n int64
+}
+
+func (c *lineCounter) add() {
+	if c.n++; c.n == cancelCheckInterval {
+		c.flush()
+	}
+}
+
+func (c *lineCounter) flush() {
+	c.p.rowsParsed.Add(c.n)
+	c.n = 0
+}
+
+// countingReader reports every Read to a progress counter, so buffered
+// readers only touch the atomic once per refill instead of once per line.
+type countingReader struct {
+	r io.Reader
+	p *progress
+}
+
+func (c countingReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.p.addProgress(n)
+	return n, err
+}
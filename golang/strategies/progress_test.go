@@ -0,0 +1,69 @@
+package strategies
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProgressReporterSeesEveryRow(t *testing.T) {
+	const rows = 20_000
+	path, _ := writeRefillDataset(t, rows, 300)
+
+	var (
+		inCall       atomic.Bool
+		calls        atomic.Int64
+		lastBytes    atomic.Int64
+		lastRows     atomic.Int64
+		overlapped   atomic.Bool
+		rowsWentBack atomic.Bool
+	)
+	opts := StrategyOptions{
+		Workers:          4,
+		BufferSize:       256,
+		ChunkSize:        4096,
+		ProgressInterval: time.Millisecond,
+		Progress: ProgressFunc(func(bytesRead, rowsParsed int64) {
+			if !inCall.CompareAndSwap(false, true) {
+				overlapped.Store(true)
+			}
+			defer inCall.Store(false)
+			if rowsParsed < lastRows.Load() {
+				rowsWentBack.Store(true)
+			}
+			calls.Add(1)
+			lastBytes.Store(bytesRead)
+			lastRows.Store(rowsParsed)
+		}),
+	}
+
+	for _, s := range strategiesWith(opts) {
+		t.Run(s.name, func(t *testing.T) {
+			calls.Store(0)
+			lastRows.Store(0)
+			overlapped.Store(false)
+			rowsWentBack.Store(false)
+
+			if _, err := s.strategy.Calculate(t.Context(), path); err != nil {
+				t.Fatal(err)
+			}
+			tracker := s.strategy.(ProgressTracker)
+			if calls.Load() == 0 {
+				t.Fatal("reporter never called")
+			}
+			if got := lastRows.Load(); got != rows || tracker.RowsParsed() != rows {
+				t.Errorf("final report has %d rows, tracker %d, want %d", got, tracker.RowsParsed(), rows)
+			}
+			if got := lastBytes.Load(); got != tracker.BytesRead() || got == 0 {
+				t.Errorf("final report has %d bytes, tracker %d", got, tracker.BytesRead())
+			}
+			if overlapped.Load() {
+				t.Error("reporter called concurrently")
+			}
+			// The perfect hash restarts its counters for the second pass.
+			if _, twoPass := s.strategy.(*PerfectHashStrategy); rowsWentBack.Load() && !twoPass {
+				t.Error("reported rows went backwards")
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package strategies
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Factory builds a strategy configured with opts.
+type Factory func(opts StrategyOptions) Strategy
+
+// Entry is a strategy in the registry: how to build it and what it
+// supports beyond Calculate, so that runners can pick the strategies that
+// honor an option without building any.
+type Entry struct {
+	New Factory
+
+	// InDefaultSuite is set for strategies runners benchmark when none are
+	// named. The rest need an input of their own, such as a compressed,
+	// binary or sharded dataset, or a cluster of workers.
+	InDefaultSuite bool
+
+	// Checkpoints is set for strategies that honor
+	// StrategyOptions.Checkpoint: every worker aggregates the chunks it
+	// pulls from the shared queue into a table of its own, which it can
+	// save between chunks, so a run that is interrupted resumes from the
+	// chunks it had saved instead of starting over.
+	Checkpoints bool
+
+	// SamplesChunks is set for strategies that honor
+	// StrategyOptions.SampleFraction: they pull a local file's chunks from
+	// the shared queue, so a sampled queue makes them aggregate only the
+	// chunks it picked. Their results then describe the sample, and
+	// SampledFraction says how much of the file that was.
+	SamplesChunks bool
+
+	// ReadsURLs is set for strategies that also accept a remote object as
+	// the file path: an http:// or https:// URL, or an s3:// or gs:// name
+	// (see IsRemote). They fetch every chunk with its own HTTP range
+	// request, so the workers download in parallel straight from object
+	// storage and nothing is copied to local disk.
+	ReadsURLs bool
+
+	// ReadsSequentially is set for strategies that read their input once
+	// from start to end, and so can consume a FIFO at the end of a
+	// generation pipeline, or /dev/stdin, without a temporary file.
+	// PipelineStrategy is the one to use for speed: a single reader feeds
+	// parallel parsers.
+	ReadsSequentially bool
+
+	// ReadsPathLists is set for strategies that read a list of files
+	// themselves, given as MultiFileStrategy takes it, so runners pass them
+	// a sharded dataset directly instead of wrapping them in a
+	// MultiFileStrategy.
+	ReadsPathLists bool
+
+	// HostsAggregator is set for strategies that honor
+	// StrategyOptions.NewAggregator, feeding every measurement to each
+	// station's StationResult.Extra. The others leave Extra nil: their hot
+	// loops keep stations in tables of their own rather than
+	// StationResults.
+	HostsAggregator bool
+
+	// Plan, if set, tells how the strategy run with opts would split a
+	// local file without aggregating it. Planning reads a few bytes at each
+	// chunk boundary or, with LineIndex set, builds the file's sidecar
+	// index if it is missing or stale, as a run would.
+	Plan func(filePath string, opts StrategyOptions) (ChunkPlan, error)
+}
+
+// registry maps the keys runners select strategies by to their entries.
+// Built-in strategies are listed here; others are added with Register.
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Entry{
+		"basic": {
+			New:               func(o StrategyOptions) Strategy { return NewBasicStrategy(o) },
+			InDefaultSuite:    true,
+			ReadsSequentially: true,
+			HostsAggregator:   true,
+		},
+		"byte": {
+			New:               func(o StrategyOptions) Strategy { return NewByteReadingStrategy(o) },
+			InDefaultSuite:    true,
+			ReadsSequentially: true,
+		},
+		"batch": {
+			New:               func(o StrategyOptions) Strategy { return NewBatchStrategy(o) },
+			InDefaultSuite:    true,
+			ReadsSequentially: true,
+		},
+		"batch-ranges": {
+			New:            func(o StrategyOptions) Strategy { return NewRangeBatchStrategy(o) },
+			InDefaultSuite: true,
+			Checkpoints:    true,
+			SamplesChunks:  true,
+			ReadsURLs:      true,
+			Plan:           scanPlan(StrategyOptions.mapCapacity),
+		},
+		"mcmp": {
+			New:            func(o StrategyOptions) Strategy { return NewMCMPStrategy(o) },
+			InDefaultSuite: true,
+			SamplesChunks:  true,
+			Plan:           chunkReaderPlan(defaultChunkBufSize, StrategyOptions.mapCapacity),
+		},
+		"mcmp-lp": {
+			New:            func(o StrategyOptions) Strategy { return NewMCMPLinearProbing(o) },
+			InDefaultSuite: true,
+			SamplesChunks:  true,
+			Plan:           chunkReaderPlan(defaultChunkBufSize, StrategyOptions.tableSize),
+		},
+		"mcmp-lp-opt": {
+			New:            func(o StrategyOptions) Strategy { return NewMCMPLinearProbingOptimized(o) },
+			InDefaultSuite: true,
+			SamplesChunks:  true,
+			Plan:           chunkReaderPlan(defaultBlockBufSize, StrategyOptions.tableSize),
+		},
+		"lp-table": {
+			New:            func(o StrategyOptions) Strategy { return NewLinearProbeTableStrategy(o) },
+			InDefaultSuite: true,
+			Checkpoints:    true,
+			SamplesChunks:  true,
+			ReadsURLs:      true,
+			Plan:           scanPlan(StrategyOptions.tableSize),
+		},
+		"robin-hood": {
+			New:            func(o StrategyOptions) Strategy { return NewRobinHoodStrategy(o) },
+			InDefaultSuite: true,
+			Checkpoints:    true,
+			SamplesChunks:  true,
+			ReadsURLs:      true,
+			Plan:           scanPlan(StrategyOptions.tableSize),
+		},
+		"swiss": {
+			New:            func(o StrategyOptions) Strategy { return NewSwissTableStrategy(o) },
+			InDefaultSuite: true,
+			Checkpoints:    true,
+			SamplesChunks:  true,
+			ReadsURLs:      true,
+			Plan:           scanPlan(func(o StrategyOptions) int { return max(o.tableSize(), swissGroupSize) }),
+		},
+		"cuckoo": {
+			New:            func(o StrategyOptions) Strategy { return NewCuckooStrategy(o) },
+			InDefaultSuite: true,
+			Checkpoints:    true,
+			SamplesChunks:  true,
+			ReadsURLs:      true,
+			Plan:           scanPlan(StrategyOptions.tableSize),
+		},
+		"perfect-hash": {
+			New:            func(o StrategyOptions) Strategy { return NewPerfectHashStrategy(o) },
+			InDefaultSuite: true,
+			Checkpoints:    true,
+			SamplesChunks:  true,
+			ReadsURLs:      true,
+			// The dense array has a slot per station, which the map
+			// capacity estimates.
+			Plan: scanPlan(StrategyOptions.mapCapacity),
+		},
+		"short-key": {
+			New:            func(o StrategyOptions) Strategy { return NewShortKeyStrategy(o) },
+			InDefaultSuite: true,
+			Checkpoints:    true,
+			SamplesChunks:  true,
+			ReadsURLs:      true,
+			// The short-key table and the fallback table for long names.
+			Plan: scanPlan(func(o StrategyOptions) int { return 2 * o.tableSize() }),
+		},
+		"soa": {
+			New:            func(o StrategyOptions) Strategy { return NewSoATableStrategy(o) },
+			InDefaultSuite: true,
+			Checkpoints:    true,
+			SamplesChunks:  true,
+			ReadsURLs:      true,
+			Plan:           scanPlan(StrategyOptions.tableSize),
+		},
+		"double-buffer": {
+			New:             func(o StrategyOptions) Strategy { return NewDoubleBufferedStrategy(o) },
+			InDefaultSuite:  true,
+			SamplesChunks:   true,
+			ReadsURLs:       true,
+			HostsAggregator: true,
+			Plan:            scanPlan(StrategyOptions.mapCapacity),
+		},
+		"sharded-map": {
+			New:             func(o StrategyOptions) Strategy { return NewShardedMapStrategy(o) },
+			InDefaultSuite:  true,
+			SamplesChunks:   true,
+			ReadsURLs:       true,
+			HostsAggregator: true,
+			// The workers share the map, so each is charged a share of it.
+			Plan: scanPlan(func(o StrategyOptions) int { return max(o.mapCapacity()/o.workers(), 1) }),
+		},
+		"pipeline": {
+			New:               func(o StrategyOptions) Strategy { return NewPipelineStrategy(o) },
+			InDefaultSuite:    true,
+			ReadsSequentially: true,
+			HostsAggregator:   true,
+		},
+		"preadv": {
+			New:             func(o StrategyOptions) Strategy { return NewPreadvStrategy(o) },
+			InDefaultSuite:  true,
+			HostsAggregator: true,
+		},
+		"io-uring": {
+			New:             func(o StrategyOptions) Strategy { return NewIOURingStrategy(o) },
+			InDefaultSuite:  true,
+			SamplesChunks:   true,
+			HostsAggregator: true,
+			Plan:            planIOURing,
+		},
+		"mmap": {
+			New:            func(o StrategyOptions) Strategy { return NewMmapStrategy(o) },
+			InDefaultSuite: true,
+			SamplesChunks:  true,
+			Plan:           planMmap,
+		},
+		"direct-io": {
+			New:             func(o StrategyOptions) Strategy { return NewDirectIOStrategy(o) },
+			InDefaultSuite:  true,
+			SamplesChunks:   true,
+			HostsAggregator: true,
+			Plan:            planDirectIO,
+		},
+		"zstd":   {New: func(o StrategyOptions) Strategy { return NewZstdStrategy(o) }},
+		"binary": {New: func(o StrategyOptions) Strategy { return NewBinaryStrategy(o) }},
+		"shards": {
+			New:            func(o StrategyOptions) Strategy { return NewShardStrategy(o) },
+			ReadsPathLists: true,
+		},
+	}
+)
+
+// Register makes a strategy available under key to runners that enumerate
+// Registered, such as the benchmark CLI. Like database/sql.Register it is
+// meant to be called from init and panics if key is empty or already taken,
+// or if entry.New is nil.
+func Register(key string, entry Entry) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if key == "" || entry.New == nil {
+		panic("strategies: Register needs a key and a factory")
+	}
+	if _, dup := registry[key]; dup {
+		panic(fmt.Sprintf("strategies: Register called twice for %q", key))
+	}
+	registry[key] = entry
+}
+
+// Lookup returns the entry registered under key.
+func Lookup(key string) (Entry, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	e, ok := registry[key]
+	return e, ok
+}
+
+// Registered lists the keys accepted by Lookup in sorted order.
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	keys := make([]string, 0, len(registry))
+	for key := range registry {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,37 @@
+package strategies
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRegisterAddsLookupKey(t *testing.T) {
+	const key = "test-basic"
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, key)
+		registryMu.Unlock()
+	})
+
+	Register(key, Entry{New: func(o StrategyOptions) Strategy { return NewBasicStrategy(o) }, ReadsSequentially: true})
+	if !slices.Contains(Registered(), key) {
+		t.Errorf("Registered() = %v, missing %q", Registered(), key)
+	}
+	entry, ok := Lookup(key)
+	if !ok {
+		t.Fatalf("Lookup(%q) found nothing", key)
+	}
+	if _, ok := entry.New(StrategyOptions{}).(*BasicStrategy); !ok {
+		t.Errorf("factory built %T, want *BasicStrategy", entry.New(StrategyOptions{}))
+	}
+	if !entry.ReadsSequentially || entry.Checkpoints {
+		t.Errorf("Lookup(%q) = %+v, lost the capabilities it was registered with", key, entry)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a taken key did not panic")
+		}
+	}()
+	Register("mcmp", Entry{New: func(o StrategyOptions) Strategy { return NewMCMPStrategy(o) }})
+}
@@ -0,0 +1,179 @@
+package strategies
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// RobinHoodStrategy aggregates into per-worker Robin Hood hash tables. On
+// insert, a key that has probed further than the slot's occupant takes the
+// slot and the occupant moves on, which evens out probe lengths compared
+// with plain linear probing and lets lookups stop early. Entries are never
+// deleted, so no backward-shift deletion is needed.
+type RobinHoodStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	probeRecorder
+	opts StrategyOptions
+}
+
+// NewRobinHoodStrategy returns a RobinHoodStrategy configured with opts.
+func NewRobinHoodStrategy(opts StrategyOptions) *RobinHoodStrategy {
+	return &RobinHoodStrategy{opts: opts}
+}
+
+func (r *RobinHoodStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	return r.aggregate(ctx, pathInput(filePath))
+}
+
+func (r *RobinHoodStrategy) CalculateReaderAt(ctx context.Context, reader io.ReaderAt, size int64) ([]StationResult, error) {
+	return r.aggregate(ctx, readerAtInput(reader, size))
+}
+
+func (r *RobinHoodStrategy) aggregate(ctx context.Context, in input) ([]StationResult, error) {
+	return runTableStrategy(ctx, in, r.opts, &r.progress, &r.malformedLines, &r.probeRecorder, &r.resultEmitter, func() stationTable {
+		return newRobinHoodTable(r.opts)
+	})
+}
+
+// rhEntry is one Robin Hood slot, padded to a 64-byte cache line.
+type rhEntry struct {
+	name                 []byte
+	hash                 uint32
+	psl                  uint32 // probe sequence length; 0 marks an empty slot
+	sum, count, min, max int64
+}
+
+// robinHoodTable starts at opts.tableSize() slots and, like lpTable,
+// doubles once opts.maxLoadFactor() of them are in use, so an insert always
+// ends at a free slot.
+type robinHoodTable struct {
+	entries []rhEntry
+	mask    uint32
+	keys    nameArena
+	used    int
+	growAt  int
+	opts    StrategyOptions
+}
+
+func newRobinHoodTable(opts StrategyOptions) *robinHoodTable {
+	t := &robinHoodTable{opts: opts}
+	t.resize(opts.tableSize())
+	return t
+}
+
+func (t *robinHoodTable) addLine(line []byte) bool {
+	name, value, err := parseLineByte(line)
+	if err != nil {
+		return false
+	}
+	t.add(name, value)
+	return true
+}
+
+func (t *robinHoodTable) add(name []byte, value int64) {
+	hash := hashKey(t.opts.hash(), name)
+	idx := hash & t.mask
+
+	for psl := uint32(1); ; psl++ {
+		e := &t.entries[idx]
+		if e.psl == 0 {
+			*e = t.newEntry(name, hash, psl, value)
+			t.added()
+			return
+		}
+		if e.hash == hash && bytes.Equal(e.name, name) {
+			e.sum += value
+			e.count++
+			e.min = min(e.min, value)
+			e.max = max(e.max, value)
+			return
+		}
+		// A richer occupant means name cannot be further along: take the
+		// slot and push the occupant onwards.
+		if e.psl < psl {
+			displaced := *e
+			*e = t.newEntry(name, hash, psl, value)
+			t.reinsert(displaced, idx)
+			t.added()
+			return
+		}
+		idx = (idx + 1) & t.mask
+	}
+}
+
+func (t *robinHoodTable) newEntry(name []byte, hash, psl uint32, value int64) rhEntry {
+	return rhEntry{name: t.keys.own(name), hash: hash, psl: psl, sum: value, count: 1, min: value, max: value}
+}
+
+// added counts a new key, doubling the table once it is full enough.
+func (t *robinHoodTable) added() {
+	if t.used++; t.used >= t.growAt {
+		t.resize(2 * len(t.entries))
+	}
+}
+
+// resize moves every entry into a fresh table of size slots, which must be
+// a power of two. Entries keep their hash and name but probe again from
+// their new home slot.
+func (t *robinHoodTable) resize(size int) {
+	old := t.entries
+	t.entries = make([]rhEntry, size)
+	adviseHugePages(t.opts, t.entries)
+	t.mask = uint32(size - 1)
+	t.growAt = max(int(float64(size)*t.opts.maxLoadFactor()), 1)
+
+	for _, e := range old {
+		if e.psl == 0 {
+			continue
+		}
+		// reinsert starts one slot on with one more probe: from the slot
+		// before home with none, the entry lands at home with a length of 1.
+		e.psl = 0
+		t.reinsert(e, (e.hash-1)&t.mask)
+	}
+}
+
+// reinsert places an entry evicted from idx, continuing its probe.
+func (t *robinHoodTable) reinsert(e rhEntry, idx uint32) {
+	for {
+		idx = (idx + 1) & t.mask
+		e.psl++
+		slot := &t.entries[idx]
+		if slot.psl == 0 {
+			*slot = e
+			return
+		}
+		if slot.psl < e.psl {
+			*slot, e = e, *slot
+		}
+	}
+}
+
+func (t *robinHoodTable) flushInto(smap StationMap, names *internTable) {
+	for i := range t.entries {
+		e := &t.entries[i]
+		if e.psl == 0 {
+			continue
+		}
+		storeStation(smap, e.hash, StationResult{
+			StationID: names.intern(e.name),
+			Sum:       e.sum,
+			Count:     e.count,
+			Maximum:   e.max,
+			Minimum:   e.min,
+		})
+	}
+}
+
+func (t *robinHoodTable) probeStats() ProbeStats {
+	c := collectProbeStats{slots: len(t.entries)}
+	for i := range t.entries {
+		if psl := t.entries[i].psl; psl != 0 {
+			c.add(int(psl))
+		}
+	}
+	return c.stats()
+}
@@ -0,0 +1,16 @@
+package strategies
+
+import "testing"
+
+func TestRobinHoodCollidingTable(t *testing.T) {
+	path, want := writeRefillDataset(t, 50_000, 500)
+
+	// A table barely larger than the key set, and allowed to fill, forces
+	// long displacement chains.
+	s := NewRobinHoodStrategy(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 8192, TableSize: 512, MaxLoadFactor: 1})
+	checkAggregates(t, s, path, want)
+
+	if stats := s.ProbeStats(); stats.Keys == 0 || stats.Average < 1 {
+		t.Errorf("implausible probe stats %+v", stats)
+	}
+}
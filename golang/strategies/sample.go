@@ -0,0 +1,61 @@
+package strategies
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// SampledFraction returns the share of filePath's bytes that a strategy
+// registered with SamplesChunks aggregates when run with opts: about opts.SampleFraction, and
+// exactly 1 when it is not set. Dividing a sampled run's row counts and
+// time by it estimates those of a full run.
+func SampledFraction(filePath string, opts StrategyOptions) (float64, error) {
+	if !opts.sampling() {
+		return 1, nil
+	}
+	plan, err := planChunks(filePath, opts, 0, nil, 0)
+	if err != nil {
+		return 0, err
+	}
+	if plan.FileSize == 0 {
+		return 1, nil
+	}
+	var sampled int64
+	for _, c := range plan.Chunks {
+		sampled += c.End - c.Start
+	}
+	return float64(sampled) / float64(plan.FileSize), nil
+}
+
+func (o StrategyOptions) sampling() bool {
+	return o.SampleFraction > 0 && o.SampleFraction < 1
+}
+
+// sampled returns q restricted to a random SampleFraction of its chunks,
+// at least one, picked by SampleSeed and handed out in file order. Without
+// sampling it returns q.
+func (o StrategyOptions) sampled(q *chunkQueue) *chunkQueue {
+	n := q.chunks()
+	if !o.sampling() || n == 0 {
+		return q
+	}
+	k := max(1, int64(math.Round(float64(n)*o.SampleFraction)))
+	rng := rand.New(rand.NewPCG(o.SampleSeed, o.SampleSeed>>32|1))
+
+	// Floyd's algorithm: k distinct indexes without materializing all n.
+	seen := make(map[int64]struct{}, k)
+	for j := n - k; j < n; j++ {
+		i := rng.Int64N(j + 1)
+		if _, dup := seen[i]; dup {
+			i = j
+		}
+		seen[i] = struct{}{}
+	}
+	q.picked = make([]int64, 0, k)
+	for i := range seen {
+		q.picked = append(q.picked, i)
+	}
+	slices.Sort(q.picked)
+	return q
+}
@@ -0,0 +1,77 @@
+package strategies
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestSampledQueuePicksChunksInOrder(t *testing.T) {
+	opts := StrategyOptions{SampleFraction: 0.1, SampleSeed: 7}
+	q := opts.sampled(newChunkQueue(1000*4096, 4096))
+	if len(q.picked) != 100 {
+		t.Fatalf("picked %d chunks, want 100", len(q.picked))
+	}
+	var last int64 = -1
+	for start, end, ok := q.pop(); ok; start, end, ok = q.pop() {
+		if start <= last || end-start != 4096 || start%4096 != 0 {
+			t.Fatalf("chunk [%d, %d) after %d", start, end, last)
+		}
+		last = start
+	}
+
+	again := opts.sampled(newChunkQueue(1000*4096, 4096))
+	other := StrategyOptions{SampleFraction: 0.1, SampleSeed: 8}.sampled(newChunkQueue(1000*4096, 4096))
+	if !slices.Equal(q.picked, again.picked) {
+		t.Error("the same seed picked different chunks")
+	}
+	if slices.Equal(q.picked, other.picked) {
+		t.Error("different seeds picked the same chunks")
+	}
+}
+
+func TestSamplersAggregateTheSameSample(t *testing.T) {
+	path, full := writeRefillDataset(t, 50_000, 300)
+	opts := StrategyOptions{Workers: 4, ChunkSize: 4096, SampleFraction: 0.2, SampleSeed: 42}
+
+	fraction, err := SampledFraction(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(fraction-0.2) > 0.02 {
+		t.Fatalf("sampled %.3f of the file, want about 0.2", fraction)
+	}
+
+	reference, err := NewMCMPStrategy(opts).Calculate(t.Context(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make(map[string]expectedStation, len(reference))
+	var rows, total int64
+	for _, r := range reference {
+		want[r.StationID] = expectedStation{sum: r.Sum, count: r.Count, min: r.Minimum, max: r.Maximum}
+		rows += r.Count
+	}
+	for _, s := range full {
+		total += s.count
+	}
+	if got := float64(rows) / float64(total); math.Abs(got-fraction) > 0.02 {
+		t.Errorf("sample holds %.3f of the rows, want about %.3f", got, fraction)
+	}
+
+	for _, s := range strategiesWith(opts) {
+		if !s.SamplesChunks {
+			continue
+		}
+		t.Run(s.name, func(t *testing.T) {
+			checkAggregates(t, s.strategy, path, want)
+
+			// The rows parsed scale back up to about the whole file's,
+			// as the runner projects them.
+			p := s.strategy.(ProgressTracker)
+			if projected := float64(p.RowsParsed()) / fraction; math.Abs(projected/float64(total)-1) > 0.1 {
+				t.Errorf("projected %.0f rows from the sample, want about %d", projected, total)
+			}
+		})
+	}
+}
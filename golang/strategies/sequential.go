@@ -0,0 +1,9 @@
+package strategies
+
+import "errors"
+
+// ErrNotSeekable is returned by strategies that read their input at
+// offsets, or more than once, when it is a pipe, FIFO or other file that
+// can only be read front to back. Such input needs a strategy registered
+// with ReadsSequentially.
+var ErrNotSeekable = errors.New("input is not a regular file; read it with a sequential strategy such as PipelineStrategy")
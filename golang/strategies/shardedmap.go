@@ -0,0 +1,139 @@
+package strategies
+
+import (
+	"context"
+	"io"
+	"math/bits"
+	"sync"
+)
+
+const (
+	defaultMapShards = 64
+	maxMapShards     = 1 << 16
+)
+
+// ShardedMapStrategy is the middle ground between the private per-worker
+// tables of the other parallel strategies and a single shared table: all
+// workers aggregate into one map, split into StrategyOptions.MapShards
+// shards that each have a lock of their own. The top bits of a station's
+// hash pick its shard, so workers only wait on each other when they hit
+// the same shard at once, and there is no merge at the end.
+type ShardedMapStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	opts StrategyOptions
+}
+
+// NewShardedMapStrategy returns a ShardedMapStrategy configured with opts.
+func NewShardedMapStrategy(opts StrategyOptions) *ShardedMapStrategy {
+	return &ShardedMapStrategy{opts: opts}
+}
+
+func (s *ShardedMapStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	return s.aggregate(ctx, pathInput(filePath))
+}
+
+func (s *ShardedMapStrategy) CalculateReaderAt(ctx context.Context, r io.ReaderAt, size int64) ([]StationResult, error) {
+	return s.aggregate(ctx, readerAtInput(r, size))
+}
+
+func (s *ShardedMapStrategy) aggregate(ctx context.Context, in input) ([]StationResult, error) {
+	s.resetProgress()
+	defer s.reportProgress(s.opts)()
+	s.resetMalformed(s.opts)
+	src, err := in.open(ctx, s.opts)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	shared := newShardedMap(s.opts)
+	err = scanChunks(ctx, src, s.opts, &s.progress, &s.malformedLines, func(int) lineSink {
+		return shared
+	})
+	if interrupted(ctx, err) {
+		return nil, partialResults(err, &s.progress, shared.maps()...)
+	}
+	if err != nil {
+		return nil, in.locate(err)
+	}
+	// The shards hold disjoint stations, so there is nothing to merge but
+	// the results to collect.
+	_, end := s.opts.startSpan(ctx, SpanMerge)
+	defer end()
+	return emitResults(&s.resultEmitter, shared.maps()...), nil
+}
+
+func (o StrategyOptions) mapShards() int {
+	if o.MapShards <= 0 {
+		return defaultMapShards
+	}
+	return 1 << bits.Len(uint(min(o.MapShards, maxMapShards)-1))
+}
+
+// mapShard is one locked part of a shardedMap.
+type mapShard struct {
+	mu       sync.Mutex
+	stations StationMap
+
+	// Padding keeps neighbouring shards' locks off each other's cache
+	// lines, which workers would otherwise contend for even when they lock
+	// different shards.
+	_ [64]byte
+}
+
+// shardedMap is a lineSink every worker shares, aggregating each line into
+// the shard its station's hash prefix picks, under that shard's lock.
+type shardedMap struct {
+	shards   []mapShard
+	shift    uint // the hash is shifted right by this to index shards
+	hash     HashFunc
+	newExtra func() Aggregator
+}
+
+func newShardedMap(opts StrategyOptions) *shardedMap {
+	n := opts.mapShards()
+	m := &shardedMap{
+		shards:   make([]mapShard, n),
+		shift:    uint(32 - bits.TrailingZeros(uint(n))),
+		hash:     opts.hash(),
+		newExtra: opts.NewAggregator,
+	}
+	for i := range m.shards {
+		m.shards[i].stations = make(StationMap, max(opts.mapCapacity()/n, 1))
+	}
+	return m
+}
+
+func (m *shardedMap) addLine(line []byte) bool {
+	name, value, err := parseLineByte(line)
+	if err != nil {
+		return false
+	}
+
+	hash := hashKey(m.hash, name)
+	shard := &m.shards[uint64(hash)>>m.shift]
+	shard.mu.Lock()
+	key, st, exists := stationKey(shard.stations, hash, name)
+	if !exists {
+		st = newStation(copyName(name), m.newExtra)
+	}
+	st.Add(value)
+	if st.Extra != nil {
+		st.Extra.Add(value)
+	}
+	shard.stations[key] = st
+	shard.mu.Unlock()
+	return true
+}
+
+// maps returns the shards' maps, for merging. Only call it once the
+// workers are done.
+func (m *shardedMap) maps() []StationMap {
+	maps := make([]StationMap, len(m.shards))
+	for i := range m.shards {
+		maps[i] = m.shards[i].stations
+	}
+	return maps
+}
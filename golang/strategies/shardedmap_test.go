@@ -0,0 +1,14 @@
+package strategies
+
+import "testing"
+
+func TestShardedMapAnyShardCount(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+	for _, shards := range []int{1, 3, 64, 1 << 20} {
+		opts := StrategyOptions{Workers: 8, ChunkSize: 4096, MapShards: shards}
+		if got := len(newShardedMap(opts).shards); got&(got-1) != 0 || got > maxMapShards || got < shards && got != maxMapShards {
+			t.Errorf("MapShards %d: got %d shards, want the next power of two up to %d", shards, got, maxMapShards)
+		}
+		checkAggregates(t, NewShardedMapStrategy(opts), path, want)
+	}
+}
@@ -0,0 +1,182 @@
+package strategies
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// SplitFile cuts the file at path into n shards of about equal size, each
+// ending at a newline, and writes them to dir as <name>-part-NNN<ext>. It
+// returns the paths of the shards written; there are fewer than n if the
+// file has fewer lines.
+func SplitFile(path, dir string, n int) ([]string, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("cannot split into %d shards", n)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fsize, err := getFileSize(f)
+	if err != nil {
+		return nil, err
+	}
+
+	// Shard i starts at the first line starting at or after i*fsize/n.
+	bounds := []int64{0}
+	buf := make([]byte, minTailRead)
+	for i := 1; i < n; i++ {
+		pos := max(int64(i)*fsize/int64(n), bounds[len(bounds)-1]+1)
+		for off := pos - 1; pos < fsize; off += int64(len(buf)) {
+			k, err := f.ReadAt(buf, off)
+			if j := bytes.IndexByte(buf[:k], '\n'); j >= 0 {
+				pos = off + int64(j) + 1
+				break
+			}
+			if err == io.EOF {
+				pos = fsize
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		if pos > bounds[len(bounds)-1] && pos < fsize {
+			bounds = append(bounds, pos)
+		}
+	}
+	bounds = append(bounds, fsize)
+
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(filepath.Base(path), ext)
+	shards := make([]string, 0, len(bounds)-1)
+	for i := range len(bounds) - 1 {
+		shard := filepath.Join(dir, fmt.Sprintf("%s-part-%03d%s", stem, i, ext))
+		if err := copyRange(shard, f, bounds[i], bounds[i+1]); err != nil {
+			return nil, err
+		}
+		shards = append(shards, shard)
+	}
+	return shards, nil
+}
+
+// copyRange writes the bytes [start, end) of f to a new file at path.
+// Copying from a limited *os.File lets the kernel move the data itself
+// (copy_file_range) where it can.
+func copyRange(path string, f *os.File, start, end int64) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Seek(start, io.SeekStart); err == nil {
+		_, err = io.Copy(out, &io.LimitedReader{R: f, N: end - start})
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ShardStrategy reads a dataset pre-split into shards, such as SplitFile
+// writes, one whole shard per worker. A worker reads its shard from the
+// first byte to the last with a double-buffered prefetcher, so no chunk
+// boundaries are probed and no file is read by two workers at once, which
+// suits filesystems that serve concurrent reads of one file slowly. With
+// more shards than workers, workers take the next shard when they finish.
+//
+// The filePath given to Calculate is a list of files or glob patterns, as
+// for MultiFileStrategy. A single file is read by one worker.
+type ShardStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	opts StrategyOptions
+}
+
+// NewShardStrategy returns a ShardStrategy configured with opts.
+func NewShardStrategy(opts StrategyOptions) *ShardStrategy {
+	return &ShardStrategy{opts: opts}
+}
+
+func (s *ShardStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	s.resetProgress()
+	defer s.reportProgress(s.opts)()
+	s.resetMalformed(s.opts)
+
+	files, err := ExpandFiles(filePath)
+	if err != nil {
+		return nil, err
+	}
+	n := min(s.opts.workers(), len(files))
+
+	// A malformed line in strict mode stops the other workers.
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	tempMaps := make([]StationMap, n)
+	errs := make([]error, n)
+	var next atomic.Int64
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := range n {
+		tempMaps[i] = make(StationMap, s.opts.mapCapacity())
+		go func(i int) {
+			defer wg.Done()
+			defer s.opts.pinWorker(i)()
+			bufs := make([][]byte, prefetchDepth)
+			for j := range bufs {
+				bufs[j] = make([]byte, s.opts.bufferSize(defaultBlockBufSize))
+			}
+
+			for !cancelled(runCtx) {
+				j := int(next.Add(1) - 1)
+				if j >= len(files) {
+					return
+				}
+				if err := s.readShard(runCtx, files[j], bufs, tempMaps[i]); err != nil {
+					if runCtx.Err() == nil {
+						errs[i] = fmt.Errorf("%s: %w", files[j], locateParseError(files[j], err))
+						stop()
+					}
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return emitResults(&s.resultEmitter, tempMaps...), nil
+}
+
+// readShard aggregates every line of the file at path into fileMap.
+func (s *ShardStrategy) readShard(ctx context.Context, path string, bufs [][]byte, fileMap StationMap) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fsize, err := getFileSize(f)
+	if err != nil {
+		return err
+	}
+
+	s.opts.adviseSequential(f)
+	prefetcher := newBlockPrefetcher(f, bufs)
+	defer prefetcher.close()
+	return consumeChunk(ctx, prefetcher, 0, fsize, mapSink{fileMap, s.opts.hash()}, &s.progress, &s.malformedLines)
+}
@@ -0,0 +1,68 @@
+package strategies
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitFileCutsAtNewlines(t *testing.T) {
+	path, _ := writeRefillDataset(t, 20_000, 300)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range []int{1, 7, 64} {
+		shards, err := SplitFile(path, t.TempDir(), n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(shards) != n {
+			t.Errorf("%d shards: wrote %d", n, len(shards))
+		}
+		var joined []byte
+		for _, shard := range shards {
+			part, err := os.ReadFile(shard)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(part) == 0 || part[len(part)-1] != '\n' {
+				t.Errorf("%d shards: %s does not end a line", n, filepath.Base(shard))
+			}
+			joined = append(joined, part...)
+		}
+		if !bytes.Equal(joined, data) {
+			t.Errorf("%d shards: shards do not add up to the file", n)
+		}
+	}
+
+	// More shards than lines.
+	small := filepath.Join(t.TempDir(), "small.txt")
+	if err := os.WriteFile(small, []byte("Oslo;1.0\nBergen;2.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if shards, err := SplitFile(small, t.TempDir(), 8); err != nil || len(shards) != 2 {
+		t.Errorf("got %d shards, %v; want 2", len(shards), err)
+	}
+}
+
+func TestShardStrategyReadsOneShardPerWorker(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+	dir := t.TempDir()
+	if _, err := SplitFile(path, dir, 6); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, workers := range []int{1, 4, 6, 16} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			s := NewShardStrategy(StrategyOptions{Workers: workers, BufferSize: 256})
+			checkAggregates(t, s, filepath.Join(dir, "refills-part-*.txt"), want)
+			if got := s.RowsParsed(); got != 20_000 {
+				t.Errorf("parsed %d rows, want 20000", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package strategies
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+)
+
+// shortKeyMaxLen is the longest name that fits in a shortKeyTable key.
+const shortKeyMaxLen = 8
+
+// ShortKeyStrategy keys stations whose names are at most eight bytes by the
+// name itself, loaded as one uint64. Such names skip the byte-by-byte hash
+// and the bytes.Equal on lookup: the slot index comes from a single
+// multiply and a match is one integer compare. Longer names fall back to
+// the linear-probing table, so datasets of mostly short names pay for the
+// general path only on the few long ones.
+type ShortKeyStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	probeRecorder
+	opts StrategyOptions
+}
+
+// NewShortKeyStrategy returns a ShortKeyStrategy configured with opts.
+func NewShortKeyStrategy(opts StrategyOptions) *ShortKeyStrategy {
+	return &ShortKeyStrategy{opts: opts}
+}
+
+func (s *ShortKeyStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	return s.aggregate(ctx, pathInput(filePath))
+}
+
+func (s *ShortKeyStrategy) CalculateReaderAt(ctx context.Context, r io.ReaderAt, size int64) ([]StationResult, error) {
+	return s.aggregate(ctx, readerAtInput(r, size))
+}
+
+func (s *ShortKeyStrategy) aggregate(ctx context.Context, in input) ([]StationResult, error) {
+	return runTableStrategy(ctx, in, s.opts, &s.progress, &s.malformedLines, &s.probeRecorder, &s.resultEmitter, func() stationTable {
+		return newShortKeyTable(s.opts)
+	})
+}
+
+// shortSlot holds one short-named station. The name is the little-endian
+// key truncated to n bytes; n == 0 marks an empty slot, so empty names go
+// to the long table.
+type shortSlot struct {
+	key                  uint64
+	n                    uint8
+	sum, count, min, max int64
+}
+
+// shortKeyTable starts at opts.tableSize() slots and, like the lpTable
+// behind it, doubles once opts.maxLoadFactor() of them are in use.
+type shortKeyTable struct {
+	slots    []shortSlot
+	occupied []int
+	shift    uint
+	growAt   int
+	long     *lpTable
+	opts     StrategyOptions
+}
+
+func newShortKeyTable(opts StrategyOptions) *shortKeyTable {
+	t := &shortKeyTable{
+		occupied: make([]int, 0, 10000),
+		long:     newLPTable(opts),
+		opts:     opts,
+	}
+	t.resize(opts.tableSize())
+	return t
+}
+
+// resize moves every station into a fresh table of size slots, which must
+// be a power of two.
+func (t *shortKeyTable) resize(size int) {
+	old := t.slots
+	t.slots = make([]shortSlot, size)
+	adviseHugePages(t.opts, t.slots)
+	t.shift = 64
+	for n := size; n > 1; n >>= 1 {
+		t.shift--
+	}
+	t.growAt = max(int(float64(size)*t.opts.maxLoadFactor()), 1)
+
+	mask := size - 1
+	for i, idx := range t.occupied {
+		pos := t.home(old[idx].key)
+		for t.slots[pos].n != 0 {
+			pos = (pos + 1) & mask
+		}
+		t.slots[pos] = old[idx]
+		t.occupied[i] = pos
+	}
+}
+
+func (t *shortKeyTable) addLine(line []byte) bool {
+	name, value, err := parseLineByte(line)
+	if err != nil {
+		return false
+	}
+	if len(name) > shortKeyMaxLen {
+		t.long.add(name, value)
+		return true
+	}
+	t.add(shortKey(name), uint8(len(name)), value)
+	return true
+}
+
+// shortKey loads name, at most eight bytes long, as a little-endian uint64
+// with the unused high bytes zeroed. The load reads past the name into the
+// rest of the line whenever name's capacity allows, which it does as soon
+// as ";x.x" follows, so the slow path only handles the shortest lines.
+func shortKey(name []byte) uint64 {
+	var key uint64
+	if cap(name) >= 8 {
+		key = binary.LittleEndian.Uint64(name[:8])
+	} else {
+		for i := len(name) - 1; i >= 0; i-- {
+			key = key<<8 | uint64(name[i])
+		}
+	}
+	return key & (^uint64(0) >> (64 - 8*len(name)))
+}
+
+func (t *shortKeyTable) add(key uint64, n uint8, value int64) {
+	mask := len(t.slots) - 1
+	idx := t.home(key)
+	for {
+		s := &t.slots[idx]
+		if s.key == key && s.n == n {
+			s.sum += value
+			s.count++
+			s.min = min(s.min, value)
+			s.max = max(s.max, value)
+			return
+		}
+		if s.n == 0 {
+			*s = shortSlot{key: key, n: n, sum: value, count: 1, min: value, max: value}
+			t.occupied = append(t.occupied, idx)
+			if len(t.occupied) >= t.growAt {
+				t.resize(2 * len(t.slots))
+			}
+			return
+		}
+		idx = (idx + 1) & mask
+	}
+}
+
+// home is the key's preferred slot: Fibonacci hashing keeps the high bits
+// of key times the golden ratio.
+func (t *shortKeyTable) home(key uint64) int {
+	return int((key * 0x9e3779b97f4a7c15) >> t.shift)
+}
+
+func (t *shortKeyTable) flushInto(smap StationMap, names *internTable) {
+	var buf [shortKeyMaxLen]byte
+	for _, idx := range t.occupied {
+		s := &t.slots[idx]
+		binary.LittleEndian.PutUint64(buf[:], s.key)
+		name := buf[:s.n]
+		storeStation(smap, hashKey(t.opts.hash(), name), StationResult{
+			StationID: names.intern(name),
+			Sum:       s.sum,
+			Count:     s.count,
+			Maximum:   s.max,
+			Minimum:   s.min,
+		})
+	}
+	t.long.flushInto(smap, names)
+}
+
+// probeStats covers both the short-key slots and the fallback table.
+func (t *shortKeyTable) probeStats() ProbeStats {
+	mask := len(t.slots) - 1
+	c := collectProbeStats{slots: len(t.slots)}
+	for _, idx := range t.occupied {
+		home := t.home(t.slots[idx].key)
+		c.add((idx-home)&mask + 1)
+	}
+	return c.stats().combine(t.long.probeStats())
+}
@@ -0,0 +1,40 @@
+package strategies
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestShortKeyMasksBytesPastTheName(t *testing.T) {
+	tests := []struct {
+		line string
+		n    int
+		want uint64
+	}{
+		{"a;1.0", 1, 0x61},
+		{"Rome;12.3", 4, 0x656d6f52},
+		{"Abidjan;-4.5", 7, 0x6e616a64696241},
+		{"Tashkent;9.9", 8, 0x746e656b68736154},
+	}
+	for _, tt := range tests {
+		if got := shortKey([]byte(tt.line)[:tt.n]); got != tt.want {
+			t.Errorf("shortKey(%q, %d) = %#x, want %#x", tt.line, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestShortKeyStrategyMixesShortAndLongNames(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	names := []string{"A", "Bo", "Oslo", "Nuuk", "Rome", "Abidjan", "Tashkent", "Adelaide", "Ouagadougou", "Palmerston North"}
+	for i := range 300 {
+		names = append(names, fmt.Sprintf("S%d", i), fmt.Sprintf("Station-%d", i))
+	}
+	path, want := writeDataset(t, rng, 50_000, names)
+
+	s := NewShortKeyStrategy(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 8192})
+	checkAggregates(t, s, path, want)
+	if stats := s.ProbeStats(); stats.Keys < len(names) {
+		t.Errorf("probe stats cover %d keys, want at least %d", stats.Keys, len(names))
+	}
+}
@@ -0,0 +1,30 @@
+package strategies
+
+import "testing"
+
+func TestSoATable(t *testing.T) {
+	path, want := writeRefillDataset(t, 50_000, 500)
+
+	// A table barely larger than the key set, and allowed to fill, forces
+	// long probe chains.
+	full := NewSoATableStrategy(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 8192, TableSize: 512, MaxLoadFactor: 1})
+	checkAggregates(t, full, path, want)
+	if stats := full.ProbeStats(); stats.Max < 8 {
+		t.Errorf("a nearly full table gave short probe chains: %+v", stats)
+	}
+	checkAggregates(t, NewSoATableStrategy(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 8192}), path, want)
+}
+
+func TestSoATablePacksAggregates(t *testing.T) {
+	table := newSoATable(StrategyOptions{TableSize: 16})
+	for _, line := range []string{"Dallol;99.9", "Dallol;34.5", "Death Valley;56.7", "Dallol;0.0"} {
+		table.addLine([]byte(line))
+	}
+
+	smap := make(StationMap)
+	table.flushInto(smap, newInternTable())
+	got := smap[hashKey(hashFnv32, []byte("Dallol"))]
+	if got.Minimum != 0 || got.Maximum != 999 || got.Sum != 1344 || got.Count != 3 {
+		t.Errorf("Dallol = %+v", got)
+	}
+}
@@ -0,0 +1,287 @@
+package strategies
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// chunkSource is the input scanChunks splits into chunks: a local file, or
+// a remote object.
+type chunkSource interface {
+	size() int64
+
+	// queue returns the queue of chunks of about chunkSize bytes that
+	// workers pull.
+	queue(chunkSize int64) (*chunkQueue, error)
+
+	// readFrom returns a reader of the input from offset on, for a chunk
+	// that ends at end. It may read past end, as far as the chunk's last
+	// line goes.
+	readFrom(ctx context.Context, offset, end int64) (io.ReadCloser, error)
+
+	Close() error
+}
+
+// openChunkSource opens filePath, or fetches it if it is remote.
+func openChunkSource(ctx context.Context, filePath string, opts StrategyOptions) (chunkSource, error) {
+	_, end := opts.startSpan(ctx, SpanOpen, SpanAttr{"path", filePath})
+	defer end()
+	if IsRemote(filePath) {
+		return openHTTPSource(ctx, filePath, opts.workers())
+	}
+
+	f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	fsize, err := getFileSize(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileSource{f, fsize, opts}, nil
+}
+
+// fileSource reads a local file. ReadAt does not share a file offset, so
+// all workers can safely read through the same handle.
+type fileSource struct {
+	f     *os.File
+	fsize int64
+	opts  StrategyOptions
+}
+
+func (s *fileSource) size() int64 { return s.fsize }
+
+func (s *fileSource) queue(chunkSize int64) (*chunkQueue, error) {
+	return s.opts.chunkQueue(s.f, s.fsize, chunkSize)
+}
+
+func (s *fileSource) readFrom(_ context.Context, offset, end int64) (io.ReadCloser, error) {
+	s.opts.adviseWillNeed(s.f, offset, end-offset)
+	return io.NopCloser(io.NewSectionReader(s.f, offset, s.fsize-offset)), nil
+}
+
+func (s *fileSource) Close() error { return s.f.Close() }
+
+// modTimer is implemented by chunk sources that know when their input was
+// last modified.
+type modTimer interface {
+	modTime() (time.Time, error)
+}
+
+func (s *fileSource) modTime() (time.Time, error) {
+	info, err := s.f.Stat()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// rangeTail is how far past the end of its chunk a range request reaches,
+// enough for the line that straddles the boundary. A longer line costs one
+// more request.
+const rangeTail = 4 << 10
+
+// maxAttempts is how many times a request is tried before its error is
+// returned, waiting retryBackoff, then twice that and so on in between.
+// Object stores shed load with 503s and drop long downloads, and a batch
+// job should ride that out rather than fail.
+const maxAttempts = 5
+
+var retryBackoff = 200 * time.Millisecond
+
+// httpSource reads a remote object from a server that honours range
+// requests.
+type httpSource struct {
+	name      string // the path the object was opened by
+	url       string
+	authorize func(*http.Request)
+	client    *http.Client
+	length    int64
+}
+
+// openHTTPSource checks that the object at path can be fetched by range
+// and learns its size, from a request for its first byte.
+func openHTTPSource(ctx context.Context, path string, workers int) (*httpSource, error) {
+	u, authorize, err := objectURL(path)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = workers
+	s := &httpSource{name: path, url: u, authorize: authorize, client: &http.Client{Transport: transport}}
+
+	resp, err := s.get(ctx, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	// An empty object has no first byte, and says so with a 416.
+	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+		return nil, fmt.Errorf("%s: server does not support range requests (%s)", path, resp.Status)
+	}
+	contentRange := resp.Header.Get("Content-Range")
+	_, total, ok := strings.Cut(contentRange, "/")
+	if s.length, err = strconv.ParseInt(total, 10, 64); !ok || err != nil {
+		return nil, fmt.Errorf("%s: no object size in Content-Range %q", path, contentRange)
+	}
+	return s, nil
+}
+
+// RemoteSize returns the size in bytes of the remote object at path.
+func RemoteSize(ctx context.Context, path string) (int64, error) {
+	s, err := openHTTPSource(ctx, path, 1)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+	return s.size(), nil
+}
+
+// get requests the bytes [start, end) of the object, retrying failed
+// connections and responses that ask for it. Other error statuses are
+// returned as they are, for the caller to judge.
+func (s *httpSource) get(ctx context.Context, start, end int64) (*http.Response, error) {
+	var err error
+	for attempt := range maxAttempts {
+		if attempt > 0 {
+			if err := sleepCtx(ctx, retryBackoff<<(attempt-1)); err != nil {
+				return nil, err
+			}
+		}
+
+		var req *http.Request
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end-1))
+		if s.authorize != nil {
+			s.authorize(req)
+		}
+
+		var resp *http.Response
+		resp, err = s.client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		if !retryable(resp.StatusCode) {
+			return resp, nil
+		}
+		resp.Body.Close()
+		err = fmt.Errorf("%s: %s", s.name, resp.Status)
+	}
+	return nil, err
+}
+
+// retryable reports whether a response with status code is worth trying
+// again: throttling and server-side failures.
+func retryable(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// sleepCtx waits for d, or until ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *httpSource) size() int64 { return s.length }
+
+func (s *httpSource) queue(chunkSize int64) (*chunkQueue, error) {
+	return newChunkQueue(s.length, chunkSize), nil
+}
+
+func (s *httpSource) readFrom(ctx context.Context, offset, end int64) (io.ReadCloser, error) {
+	return &rangeReader{ctx: ctx, src: s, pos: offset, end: end + rangeTail}, nil
+}
+
+func (s *httpSource) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// rangeReader streams an object from pos on, one range request at a time:
+// the first covers the chunk and its tail, and each further one another
+// rangeTail bytes. A response that breaks off is resumed from where it
+// stopped with a new request.
+type rangeReader struct {
+	ctx      context.Context
+	src      *httpSource
+	pos      int64 // offset of the next byte to read
+	end      int64 // end of the open request
+	body     io.ReadCloser
+	failures int // consecutive broken responses
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if r.body == nil {
+		if r.pos >= r.src.length {
+			return 0, io.EOF
+		}
+		r.end = min(max(r.end, r.pos+rangeTail), r.src.length)
+		resp, err := r.src.get(r.ctx, r.pos, r.end)
+		if err != nil {
+			return 0, err
+		}
+		if resp.StatusCode != http.StatusPartialContent {
+			resp.Body.Close()
+			return 0, fmt.Errorf("%s: range request for bytes %d-%d: %s", r.src.name, r.pos, r.end-1, resp.Status)
+		}
+		r.body = resp.Body
+	}
+
+	n, err := r.body.Read(p)
+	r.pos += int64(n)
+	if n > 0 {
+		r.failures = 0
+	}
+	if err == nil {
+		return n, nil
+	}
+	r.body.Close()
+	r.body = nil
+	if err == io.EOF && r.pos >= r.end {
+		return n, nil
+	}
+
+	// The response was cut short; ask for the rest.
+	if r.failures++; r.failures >= maxAttempts || r.ctx.Err() != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return n, fmt.Errorf("%s: range response ended at byte %d, short of %d: %w", r.src.name, r.pos, r.end, err)
+	}
+	if err := sleepCtx(r.ctx, retryBackoff<<(r.failures-1)); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+func (r *rangeReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	return r.body.Close()
+}
@@ -0,0 +1,78 @@
+package strategies
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serveDataset serves the file at path over HTTP with range support and
+// returns its URL and a count of the requests made for it.
+func serveDataset(t *testing.T, path string) (string, *atomic.Int64) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.ServeContent(w, r, "measurements.txt", time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/measurements.txt", &requests
+}
+
+func TestURLReadersReadRangedDownloads(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+	url, requests := serveDataset(t, path)
+
+	for _, s := range strategiesWith(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 16 << 10}) {
+		if !s.ReadsURLs {
+			continue
+		}
+		t.Run(s.name, func(t *testing.T) {
+			requests.Store(0)
+			checkAggregates(t, s.strategy, url, want)
+			if requests.Load() < 2 {
+				t.Errorf("made %d requests, want one per chunk", requests.Load())
+			}
+		})
+	}
+}
+
+func TestURLReaderFollowsLongLineAcrossRanges(t *testing.T) {
+	path, _ := writeRefillDataset(t, 5_000, 200)
+	long := "Oslo;" + strings.Repeat("9", 3*rangeTail)
+	insertLines(t, path, []int{1000, 1001}, []string{long, long})
+	url, _ := serveDataset(t, path)
+
+	s := NewSwissTableStrategy(StrategyOptions{Workers: 4, ChunkSize: 8 << 10})
+	if _, err := s.Calculate(t.Context(), url); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.MalformedLines(); got != 2 {
+		t.Errorf("got %d malformed lines, want 2", got)
+	}
+	if got := s.RowsParsed(); got != 5_002 {
+		t.Errorf("parsed %d rows, want 5002", got)
+	}
+}
+
+func TestURLReaderRequiresRangeSupport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Oslo;1.0\n"))
+	}))
+	defer srv.Close()
+
+	_, err := NewDoubleBufferedStrategy(StrategyOptions{}).Calculate(t.Context(), srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "range requests") {
+		t.Errorf("got error %v, want one about range requests", err)
+	}
+}
@@ -0,0 +1,104 @@
+package strategies
+
+import (
+	"bytes"
+	"io"
+)
+
+// lineSplitter replaces bufio.Scanner for the sequential line-based
+// strategies. It reads large blocks and hands out lines as slices of the
+// block, carrying a partial last line over to the next read. Unlike
+// Scanner it has no maximum line length: a block too small for a line is
+// doubled. Like Scanner's ScanLines, it drops a trailing '\r' and yields a
+// final line that has no newline.
+type lineSplitter struct {
+	r          io.Reader
+	buf        []byte
+	start, end int   // unconsumed bytes are buf[start:end]
+	base       int64 // file offset of buf[0]
+	line       []byte
+	lineOff    int64 // file offset of line
+	err        error
+	eof        bool
+
+	// retain makes refills carry the partial line into a new block rather
+	// than to the front of the current one, so lines already returned stay
+	// valid after the splitter moves on. Use it when lines are handed to
+	// other goroutines.
+	retain bool
+}
+
+func newLineSplitter(r io.Reader, size int) *lineSplitter {
+	return &lineSplitter{r: r, buf: make([]byte, size)}
+}
+
+// next advances to the next line, which bytes then returns. It returns
+// false at the end of the input or on a read error, reported by err.
+func (s *lineSplitter) next() bool {
+	for {
+		if idx := bytes.IndexByte(s.buf[s.start:s.end], '\n'); idx >= 0 {
+			s.setLine(s.start, s.start+idx)
+			s.start += idx + 1
+			return true
+		}
+		if s.eof {
+			if s.start == s.end {
+				return false
+			}
+			s.setLine(s.start, s.end)
+			s.start = s.end
+			return true
+		}
+		s.fill()
+	}
+}
+
+func (s *lineSplitter) setLine(start, end int) {
+	line := s.buf[start:end]
+	s.lineOff = s.base + int64(start)
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	s.line = line
+}
+
+// fill moves the partial line to the front of a block with free space,
+// growing it if the line fills the whole block, and reads more after it.
+func (s *lineSplitter) fill() {
+	tail := s.end - s.start
+	dst := s.buf
+	if s.retain || tail == len(s.buf) {
+		size := len(s.buf)
+		if tail == size {
+			size *= 2
+		}
+		dst = make([]byte, size)
+	}
+	copy(dst, s.buf[s.start:s.end])
+	s.base += int64(s.start)
+	s.buf, s.start, s.end = dst, 0, tail
+
+	n, err := s.r.Read(s.buf[s.end:])
+	s.end += n
+	if err == io.EOF {
+		s.eof = true
+	} else if err != nil {
+		s.err, s.eof = err, true
+		s.start = s.end // drop the partial line, as Scanner does
+	}
+}
+
+// bytes returns the current line. It is only valid until the next call to
+// next unless retain is set.
+func (s *lineSplitter) bytes() []byte {
+	return s.line
+}
+
+// offset returns the file offset of the current line, for error reports.
+func (s *lineSplitter) offset() int64 {
+	return s.lineOff
+}
+
+func (s *lineSplitter) Err() error {
+	return s.err
+}
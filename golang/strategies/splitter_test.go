@@ -0,0 +1,70 @@
+package strategies
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLineSplitter(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"empty", "", nil},
+		{"trailing newline", "a;1.0\nb;2.0\n", []string{"a;1.0", "b;2.0"}},
+		{"no trailing newline", "a;1.0\nb;2.0", []string{"a;1.0", "b;2.0"}},
+		{"empty lines", "\n\na;1.0\n", []string{"", "", "a;1.0"}},
+		{"crlf", "a;1.0\r\nb;2.0\r\n", []string{"a;1.0", "b;2.0"}},
+		{"longer than the block", "a;1.0\n" + long + "\nb;2.0", []string{"a;1.0", long, "b;2.0"}},
+	}
+	for _, tt := range tests {
+		for _, retain := range []bool{false, true} {
+			// One byte per Read forces a refill on every line.
+			s := newLineSplitter(iotest.OneByteReader(strings.NewReader(tt.input)), 8)
+			s.retain = retain
+
+			// With retain, lines are kept without copying: they must
+			// survive later refills.
+			var got [][]byte
+			for s.next() {
+				line := s.bytes()
+				if !retain {
+					line = append([]byte(nil), line...)
+				}
+				got = append(got, line)
+			}
+			if err := s.Err(); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("%s (retain=%v): got %q, want %q", tt.name, retain, got, tt.want)
+			}
+			for i := range got {
+				if string(got[i]) != tt.want[i] {
+					t.Fatalf("%s (retain=%v): got %q, want %q", tt.name, retain, got, tt.want)
+				}
+			}
+		}
+	}
+}
+
+func TestLineSplitterReportsReadErrors(t *testing.T) {
+	s := newLineSplitter(iotest.TimeoutReader(strings.NewReader("a;1.0\nb;2.0\n")), 8)
+	for s.next() {
+	}
+	if s.Err() != iotest.ErrTimeout {
+		t.Errorf("Err() = %v, want %v", s.Err(), iotest.ErrTimeout)
+	}
+}
+
+func TestSequentialStrategiesSplitLines(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+
+	opts := StrategyOptions{Workers: 4, BufferSize: 64}
+	checkAggregates(t, NewBasicStrategy(opts), path, want)
+	checkAggregates(t, NewByteReadingStrategy(opts), path, want)
+	checkAggregates(t, NewBatchStrategy(opts), path, want)
+}
@@ -0,0 +1,131 @@
+package strategies
+
+import (
+	"context"
+	"iter"
+)
+
+// Stream runs s on filePath and passes the result for each station to
+// yield, stopping early once yield returns false. The strategies in this
+// package call yield while they merge their per-worker tables, so the
+// merged results are never held in memory all at once; any other Strategy
+// is run with Calculate and its slice replayed. Results come in no
+// particular order, and none come at all if the run fails.
+func Stream(ctx context.Context, s Strategy, filePath string, yield func(StationResult) bool) error {
+	if st, ok := s.(streamer); ok {
+		st.setYield(yield)
+		defer st.setYield(nil)
+		_, err := s.Calculate(ctx, filePath)
+		return err
+	}
+
+	results, err := s.Calculate(ctx, filePath)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		if !yield(r) {
+			break
+		}
+	}
+	return nil
+}
+
+// Results is Stream as an iterator. A failed run yields a single zero
+// StationResult with the error:
+//
+//	for r, err := range strategies.Results(ctx, s, path) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func Results(ctx context.Context, s Strategy, filePath string) iter.Seq2[StationResult, error] {
+	return func(yield func(StationResult, error) bool) {
+		stopped := false
+		err := Stream(ctx, s, filePath, func(r StationResult) bool {
+			stopped = !yield(r, nil)
+			return !stopped
+		})
+		if err != nil && !stopped {
+			yield(StationResult{}, err)
+		}
+	}
+}
+
+// streamer is implemented by strategies embedding resultEmitter.
+type streamer interface {
+	setYield(yield func(StationResult) bool)
+}
+
+// resultEmitter is embedded in strategies so Stream can redirect their
+// results from the returned slice to a callback.
+type resultEmitter struct {
+	yield func(StationResult) bool
+}
+
+func (e *resultEmitter) setYield(yield func(StationResult) bool) {
+	e.yield = yield
+}
+
+// emitResults merges the per-worker maps into the slice Calculate returns
+// or, under Stream, hands each merged station to the callback and returns
+// nil.
+func emitResults[K comparable](e *resultEmitter, maps ...map[K]StationResult) []StationResult {
+	if e.yield != nil {
+		streamMerged(maps, e.yield)
+		return nil
+	}
+	return calcAverges(mergeMaps(maps))
+}
+
+// streamMerged passes every station in maps to yield once, merged across
+// all of them, until yield returns false. Rather than building a merged
+// map it folds each station of maps[i] together with its entries in the
+// later maps and deletes those, so the maps are consumed along the way.
+// That needs a station to have the same key in every map, which only a
+// hash collision breaks; then the maps are keyed by name first.
+func streamMerged[K comparable](maps []map[K]StationResult, yield func(StationResult) bool) bool {
+	if keysClash(maps) {
+		byName := make([]map[string]StationResult, len(maps))
+		for i, m := range maps {
+			byName[i] = make(map[string]StationResult, len(m))
+			for _, res := range m {
+				byName[i][res.StationID] = res
+			}
+		}
+		return streamMerged(byName, yield)
+	}
+	for i, m := range maps {
+		for key, res := range m {
+			for _, later := range maps[i+1:] {
+				if other, ok := later[key]; ok {
+					res = mergeResult(res, other)
+					delete(later, key)
+				}
+			}
+			res.Average = float64(res.Sum) / float64(res.Count)
+			if !yield(res) {
+				return false
+			}
+		}
+		maps[i] = nil
+	}
+	return true
+}
+
+// keysClash reports whether any key holds different stations in maps. A
+// station under different keys in two maps leaves one of them holding
+// another station under its key in the other, so that is never missed.
+func keysClash[K comparable](maps []map[K]StationResult) bool {
+	for i, m := range maps {
+		for key, res := range m {
+			for _, later := range maps[i+1:] {
+				if other, ok := later[key]; ok && other.StationID != res.StationID {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package strategies
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStreamYieldsEveryStationOnce(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+
+	for _, s := range strategiesWith(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 4096}) {
+		t.Run(s.name, func(t *testing.T) {
+			got := make(map[string]expectedStation, len(want))
+			err := Stream(t.Context(), s.strategy, path, func(r StationResult) bool {
+				if _, dup := got[r.StationID]; dup {
+					t.Fatalf("station %q yielded twice", r.StationID)
+				}
+				if r.Average != float64(r.Sum)/float64(r.Count) {
+					t.Errorf("%s: average %g, want %g", r.StationID, r.Average, float64(r.Sum)/float64(r.Count))
+				}
+				got[r.StationID] = expectedStation{sum: r.Sum, count: r.Count, min: r.Minimum, max: r.Maximum}
+				return true
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("got %d stations, want %d", len(got), len(want))
+			}
+			for name, exp := range want {
+				if got[name] != exp {
+					t.Errorf("%s: got %+v, want %+v", name, got[name], exp)
+				}
+			}
+
+			// Stream must not leave the strategy streaming.
+			checkAggregates(t, s.strategy, path, want)
+		})
+	}
+}
+
+func TestStreamStopsWhenYieldReturnsFalse(t *testing.T) {
+	path, _ := writeRefillDataset(t, 5_000, 100)
+
+	for _, s := range strategiesWith(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 4096}) {
+		t.Run(s.name, func(t *testing.T) {
+			calls := 0
+			err := Stream(t.Context(), s.strategy, path, func(StationResult) bool {
+				calls++
+				return calls < 3
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if calls != 3 {
+				t.Errorf("yield called %d times after returning false on the 3rd, want 3", calls)
+			}
+		})
+	}
+}
+
+func TestResultsYieldsRunError(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+
+	var errs []error
+	for r, err := range Results(t.Context(), NewMCMPStrategy(StrategyOptions{}), missing) {
+		if r != (StationResult{}) {
+			t.Errorf("got result %+v alongside the error", r)
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], os.ErrNotExist) {
+		t.Errorf("got errors %v, want one os.ErrNotExist", errs)
+	}
+}
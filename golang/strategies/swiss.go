@@ -0,0 +1,201 @@
+package strategies
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"math/bits"
+)
+
+const (
+	swissGroupSize = 16
+	swissEmpty     = 0x80 // control byte of an unused slot; full slots hold a 7-bit tag
+
+	swarLSB = 0x0101010101010101
+	swarMSB = 0x8080808080808080
+)
+
+// SwissTableStrategy aggregates into per-worker Swiss-style tables: slots
+// are arranged in groups of 16 with one control byte each holding 7 bits
+// of the key's hash. A lookup compares all 16 control bytes of a group at
+// once (two 8-byte SWAR words) and only touches slots whose tag matches,
+// so collisions rarely cost a key comparison.
+type SwissTableStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	probeRecorder
+	opts StrategyOptions
+}
+
+// NewSwissTableStrategy returns a SwissTableStrategy configured with opts.
+func NewSwissTableStrategy(opts StrategyOptions) *SwissTableStrategy {
+	return &SwissTableStrategy{opts: opts}
+}
+
+func (s *SwissTableStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	return s.aggregate(ctx, pathInput(filePath))
+}
+
+func (s *SwissTableStrategy) CalculateReaderAt(ctx context.Context, r io.ReaderAt, size int64) ([]StationResult, error) {
+	return s.aggregate(ctx, readerAtInput(r, size))
+}
+
+func (s *SwissTableStrategy) aggregate(ctx context.Context, in input) ([]StationResult, error) {
+	return runTableStrategy(ctx, in, s.opts, &s.progress, &s.malformedLines, &s.probeRecorder, &s.resultEmitter, func() stationTable {
+		return newSwissTable(s.opts)
+	})
+}
+
+type swissSlot struct {
+	name                 []byte
+	hash                 uint32
+	sum, count, min, max int64
+}
+
+// swissTable starts at opts.tableSize() slots and, like lpTable, doubles
+// once opts.maxLoadFactor() of them are in use, so a probe always finds a
+// group with a free slot.
+type swissTable struct {
+	ctrl      []byte
+	slots     []swissSlot
+	groupMask uint32
+	keys      nameArena
+	used      int
+	growAt    int
+	opts      StrategyOptions
+}
+
+func newSwissTable(opts StrategyOptions) *swissTable {
+	t := &swissTable{opts: opts}
+	t.resize(max(opts.tableSize(), swissGroupSize))
+	return t
+}
+
+func (t *swissTable) addLine(line []byte) bool {
+	name, value, err := parseLineByte(line)
+	if err != nil {
+		return false
+	}
+	t.add(name, value)
+	return true
+}
+
+func (t *swissTable) add(name []byte, value int64) {
+	hash := hashKey(t.opts.hash(), name)
+	tag := byte(hash & 0x7f)
+	group := (hash >> 7) & t.groupMask
+
+	for {
+		base := int(group) * swissGroupSize
+		lo := binary.LittleEndian.Uint64(t.ctrl[base:])
+		hi := binary.LittleEndian.Uint64(t.ctrl[base+8:])
+
+		for half, word := range [2]uint64{lo, hi} {
+			for m := swarMatch(word, tag); m != 0; m &= m - 1 {
+				i := base + half*8 + bits.TrailingZeros64(m)/8
+				s := &t.slots[i]
+				if s.hash == hash && bytes.Equal(s.name, name) {
+					s.sum += value
+					s.count++
+					s.min = min(s.min, value)
+					s.max = max(s.max, value)
+					return
+				}
+			}
+		}
+
+		// No deletions, so the first group with a free slot ends the probe.
+		if emptyLo, emptyHi := swarEmpty(lo), swarEmpty(hi); emptyLo|emptyHi != 0 {
+			i := base + 8 + bits.TrailingZeros64(emptyHi)/8
+			if emptyLo != 0 {
+				i = base + bits.TrailingZeros64(emptyLo)/8
+			}
+			t.ctrl[i] = tag
+			t.slots[i] = swissSlot{name: t.keys.own(name), hash: hash, sum: value, count: 1, min: value, max: value}
+			if t.used++; t.used >= t.growAt {
+				t.resize(2 * len(t.slots))
+			}
+			return
+		}
+		group = (group + 1) & t.groupMask
+	}
+}
+
+// resize moves every station into fresh arrays of size slots, which must be
+// a power of two of at least a group. Slots keep their hash and name.
+func (t *swissTable) resize(size int) {
+	oldCtrl, oldSlots := t.ctrl, t.slots
+	t.ctrl = make([]byte, size)
+	for i := range t.ctrl {
+		t.ctrl[i] = swissEmpty
+	}
+	t.slots = make([]swissSlot, size)
+	adviseHugePages(t.opts, t.slots)
+	t.groupMask = uint32(size/swissGroupSize - 1)
+	t.growAt = max(int(float64(size)*t.opts.maxLoadFactor()), 1)
+
+	for i, c := range oldCtrl {
+		if c == swissEmpty {
+			continue
+		}
+		group := (oldSlots[i].hash >> 7) & t.groupMask
+		for {
+			base := int(group) * swissGroupSize
+			lo := swarEmpty(binary.LittleEndian.Uint64(t.ctrl[base:]))
+			hi := swarEmpty(binary.LittleEndian.Uint64(t.ctrl[base+8:]))
+			if lo|hi != 0 {
+				j := base + 8 + bits.TrailingZeros64(hi)/8
+				if lo != 0 {
+					j = base + bits.TrailingZeros64(lo)/8
+				}
+				t.ctrl[j] = c
+				t.slots[j] = oldSlots[i]
+				break
+			}
+			group = (group + 1) & t.groupMask
+		}
+	}
+}
+
+// swarMatch sets the high bit of every byte in word equal to tag. It can
+// report false positives above a true match, which the key check absorbs.
+func swarMatch(word uint64, tag byte) uint64 {
+	x := word ^ (swarLSB * uint64(tag))
+	return (x - swarLSB) &^ x & swarMSB
+}
+
+// swarEmpty sets the high bit of every empty control byte in word.
+func swarEmpty(word uint64) uint64 {
+	return word & swarMSB
+}
+
+func (t *swissTable) flushInto(smap StationMap, names *internTable) {
+	for i, c := range t.ctrl {
+		if c == swissEmpty {
+			continue
+		}
+		s := &t.slots[i]
+		storeStation(smap, s.hash, StationResult{
+			StationID: names.intern(s.name),
+			Sum:       s.sum,
+			Count:     s.count,
+			Maximum:   s.max,
+			Minimum:   s.min,
+		})
+	}
+}
+
+// probeStats counts groups visited: a key in its home group has length 1.
+func (t *swissTable) probeStats() ProbeStats {
+	c := collectProbeStats{slots: len(t.slots)}
+	for i, ctrl := range t.ctrl {
+		if ctrl == swissEmpty {
+			continue
+		}
+		home := (t.slots[i].hash >> 7) & t.groupMask
+		c.add(int((uint32(i/swissGroupSize)-home)&t.groupMask) + 1)
+	}
+	return c.stats()
+}
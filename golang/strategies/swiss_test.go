@@ -0,0 +1,27 @@
+package strategies
+
+import "testing"
+
+func TestSwissTableCollidingTable(t *testing.T) {
+	path, want := writeRefillDataset(t, 50_000, 500)
+
+	// 32 groups allowed to fill up hold the stations in long group chains.
+	s := NewSwissTableStrategy(StrategyOptions{Workers: 4, BufferSize: 256, ChunkSize: 8192, TableSize: 512, MaxLoadFactor: 1})
+	checkAggregates(t, s, path, want)
+	if stats := s.ProbeStats(); stats.Max < 2 {
+		t.Errorf("no key left its home group: %+v", stats)
+	}
+}
+
+func TestSwarMatch(t *testing.T) {
+	word := uint64(0x80_05_11_05_80_7f_00_05)
+	if got, want := swarMatch(word, 0x05), uint64(0x00_80_00_80_00_00_00_80); got&want != want {
+		t.Errorf("swarMatch missed a tag: got %#x, want at least %#x", got, want)
+	}
+	if got := swarMatch(word, 0x42); got != 0 {
+		t.Errorf("swarMatch(0x42) = %#x, want 0", got)
+	}
+	if got, want := swarEmpty(word), uint64(0x80_00_00_00_80_00_00_00); got != want {
+		t.Errorf("swarEmpty = %#x, want %#x", got, want)
+	}
+}
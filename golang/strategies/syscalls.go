@@ -0,0 +1,40 @@
+package strategies
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// SyscallCounter is implemented by strategies that count the read system
+// calls they issue, so that I/O batching schemes can be compared directly.
+type SyscallCounter interface {
+	ReadSyscalls() int64
+}
+
+// syscallCount is embedded in strategies to satisfy SyscallCounter.
+type syscallCount struct {
+	readSyscalls atomic.Int64
+}
+
+func (s *syscallCount) ReadSyscalls() int64 {
+	return s.readSyscalls.Load()
+}
+
+func (s *syscallCount) resetSyscalls() {
+	s.readSyscalls.Store(0)
+}
+
+func (s *syscallCount) addSyscall() {
+	s.readSyscalls.Add(1)
+}
+
+// syscallReader counts every Read on the underlying file as one syscall.
+type syscallReader struct {
+	r io.Reader
+	c *syscallCount
+}
+
+func (s syscallReader) Read(b []byte) (int, error) {
+	s.c.addSyscall()
+	return s.r.Read(b)
+}
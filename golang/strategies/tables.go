@@ -0,0 +1,282 @@
+package strategies
+
+import (
+	"context"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// stationTable is a per-worker hash table the table-driven strategies
+// aggregate lines into. Tables own their keys, so lines may alias a read
+// buffer that is refilled afterwards.
+type stationTable interface {
+	lineSink
+	flushInto(smap StationMap, names *internTable)
+	probeStats() ProbeStats
+}
+
+// probeHistogramBuckets is the length of ProbeStats.Histogram. The last
+// bucket also counts every longer probe.
+const probeHistogramBuckets = 16
+
+// ProbeStats describes how far stored keys sit from their home slot. A key
+// stored in its home slot has a probe length of 1.
+type ProbeStats struct {
+	Keys    int
+	Average float64
+	Max     int
+
+	// Slots is the table capacity, so Keys/Slots is the load factor.
+	Slots int
+
+	// Histogram[i] counts keys with a probe length of i+1.
+	Histogram [probeHistogramBuckets]int
+}
+
+// LoadFactor returns the fraction of slots in use.
+func (s ProbeStats) LoadFactor() float64 {
+	if s.Slots == 0 {
+		return 0
+	}
+	return float64(s.Keys) / float64(s.Slots)
+}
+
+// combine returns the statistics of s and o taken together.
+func (s ProbeStats) combine(o ProbeStats) ProbeStats {
+	total := ProbeStats{
+		Keys:  s.Keys + o.Keys,
+		Max:   max(s.Max, o.Max),
+		Slots: s.Slots + o.Slots,
+	}
+	if total.Keys > 0 {
+		total.Average = (s.Average*float64(s.Keys) + o.Average*float64(o.Keys)) / float64(total.Keys)
+	}
+	for i := range total.Histogram {
+		total.Histogram[i] = s.Histogram[i] + o.Histogram[i]
+	}
+	return total
+}
+
+// ProbeStatsReporter is implemented by strategies that report the probe
+// lengths of their hash tables after a run, summed over all workers.
+type ProbeStatsReporter interface {
+	ProbeStats() ProbeStats
+}
+
+// probeRecorder is embedded in strategies to satisfy ProbeStatsReporter.
+type probeRecorder struct {
+	last atomic.Pointer[ProbeStats]
+}
+
+func (r *probeRecorder) ProbeStats() ProbeStats {
+	if s := r.last.Load(); s != nil {
+		return *s
+	}
+	return ProbeStats{}
+}
+
+func (r *probeRecorder) recordProbes(tables []stationTable) {
+	var total ProbeStats
+	for _, t := range tables {
+		if t != nil {
+			total = total.combine(t.probeStats())
+		}
+	}
+	r.last.Store(&total)
+}
+
+// collectProbeStats accumulates probe lengths one key at a time for a
+// table of the given number of slots.
+type collectProbeStats struct {
+	slots          int
+	keys, sum, max int
+	histogram      [probeHistogramBuckets]int
+}
+
+func (c *collectProbeStats) add(probeLen int) {
+	c.keys++
+	c.sum += probeLen
+	c.max = max(c.max, probeLen)
+	c.histogram[min(probeLen, probeHistogramBuckets)-1]++
+}
+
+func (c *collectProbeStats) stats() ProbeStats {
+	s := ProbeStats{Keys: c.keys, Max: c.max, Slots: c.slots, Histogram: c.histogram}
+	if c.keys > 0 {
+		s.Average = float64(c.sum) / float64(c.keys)
+	}
+	return s
+}
+
+// runTableStrategy is the shared driver for strategies that differ only in
+// their hash table: workers pull chunks from the queue, read them with a
+// double-buffered prefetcher and aggregate into a table from newTable.
+func runTableStrategy(ctx context.Context, in input, opts StrategyOptions, p *progress, m *malformedLines, probes *probeRecorder, e *resultEmitter, newTable func() stationTable) ([]StationResult, error) {
+	p.resetProgress()
+	defer p.reportProgress(opts)()
+	m.resetMalformed(opts)
+	src, err := in.open(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	tables := make([]stationTable, opts.workers())
+	newSink := func(worker int) lineSink {
+		tables[worker] = newTable()
+		return tables[worker]
+	}
+	var resumed []StationResult // stations of the chunks a checkpoint had saved
+	if opts.Checkpoint != "" {
+		resumed, err = scanCheckpointed(ctx, src, opts, p, m, tables, newSink)
+	} else {
+		err = scanChunks(ctx, src, opts, p, m, newSink)
+	}
+	if err != nil && !interrupted(ctx, err) {
+		return nil, in.locate(err)
+	}
+
+	// A cancelled run still merges the tables, into a *PartialError.
+	_, end := opts.startSpan(ctx, SpanMerge)
+	defer end()
+	names := newInternTable()
+	tempMaps := make([]StationMap, len(tables))
+	for i, t := range tables {
+		tempMaps[i] = make(StationMap, opts.mapCapacity())
+		if t != nil { // a worker cancelled before it started has none
+			t.flushInto(tempMaps[i], names)
+		}
+	}
+	probes.recordProbes(tables)
+	if opts.Checkpoint == "" {
+		if err != nil {
+			return nil, partialResults(err, p, tempMaps...)
+		}
+		return emitResults(e, tempMaps...), nil
+	}
+
+	// The tables key stations by hashes of their own, so the resumed
+	// stations are merged by name. A complete run removes the checkpoint,
+	// so a later one does not resume from it.
+	byName := []map[string]StationResult{mergeByName(resumed)}
+	for _, smap := range tempMaps {
+		byName = append(byName, mergeByName(slices.Collect(maps.Values(smap))))
+	}
+	if err != nil {
+		return nil, partialResults(err, p, byName...)
+	}
+	results := emitResults(e, byName...)
+	os.Remove(opts.Checkpoint)
+	return results, nil
+}
+
+// scanChunks feeds every line of src to per-worker sinks. Each of the
+// opts.workers() workers gets its sink from newSink on its own goroutine,
+// pulls chunks from the queue and reads them with a double-buffered
+// prefetcher. Lines the sinks reject are passed to m.
+func scanChunks(ctx context.Context, src chunkSource, opts StrategyOptions, p *progress, m *malformedLines, newSink func(worker int) lineSink) error {
+	chunkSize := opts.chunkSize(src.size(), opts.workers())
+	queue, err := src.queue(chunkSize)
+	if err != nil {
+		return err
+	}
+	return scanQueue(ctx, src, queue, chunkSize, opts, p, m, newSink, nil)
+}
+
+// scanQueue is scanChunks over a queue of chunks of about chunkSize bytes
+// already cut from src. If chunkDone is set, each worker calls it with the
+// index of every chunk it has aggregated, before pulling the next one.
+func scanQueue(ctx context.Context, src chunkSource, queue *chunkQueue, chunkSize int64, opts StrategyOptions, p *progress, m *malformedLines,
+	newSink func(worker int) lineSink, chunkDone func(worker int, chunk int64) error) error {
+	n := opts.workers()
+	bufSize := scanBufferSize(opts, chunkSize)
+
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := range n {
+		go func(i int) {
+			defer wg.Done()
+			defer opts.pinWorker(i)()
+			clock := opts.PhaseTimer.worker()
+			defer clock.done()
+			sink := clock.sink(newSink(i))
+
+			bufs := make([][]byte, prefetchDepth)
+			for j := range bufs {
+				bufs[j] = make([]byte, bufSize)
+			}
+
+			for start, end, ok := queue.pop(); ok; start, end, ok = queue.pop() {
+				_, endSpan := opts.startSpan(ctx, SpanChunk, SpanAttr{"worker", int64(i)}, SpanAttr{"offset", start}, SpanAttr{"bytes", end - start})
+				r, err := src.readFrom(ctx, max(start-1, 0), end)
+				if err != nil {
+					endSpan()
+					errs[i] = err
+					return
+				}
+				prefetcher := newBlockPrefetcher(r, bufs)
+				chunk := queue.index(start)
+				errs[i] = inChunk(consumeChunk(ctx, clock.blocks(prefetcher), start, end, sink, p, m), chunk, i)
+				clock.chunkDone()
+				prefetcher.close()
+				r.Close()
+				endSpan()
+				if errs[i] == nil && chunkDone != nil {
+					errs[i] = chunkDone(i, chunk)
+				}
+				if errs[i] != nil {
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return firstError(errs)
+}
+
+// scanBufferSize is the size of each of a scanChunks worker's
+// prefetchDepth read buffers: the configured size, but no more than a
+// chunk spread over them.
+func scanBufferSize(opts StrategyOptions, chunkSize int64) int64 {
+	return min(int64(opts.bufferSize(defaultBlockBufSize)), max(chunkSize/prefetchDepth, minTailRead))
+}
+
+// LinearProbeTableStrategy runs the MCMP linear-probing table under the
+// shared table driver, so the other table designs can be compared with it
+// on identical I/O and parsing.
+type LinearProbeTableStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	probeRecorder
+	opts StrategyOptions
+}
+
+// NewLinearProbeTableStrategy returns a LinearProbeTableStrategy configured with opts.
+func NewLinearProbeTableStrategy(opts StrategyOptions) *LinearProbeTableStrategy {
+	return &LinearProbeTableStrategy{opts: opts}
+}
+
+func (l *LinearProbeTableStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	return l.aggregate(ctx, pathInput(filePath))
+}
+
+func (l *LinearProbeTableStrategy) CalculateReaderAt(ctx context.Context, r io.ReaderAt, size int64) ([]StationResult, error) {
+	return l.aggregate(ctx, readerAtInput(r, size))
+}
+
+func (l *LinearProbeTableStrategy) aggregate(ctx context.Context, in input) ([]StationResult, error) {
+	return runTableStrategy(ctx, in, l.opts, &l.progress, &l.malformedLines, &l.probeRecorder, &l.resultEmitter, func() stationTable {
+		return newLPTable(l.opts)
+	})
+}
@@ -0,0 +1,33 @@
+package strategies
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestTablesGrowPastTableSize(t *testing.T) {
+	names := make([]string, 500)
+	for i := range names {
+		names[i] = fmt.Sprintf("S%d", i)
+	}
+	path, want := writeDataset(t, rand.New(rand.NewSource(1)), 20_000, names)
+
+	// 64 slots cannot hold 500 stations: every table must double rather
+	// than probe a full table forever. Short names keep short-key's tables
+	// on its fast path.
+	opts := StrategyOptions{Workers: 2, ChunkSize: 8192, TableSize: 64}
+	for _, s := range strategiesWith(opts) {
+		r, ok := s.strategy.(ProbeStatsReporter)
+		if _, dense := s.strategy.(*PerfectHashStrategy); !ok || dense {
+			// Perfect hashing sizes its table to the stations it found.
+			continue
+		}
+		t.Run(s.name, func(t *testing.T) {
+			checkAggregates(t, s.strategy, path, want)
+			if lf := r.ProbeStats().LoadFactor(); lf > defaultMaxLoadFactor {
+				t.Errorf("load factor = %.2f, want at most %.2f", lf, defaultMaxLoadFactor)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package strategies
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestStrategiesKeepFinalLineWithoutNewline(t *testing.T) {
+	path, want := writeRefillDataset(t, 5_000, 200)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data[:len(data)-1], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range getAllStrategies() {
+		t.Run(s.name, func(t *testing.T) {
+			checkAggregates(t, s.strategy, path, want)
+		})
+	}
+}
+
+func TestStrategiesAcceptWindowsLineEndingsAndBOM(t *testing.T) {
+	path, want := writeRefillDataset(t, 5_000, 200)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append([]byte(utf8BOM), bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))...)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range getAllStrategies() {
+		t.Run(s.name, func(t *testing.T) {
+			checkAggregates(t, s.strategy, path, want)
+		})
+	}
+}
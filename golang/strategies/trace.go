@@ -0,0 +1,50 @@
+package strategies
+
+import "context"
+
+// Span names shared by the strategies that emit them.
+const (
+	SpanOpen  = "open"  // opening the input
+	SpanChunk = "chunk" // one worker aggregating one chunk
+	SpanMerge = "merge" // merging the workers' results
+)
+
+// Tracer records spans marking the phases of a run, for a timeline of
+// where the wall-clock time goes across workers. The benchmark runner
+// exports them to an OpenTelemetry collector with -otel.
+//
+// Only the chunked strategies emit spans: those on the shared chunk driver
+// (the hash-table strategies, double-buffer and sharded-map), the MCMP
+// family and mmap emit SpanOpen, SpanChunk and SpanMerge. The sequential
+// readers (basic, byte, batch and pipeline), preadv, io-uring, direct-io,
+// zstd and binary, for binary files, emit none, so a trace of them shows
+// only the runner's own span around the whole run.
+type Tracer interface {
+	// StartSpan begins a span named name, a child of the span in ctx if
+	// there is one, and returns a context carrying the new span and a
+	// function ending it. It is called from every worker at once.
+	StartSpan(ctx context.Context, name string, attrs ...SpanAttr) (context.Context, func())
+}
+
+// SpanAttr is an attribute of a span. Value is a string or an int64.
+type SpanAttr struct {
+	Key   string
+	Value any
+}
+
+// startSpan starts a span with opts.Tracer, or does nothing without one.
+// opts.PhaseTimer times the spans that are phases of their own.
+func (o StrategyOptions) startSpan(ctx context.Context, name string, attrs ...SpanAttr) (context.Context, func()) {
+	end := func() {}
+	if o.Tracer != nil {
+		ctx, end = o.Tracer.StartSpan(ctx, name, attrs...)
+	}
+	if o.PhaseTimer == nil {
+		return ctx, end
+	}
+	stop := o.PhaseTimer.timeSpan(name)
+	return ctx, func() {
+		end()
+		stop()
+	}
+}
@@ -0,0 +1,62 @@
+package strategies
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+)
+
+// recordingTracer keeps the name and attributes of every ended span.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []recordedSpan
+}
+
+type recordedSpan struct {
+	name  string
+	attrs map[string]any
+}
+
+func (r *recordingTracer) StartSpan(ctx context.Context, name string, attrs ...SpanAttr) (context.Context, func()) {
+	span := recordedSpan{name, make(map[string]any)}
+	for _, a := range attrs {
+		span.attrs[a.Key] = a.Value
+	}
+	return ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.spans = append(r.spans, span)
+	}
+}
+
+func TestStrategiesTraceTheirPhases(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"mcmp", "mcmp-lp", "mcmp-lp-opt", "mmap", "swiss", "double-buffer"} {
+		t.Run(key, func(t *testing.T) {
+			tracer := &recordingTracer{}
+			s := registeredStrategy(key, StrategyOptions{Workers: 4, ChunkSize: 4096, Tracer: tracer})
+			checkAggregates(t, s.strategy, path, want)
+
+			count := make(map[string]int)
+			var chunked int64
+			for _, span := range tracer.spans {
+				count[span.name]++
+				if span.name == SpanChunk {
+					chunked += span.attrs["bytes"].(int64)
+				}
+			}
+			if count[SpanOpen] != 1 || count[SpanMerge] != 1 {
+				t.Errorf("got %d open and %d merge spans, want 1 each", count[SpanOpen], count[SpanMerge])
+			}
+			if chunked != info.Size() {
+				t.Errorf("chunk spans cover %d bytes, want %d", chunked, info.Size())
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+//go:build linux
+
+package strategies
+
+import (
+	"fmt"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// Minimal pure-Go io_uring binding: just enough to submit IORING_OP_READ
+// requests and reap their completions. See io_uring(7) for the layout.
+
+const (
+	sysIOURingSetup = 425
+	sysIOURingEnter = 426
+
+	ioringOffSQRing = 0
+	ioringOffCQRing = 0x8000000
+	ioringOffSQEs   = 0x10000000
+
+	ioringOpRead          = 22
+	ioringEnterGetEvents  = 1 << 0
+	ioringFeatSingleMmap  = 1 << 0
+	sqeSize, cqeSize      = 64, 16
+	uringDefaultQueueSize = 8
+)
+
+type sqringOffsets struct {
+	head, tail, ringMask, ringEntries, flags, dropped, array, resv1 uint32
+	resv2                                                           uint64
+}
+
+type cqringOffsets struct {
+	head, tail, ringMask, ringEntries, overflow, cqes, flags, resv1 uint32
+	resv2                                                           uint64
+}
+
+type uringParams struct {
+	sqEntries, cqEntries, flags, sqThreadCPU, sqThreadIdle, features, wqFd uint32
+	resv                                                                   [3]uint32
+	sqOff                                                                  sqringOffsets
+	cqOff                                                                  cqringOffsets
+}
+
+type uringSQE struct {
+	opcode      uint8
+	flags       uint8
+	ioprio      uint16
+	fd          int32
+	off         uint64
+	addr        uint64
+	len         uint32
+	rwFlags     uint32
+	userData    uint64
+	bufIndex    uint16
+	personality uint16
+	spliceFdIn  int32
+	addr3       uint64
+	pad         uint64
+}
+
+type uringCQE struct {
+	userData uint64
+	res      int32
+	flags    uint32
+}
+
+// uring is a single io_uring instance owned by one goroutine.
+type uring struct {
+	fd int
+
+	sqRing, cqRing, sqeMem []byte
+
+	sqHead, sqTail, sqMask *uint32
+	sqArray                []uint32
+	sqes                   []uringSQE
+
+	cqHead, cqTail, cqMask *uint32
+	cqes                   []uringCQE
+}
+
+func newURing(entries uint32) (*uring, error) {
+	var params uringParams
+	fd, _, errno := syscall.Syscall(sysIOURingSetup, uintptr(entries), uintptr(unsafe.Pointer(&params)), 0)
+	if errno != 0 {
+		return nil, fmt.Errorf("io_uring_setup: %w", errno)
+	}
+	r := &uring{fd: int(fd)}
+
+	sqSize := int(params.sqOff.array + params.sqEntries*4)
+	cqSize := int(params.cqOff.cqes + params.cqEntries*cqeSize)
+	if params.features&ioringFeatSingleMmap != 0 {
+		sqSize = max(sqSize, cqSize)
+	}
+
+	var err error
+	if r.sqRing, err = r.mmap(ioringOffSQRing, sqSize); err != nil {
+		r.close()
+		return nil, err
+	}
+	if params.features&ioringFeatSingleMmap != 0 {
+		r.cqRing = r.sqRing
+	} else if r.cqRing, err = r.mmap(ioringOffCQRing, cqSize); err != nil {
+		r.close()
+		return nil, err
+	}
+	if r.sqeMem, err = r.mmap(ioringOffSQEs, int(params.sqEntries)*sqeSize); err != nil {
+		r.close()
+		return nil, err
+	}
+
+	sq, cq := params.sqOff, params.cqOff
+	r.sqHead = (*uint32)(unsafe.Pointer(&r.sqRing[sq.head]))
+	r.sqTail = (*uint32)(unsafe.Pointer(&r.sqRing[sq.tail]))
+	r.sqMask = (*uint32)(unsafe.Pointer(&r.sqRing[sq.ringMask]))
+	r.sqArray = unsafe.Slice((*uint32)(unsafe.Pointer(&r.sqRing[sq.array])), params.sqEntries)
+	r.sqes = unsafe.Slice((*uringSQE)(unsafe.Pointer(&r.sqeMem[0])), params.sqEntries)
+
+	r.cqHead = (*uint32)(unsafe.Pointer(&r.cqRing[cq.head]))
+	r.cqTail = (*uint32)(unsafe.Pointer(&r.cqRing[cq.tail]))
+	r.cqMask = (*uint32)(unsafe.Pointer(&r.cqRing[cq.ringMask]))
+	r.cqes = unsafe.Slice((*uringCQE)(unsafe.Pointer(&r.cqRing[cq.cqes])), params.cqEntries)
+	return r, nil
+}
+
+func (r *uring) mmap(offset int64, size int) ([]byte, error) {
+	mem, err := syscall.Mmap(r.fd, offset, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE)
+	if err != nil {
+		return nil, fmt.Errorf("io_uring mmap: %w", err)
+	}
+	return mem, nil
+}
+
+// queueRead places a read of len(buf) bytes at off into the submission
+// queue. The request is not visible to the kernel until submit is called.
+// The caller must keep buf alive and untouched until its completion arrives.
+func (r *uring) queueRead(fd int, buf []byte, off int64, userData uint64) {
+	tail := atomic.LoadUint32(r.sqTail)
+	idx := tail & *r.sqMask
+	r.sqes[idx] = uringSQE{
+		opcode:   ioringOpRead,
+		fd:       int32(fd),
+		off:      uint64(off),
+		addr:     uint64(uintptr(unsafe.Pointer(&buf[0]))),
+		len:      uint32(len(buf)),
+		userData: userData,
+	}
+	r.sqArray[idx] = idx
+	atomic.StoreUint32(r.sqTail, tail+1)
+}
+
+// submitAndWait submits every queued request and blocks until at least
+// minComplete completions are available.
+func (r *uring) submitAndWait(toSubmit, minComplete uint32) error {
+	flags := uintptr(0)
+	if minComplete > 0 {
+		flags = ioringEnterGetEvents
+	}
+	for {
+		_, _, errno := syscall.Syscall6(sysIOURingEnter, uintptr(r.fd), uintptr(toSubmit), uintptr(minComplete), flags, 0, 0)
+		if errno == syscall.EINTR {
+			continue
+		}
+		if errno != 0 {
+			return fmt.Errorf("io_uring_enter: %w", errno)
+		}
+		return nil
+	}
+}
+
+// reap pops one completion if any is ready.
+func (r *uring) reap() (uringCQE, bool) {
+	head := atomic.LoadUint32(r.cqHead)
+	if head == atomic.LoadUint32(r.cqTail) {
+		return uringCQE{}, false
+	}
+	cqe := r.cqes[head&*r.cqMask]
+	atomic.StoreUint32(r.cqHead, head+1)
+	return cqe, true
+}
+
+func (r *uring) close() {
+	if r.sqeMem != nil {
+		syscall.Munmap(r.sqeMem)
+	}
+	if r.cqRing != nil && &r.cqRing[0] != &r.sqRing[0] {
+		syscall.Munmap(r.cqRing)
+	}
+	if r.sqRing != nil {
+		syscall.Munmap(r.sqRing)
+	}
+	syscall.Close(r.fd)
+}
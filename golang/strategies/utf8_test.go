@@ -0,0 +1,32 @@
+package strategies
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// unicodeNames mixes two-, three- and four-byte UTF-8 with names that
+// differ only in Unicode normalisation or a trailing no-break space, which
+// every strategy must keep apart byte for byte.
+var unicodeNames = []string{
+	"São Paulo", "İzmir", "Kraków", "Z\u00fcrich", "Zu\u0308rich", "Reykjavík",
+	"Chișinău", "Tromsø", "Wrocław", "Москва", "Αθήνα", "東京", "서울",
+	"मुंबई", "𐌰𐌸𐌹𐌽𐌰", "Nowhere\u00a0", "Nowhere",
+}
+
+func TestStrategiesPreserveMultiByteNames(t *testing.T) {
+	path, want := writeDataset(t, rand.New(rand.NewSource(1)), 20_000, unicodeNames)
+
+	// Odd buffer and chunk sizes put block and chunk boundaries inside
+	// multi-byte characters as well as between them.
+	for _, opts := range []StrategyOptions{
+		{Workers: 4, BufferSize: 61, ChunkSize: 4099},
+		{Workers: 3, BufferSize: 257, ChunkSize: 1021},
+	} {
+		for _, s := range strategiesWith(opts) {
+			t.Run(s.name, func(t *testing.T) {
+				checkAggregates(t, s.strategy, path, want)
+			})
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package strategies
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+// OutOfRange is a station with measurements outside the range ScanRange
+// checks.
+type OutOfRange struct {
+	// Station is the station's name.
+	Station string
+
+	// Count is the number of its measurements out of range, and Worst the
+	// one furthest outside it, in the units of StationResult.
+	Count int64
+	Worst int64
+
+	// InRange aggregates its other measurements, with an Extra under
+	// StrategyOptions.NewAggregator. Its Count is 0 when none was in
+	// range.
+	InRange StationResult
+}
+
+// rangeStation is a worker's tally of one station for ScanRange.
+type rangeStation struct {
+	OutOfRange
+}
+
+// merge folds in another worker's tally of the same station, taking
+// excess to rank their worst measurements.
+func (s *rangeStation) merge(other *rangeStation, excess func(int64) int64) {
+	if other.Count > 0 && (s.Count == 0 || excess(other.Worst) > excess(s.Worst)) {
+		s.Worst = other.Worst
+	}
+	s.Count += other.Count
+	s.InRange = mergeResult(s.InRange, other.InRange)
+}
+
+// rangeSink aggregates every well-formed line whose value lies in
+// [lo, hi] and tallies the others, by station name.
+type rangeSink struct {
+	lo, hi   int64
+	newExtra func() Aggregator
+	stations map[string]*rangeStation
+}
+
+// excess is how far value lies outside [lo, hi], or 0 inside it.
+func (s rangeSink) excess(value int64) int64 {
+	return max(s.lo-value, value-s.hi, 0)
+}
+
+func (s rangeSink) addLine(line []byte) bool {
+	name, value, err := parseLineByte(line)
+	if err != nil {
+		return false
+	}
+
+	st, ok := s.stations[string(name)]
+	if !ok {
+		st = &rangeStation{OutOfRange{Station: string(name), InRange: newStation(string(name), s.newExtra)}}
+		s.stations[st.Station] = st
+	}
+	if e := s.excess(value); e > 0 {
+		if st.Count == 0 || e > s.excess(st.Worst) {
+			st.Worst = value
+		}
+		st.Count++
+		return true
+	}
+	st.InRange.Add(value)
+	if st.InRange.Extra != nil {
+		st.InRange.Extra.Add(value)
+	}
+	return true
+}
+
+// ScanRange reads filePath as EstimateStations does and returns the
+// stations with measurements outside [lo, hi], in the units of
+// StationResult, those with the most first. A value out of range is a
+// parse bug or corrupt input that every strategy would otherwise fold into
+// the station's minimum, maximum and mean; the InRange aggregates leave
+// them out. Malformed lines are skipped.
+func ScanRange(ctx context.Context, filePath string, opts StrategyOptions, lo, hi int64) ([]OutOfRange, error) {
+	src, err := pathInput(filePath).open(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	sinks := make([]rangeSink, opts.workers())
+	err = scanChunks(ctx, src, opts, &progress{}, &malformedLines{}, func(worker int) lineSink {
+		sinks[worker] = rangeSink{lo, hi, opts.NewAggregator, make(map[string]*rangeStation)}
+		return sinks[worker]
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	excess := rangeSink{lo: lo, hi: hi}.excess
+	merged := make(map[string]*rangeStation)
+	for _, s := range sinks {
+		for name, st := range s.stations {
+			if acc, ok := merged[name]; ok {
+				acc.merge(st, excess)
+			} else {
+				merged[name] = st
+			}
+		}
+	}
+	var report []OutOfRange
+	for _, st := range merged {
+		if st.Count > 0 {
+			if in := &st.InRange; in.Count > 0 {
+				in.Average = float64(in.Sum) / float64(in.Count)
+			}
+			report = append(report, st.OutOfRange)
+		}
+	}
+	slices.SortFunc(report, func(a, b OutOfRange) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Station, b.Station))
+	})
+	return report, nil
+}
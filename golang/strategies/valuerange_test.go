@@ -0,0 +1,38 @@
+package strategies
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestScanRangeTalliesOutOfRangeValues(t *testing.T) {
+	path, want := writeRefillDataset(t, 5_000, 200)
+	names := make([]string, 0, len(want))
+	for name := range want {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	bad := []string{names[3] + ";150.0", "Oslo;-300.5", names[3] + ";-120.7", "Oslo;999.9", "Oslo;12.3", names[9] + ";100.0", "Oslo;bad"}
+	insertLines(t, path, []int{0, 17, 600, 2500, 3333, 4000, 5000}, bad)
+
+	report, err := ScanRange(t.Context(), path, StrategyOptions{Workers: 4, ChunkSize: 4096}, -999, 999)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([][3]any, len(report))
+	for i, r := range report {
+		got[i] = [3]any{r.Station, r.Count, r.Worst}
+	}
+	wantReport := [][3]any{{"Oslo", int64(2), int64(9999)}, {names[3], int64(2), int64(1500)}, {names[9], int64(1), int64(1000)}}
+	if !slices.Equal(got, wantReport) {
+		t.Fatalf("ScanRange() = %v, want %v", got, wantReport)
+	}
+
+	if in := report[0].InRange; in.Count != 1 || in.Sum != 123 || in.Average != 123 || in.Minimum != 123 || in.Maximum != 123 {
+		t.Errorf("Oslo in range = %+v, want the one 12.3", in)
+	}
+	exp := want[names[3]]
+	if in := report[1].InRange; in.Count != exp.count || in.Sum != exp.sum || in.Minimum != exp.min || in.Maximum != exp.max {
+		t.Errorf("%s in range = %+v, want %+v", names[3], in, exp)
+	}
+}
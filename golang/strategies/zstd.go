@@ -0,0 +1,407 @@
+package strategies
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// ZstdStrategy reads zstd-compressed measurement files. The frames of the
+// file are found up front by walking their block headers, and workers pull
+// whole frames from a shared queue, each decompressing into its own buffer
+// and map, so files made of many frames (pzstd output, the seekable format)
+// decompress in parallel. Lines cut by a frame boundary are put back
+// together once every frame is done.
+//
+// Files that do not start with a zstd frame are read as plain text, split
+// into chunks that take the place of frames.
+type ZstdStrategy struct {
+	progress
+	malformedLines
+	resultEmitter
+	opts StrategyOptions
+}
+
+// NewZstdStrategy returns a ZstdStrategy configured with opts.
+func NewZstdStrategy(opts StrategyOptions) *ZstdStrategy {
+	return &ZstdStrategy{opts: opts}
+}
+
+// frameExtent is a region of the file that decodes on its own: a zstd frame,
+// or a chunk of an uncompressed file.
+type frameExtent struct {
+	offset int64
+	size   int64
+}
+
+// frameEdges holds what a worker could not parse on its own: the partial
+// lines before the first and after the last newline of a decoded frame.
+type frameEdges struct {
+	head  []byte
+	tail  []byte
+	split bool  // the frame contains a newline; if not, head is all of it
+	size  int64 // decoded bytes
+}
+
+func (z *ZstdStrategy) Calculate(ctx context.Context, filePath string) ([]StationResult, error) {
+	z.resetProgress()
+	defer z.reportProgress(z.opts)()
+	z.resetMalformed(z.opts)
+	f, err := os.OpenFile(filePath, os.O_RDONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fsize, err := getFileSize(f)
+	if err != nil {
+		return nil, err
+	}
+	frames, compressed, err := zstdFrames(f, fsize)
+	if err != nil {
+		return nil, err
+	}
+	if !compressed {
+		frames = plainExtents(fsize, z.opts.chunkSize(fsize, z.opts.workers()))
+	}
+
+	n := max(min(z.opts.workers(), len(frames)), 1)
+	decoders := make([]*zstd.Decoder, n)
+	if compressed {
+		// A lone frame gets the decoder's own block-level concurrency.
+		concurrency := 1
+		if n == 1 {
+			concurrency = 0
+		}
+		for i := range decoders {
+			if decoders[i], err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(concurrency)); err != nil {
+				return nil, err
+			}
+			defer decoders[i].Close()
+		}
+	}
+
+	// A worker that hits a malformed line in strict mode stops the others.
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	// Workers only know the offsets of a frame's lines within it, so a
+	// quarantine gets them once every frame has been decoded.
+	var held *frameQuarantine
+	if z.quarantine != nil {
+		held = &frameQuarantine{make([][]quarantinedLine, len(frames))}
+		z.quarantine = nil
+	}
+
+	bufSize := z.opts.bufferSize(defaultBlockBufSize)
+	edges := make([]frameEdges, len(frames))
+	tempMaps := make([]StationMap, n)
+	errs := make([]error, n)
+	errFrames := make([]int, n)
+	var next atomic.Int64
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := range n {
+		tempMaps[i] = make(StationMap, z.opts.mapCapacity())
+		go func(i int) {
+			defer wg.Done()
+			defer z.opts.pinWorker(i)()
+			buf := make([]byte, bufSize)
+			rows := lineCounter{p: &z.progress}
+			defer rows.flush()
+
+			for !cancelled(runCtx) {
+				j := int(next.Add(1) - 1)
+				if j >= len(frames) {
+					return
+				}
+				section := io.NewSectionReader(f, frames[j].offset, frames[j].size)
+				r, err := decodeFrame(countingReader{section, &z.progress}, frames[j], decoders[i])
+				if err == nil {
+					if held == nil {
+						edges[j], err = parseFrame(r, buf, mapSink{tempMaps[i], z.opts.hash()}, &rows, &z.malformedLines)
+					} else {
+						fm := malformedLines{mode: z.mode, quarantine: held.frame(j)}
+						edges[j], err = parseFrame(r, buf, mapSink{tempMaps[i], z.opts.hash()}, &rows, &fm)
+						z.count.Add(fm.count.Load())
+					}
+				}
+				if err != nil {
+					errs[i], errFrames[i] = inChunk(err, int64(j), i), j
+					stop()
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for i, err := range errs {
+		if err != nil {
+			return nil, locateFrameParseError(f, frames, decoders[i], errFrames[i], err)
+		}
+	}
+
+	frame, err := z.joinEdges(edges, tempMaps[0], held)
+	if err != nil {
+		return nil, locateFrameParseError(f, frames, decoders[0], frame, err)
+	}
+	if held != nil {
+		held.flush(edges, z.opts.Quarantine)
+	}
+	return emitResults(&z.resultEmitter, tempMaps...), nil
+}
+
+// decodeFrame returns the decoded contents of ext, whose raw bytes r
+// reads. dec is nil for uncompressed input.
+func decodeFrame(r io.Reader, ext frameExtent, dec *zstd.Decoder) (io.Reader, error) {
+	if dec == nil {
+		return r, nil
+	}
+	if err := dec.Reset(r); err != nil {
+		return nil, fmt.Errorf("zstd frame at byte %d: %w", ext.offset, err)
+	}
+	return dec, nil
+}
+
+// parseFrame aggregates every whole line of the decoded frame r into
+// sink, using buf to read it, and returns the partial lines at either end.
+// Malformed lines are passed to m with their offset in the decoded frame.
+func parseFrame(r io.Reader, buf []byte, sink mapSink, rows *lineCounter, m *malformedLines) (frameEdges, error) {
+	var e frameEdges
+	carry := 0
+	for {
+		n, err := io.ReadFull(r, buf[carry:])
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			return frameEdges{}, err
+		}
+		data := buf[:carry+n]
+		// Offset of data in the decoded frame.
+		offset := e.size - int64(carry)
+		e.size += int64(n)
+
+		if !e.split {
+			i := bytes.IndexByte(data, '\n')
+			if i == -1 {
+				e.head = append(e.head, data...)
+				if eof {
+					return e, nil
+				}
+				continue
+			}
+			e.head = append(e.head, data[:i]...)
+			e.split = true
+			data = data[i+1:]
+			offset += int64(i + 1)
+		}
+
+		cut := bytes.LastIndexByte(data, '\n')
+		if err := parseLines(data[:cut+1], offset, sink, rows, m); err != nil {
+			return frameEdges{}, err
+		}
+		if eof {
+			e.tail = bytes.Clone(data[cut+1:])
+			return e, nil
+		}
+		carry = copy(buf, data[cut+1:])
+		if carry == len(buf) {
+			return frameEdges{}, fmt.Errorf("line longer than %d-byte buffer", len(buf))
+		}
+	}
+}
+
+// joinEdges aggregates the lines that span frame boundaries into fileMap,
+// holding the malformed ones in held if that is set. On a parse error it
+// also returns the frame the line starts in.
+func (z *ZstdStrategy) joinEdges(edges []frameEdges, fileMap StationMap, held *frameQuarantine) (int, error) {
+	rows := lineCounter{p: &z.progress}
+	defer rows.flush()
+
+	hash := z.opts.hash()
+	var line []byte
+	var start int         // frame the pending line starts in
+	var startOffset int64 // and its offset there
+	add := func() error {
+		rows.add()
+		if addLine(line, fileMap, hash) {
+			return nil
+		}
+		if held != nil && z.mode == ParseLenient {
+			held.frame(start).QuarantineLine(startOffset, line)
+		}
+		return z.reject(startOffset, line)
+	}
+
+	for i, e := range edges {
+		line = append(line, e.head...)
+		if !e.split {
+			continue
+		}
+		if err := add(); err != nil {
+			return start, err
+		}
+		line = append(line[:0], e.tail...)
+		start, startOffset = i, e.size-int64(len(e.tail))
+		if len(e.tail) == 0 {
+			start, startOffset = i+1, 0
+		}
+	}
+	if len(line) > 0 {
+		if err := add(); err != nil {
+			return start, err
+		}
+	}
+	return 0, nil
+}
+
+// frameQuarantine holds the malformed lines of each frame, by their offset
+// within the decoded frame, for StrategyOptions.Quarantine.
+type frameQuarantine struct {
+	lines [][]quarantinedLine
+}
+
+type quarantinedLine struct {
+	offset int64
+	line   string
+}
+
+// frame returns the Quarantine holding frame j's lines. One worker decodes
+// a frame, so its calls never overlap.
+func (q *frameQuarantine) frame(j int) Quarantine {
+	return QuarantineFunc(func(offset int64, line []byte) {
+		q.lines[j] = append(q.lines[j], quarantinedLine{offset, string(line)})
+	})
+}
+
+// flush passes the lines held to to, each offset by the decoded size of
+// the frames before its own, so that offsets are in the decoded input.
+func (q *frameQuarantine) flush(edges []frameEdges, to Quarantine) {
+	var base int64
+	for j, e := range edges {
+		for _, l := range q.lines[j] {
+			to.QuarantineLine(base+l.offset, []byte(l.line))
+		}
+		base += e.size
+	}
+}
+
+// locateFrameParseError is locateParseError for input read frame by frame:
+// the offset of a *ParseError is within the decoded frame, so the newlines
+// of every frame before it are counted too, and their bytes added to make
+// the offset the decoded input's. Other errors pass through.
+func locateFrameParseError(f *os.File, frames []frameExtent, dec *zstd.Decoder, frame int, err error) error {
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 0 {
+		return err
+	}
+
+	buf := make([]byte, defaultBlockBufSize)
+	perr.Line = 1
+	var decoded int64
+	for j := range frame + 1 {
+		r, rerr := decodeFrame(io.NewSectionReader(f, frames[j].offset, frames[j].size), frames[j], dec)
+		if rerr != nil {
+			return err
+		}
+		if j == frame {
+			r = io.LimitReader(r, perr.Offset)
+		}
+		for {
+			n, rerr := r.Read(buf)
+			perr.Line += int64(bytes.Count(buf[:n], newline))
+			decoded += int64(n)
+			if rerr == io.EOF {
+				break
+			}
+			if rerr != nil {
+				return err
+			}
+		}
+	}
+	perr.Offset = decoded
+	return perr
+}
+
+// zstdFrames lists the zstd frames of f, skipping skippable frames such as
+// the seek table of the seekable format. ok is false if f does not start
+// with a zstd frame.
+func zstdFrames(f io.ReaderAt, size int64) (frames []frameExtent, ok bool, err error) {
+	var h zstd.Header
+	hdr := make([]byte, zstd.HeaderMaxSize)
+	for offset := int64(0); offset < size; {
+		n, err := f.ReadAt(hdr, offset)
+		if err != nil && err != io.EOF {
+			return nil, false, err
+		}
+		if err := h.Decode(hdr[:n]); err != nil {
+			if offset == 0 && errors.Is(err, zstd.ErrMagicMismatch) {
+				return nil, false, nil
+			}
+			return nil, false, fmt.Errorf("zstd frame at byte %d: %w", offset, err)
+		}
+		if h.Skippable {
+			offset += int64(h.HeaderSize) + int64(h.SkippableSize)
+			continue
+		}
+
+		end, err := zstdFrameEnd(f, offset+int64(h.HeaderSize), h.HasCheckSum)
+		if err == nil && end > size {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("zstd frame at byte %d: %w", offset, err)
+		}
+		frames = append(frames, frameExtent{offset, end - offset})
+		offset = end
+	}
+	return frames, true, nil
+}
+
+// zstdFrameEnd walks the block headers of the frame whose first block
+// starts at offset and returns the offset just past the frame.
+func zstdFrameEnd(f io.ReaderAt, offset int64, checksum bool) (int64, error) {
+	var bh [3]byte
+	for last := false; !last; {
+		if _, err := f.ReadAt(bh[:], offset); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		header := uint32(bh[0]) | uint32(bh[1])<<8 | uint32(bh[2])<<16
+		last = header&1 == 1
+		size := int64(header >> 3)
+		switch (header >> 1) & 3 {
+		case 1:
+			size = 1 // an RLE block stores its byte once
+		case 3:
+			return 0, errors.New("reserved block type")
+		}
+		offset += int64(len(bh)) + size
+	}
+	if checksum {
+		offset += 4
+	}
+	return offset, nil
+}
+
+// plainExtents splits an uncompressed file of size bytes into chunks that
+// stand in for frames.
+func plainExtents(size, chunkSize int64) []frameExtent {
+	var extents []frameExtent
+	for offset := int64(0); offset < size; offset += chunkSize {
+		extents = append(extents, frameExtent{offset, min(chunkSize, size-offset)})
+	}
+	return extents
+}
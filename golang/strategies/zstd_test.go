@@ -0,0 +1,139 @@
+package strategies
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/klauspost/compress/zstd"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// compressFrames writes path compressed as one zstd frame per frameSize
+// bytes of input, followed by a skippable frame the way the seekable format
+// ends with its seek table, and returns the new file's path.
+func compressFrames(t *testing.T, path string, frameSize int) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderCRC(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer enc.Close()
+
+	var out []byte
+	for len(data) > 0 {
+		n := min(frameSize, len(data))
+		out = enc.EncodeAll(data[:n], out)
+		data = data[n:]
+	}
+	out = binary.LittleEndian.AppendUint32(out, 0x184D2A5E)
+	out = binary.LittleEndian.AppendUint32(out, 3)
+	out = append(out, 1, 2, 3)
+
+	zpath := path + ".zst"
+	if err := os.WriteFile(zpath, out, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return zpath
+}
+
+func TestZstdStrategyJoinsLinesAcrossFrames(t *testing.T) {
+	path, want := writeRefillDataset(t, 20_000, 300)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Frames of 7 bytes are shorter than any line, so some hold no newline.
+	for _, frameSize := range []int{1 << 30, 4096, 1000, 7} {
+		zpath := compressFrames(t, path, frameSize)
+		f, err := os.Open(zpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zinfo, _ := f.Stat()
+		frames, _, err := zstdFrames(f, zinfo.Size())
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := (info.Size() + int64(frameSize) - 1) / int64(frameSize); int64(len(frames)) != n {
+			t.Fatalf("%d-byte frames: found %d frames, want %d", frameSize, len(frames), n)
+		}
+		checkAggregates(t, NewZstdStrategy(StrategyOptions{Workers: 4, BufferSize: 256}), zpath, want)
+	}
+}
+
+func TestZstdStrategyReportsMalformedLine(t *testing.T) {
+	path, _ := writeRefillDataset(t, 5_000, 200)
+	insertLines(t, path, []int{3210}, []string{"Oslo;12,3"})
+
+	for _, frameSize := range []int{4096, 5} {
+		zpath := compressFrames(t, path, frameSize)
+		_, err := NewZstdStrategy(StrategyOptions{Workers: 4, ParseMode: ParseStrict}).Calculate(t.Context(), zpath)
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Fatalf("got error %v, want a *ParseError", err)
+		}
+		if perr.Line != 3211 || perr.Text != "Oslo;12,3" {
+			t.Errorf("%d-byte frames: got line %d %q, want line 3211 %q", frameSize, perr.Line, perr.Text, "Oslo;12,3")
+		}
+	}
+}
+
+func TestZstdStrategyQuarantinesAtDecodedOffsets(t *testing.T) {
+	path, _ := writeRefillDataset(t, 5_000, 200)
+	bad := []string{"Oslo;12,3", "no separator"}
+	insertLines(t, path, []int{1234, 3210}, bad)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 5-byte frames split every line across frames.
+	for _, frameSize := range []int{4096, 5} {
+		zpath := compressFrames(t, path, frameSize)
+		got := map[int64]string{}
+		var mu sync.Mutex
+		quarantine := QuarantineFunc(func(offset int64, line []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			got[offset] = string(line)
+		})
+		if _, err := NewZstdStrategy(StrategyOptions{Workers: 4, Quarantine: quarantine}).Calculate(t.Context(), zpath); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(bad) {
+			t.Fatalf("%d-byte frames: quarantined %q, want %q", frameSize, got, bad)
+		}
+		for offset, line := range got {
+			if !bytes.HasPrefix(data[offset:], []byte(line+"\n")) || !slices.Contains(bad, line) {
+				t.Errorf("%d-byte frames: quarantined %q at %d, where the input has %q", frameSize, line, offset, data[offset:min(offset+20, int64(len(data)))])
+			}
+		}
+	}
+}
+
+func TestZstdStrategyRejectsTruncatedFile(t *testing.T) {
+	path, _ := writeRefillDataset(t, 5_000, 200)
+	zpath := compressFrames(t, path, 4096)
+	data, err := os.ReadFile(zpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cut into the last data frame, past the skippable frame.
+	if err := os.WriteFile(zpath, data[:len(data)-30], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewZstdStrategy(StrategyOptions{}).Calculate(t.Context(), zpath); err == nil {
+		t.Fatal("truncated file read without error")
+	}
+}
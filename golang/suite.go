@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"slices"
+	"strings"
+)
+
+// strategyEntry is a registered strategy the runner knows how to build,
+// with what it supports. Display names come from the strategies themselves
+// (strategies.Metadata).
+type strategyEntry struct {
+	key string
+	strategies.Entry
+}
+
+// strategy builds the entry's strategy, reading every file of the dataset
+// when the run has several.
+func (e strategyEntry) strategy(opts strategies.StrategyOptions) strategies.Strategy {
+	if !e.ReadsPathLists && datasetFiles != nil {
+		return strategies.NewMultiFileStrategy(e.New, opts)
+	}
+	return e.New(opts)
+}
+
+// supported reports whether the entry's strategy runs on this operating
+// system.
+func (e strategyEntry) supported() bool {
+	return strategies.Supported(e.New(strategies.StrategyOptions{}))
+}
+
+// name returns the display name of the entry's strategy.
+func (e strategyEntry) name() string {
+	return strategyName(e.New(strategies.StrategyOptions{}))
+}
+
+// strategyName is the strategy's Metadata name, or its Go type for
+// strategies that do not describe themselves.
+func strategyName(s strategies.Strategy) string {
+	if m, ok := s.(strategies.Metadata); ok {
+		return m.Name()
+	}
+	return fmt.Sprintf("%T", s)
+}
+
+// strategyDescription is the strategy's Metadata description, if any.
+func strategyDescription(s strategies.Strategy) string {
+	if m, ok := s.(strategies.Metadata); ok {
+		return m.Describe()
+	}
+	return ""
+}
+
+// defaultSuite lists the strategies run when no mode flag is given.
+var defaultSuite = suiteKeys()
+
+// suiteKeys lists the registered strategies marked InDefaultSuite, in
+// sorted order.
+func suiteKeys() []string {
+	var keys []string
+	for _, key := range strategies.Registered() {
+		if entry, _ := strategies.Lookup(key); entry.InDefaultSuite {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func lookupStrategy(key string) (strategyEntry, bool) {
+	entry, ok := strategies.Lookup(key)
+	return strategyEntry{key, entry}, ok
+}
+
+// namedStrategy is a strategy instance ready to be benchmarked.
+type namedStrategy struct {
+	name     string
+	strategy strategies.Strategy
+	noGC     bool // run with the collector off, under -gc off or compare
+
+	// key and opts are what the strategy was built from, for building
+	// variants of it, such as -io-hints compare's.
+	key  string
+	opts strategies.StrategyOptions
+}
+
+func buildStrategies(keys []string, opts strategies.StrategyOptions) []namedStrategy {
+	built := make([]namedStrategy, 0, len(keys))
+	for _, key := range keys {
+		if entry, ok := lookupStrategy(key); ok {
+			s := entry.strategy(opts)
+			built = append(built, namedStrategy{name: strategyName(s), strategy: s, key: key, opts: opts})
+		}
+	}
+	return built
+}
+
+// selectedStrategies returns the keys given with -strategies, or the
+// default suite when the flag is unset.
+func selectedStrategies() ([]string, error) {
+	if *strategyList == "" {
+		// Leave out the stand-ins for strategies this OS cannot run.
+		var keys []string
+		for _, key := range defaultSuite {
+			if entry, _ := lookupStrategy(key); entry.supported() {
+				keys = append(keys, key)
+			}
+		}
+		return keys, nil
+	}
+	var keys []string
+	for _, key := range strings.Split(*strategyList, ",") {
+		key = strings.TrimSpace(key)
+		if _, ok := lookupStrategy(key); !ok {
+			return nil, fmt.Errorf("unknown strategy %q (available: %s)", key, strategyKeys())
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func strategyKeys() string {
+	return strings.Join(strategies.Registered(), ", ")
+}
+
+// inputSuite narrows the default suite to the strategies that can read
+// dataFile.
+func inputSuite(dataFile string) {
+	files := dataFiles(dataFile)
+	switch {
+	case isURL(dataFile):
+		defaultSuite = urlKeys()
+	case isStream(dataFile):
+		defaultSuite = []string{"pipeline"}
+	case slices.ContainsFunc(files, isBinary):
+		// The other built-ins would parse the records as text.
+		defaultSuite = []string{"binary"}
+	case slices.ContainsFunc(files, isCompressed):
+		// The other built-ins would parse the compressed bytes as text.
+		defaultSuite = []string{"zstd"}
+	case datasetFiles != nil:
+		// Shards, as split writes them, can also go one per worker.
+		defaultSuite = append(defaultSuite, "shards")
+	}
+}
+
+// checkSuite checks that every strategy of keys can do what the flags ask
+// of it on dataFile.
+func checkSuite(keys []string, dataFile string) error {
+	for _, key := range keys {
+		entry, _ := lookupStrategy(key)
+		switch {
+		case *sample > 0 && !entry.SamplesChunks:
+			return fmt.Errorf("%s cannot sample chunks; pick from %s with -strategies", key, strings.Join(samplerKeys(strategies.Registered()), ", "))
+		case *distribution && !entry.HostsAggregator:
+			return fmt.Errorf("%s cannot compute distributions; pick from %s with -strategies", key, strings.Join(distributionKeys(strategies.Registered()), ", "))
+		case isURL(dataFile) && !entry.ReadsURLs:
+			return fmt.Errorf("%s cannot read a URL; pick from %s with -strategies", key, strings.Join(urlKeys(), ", "))
+		}
+	}
+	if entry, _ := lookupStrategy(keys[0]); isStream(dataFile) && (len(keys) > 1 || !entry.ReadsSequentially) {
+		// A FIFO is consumed by the first strategy that reads it.
+		return fmt.Errorf("%s can only be read once; pick one of %s with -strategies", dataFile, strings.Join(sequentialKeys(), ", "))
+	}
+	if entry, _ := lookupStrategy(keys[0]); *checkpoint != "" && (len(keys) > 1 || !entry.Checkpoints) {
+		return fmt.Errorf("-checkpoint resumes a single strategy; pick one of %s with -strategies",
+			strings.Join(checkpointKeys(strategies.Registered()), ", "))
+	}
+	return nil
+}
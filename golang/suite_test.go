@@ -0,0 +1,20 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDefaultSuiteFollowsTheRegistry(t *testing.T) {
+	suite := suiteKeys()
+	for _, key := range []string{"mcmp", "mcmp-lp", "mcmp-lp-opt", "lp-table", "swiss", "basic"} {
+		if !slices.Contains(suite, key) {
+			t.Errorf("default suite %v lacks %q", suite, key)
+		}
+	}
+	for _, key := range []string{"zstd", "binary", "shards", "cluster"} {
+		if slices.Contains(suite, key) {
+			t.Errorf("default suite %v has %q, which needs an input of its own", suite, key)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+func printSummary(results []BenchmarkResult) {
+	out.Headerf("=== Performance Summary ===")
+	out.Println()
+
+	if len(results) == 0 {
+		out.Println("No results to display")
+		return
+	}
+	out.Printf("GC: %s\n", results[0].GC)
+	if *gcMode == "compare" {
+		out.Printf("GC of the%s runs: %s\n", noGCSuffix, noGCConfig)
+	}
+	out.Printf("Hash: %s\n", hashFunc)
+	tolerance := ""
+	if tolerantNums {
+		tolerance = " or fewer"
+	}
+	out.Printf("Format: %q delimited, %d decimal(s)%s\n", delimiter, decimals, tolerance)
+	out.Printf("Parse mode: %s\n", parseMode)
+	if *iterations > 1 {
+		out.Printf("Iterations: %d, %s in %s order, median run shown\n", *iterations, *schedule, *runOrderFlag)
+	}
+	out.Println()
+
+	// Find the fastest strategy
+	var fastest *BenchmarkResult
+	for i := range results {
+		if results[i].Success && (fastest == nil || results[i].ExecutionTime < fastest.ExecutionTime) {
+			fastest = &results[i]
+		}
+	}
+
+	// Create a tabwriter for nicely formatted table output
+	w := tabwriter.NewWriter(out.Writer(), 0, 0, 3, ' ', 0)
+
+	// Print header
+	fmt.Fprintln(w, out.Paint("STRATEGY\tTIME\tMEMORY (MB)\tRESULTS\tMALFORMED\tSTATUS", ColorBold, ColorCyan))
+	fmt.Fprintf(w, "───────────────────────\t────────────\t───────────\t────────\t─────────\t──────────────\n")
+
+	// Add rows to the table
+	for _, result := range results {
+		memoryMB := float64(result.MemoryUsed) / 1024 / 1024
+		timeStr := formatDuration(result.ExecutionTime)
+		statusStr := ""
+		rowColor := ""
+
+		if result.Success {
+			if fastest != nil && result.StrategyName == fastest.StrategyName {
+				statusStr = "✓ FASTEST"
+				rowColor = ColorGreen
+			} else {
+				statusStr = "✓"
+				rowColor = ""
+			}
+		} else if result.Partial {
+			statusStr = "◐ PARTIAL"
+			rowColor = ColorYellow
+		} else {
+			statusStr = "✗ FAILED"
+			rowColor = ColorRed
+		}
+
+		malformed := "-"
+		if result.Success && result.MalformedLines >= 0 {
+			malformed = strconv.FormatInt(result.MalformedLines, 10)
+		}
+
+		row := fmt.Sprintf("%s\t%s\t%.2f\t%d\t%s\t%s",
+			result.StrategyName,
+			timeStr,
+			memoryMB,
+			result.ResultCount,
+			malformed,
+			statusStr)
+		fmt.Fprintln(w, out.Paint(row, rowColor))
+
+		// Add error row if needed
+		if result.Error != nil {
+			fmt.Fprintln(w, out.Paint(fmt.Sprintf("  Error: %v", result.Error), ColorRed)+"\t\t\t\t\t")
+		}
+	}
+
+	w.Flush()
+
+	// Print comparison if multiple successful results
+	successfulResults := 0
+	for _, r := range results {
+		if r.Success {
+			successfulResults++
+		}
+	}
+
+	if successfulResults > 1 && fastest != nil {
+		out.Println()
+		out.Headerf("Speed Comparison (relative to fastest):")
+		for _, result := range results {
+			if result.Success && result.StrategyName != fastest.StrategyName {
+				ratio := float64(result.ExecutionTime) / float64(fastest.ExecutionTime)
+				out.Printf("  %s is %.2fx slower than %s\n",
+					result.StrategyName, ratio, fastest.StrategyName)
+			}
+		}
+	}
+}
+
+// printVerboseReport lists I/O and hash table details for the strategies
+// that collect them, so batching schemes such as preadv can be compared by
+// syscall count and table designs by probe length, and the collections
+// every strategy's run paid for.
+func printVerboseReport(results []BenchmarkResult, dataSize int64) {
+	out.Println()
+	out.Headerf("=== Verbose Report ===")
+	out.Println()
+
+	w := tabwriter.NewWriter(out.Writer(), 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, out.Paint("STRATEGY\tREAD SYSCALLS\tBYTES/SYSCALL\tAVG PROBE\tMAX PROBE\tGC CYCLES\tGC PAUSE", ColorBold, ColorCyan))
+	fmt.Fprintf(w, "───────────────────────\t────────────\t─────────────\t─────────\t─────────\t─────────\t────────\n")
+
+	for _, result := range results {
+		syscalls, perCall := "-", "-"
+		if result.Success && result.ReadSyscalls >= 0 {
+			syscalls = strconv.FormatInt(result.ReadSyscalls, 10)
+			if result.ReadSyscalls > 0 {
+				perCall = formatByteSize(int(dataSize / result.ReadSyscalls))
+			}
+		}
+		avgProbe, maxProbe := "-", "-"
+		if result.Probes != nil && result.Probes.Keys > 0 {
+			avgProbe = fmt.Sprintf("%.2f", result.Probes.Average)
+			maxProbe = strconv.Itoa(result.Probes.Max)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", result.StrategyName, syscalls, perCall, avgProbe, maxProbe,
+			result.GCCycles, formatDuration(result.GCPause))
+	}
+	w.Flush()
+}
+
+func formatDuration(d time.Duration) string {
+	if d < time.Millisecond {
+		return fmt.Sprintf("%.2f μs", float64(d.Microseconds()))
+	}
+	if d < time.Second {
+		return fmt.Sprintf("%.2f ms", float64(d.Milliseconds()))
+	}
+	if d < time.Minute {
+		return fmt.Sprintf("%.2f s", d.Seconds())
+	}
+	return fmt.Sprintf("%.2f min", d.Minutes())
+}
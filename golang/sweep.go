@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"os"
+	"runtime/debug"
+	"strings"
+	"text/tabwriter"
+)
+
+// sweepBuffers is the old spelling of "sweep -strategy", kept so that
+// scripts written for it still run.
+var sweepBuffers = flag.String("sweep-buffers", "", "deprecated: use the sweep subcommand")
+
+// sweepBufferSizes is the read-buffer matrix sweep runs through.
+var sweepBufferSizes = []int{
+	64 << 10, 128 << 10, 256 << 10, 512 << 10,
+	1 << 20, 2 << 20, 4 << 20, 8 << 20, 16 << 20,
+}
+
+// runSweepCommand implements "sweep [flags] [file]": it benchmarks one
+// strategy at each of sweepBufferSizes and returns the exit status.
+func runSweepCommand(args []string) int {
+	fs := flag.NewFlagSet("sweep", flag.ExitOnError)
+	key := fs.String("strategy", "mcmp", "strategy to benchmark at each buffer size")
+	fs.DurationVar(timeout, "timeout", 0, "abort a run and mark it FAILED after this long, e.g. 2m (0 = no limit)")
+	noColor := fs.Bool("no-color", false, "disable ANSI colors (also honors NO_COLOR and non-TTY output)")
+	strategyFlags(fs)
+	recordFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s sweep [flags] [file]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Benchmark one strategy with read buffers from 64KiB to 16MiB, overriding -buffer-size,\n")
+		fmt.Fprintf(fs.Output(), "and print the throughput at each, highlighting the fastest.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	out = newOutput(*noColor)
+	return sweepFile(*key, getDataFile(fs.Args()))
+}
+
+// sweepFile runs the buffer sweep of the strategy key on dataFile, for
+// sweep and -sweep-buffers, and returns the exit status.
+func sweepFile(key, dataFile string) int {
+	if datasetFiles != nil || isStream(dataFile) {
+		out.Errorf("Error: sweep reads the file once per buffer size, so it needs a single regular file")
+		return 1
+	}
+	if err := checkStrategyFlags(); err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	if err := setRecordFormat(); err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	entry, ok := lookupStrategy(key)
+	if !ok {
+		out.Errorf("Error: unknown strategy %q (available: %s)", key, strategyKeys())
+		return 1
+	}
+	var dataSize int64
+	if isURL(dataFile) {
+		if !entry.ReadsURLs {
+			out.Errorf("Error: %s cannot read a URL; pick one of %s", key, strings.Join(urlKeys(), ", "))
+			return 1
+		}
+		dataSize = remoteSize(dataFile)
+	} else if info, err := os.Stat(dataFile); err == nil {
+		dataSize = info.Size()
+	}
+	if maxMemory > 0 {
+		debug.SetMemoryLimit(int64(maxMemory))
+	}
+
+	runBufferSweep(entry, strategyOptions(), dataFile, dataSize)
+	return 0
+}
+
+// runBufferSweep runs a single strategy once per buffer size and prints the
+// throughput achieved with each, highlighting the fastest.
+func runBufferSweep(entry strategyEntry, opts strategies.StrategyOptions, dataFile string, dataSize int64) {
+	out.Headerf("=== Buffer Size Sweep: %s ===", entry.name())
+	out.Println()
+
+	results := make([]BenchmarkResult, 0, len(sweepBufferSizes))
+	for _, size := range sweepBufferSizes {
+		opts.BufferSize = size
+		label := formatByteSize(size)
+
+		out.Warnf("⏱️  Running: %s @ %s", entry.name(), label)
+		result := benchmarkStrategy(label, entry.strategy(opts), dataFile)
+		if result.Success {
+			out.Successf("✓ Completed in: %v", result.ExecutionTime)
+		} else {
+			out.Errorf("✗ Failed: %v", result.Error)
+		}
+		results = append(results, result)
+	}
+	out.Println()
+
+	var fastest *BenchmarkResult
+	for i := range results {
+		if results[i].Success && (fastest == nil || results[i].ExecutionTime < fastest.ExecutionTime) {
+			fastest = &results[i]
+		}
+	}
+
+	w := tabwriter.NewWriter(out.Writer(), 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, out.Paint("BUFFER\tTIME\tTHROUGHPUT (MB/s)\tSTATUS", ColorBold, ColorCyan))
+	fmt.Fprintf(w, "──────────\t────────────\t─────────────────\t──────────────\n")
+
+	for i := range results {
+		result := &results[i]
+		if !result.Success {
+			fmt.Fprintln(w, out.Paint(fmt.Sprintf("%s\t-\t-\t✗ FAILED", result.StrategyName), ColorRed))
+			continue
+		}
+
+		throughput := float64(dataSize) / 1024 / 1024 / result.ExecutionTime.Seconds()
+		status, rowColor := "✓", ""
+		if result == fastest {
+			status, rowColor = "✓ FASTEST", ColorGreen
+		}
+		row := fmt.Sprintf("%s\t%s\t%.1f\t%s",
+			result.StrategyName, formatDuration(result.ExecutionTime), throughput, status)
+		fmt.Fprintln(w, out.Paint(row, rowColor))
+	}
+	w.Flush()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+var (
+	top          = flag.Int("top", 0, "after the summary, rank the N stations with the highest and lowest -by value (0 = off)")
+	topBy        = flag.String("by", "max", "value -top ranks stations by: max, min or mean, or with -distribution stddev or median")
+	distribution = flag.Bool("distribution", false, "also compute each station's standard deviation and median, p90 and p99 (t-digest) and show them in -top, 10 stations unless set; costs extra work per row")
+)
+
+// topFields maps each -by field to the station value it ranks by. The
+// stddev and median need -distribution.
+var topFields = map[string]func(strategies.StationResult) float64{
+	"max":    func(st strategies.StationResult) float64 { return float64(st.Maximum) },
+	"min":    func(st strategies.StationResult) float64 { return float64(st.Minimum) },
+	"mean":   func(st strategies.StationResult) float64 { return st.Average },
+	"stddev": func(st strategies.StationResult) float64 { return distributionOf(st).StdDev() },
+	"median": func(st strategies.StationResult) float64 { return distributionOf(st).Median() },
+}
+
+// distributionOf returns the Distribution -distribution adds to st, or nil
+// without it.
+func distributionOf(st strategies.StationResult) *strategies.Distribution {
+	d, _ := st.Extra.(*strategies.Distribution)
+	return d
+}
+
+// distributionKeys returns the keys whose strategies honor -distribution,
+// in order.
+func distributionKeys(keys []string) []string {
+	var aggregators []string
+	for _, key := range keys {
+		if entry, ok := lookupStrategy(key); ok && entry.supported() && entry.HostsAggregator {
+			aggregators = append(aggregators, key)
+		}
+	}
+	return aggregators
+}
+
+// printTopStations prints the n stations with the highest and the n with
+// the lowest value of field, from the first successful strategy's results
+// (see stationsFrom) and among the stations -filter selects.
+func printTopStations(results []BenchmarkResult, n int, field string) {
+	i := stationsFrom(results)
+	if i < 0 {
+		out.Errorf("No successful strategy to rank stations from")
+		out.Println()
+		return
+	}
+	ranked := rankStations(filterStations(results[i].Stations), field)
+	if len(ranked) == 0 {
+		out.Errorf("No station matches -filter %s to rank", *filter)
+		out.Println()
+		return
+	}
+	n = min(n, len(ranked))
+
+	out.Headerf("=== Top %d stations by %s (from %s) ===", n, field, results[i].StrategyName)
+	out.Println()
+	printExtremes(ranked, n)
+}
+
+// rankStations returns a copy of stations sorted by field, highest first,
+// and by name among equals.
+func rankStations(stations []strategies.StationResult, field string) []strategies.StationResult {
+	value := topFields[field]
+	ranked := slices.Clone(stations)
+	slices.SortStableFunc(ranked, func(a, b strategies.StationResult) int {
+		return cmp.Or(cmp.Compare(value(b), value(a)), strings.Compare(a.StationID, b.StationID))
+	})
+	return ranked
+}
+
+// printExtremes prints the first n stations of ranked as the highest and
+// the last n, last first, as the lowest. n is at most len(ranked).
+func printExtremes(ranked []strategies.StationResult, n int) {
+	lowest := make([]strategies.StationResult, n)
+	for place := range lowest {
+		lowest[place] = ranked[len(ranked)-1-place]
+	}
+	printRanking("Highest:", ranked[:n])
+	printRanking("Lowest:", lowest)
+}
+
+// printRanking prints stations in the order given, each with its place,
+// name and min/mean/max, then its spread under -distribution.
+func printRanking(title string, stations []strategies.StationResult) {
+	out.Println(out.Paint(title, ColorBlue))
+	w := tabwriter.NewWriter(out.Writer(), 0, 0, 2, ' ', 0)
+	for place, st := range stations {
+		fmt.Fprintf(w, "  %2d.\t%s\t%s/%s/%s", place+1, st.StationID, formatTemperature(float64(st.Minimum), decimals),
+			formatTemperature(st.Average, decimals), formatTemperature(float64(st.Maximum), decimals))
+		if d := distributionOf(st); d != nil {
+			fmt.Fprintf(w, "\tσ %s\tp50 %s\tp90 %s\tp99 %s", formatDeviation(d.StdDev(), decimals),
+				formatEstimate(d.Median(), decimals), formatEstimate(d.Quantile(0.9), decimals), formatEstimate(d.Quantile(0.99), decimals))
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+	out.Println()
+}
+
+// formatUnits renders v, in units of the last of digits fraction digits,
+// as a decimal with one more digit, since it is an estimate rather than a
+// measurement.
+func formatUnits(v float64, digits int) string {
+	return strconv.FormatFloat(v/math.Pow10(digits), 'f', digits+1, 64)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"os"
+	"slices"
+	"time"
+)
+
+var (
+	finalists = flag.Int("finalists", 0, "tournament: eliminate strategies in heats on growing samples from the head of the file until this many are left for the full run (0 = run all)")
+	heatSize  = byteSize(16 << 20)
+)
+
+func init() {
+	flag.Var(&heatSize, "heat-size", "bytes from the head of the file in the first -finalists heat; each later heat reads four times more")
+}
+
+// heatGrowth is how many times larger each heat's sample is than the last.
+const heatGrowth = 4
+
+// runTournament narrows keys down to the finalists fastest strategies
+// before the full run. Heats run on samples from the head of dataFile,
+// starting at heatSize and growing heatGrowth-fold; each keeps the faster
+// half of the field, or the finalists if that is more, until only they are
+// left. A strategy that fails a heat is out. Heats stop once a sample
+// would be as large as the file itself, leaving the rest to the full run.
+// The survivors keep their order in keys.
+func runTournament(keys []string, opts strategies.StrategyOptions, dataFile string, dataSize, heatSize int64, finalists int) []string {
+	keys = slices.Clone(keys)
+	for heat, size := 1, heatSize; len(keys) > finalists && size < dataSize; heat, size = heat+1, size*heatGrowth {
+		keep := max(finalists, (len(keys)+1)/2)
+		out.Headerf("=== Heat %d: %d strategies on a %s sample, %d go through ===", heat, len(keys), formatByteSize(int(size)), keep)
+
+		samplePath, _, err := writeHead(dataFile, "tournament-heat-*.txt", size, 0)
+		if err != nil {
+			out.Errorf("Error creating the heat sample: %v (running every strategy left)", err)
+			out.Println()
+			return keys
+		}
+
+		type entrant struct {
+			key  string
+			time time.Duration
+			err  error
+		}
+		field := make([]entrant, 0, len(keys))
+		for _, key := range keys {
+			entry, _ := lookupStrategy(key)
+			s := entry.strategy(opts)
+			result := benchmarkStrategy(strategyName(s), s, samplePath)
+			field = append(field, entrant{key, result.ExecutionTime, result.Error})
+		}
+		os.Remove(samplePath)
+
+		// Failures sort last and never go through.
+		slices.SortStableFunc(field, func(a, b entrant) int {
+			if (a.err == nil) != (b.err == nil) {
+				if a.err == nil {
+					return -1
+				}
+				return 1
+			}
+			return cmp.Compare(a.time, b.time)
+		})
+		through := make(map[string]bool, keep)
+		for place, e := range field {
+			entry, _ := lookupStrategy(e.key)
+			switch {
+			case e.err != nil:
+				out.Errorf("  %2d. %-24s ✗ %v", place+1, entry.name(), e.err)
+			case place < keep:
+				through[e.key] = true
+				out.Successf("  %2d. %-24s %s", place+1, entry.name(), formatDuration(e.time))
+			default:
+				out.Printf("  %2d. %-24s %s  out\n", place+1, entry.name(), formatDuration(e.time))
+			}
+		}
+		out.Println()
+
+		keys = slices.DeleteFunc(keys, func(key string) bool { return !through[key] })
+		if len(keys) == 0 {
+			out.Errorf("Error: every strategy failed heat %d", heat)
+			os.Exit(1)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+var unit = flag.String("unit", "celsius", "scale every printed and exported temperature is in: celsius, fahrenheit or kelvin; aggregation stays in Celsius")
+
+// temperatureUnit is a scale -unit renders temperatures in: a temperature
+// of c degrees Celsius is c*scale+offset in it.
+type temperatureUnit struct {
+	scale, offset float64
+}
+
+// temperatureUnits are the scales -unit accepts.
+var temperatureUnits = map[string]temperatureUnit{
+	"celsius":    {1, 0},
+	"fahrenheit": {1.8, 32},
+	"kelvin":     {1, 273.15},
+}
+
+// outputUnit is the scale of every temperature printed, served or
+// exported. Strategies aggregate in Celsius regardless; the conversion
+// happens only when a value is formatted.
+var outputUnit = temperatureUnits["celsius"]
+
+// setOutputUnit looks up -unit.
+func setOutputUnit() error {
+	u, ok := temperatureUnits[*unit]
+	if !ok {
+		names := make([]string, 0, len(temperatureUnits))
+		for name := range temperatureUnits {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return fmt.Errorf("-unit must be one of %s, got %q", strings.Join(names, ", "), *unit)
+	}
+	outputUnit = u
+	return nil
+}
+
+// inUnit converts v, a Celsius temperature in units of the last of digits
+// fraction digits, to -unit in the same units.
+func inUnit(v float64, digits int) float64 {
+	return v*outputUnit.scale + outputUnit.offset*math.Pow10(digits)
+}
+
+// formatTemperature renders v, a Celsius temperature in units of the last
+// of digits fraction digits, as a decimal in -unit, rounded half up to as
+// many digits. In Celsius it is formatFixed, the mean rounded as
+// roundedMean rounds it.
+func formatTemperature(v float64, digits int) string {
+	return formatFixed(int64(math.Floor(inUnit(v, digits)+0.5)), digits)
+}
+
+// formatEstimate is formatUnits for a temperature, such as a percentile,
+// in -unit.
+func formatEstimate(v float64, digits int) string {
+	return formatUnits(inUnit(v, digits), digits)
+}
+
+// formatDeviation is formatUnits for a difference of temperatures, such
+// as a standard deviation, in -unit, which scales it but does not offset
+// it.
+func formatDeviation(v float64, digits int) string {
+	return formatUnits(v*outputUnit.scale, digits)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+)
+
+var validate = flag.Bool("validate", false, "fail any strategy whose aggregated row count differs from the number of lines in the file")
+
+// countFileRows returns the number of lines in path, counting a final
+// line that has no newline.
+func countFileRows(path string) (int64, error) {
+	f, err := openInput(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 1<<20)
+	var rows int64
+	last := byte('\n')
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			rows += int64(bytes.Count(buf[:n], []byte{'\n'}))
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if last != '\n' {
+		rows++
+	}
+	return rows, nil
+}
+
+// validateRows fails a successful result whose stations and skipped
+// malformed lines do not add up to every row of the file, which catches
+// records lost at chunk boundaries or at the end of the file.
+func validateRows(result *BenchmarkResult, fileRows int64) {
+	malformed := max(result.MalformedLines, 0)
+	if !result.Success || result.Rows+malformed == fileRows {
+		return
+	}
+	result.Success = false
+	result.Error = fmt.Errorf("validation: processed %d rows and skipped %d malformed, file has %d", result.Rows, malformed, fileRows)
+}
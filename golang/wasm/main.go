@@ -0,0 +1,167 @@
+//go:build js && wasm
+
+// Command wasm is the browser demo: it aggregates a measurements file
+// chosen in index.html without uploading it anywhere. It registers one
+// JavaScript function,
+//
+//	onebillionAggregate(file, {delimiter, decimals, strict}) → Promise
+//
+// whose promise resolves to {stations, rows, malformed, millis}, stations
+// being {name, min, mean, max, count} objects sorted by name. The file is
+// read slice by slice as the workers need it, so it never has to fit in
+// memory. Build it with "make wasm".
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"syscall/js"
+	"time"
+)
+
+// readBufferSize is the size of each slice read from the file. Every read
+// waits for a promise, so fewer, larger ones are faster.
+const readBufferSize = 4 << 20
+
+func main() {
+	js.Global().Set("onebillionAggregate", js.FuncOf(aggregate))
+	select {}
+}
+
+// aggregate starts aggregating the File in args[0] with the options in
+// args[1] and returns a promise of the result.
+func aggregate(_ js.Value, args []js.Value) any {
+	if len(args) == 0 || args[0].Type() != js.TypeObject {
+		return rejected(errors.New("onebillionAggregate needs a File"))
+	}
+	file := args[0]
+	format := strategies.DefaultRecordFormat()
+	var opts strategies.StrategyOptions
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		if d := args[1].Get("delimiter"); d.Type() == js.TypeString && d.String() != "" {
+			format.Delimiter = d.String()[0]
+		}
+		if d := args[1].Get("decimals"); d.Type() == js.TypeNumber {
+			format.FractionDigits = d.Int()
+		}
+		if args[1].Get("strict").Truthy() {
+			opts.ParseMode = strategies.ParseStrict
+		}
+	}
+	if err := strategies.SetRecordFormat(format); err != nil {
+		return rejected(err)
+	}
+	opts.BufferSize = readBufferSize
+
+	return newPromise(func() (any, error) {
+		start := time.Now()
+		s := strategies.NewSwissTableStrategy(opts)
+		results, err := s.CalculateReaderAt(context.Background(), blobReader{file}, int64(file.Get("size").Float()))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"stations":  stationObjects(results, format.FractionDigits),
+			"rows":      float64(s.RowsParsed()),
+			"malformed": float64(s.MalformedLines()),
+			"millis":    float64(time.Since(start).Milliseconds()),
+		}, nil
+	})
+}
+
+// stationObjects converts results to JavaScript objects sorted by name,
+// with temperatures in degrees and the mean rounded half up.
+func stationObjects(results []strategies.StationResult, digits int) []any {
+	slices.SortFunc(results, func(a, b strategies.StationResult) int {
+		return strings.Compare(a.StationID, b.StationID)
+	})
+	unit := math.Pow10(digits)
+	stations := make([]any, len(results))
+	for i, st := range results {
+		stations[i] = map[string]any{
+			"name":  st.StationID,
+			"min":   float64(st.Minimum) / unit,
+			"mean":  math.Floor(st.Average+0.5) / unit,
+			"max":   float64(st.Maximum) / unit,
+			"count": float64(st.Count),
+		}
+	}
+	return stations
+}
+
+// blobReader reads a JavaScript Blob, such as a File from an <input>,
+// through the promises of Blob.arrayBuffer. It must not be used on the
+// goroutine of a js.FuncOf callback, which holds up the event loop the
+// promises need.
+type blobReader struct {
+	blob js.Value
+}
+
+func (b blobReader) ReadAt(p []byte, off int64) (int, error) {
+	size := int64(b.blob.Get("size").Float())
+	if off >= size {
+		return 0, io.EOF
+	}
+	end := min(off+int64(len(p)), size)
+	buf, err := await(b.blob.Call("slice", off, end).Call("arrayBuffer"))
+	if err != nil {
+		return 0, err
+	}
+	n := js.CopyBytesToGo(p, js.Global().Get("Uint8Array").New(buf))
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// await blocks the calling goroutine until promise settles.
+func await(promise js.Value) (js.Value, error) {
+	var value js.Value
+	var err error
+	done := make(chan struct{})
+	onFulfilled := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		value = args[0]
+		close(done)
+		return nil
+	})
+	onRejected := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		err = errors.New(args[0].Call("toString").String())
+		close(done)
+		return nil
+	})
+	defer onFulfilled.Release()
+	defer onRejected.Release()
+
+	promise.Call("then", onFulfilled, onRejected)
+	<-done
+	return value, err
+}
+
+// newPromise returns a JavaScript promise of the result of run, which runs
+// on a goroutine of its own so that it may await other promises.
+func newPromise(run func() (any, error)) js.Value {
+	var executor js.Func
+	executor = js.FuncOf(func(_ js.Value, args []js.Value) any {
+		resolve, reject := args[0], args[1]
+		go func() {
+			defer executor.Release()
+			value, err := run()
+			if err != nil {
+				reject.Invoke(js.Global().Get("Error").New(err.Error()))
+				return
+			}
+			resolve.Invoke(value)
+		}()
+		return nil
+	})
+	return js.Global().Get("Promise").New(executor)
+}
+
+func rejected(err error) js.Value {
+	return js.Global().Get("Promise").Call("reject", js.Global().Get("Error").New(err.Error()))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/utkarsh5026/onebillion/golang/strategies"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+var (
+	cluster      = flag.String("cluster", "", "comma-separated host:port of machines running the worker command; the cluster strategy splits the file across them")
+	clusterToken = flag.String("cluster-token", "", "secret the workers were started with -token (default $"+clusterTokenEnv+")")
+)
+
+// clusterTokenEnv names the environment variable the worker's -token and
+// the coordinator's -cluster-token default to, which keeps the secret out
+// of process listings.
+const clusterTokenEnv = "ONEBILLION_CLUSTER_TOKEN"
+
+// runWorkerCommand implements "worker [flags]": it serves byte ranges of
+// measurements files to a coordinator started with -cluster until it is
+// interrupted, and returns the exit status.
+func runWorkerCommand(args []string) int {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:7070", "address to accept coordinators on; use :7070 to accept them from other machines")
+	token := fs.String("token", "", "secret coordinators must send with -cluster-token (default $"+clusterTokenEnv+")")
+	dataRoot := fs.String("data-root", ".", "directory coordinators may read files under")
+	allowURLs := fs.Bool("allow-urls", false, "also let coordinators have this worker fetch http(s), s3:// and gs:// URLs")
+	noColor := fs.Bool("no-color", false, "disable ANSI colors (also honors NO_COLOR and non-TTY output)")
+	fs.IntVar(&workers, "workers", 0, "goroutines aggregating each range (0 = runtime.NumCPU())")
+	fs.Var(&bufferSize, "buffer-size", "read buffer size per goroutine, e.g. 64KiB or 1MiB (0 = 1MiB)")
+	fs.Var(&chunkSize, "chunk-size", "size of the chunks a range is cut into (0 = derived from the range size)")
+	fs.StringVar(&delimiter, "delimiter", ";", "byte separating station name from value, e.g. , or tab")
+	fs.IntVar(&decimals, "decimals", 1, "digits after the decimal point in every value (0-6)")
+	fs.BoolVar(&tolerantNums, "tolerant-decimals", false, "also accept values with fewer than -decimals fraction digits or none")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s worker [flags]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Aggregate byte ranges for a coordinator run with -cluster. The coordinator's file path\n")
+		fmt.Fprintf(fs.Output(), "must open the same file here, under -data-root: a shared filesystem or an identical copy,\n")
+		fmt.Fprintf(fs.Output(), "or a URL with -allow-urls. Format flags must match the coordinator's; its -parse-mode\n")
+		fmt.Fprintf(fs.Output(), "applies.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	out = newOutput(*noColor)
+
+	out.Headerf("=== One Billion Row Challenge - Cluster Worker ===")
+	out.Println()
+
+	if err := setRecordFormat(); err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	if *token == "" {
+		*token = os.Getenv(clusterTokenEnv)
+	}
+	if *token == "" {
+		out.Errorf("Error: worker needs a shared -token (or %s) for coordinators to send", clusterTokenEnv)
+		return 1
+	}
+	lis, err := net.Listen("tcp", *listen)
+	if err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	out.Printf("%s %s\n", out.Paint("Listening on:", ColorBlue), lis.Addr())
+	out.Printf("%s %s\n\n", out.Paint("Data root:", ColorBlue), *dataRoot)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	opts := strategies.StrategyOptions{
+		Workers:    workers,
+		BufferSize: int(bufferSize),
+		ChunkSize:  int(chunkSize),
+	}
+	cfg := strategies.ClusterWorkerConfig{Token: *token, DataRoot: *dataRoot, AllowURLs: *allowURLs}
+	if err := strategies.ServeClusterWorker(ctx, lis, cfg, opts); err != nil {
+		out.Errorf("Error: %v", err)
+		return 1
+	}
+	out.Successf("✓ Stopped")
+	return 0
+}
+
+// registerCluster registers the "cluster" strategy, which sends the input
+// to the -cluster workers, and returns their addresses.
+func registerCluster() ([]string, error) {
+	if *clusterToken == "" {
+		*clusterToken = os.Getenv(clusterTokenEnv)
+	}
+	if *clusterToken == "" {
+		return nil, fmt.Errorf("-cluster needs the workers' shared -cluster-token (or %s)", clusterTokenEnv)
+	}
+	addrs := strings.Split(*cluster, ",")
+	strategies.Register("cluster", strategies.Entry{
+		New: func(o strategies.StrategyOptions) strategies.Strategy {
+			return strategies.NewClusterStrategy(addrs, *clusterToken, o)
+		},
+		ReadsURLs: true,
+	})
+	return addrs, nil
+}